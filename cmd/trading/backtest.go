@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/mumugogoing/meme_bot/pkg/backtest"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+)
+
+// runBacktest replays recorded token histories through the trading pipeline:
+//
+//	trading backtest -data histories.jsonl [-seed 1] [-slippage-noise 0.01] [-out result.json]
+//
+// Strategy, risk and sizing settings come from the environment as for the bot.
+func runBacktest(args []string) int {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	data := fs.String("data", "", "token histories (JSONL)")
+	seed := fs.Int64("seed", 1, "random seed for simulated slippage")
+	noise := fs.Float64("slippage-noise", 0, "standard deviation of extra slippage per fill")
+	out := fs.String("out", "", "write the full result (trades, equity curve) as JSON to this file")
+	verbose := fs.Bool("v", false, "log every pipeline step")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" {
+		fmt.Fprintln(os.Stderr, "backtest: -data is required")
+		fs.Usage()
+		return 2
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	cfg := config.LoadConfig()
+
+	histories, err := backtest.ReadDataset(*data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backtest: %v\n", err)
+		return 1
+	}
+	result, err := backtest.Run(cfg, histories, backtest.Options{Seed: *seed, SlippageNoise: *noise})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backtest: %v\n", err)
+		return 1
+	}
+
+	if *out != "" {
+		if err := writeJSON(*out, result); err != nil {
+			fmt.Fprintf(os.Stderr, "backtest: %v\n", err)
+			return 1
+		}
+	}
+	printBacktest(result)
+	return 0
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// printBacktest prints the headline numbers and attribution tables
+func printBacktest(r *backtest.Result) {
+	fmt.Printf("Replayed %d tokens from %s to %s (seed %d)\n",
+		r.Tokens, r.Start.Format("2006-01-02 15:04"), r.End.Format("2006-01-02 15:04"), r.Seed)
+	fmt.Printf("Trades: %d, win rate %.1f%%\n", len(r.Trades), r.WinRate*100)
+	fmt.Printf("Equity: $%.2f -> $%.2f (PnL $%+.2f)\n", r.StartingEquity, r.FinalEquity, r.PnLUSD)
+	fmt.Printf("Max drawdown: $%.2f (%.2f%%)\n", r.MaxDrawdownUSD, r.MaxDrawdown*100)
+
+	printAttribution("exit reason", r.ByExitReason)
+	printAttribution("strategy", r.ByStrategy)
+
+	if len(r.Skipped) > 0 {
+		fmt.Printf("\n%-36s %7s\n", "not traded", "tokens")
+		for _, reason := range sortedKeys(r.Skipped) {
+			fmt.Printf("%-36s %7d\n", reason, r.Skipped[reason])
+		}
+	}
+}
+
+// printAttribution prints trades, win rate and PnL per key
+func printAttribution(title string, byKey map[string]backtest.Attribution) {
+	if len(byKey) == 0 {
+		return
+	}
+	fmt.Printf("\n%-20s %7s %9s %12s\n", title, "trades", "win rate", "pnl")
+	for _, key := range sortedKeys(byKey) {
+		a := byKey[key]
+		fmt.Printf("%-20s %7d %8.1f%% %12.2f\n", key, a.Trades, a.WinRate*100, a.PnLUSD)
+	}
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mumugogoing/meme_bot/pkg/model"
+)
+
+// runCalibrate reports how well past win probabilities matched outcomes and
+// optionally fits a calibration:
+//
+//	trading calibrate -data trades.jsonl [-bins 10] [-method isotonic|platt -out calibration.json]
+//
+// When fitting, the most recent -holdout share of trades is held back and the
+// calibrated report is computed on it.
+func runCalibrate(args []string) int {
+	fs := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	data := fs.String("data", "", "labeled candidates (JSONL)")
+	bins := fs.Int("bins", 10, "number of reliability bins")
+	method := fs.String("method", model.MethodIsotonic, "calibration method: isotonic or platt")
+	out := fs.String("out", "", "write a fitted calibration to this file")
+	holdout := fs.Float64("holdout", 0.3, "share of the most recent trades held out when fitting")
+	minStrategy := fs.Int("min-strategy", 50, "minimum trades for a per-strategy calibrator")
+	version := fs.String("version", "", "calibration version (default: fit timestamp)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" {
+		fmt.Fprintln(os.Stderr, "calibrate: -data is required")
+		fs.Usage()
+		return 2
+	}
+
+	records, err := readLabeledCandidates(*data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Candidate.ListedAt.Before(records[j].Candidate.ListedAt)
+	})
+	observations := model.ObservationsFromRecords(records)
+
+	if *out == "" {
+		printCalibrationReport(model.NewCalibrationReport(observations, *bins, nil))
+		return 0
+	}
+
+	split := len(observations) - int(float64(len(observations))*(*holdout))
+	fitSet, testSet := observations[:split], observations[split:]
+	calibration, err := model.FitCalibration(fitSet, *method, *minStrategy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+	if *version != "" {
+		calibration.Version = *version
+	}
+	if err := calibration.Save(*out); err != nil {
+		fmt.Fprintf(os.Stderr, "calibrate: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Fitted %s calibration %s on %d trades, wrote %s\n", *method, calibration.Version, len(fitSet), *out)
+	if len(testSet) == 0 {
+		testSet = fitSet
+		fmt.Println("No holdout; report is in-sample")
+	} else {
+		fmt.Printf("Holdout: %d most recent trades\n", len(testSet))
+	}
+	printCalibrationReport(model.NewCalibrationReport(testSet, *bins, calibration))
+	return 0
+}
+
+// printCalibrationReport prints reliability tables for the report
+func printCalibrationReport(report model.CalibrationReport) {
+	printReliability("all strategies (raw)", report.Raw)
+	if report.Calibrated != nil {
+		printReliability("all strategies (calibrated "+report.CalibrationVersion+")", *report.Calibrated)
+	}
+
+	names := make([]string, 0, len(report.ByStrategy))
+	for name := range report.ByStrategy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printReliability(name+" (raw)", report.ByStrategy[name])
+	}
+}
+
+// printReliability prints one reliability table
+func printReliability(title string, r model.ReliabilityReport) {
+	fmt.Printf("\n%s: %d trades, win rate %.3f, Brier %.4f, ECE %.4f\n", title, r.Examples, r.BaseRate, r.Brier, r.ECE)
+	fmt.Printf("  %-11s %6s %10s %10s\n", "bin", "trades", "predicted", "observed")
+	for _, bin := range r.Bins {
+		if bin.Count == 0 {
+			continue
+		}
+		gap := strings.Repeat("*", int(20*math.Abs(bin.MeanPredicted-bin.ObservedRate)+0.5))
+		fmt.Printf("  %.2f-%.2f   %6d %10.3f %10.3f %s\n", bin.Lower, bin.Upper, bin.Count, bin.MeanPredicted, bin.ObservedRate, gap)
+	}
+}
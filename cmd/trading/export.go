@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
+)
+
+// runExport writes the labeled candidates train and calibrate read, one per
+// closed position in the database:
+//
+//	trading export [-db sqlite://./meme_bot.db] [-since 2024-01-01T00:00:00Z] -out trades.jsonl
+//
+// The database defaults to DATABASE_URL as for the bot.
+func runExport(args []string) int {
+	cfg := config.LoadConfig()
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	db := fs.String("db", cfg.DatabaseURL, "database URL")
+	since := fs.String("since", "", "only positions closed at or after this time (RFC 3339)")
+	out := fs.String("out", "", "labeled candidates file to write (JSONL)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "export: -out is required")
+		fs.Usage()
+		return 2
+	}
+	var from time.Time
+	if *since != "" {
+		parsed, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export: -since: %v\n", err)
+			return 2
+		}
+		from = parsed
+	}
+
+	store, err := storage.Open(*db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	if store == nil {
+		fmt.Fprintln(os.Stderr, "export: no database; set -db or DATABASE_URL")
+		return 2
+	}
+	defer store.Close()
+
+	records, unmatched, err := storage.LabeledCandidates(context.Background(), store, store, from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			file.Close()
+			fmt.Fprintf(os.Stderr, "export: %v\n", err)
+			return 1
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("export: wrote %d labeled candidates to %s", len(records), *out)
+	if unmatched > 0 {
+		fmt.Printf(" (%d closed positions without a stored candidate skipped)", unmatched)
+	}
+	fmt.Println()
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/journal"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// runJournal prints the journaled story of a token, grouped by correlation ID:
+//
+//	trading journal [-dir ./journal] (-id <correlation id> | -address <token> [-chain base]) [-json]
+//
+// The directory defaults to JOURNAL_DIR as for the bot.
+func runJournal(args []string) int {
+	cfg := config.LoadConfig()
+	fs := flag.NewFlagSet("journal", flag.ContinueOnError)
+	dir := fs.String("dir", cfg.JournalDir, "journal directory")
+	id := fs.String("id", "", "correlation ID")
+	chain := fs.String("chain", "", "chain of the token")
+	address := fs.String("address", "", "token address")
+	asJSON := fs.Bool("json", false, "print the records as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *id == "" && *address == "" {
+		fmt.Fprintln(os.Stderr, "journal: -id or -address is required")
+		fs.Usage()
+		return 2
+	}
+
+	records, err := journal.Read(*dir, journal.Filter{
+		CorrelationID: *id,
+		Chain:         models.Chain(*chain),
+		TokenAddress:  *address,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "journal: %v\n", err)
+		return 1
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(os.Stderr, "journal: no records found")
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, record := range records {
+			encoder.Encode(record)
+		}
+		return 0
+	}
+
+	const layout = "2006-01-02T15:04:05.000000Z07:00"
+	current := ""
+	for _, record := range records {
+		if record.CorrelationID != current {
+			current = record.CorrelationID
+			fmt.Printf("\n%s:%s  correlation %s\n", record.Chain, record.TokenAddress, current)
+		}
+		fmt.Printf("  %s  %-18s %s\n", record.Time.Format(layout), record.Stage, record.Summary())
+	}
+	return 0
+}
@@ -2,23 +2,60 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mumugogoing/meme_bot/pkg/agents/listing"
+	"github.com/mumugogoing/meme_bot/pkg/agents/risk"
 	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/journal"
+	"github.com/mumugogoing/meme_bot/pkg/model"
+	"github.com/mumugogoing/meme_bot/pkg/models"
 	"github.com/mumugogoing/meme_bot/pkg/orchestrator"
 	"github.com/rs/cors"
 )
 
 var orch *orchestrator.Orchestrator
 
+// Page size of /api/candidates when no limit is given, and the largest allowed
+const (
+	defaultCandidateLimit = 100
+	maxCandidateLimit     = 1000
+)
+
 func main() {
+	// Offline subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "train":
+			os.Exit(runTrain(os.Args[2:]))
+		case "calibrate":
+			os.Exit(runCalibrate(os.Args[2:]))
+		case "backtest":
+			os.Exit(runBacktest(os.Args[2:]))
+		case "sweep":
+			os.Exit(runSweep(os.Args[2:]))
+		case "journal":
+			os.Exit(runJournal(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		}
+	}
+	
 	log.Println("Meme Coin Trading Bot - Starting...")
 	
 	// Load configuration
@@ -41,18 +78,33 @@ func startAPIServer(cfg *config.Config) {
 	router.HandleFunc("/api/health", healthHandler).Methods("GET")
 	router.HandleFunc("/api/status", statusHandler).Methods("GET")
 	router.HandleFunc("/api/candidates", candidatesHandler).Methods("GET")
+	router.HandleFunc("/api/candidates/{chain}/{address}/approve", approveCandidateHandler).Methods("POST")
+	router.HandleFunc("/api/candidates/{chain}/{address}/reject", rejectCandidateHandler).Methods("POST")
+	router.HandleFunc("/api/candidates/{address}/approve", approveCandidateHandler).Methods("POST")
+	router.HandleFunc("/api/candidates/{address}/reject", rejectCandidateHandler).Methods("POST")
+	router.HandleFunc("/api/watchlist", watchlistHandler).Methods("GET")
 	router.HandleFunc("/api/metrics", metricsHandler).Methods("GET")
 	router.HandleFunc("/api/risk", riskHandler).Methods("GET")
 	router.HandleFunc("/api/risk/resume", resumeTradingHandler).Methods("POST")
+	router.HandleFunc("/api/positions/{chain}/{address}/close", closePositionHandler).Methods("POST")
+	router.HandleFunc("/api/calibration", calibrationHandler(cfg)).Methods("GET")
+	router.HandleFunc("/api/shadow", shadowHandler).Methods("GET")
+	router.HandleFunc("/api/journal", journalHandler(cfg)).Methods("GET")
 	
 	// Serve frontend static files for all other routes
 	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./frontend")))
 	
-	// CORS middleware
+	// Require the API token on everything but the health check
+	if cfg.APIToken == "" {
+		log.Println("WARNING: API_TOKEN is not set; anyone who can reach the API can approve trades")
+	}
+	router.Use(authMiddleware(cfg.APIToken))
+	
+	// CORS middleware; only the configured origins may call the API from a browser
 	c := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"*"},
+		AllowedOrigins: cfg.APIAllowedOrigins,
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
 	})
 	
 	handler := c.Handler(router)
@@ -86,6 +138,24 @@ func startAPIServer(cfg *config.Config) {
 	}
 }
 
+// authMiddleware rejects API requests without the bearer token. The health
+// check and the dashboard's static files stay open; an empty token disables it.
+func authMiddleware(token string) mux.MiddlewareFunc {
+	expected := []byte("Bearer " + token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" || !strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/api/health" ||
+				subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "missing or invalid API token"})
+		})
+	}
+}
+
 // Health check endpoint
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -107,32 +177,264 @@ func statusHandler(w http.ResponseWriter, r *http.Request) {
 	
 	listing := orch.GetListing()
 	candidateCount := listing.GetCandidateCount()
+	watchlistCount := orch.GetWatchlist().Count()
 	
 	response := map[string]interface{}{
 		"status":          "running",
 		"candidate_count": candidateCount,
+		"watchlist_count": watchlistCount,
+		"queue_depth":     listing.GetQueue().Len(),
 		"trading_halted":  riskStatus.TradingHalted,
 		"metrics": map[string]interface{}{
 			"tokens_found":    metrics.TokensFound,
 			"tokens_filtered": metrics.TokensFiltered,
 			"candidates":      metrics.CandidatesListed,
 			"executions":      metrics.TradesExecuted,
+			"queue_dropped":   metrics.QueueDropped,
 		},
 	}
 	
 	json.NewEncoder(w).Encode(response)
 }
 
-// Candidates endpoint
+// candidateView is a candidate with the summary fields the dashboard renders
+type candidateView struct {
+	*models.CandidateToken
+	Symbol         string                  `json:"symbol"`
+	Chain          models.Chain            `json:"chain"`
+	Address        string                  `json:"address"`
+	Strategy       string                  `json:"strategy"`
+	WinProbability float64                 `json:"win_probability"`
+	Action         string                  `json:"action"`
+	Confidence     string                  `json:"confidence"`
+	HoneypotScore  float64                 `json:"honeypot_score"`
+	Volume24h      float64                 `json:"volume_24h"`
+	Liquidity      float64                 `json:"liquidity"`
+	Price          float64                 `json:"price"`
+	PositionSize   float64                 `json:"position_size"`
+	Rationale      []models.RationaleEntry `json:"rationale"`
+	Explanation    []string                `json:"explanation"`
+}
+
+func newCandidateView(candidate *models.CandidateToken) candidateView {
+	decision := candidate.StrategyDecision
+	view := candidateView{
+		CandidateToken: candidate,
+		Symbol:         candidate.Token.Metadata[models.MetadataSymbol],
+		Chain:          candidate.Token.Chain,
+		Address:        candidate.Token.TokenAddress,
+		Strategy:       decision.Strategy,
+		WinProbability: decision.WinProbability,
+		Action:         decision.Action,
+		Confidence:     decision.Confidence,
+		HoneypotScore:  candidate.SafetyReport.HoneypotScore,
+		Volume24h:      candidate.OffChainMetrics.Volume24hDEX + candidate.OffChainMetrics.Volume24hCEX,
+		Liquidity:      candidate.OffChainMetrics.LiquidityUSD,
+		Price:          candidate.OffChainMetrics.PriceOnDEX,
+		PositionSize:   decision.SuggestedAmountUSD,
+		Rationale:      decision.Rationale,
+		Explanation:    make([]string, 0, len(decision.Rationale)),
+	}
+	
+	// Largest contributions first
+	ranked := append([]models.RationaleEntry(nil), decision.Rationale...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return math.Abs(ranked[i].Delta) > math.Abs(ranked[j].Delta)
+	})
+	for _, entry := range ranked {
+		view.Explanation = append(view.Explanation, entry.String())
+	}
+	
+	return view
+}
+
+// Candidates endpoint; filter with ?status=a,b&chain=&since=&until=&min_win_probability=
+// and page with ?offset=&limit=
 func candidatesHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	
-	listing := orch.GetListing()
-	candidates := listing.GetAllCandidates()
+	filter, err := parseCandidateFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	
+	candidates, total := orch.GetListing().GetAllCandidates(filter)
+	
+	views := make([]candidateView, 0, len(candidates))
+	for _, candidate := range candidates {
+		views = append(views, newCandidateView(candidate))
+	}
+	
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":      len(views),
+		"total":      total,
+		"offset":     filter.Offset,
+		"limit":      filter.Limit,
+		"candidates": views,
+	})
+}
+
+// parseCandidateFilter reads the candidate filter and page from the query string
+func parseCandidateFilter(r *http.Request) (listing.CandidateFilter, error) {
+	query := r.URL.Query()
+	filter := listing.CandidateFilter{
+		Chain: models.Chain(query.Get("chain")),
+		Limit: defaultCandidateLimit,
+	}
+	
+	if value := query.Get("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			filter.Statuses = append(filter.Statuses, models.CandidateStatus(strings.TrimSpace(status)))
+		}
+	}
+	
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 time", name)
+			}
+			*target = t
+		}
+	}
+	
+	if value := query.Get("min_win_probability"); value != "" {
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil || p < 0 || p > 1 {
+			return filter, errors.New("min_win_probability must be between 0 and 1")
+		}
+		filter.MinWinProbability = p
+	}
+	
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, errors.New("offset must not be negative")
+		}
+		filter.Offset = n
+	}
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxCandidateLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxCandidateLimit)
+		}
+		filter.Limit = n
+	}
+	
+	return filter, nil
+}
+
+// candidateKey identifies the candidate named in the request path
+func candidateKey(r *http.Request) models.CandidateKey {
+	vars := mux.Vars(r)
+	return models.CandidateKey{Chain: models.Chain(vars["chain"]), TokenAddress: vars["address"]}
+}
+
+// decisionKey returns the candidate an approve or reject request is for. A
+// request without a chain is resolved by address, provided the address is
+// listed on one chain only.
+func decisionKey(r *http.Request) (models.CandidateKey, error) {
+	key := candidateKey(r)
+	if key.Chain != "" {
+		return key, nil
+	}
+	return orch.GetListing().Resolve(key.TokenAddress)
+}
+
+// candidateDecision is the optional body of an approve or reject request
+type candidateDecision struct {
+	SizeUSD float64 `json:"size_usd"` // approve only; overrides the suggested size
+	Reason  string  `json:"reason"`
+}
+
+// readCandidateDecision decodes the request body, which may be empty
+func readCandidateDecision(w http.ResponseWriter, r *http.Request) (candidateDecision, bool) {
+	var body candidateDecision
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid body: " + err.Error()})
+		return body, false
+	}
+	if body.SizeUSD < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "size_usd must not be negative"})
+		return body, false
+	}
+	return body, true
+}
+
+// writeCandidateResult writes the candidate after an operator decision, or why it failed
+func writeCandidateResult(w http.ResponseWriter, candidate *models.CandidateToken, err error, status int) {
+	switch {
+	case errors.Is(err, listing.ErrCandidateNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, listing.ErrIllegalTransition), errors.Is(err, listing.ErrAmbiguousAddress):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, listing.ErrApprovalExpired):
+		w.WriteHeader(http.StatusGone)
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(status)
+	}
+	
+	response := map[string]interface{}{}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+	if candidate != nil {
+		response["candidate"] = newCandidateView(candidate)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Approve endpoint; executes the candidate through the risk checks, optionally at {"size_usd": N}
+func approveCandidateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	body, ok := readCandidateDecision(w, r)
+	if !ok {
+		return
+	}
+	
+	key, err := decisionKey(r)
+	if err != nil {
+		writeCandidateResult(w, nil, err, http.StatusAccepted)
+		return
+	}
+	candidate, err := orch.ApproveCandidate(key, body.SizeUSD, body.Reason)
+	writeCandidateResult(w, candidate, err, http.StatusAccepted)
+}
+
+// Reject endpoint
+func rejectCandidateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	body, ok := readCandidateDecision(w, r)
+	if !ok {
+		return
+	}
+	
+	key, err := decisionKey(r)
+	if err != nil {
+		writeCandidateResult(w, nil, err, http.StatusOK)
+		return
+	}
+	candidate, err := orch.RejectCandidate(key, body.Reason)
+	writeCandidateResult(w, candidate, err, http.StatusOK)
+}
+
+// Watchlist endpoint; tokens being re-evaluated after a "monitor" decision
+func watchlistHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	entries := orch.GetWatchlist().GetAll()
 	
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"count":      len(candidates),
-		"candidates": candidates,
+		"count":  len(entries),
+		"tokens": entries,
 	})
 }
 
@@ -168,3 +470,126 @@ func resumeTradingHandler(w http.ResponseWriter, r *http.Request) {
 		"message": "Trading resumed",
 	})
 }
+
+// positionClose is the body of a close position request
+type positionClose struct {
+	PnLUSD float64 `json:"pnl_usd"` // realized profit, negative for a loss
+	Reason string  `json:"reason"`
+}
+
+// Close position endpoint; records a position sold outside the bot and closes its candidate
+func closePositionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	var body positionClose
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid body: " + err.Error()})
+		return
+	}
+	
+	position, candidate, err := orch.ClosePosition(candidateKey(r), body.PnLUSD, body.Reason)
+	if errors.Is(err, risk.ErrNoOpenPosition) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	
+	response := map[string]interface{}{"position": position}
+	if err != nil {
+		// The position is closed even if its candidate could not be
+		response["error"] = err.Error()
+	}
+	if candidate != nil {
+		response["candidate"] = newCandidateView(candidate)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Shadow comparison endpoint; live vs shadow hit rate, PnL and disagreements
+func shadowHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	report := orch.GetStrategy().ShadowReport()
+	if report == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "STRATEGY_SHADOW is not set"})
+		return
+	}
+	
+	json.NewEncoder(w).Encode(report)
+}
+
+// Calibration report endpoint; ?bins=N sets the number of reliability bins
+func calibrationHandler(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		
+		if cfg.CalibrationDataFile == "" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "CALIBRATION_DATA_FILE is not set"})
+			return
+		}
+		
+		bins := 10
+		if value := r.URL.Query().Get("bins"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 100 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "bins must be between 1 and 100"})
+				return
+			}
+			bins = n
+		}
+		
+		records, err := readLabeledCandidates(cfg.CalibrationDataFile)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		
+		report := model.NewCalibrationReport(model.ObservationsFromRecords(records), bins, orch.GetStrategy().Calibration())
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Journal endpoint; the journaled events of a token, by ?correlation_id= or ?chain=&address=
+func journalHandler(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		
+		if cfg.JournalDir == "" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "JOURNAL_DIR is not set"})
+			return
+		}
+		
+		query := r.URL.Query()
+		filter := journal.Filter{
+			CorrelationID: query.Get("correlation_id"),
+			Chain:         models.Chain(query.Get("chain")),
+			TokenAddress:  query.Get("address"),
+		}
+		if filter.CorrelationID == "" && filter.TokenAddress == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "correlation_id or address is required"})
+			return
+		}
+		
+		records, err := journal.Read(cfg.JournalDir, filter)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if records == nil {
+			records = []journal.Record{}
+		}
+		
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"count":   len(records),
+			"records": records,
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mumugogoing/meme_bot/pkg/backtest"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+)
+
+// runSweep backtests a grid or random sample of parameter sets and ranks them:
+//
+//	trading sweep -data histories.jsonl -spec sweep.json [-folds 3] [-samples 200] [-workers 8] [-out ranked.csv]
+//
+// Flags override the spec file. Parameters the spec does not vary come from the
+// environment as for the bot.
+func runSweep(args []string) int {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	data := fs.String("data", "", "token histories (JSONL)")
+	specPath := fs.String("spec", "", "sweep spec (JSON): parameters, samples, folds, objective")
+	samples := fs.Int("samples", -1, "random search with this many configurations (0 for the full grid)")
+	folds := fs.Int("folds", -1, "walk-forward folds (0 to backtest on the whole dataset)")
+	objective := fs.String("objective", "", "rank by pnl, win_rate or pnl_over_drawdown")
+	seed := fs.Int64("seed", 0, "random seed for sampling and simulated slippage")
+	workers := fs.Int("workers", 0, "parallel backtests (default: every CPU)")
+	top := fs.Int("top", 10, "configurations to print")
+	out := fs.String("out", "", "write the ranked configurations to this file (.csv or .json)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" || *specPath == "" {
+		fmt.Fprintln(os.Stderr, "sweep: -data and -spec are required")
+		fs.Usage()
+		return 2
+	}
+
+	raw, err := os.ReadFile(*specPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sweep: %v\n", err)
+		return 1
+	}
+	var spec backtest.SweepSpec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "sweep: parse %s: %v\n", *specPath, err)
+		return 1
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "samples":
+			spec.Samples = *samples
+		case "folds":
+			spec.Folds = *folds
+		case "objective":
+			spec.Objective = *objective
+		case "seed":
+			spec.Seed = *seed
+		case "workers":
+			spec.Workers = *workers
+		}
+	})
+
+	log.SetOutput(io.Discard)
+	cfg := config.LoadConfig()
+
+	histories, err := backtest.ReadDataset(*data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sweep: %v\n", err)
+		return 1
+	}
+	result, err := backtest.Sweep(cfg, nil, histories, spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sweep: %v\n", err)
+		return 1
+	}
+
+	if *out != "" {
+		if err := writeSweep(*out, result); err != nil {
+			fmt.Fprintf(os.Stderr, "sweep: %v\n", err)
+			return 1
+		}
+	}
+	printSweep(result, *top)
+	return 0
+}
+
+// writeSweep writes the result as CSV or JSON, by the file's extension
+func writeSweep(path string, r *backtest.SweepResult) error {
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		return writeJSON(path, r)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := backtest.WriteSweepCSV(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printSweep prints the walk-forward folds and the top configurations
+func printSweep(r *backtest.SweepResult, top int) {
+	fmt.Printf("Swept %d configurations, ranked by %s\n", len(r.Configs), r.Spec.Objective)
+
+	if len(r.Folds) > 0 {
+		fmt.Printf("\n%-5s %-33s %-33s %10s %10s\n", "fold", "train", "test", "train obj", "test obj")
+		for _, fold := range r.Folds {
+			fmt.Printf("%-5d %-33s %-33s %10.2f %10.2f\n", fold.Fold,
+				formatWindow(fold.Train), formatWindow(fold.Test), fold.TrainObjective, fold.TestObjective)
+		}
+		fmt.Printf("Walk-forward out-of-sample PnL: $%+.2f\n", r.WalkForwardPnLUSD)
+	}
+
+	fmt.Printf("\n%-5s %7s %9s %12s %10s  %s\n", "rank", "trades", "win rate", "pnl", "score", "params")
+	for i, c := range r.Configs {
+		if i >= top {
+			break
+		}
+		metrics := c.Train
+		if len(r.Folds) > 0 {
+			metrics = c.Test
+		}
+		params := make([]string, 0, len(c.Params))
+		for _, name := range sortedKeys(c.Params) {
+			params = append(params, fmt.Sprintf("%s=%g", name, c.Params[name]))
+		}
+		if c.Error != "" {
+			params = append(params, "error: "+c.Error)
+		}
+		fmt.Printf("%-5d %7d %8.1f%% %12.2f %10.2f  %s\n",
+			c.Rank, metrics.Trades, metrics.WinRate*100, metrics.PnLUSD, c.Score, strings.Join(params, " "))
+	}
+}
+
+// formatWindow prints a window's span and size
+func formatWindow(w backtest.Window) string {
+	return fmt.Sprintf("%s..%s (%d)", w.Start.Format("01-02 15:04"), w.End.Format("01-02 15:04"), w.Tokens)
+}
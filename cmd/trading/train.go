@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mumugogoing/meme_bot/pkg/model"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// runTrain fits a win probability model from labeled candidates:
+//
+//	trading train -data trades.jsonl -out model.json [-type logistic|gbdt]
+//
+// Each input line is a models.LabeledCandidate. The most recent -holdout share
+// of trades is held back to report out-of-sample metrics.
+func runTrain(args []string) int {
+	opts := model.DefaultTrainOptions()
+
+	fs := flag.NewFlagSet("train", flag.ContinueOnError)
+	data := fs.String("data", "", "labeled candidates (JSONL)")
+	out := fs.String("out", "model.json", "model file to write")
+	holdout := fs.Float64("holdout", 0.2, "share of the most recent trades held out for evaluation")
+	fs.StringVar(&opts.Type, "type", opts.Type, "model type: logistic or gbdt")
+	fs.StringVar(&opts.Version, "version", "", "model version (default: training timestamp)")
+	fs.IntVar(&opts.Epochs, "epochs", opts.Epochs, "logistic: gradient descent epochs")
+	fs.Float64Var(&opts.LearningRate, "lr", opts.LearningRate, "logistic: learning rate")
+	fs.Float64Var(&opts.L2, "l2", opts.L2, "L2 regularization")
+	fs.IntVar(&opts.Trees, "trees", opts.Trees, "gbdt: number of trees")
+	fs.IntVar(&opts.MaxDepth, "depth", opts.MaxDepth, "gbdt: maximum tree depth")
+	fs.IntVar(&opts.MinLeaf, "min-leaf", opts.MinLeaf, "gbdt: minimum examples per leaf")
+	fs.Float64Var(&opts.Shrinkage, "shrinkage", opts.Shrinkage, "gbdt: learning rate per tree")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" {
+		fmt.Fprintln(os.Stderr, "train: -data is required")
+		fs.Usage()
+		return 2
+	}
+
+	records, err := readLabeledCandidates(*data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "train: %v\n", err)
+		return 1
+	}
+
+	examples := model.ExamplesFromRecords(records)
+	split := len(examples) - int(float64(len(examples))*(*holdout))
+	trainSet, testSet := examples[:split], examples[split:]
+
+	m, err := model.Train(trainSet, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "train: %v\n", err)
+		return 1
+	}
+	if err := m.Save(*out); err != nil {
+		fmt.Fprintf(os.Stderr, "train: %v\n", err)
+		return 1
+	}
+
+	report := map[string]interface{}{
+		"model":   *out,
+		"type":    m.Type,
+		"version": m.Version,
+		"train":   model.Evaluate(m, trainSet),
+	}
+	if len(testSet) > 0 {
+		report["holdout"] = model.Evaluate(m, testSet)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	return 0
+}
+
+// readLabeledCandidates reads a JSONL file of labeled candidates
+func readLabeledCandidates(path string) ([]models.LabeledCandidate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := model.ReadRecords(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return records, nil
+}
@@ -1,10 +1,11 @@
 // Configuration
-const API_BASE_URL = 'http://localhost:8080/api';
+const API_BASE_URL = '/api';
 const REFRESH_INTERVAL = 5000; // 5 seconds
 
 // State
 let refreshIntervalId = null;
 let lastUpdate = null;
+let tokenAsked = false; // whether the operator was already asked for the API token
 
 // Initialize on page load
 document.addEventListener('DOMContentLoaded', () => {
@@ -12,6 +13,7 @@ document.addEventListener('DOMContentLoaded', () => {
     
     // Set up event listeners
     document.getElementById('refresh-candidates').addEventListener('click', loadCandidates);
+    document.getElementById('candidate-status-filter').addEventListener('change', loadCandidates);
     
     // Start auto-refresh
     startAutoRefresh();
@@ -20,6 +22,32 @@ document.addEventListener('DOMContentLoaded', () => {
     loadAllData();
 });
 
+// Call the API with the token the bot was started with (API_TOKEN), asking
+// for it once when the API refuses the request
+async function apiFetch(url, options = {}) {
+    const send = () => {
+        const headers = { ...(options.headers || {}) };
+        const token = localStorage.getItem('apiToken');
+        if (token) {
+            headers['Authorization'] = `Bearer ${token}`;
+        }
+        return fetch(url, { ...options, headers });
+    };
+    
+    let response = await send();
+    if (response.status === 401 && !tokenAsked) {
+        // Parallel refreshes share one prompt
+        tokenAsked = true;
+        const token = window.prompt('API token');
+        if (token) {
+            localStorage.setItem('apiToken', token);
+            tokenAsked = false;
+            response = await send();
+        }
+    }
+    return response;
+}
+
 // Auto-refresh functionality
 function startAutoRefresh() {
     if (refreshIntervalId) {
@@ -60,7 +88,7 @@ function updateLastUpdateTime() {
 // Load status
 async function loadStatus() {
     try {
-        const response = await fetch(`${API_BASE_URL}/status`);
+        const response = await apiFetch(`${API_BASE_URL}/status`);
         if (!response.ok) throw new Error('Failed to load status');
         
         const data = await response.json();
@@ -88,7 +116,7 @@ async function loadStatus() {
 // Load metrics
 async function loadMetrics() {
     try {
-        const response = await fetch(`${API_BASE_URL}/metrics`);
+        const response = await apiFetch(`${API_BASE_URL}/metrics`);
         if (!response.ok) throw new Error('Failed to load metrics');
         
         const data = await response.json();
@@ -106,7 +134,7 @@ async function loadMetrics() {
 // Load risk status
 async function loadRisk() {
     try {
-        const response = await fetch(`${API_BASE_URL}/risk`);
+        const response = await apiFetch(`${API_BASE_URL}/risk`);
         if (!response.ok) throw new Error('Failed to load risk status');
         
         const data = await response.json();
@@ -160,7 +188,7 @@ function createRiskItem(label, value) {
 // Resume trading
 async function resumeTrading() {
     try {
-        const response = await fetch(`${API_BASE_URL}/risk/resume`, {
+        const response = await apiFetch(`${API_BASE_URL}/risk/resume`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json'
@@ -184,7 +212,9 @@ async function resumeTrading() {
 // Load candidates
 async function loadCandidates() {
     try {
-        const response = await fetch(`${API_BASE_URL}/candidates`);
+        const status = document.getElementById('candidate-status-filter').value;
+        const query = status ? `?status=${encodeURIComponent(status)}` : '';
+        const response = await apiFetch(`${API_BASE_URL}/candidates${query}`);
         if (!response.ok) throw new Error('Failed to load candidates');
         
         const data = await response.json();
@@ -208,6 +238,14 @@ async function loadCandidates() {
         
         candidatesList.innerHTML = html;
         
+        // Add event listeners for approve/reject buttons on pending candidates
+        candidatesList.querySelectorAll('.approve-candidate-btn').forEach(button => {
+            button.addEventListener('click', () => approveCandidate(button.dataset.chain, button.dataset.address, button.dataset.size));
+        });
+        candidatesList.querySelectorAll('.reject-candidate-btn').forEach(button => {
+            button.addEventListener('click', () => rejectCandidate(button.dataset.chain, button.dataset.address));
+        });
+        
     } catch (error) {
         console.error('Error loading candidates:', error);
         document.getElementById('candidates-list').innerHTML = `
@@ -237,11 +275,130 @@ function createCandidateCard(candidate) {
                 ${createDetailItem('Liquidity', `$${formatNumber(candidate.liquidity || 0)}`)}
                 ${createDetailItem('Price', `$${(candidate.price || 0).toFixed(8)}`)}
                 ${createDetailItem('Position Size', `$${(candidate.position_size || 0).toFixed(2)}`)}
+                ${createDetailItem('Strategy', candidate.strategy || 'N/A')}
+                ${createDetailItem('Status', candidate.status || 'N/A')}
             </div>
+            ${createRationale(candidate.rationale)}
+            ${createHistory(candidate.history)}
+            ${createCandidateActions(candidate)}
         </div>
     `;
 }
 
+// Create approve/reject buttons for a pending candidate
+function createCandidateActions(candidate) {
+    if (candidate.status !== 'pending') {
+        return '';
+    }
+    
+    return `
+        <div class="candidate-actions">
+            <button class="btn btn-success approve-candidate-btn" data-chain="${candidate.chain}" data-address="${candidate.address}" data-size="${(candidate.position_size || 0).toFixed(2)}">Approve</button>
+            <button class="btn btn-danger reject-candidate-btn" data-chain="${candidate.chain}" data-address="${candidate.address}">Reject</button>
+        </div>
+    `;
+}
+
+// Approve a candidate, optionally at a different position size
+async function approveCandidate(chain, address, suggestedSize) {
+    const size = prompt(`Position size in USD for ${address}`, suggestedSize);
+    if (size === null) {
+        return;
+    }
+    
+    await decideCandidate(chain, address, 'approve', {
+        size_usd: parseFloat(size) || 0
+    });
+}
+
+// Reject a candidate with an optional reason
+async function rejectCandidate(chain, address) {
+    const reason = prompt(`Reason for rejecting ${address} (optional)`, '');
+    if (reason === null) {
+        return;
+    }
+    
+    await decideCandidate(chain, address, 'reject', { reason });
+}
+
+// Send an operator decision on a candidate
+async function decideCandidate(chain, address, decision, body) {
+    try {
+        const response = await apiFetch(`${API_BASE_URL}/candidates/${encodeURIComponent(chain)}/${encodeURIComponent(address)}/${decision}`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify(body)
+        });
+        
+        const data = await response.json();
+        if (!response.ok) throw new Error(data.error || `Failed to ${decision} candidate`);
+        
+        // Reload data to reflect changes
+        await loadAllData();
+        
+    } catch (error) {
+        console.error(`Error trying to ${decision} candidate:`, error);
+        alert(error.message);
+    }
+}
+
+// Create status history HTML, oldest first
+function createHistory(history) {
+    if (!history || history.length === 0) {
+        return '';
+    }
+    
+    const items = history
+        .map(entry => `
+            <li class="history-item">
+                <span class="history-time">${new Date(entry.at).toLocaleTimeString()}</span>
+                <span class="history-status">${entry.to}</span>
+                <span class="history-actor">${entry.actor}</span>
+                <span class="history-reason">${entry.reason || ''}</span>
+            </li>
+        `)
+        .join('');
+    
+    return `
+        <details class="candidate-rationale">
+            <summary>Status history</summary>
+            <ul class="rationale-list">${items}</ul>
+        </details>
+    `;
+}
+
+// Create rationale HTML, largest contributions first
+function createRationale(rationale) {
+    if (!rationale || rationale.length === 0) {
+        return '';
+    }
+    
+    const items = [...rationale]
+        .sort((a, b) => Math.abs(b.delta) - Math.abs(a.delta))
+        .map(entry => {
+            const delta = (entry.delta * 100).toFixed(1);
+            const sign = entry.delta >= 0 ? '+' : '';
+            const cls = entry.delta >= 0 ? 'positive' : 'negative';
+            return `
+                <li class="rationale-item">
+                    <span class="rationale-factor">${entry.factor.replace(/_/g, ' ')}</span>
+                    <span class="rationale-value">${formatNumber(entry.value)}</span>
+                    <span class="rationale-delta ${cls}">${sign}${delta}%</span>
+                </li>
+            `;
+        })
+        .join('');
+    
+    return `
+        <details class="candidate-rationale">
+            <summary>Why this decision</summary>
+            <ul class="rationale-list">${items}</ul>
+        </details>
+    `;
+}
+
 // Create detail item HTML
 function createDetailItem(label, value) {
     return `
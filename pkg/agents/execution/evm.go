@@ -0,0 +1,463 @@
+package execution
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/evm"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+const (
+	// transferTopic is keccak256("Transfer(address,address,uint256)")
+	transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+	// swapDeadline bounds how long a swap stays valid after signing
+	swapDeadline = 60 * time.Second
+	// gasHeadroom pads the node's gas estimate
+	gasHeadroom = 1.2
+	// receiptPollInterval is how often a sent transaction's receipt is checked
+	receiptPollInterval = 2 * time.Second
+)
+
+var (
+	selectorGetAmountsOut      = evm.Selector("getAmountsOut(uint256,address[])")
+	selectorSwapExactETHForFoT = evm.Selector("swapExactETHForTokensSupportingFeeOnTransferTokens(uint256,address[],address,uint256)")
+	selectorQuoteExactInput    = evm.Selector("quoteExactInputSingle((address,address,uint256,uint24,uint160))")
+	selectorExactInputSingle   = evm.Selector("exactInputSingle((address,address,uint24,address,uint256,uint256,uint160))")
+	selectorFee                = evm.Selector("fee()")
+	selectorMulticallDeadline  = evm.Selector("multicall(uint256,bytes[])")
+)
+
+// errOKXWalletEVM is returned while live EVM swaps can only be signed locally
+var errOKXWalletEVM = errors.New("EVM swaps are signed with PRIVATE_KEY; set USE_OKX_WALLET=false")
+
+// evmSwap is a buy of a token with ETH through one router
+type evmSwap struct {
+	router evm.Address
+	value  *big.Int // ETH in, in wei
+	quote  *big.Int // tokens out quoted by the router or quoter
+	net    *big.Int // the quote less the token's transfer tax, what should arrive
+	minOut *big.Int
+	data   []byte
+	token  evm.Address
+}
+
+// evmSwapper builds, signs and sends Uniswap swaps on Base
+type evmSwapper struct {
+	client        *evm.Client
+	prices        PriceSource // prices WETH to size swaps
+	key           *evm.PrivateKey
+	chainID       *big.Int
+	routerV2      evm.Address
+	routerV3      evm.Address
+	quoterV3      evm.Address
+	weth          evm.Address
+	maxSlippage   float64
+	confirmations int
+	timeout       time.Duration
+	pollInterval  time.Duration
+	now           func() time.Time
+
+	nonceMu   sync.Mutex
+	nextNonce uint64 // 0 until the first transaction is sent
+
+	pendingMu sync.Mutex
+	pending   map[string]*evmSwap // swaps sent but not confirmed in time, by tx hash
+}
+
+// newEVMSwapper creates a swapper from the Base settings of cfg
+func newEVMSwapper(cfg *config.Config, prices PriceSource) (*evmSwapper, error) {
+	if cfg.UseOKXWallet {
+		return nil, errOKXWalletEVM
+	}
+	if cfg.PrivateKey == "" {
+		return nil, errors.New("PRIVATE_KEY is not set")
+	}
+	key, err := evm.ParsePrivateKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &evmSwapper{
+		client:        evm.NewClient(cfg.BaseRPCURL),
+		prices:        prices,
+		key:           key,
+		chainID:       big.NewInt(cfg.BaseChainID),
+		maxSlippage:   cfg.MaxSlippage,
+		confirmations: cfg.ConfirmationsWait,
+		timeout:       cfg.ConfirmTimeout,
+		pollInterval:  receiptPollInterval,
+		now:           time.Now,
+	}
+	for _, c := range []struct {
+		name, value string
+		dst         *evm.Address
+	}{
+		{"BASE_ROUTER_V2", cfg.BaseRouterV2, &s.routerV2},
+		{"BASE_ROUTER_V3", cfg.BaseRouterV3, &s.routerV3},
+		{"BASE_QUOTER_V3", cfg.BaseQuoterV3, &s.quoterV3},
+		{"BASE_WETH", cfg.BaseWETH, &s.weth},
+	} {
+		if *c.dst, err = evm.ParseAddress(c.value); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return s, nil
+}
+
+// Buy swaps the candidate's suggested amount of ETH for the token and waits
+// for the configured confirmations. Errors before broadcast leave the result
+// without a TxHash.
+func (s *evmSwapper) Buy(ctx context.Context, candidate *models.CandidateToken, result *models.ExecutionResult) error {
+	swap, err := s.prepare(ctx, candidate)
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.send(ctx, swap)
+	if err != nil {
+		return err
+	}
+	result.TxHash = hash
+
+	receipt, err := s.waitForReceipt(ctx, hash)
+	if err != nil {
+		s.track(hash, swap)
+		return err
+	}
+	s.settle(swap, receipt, result)
+	return nil
+}
+
+// Reconcile checks on a swap that was sent but not confirmed in time. It is
+// settled once its receipt is final and failed if the node dropped it;
+// otherwise the result is left pending.
+func (s *evmSwapper) Reconcile(ctx context.Context, result *models.ExecutionResult) error {
+	receipt, err := s.settled(ctx, result.TxHash)
+	if err != nil {
+		return err
+	}
+	if receipt != nil {
+		s.settle(s.untrack(result.TxHash), receipt, result)
+		return nil
+	}
+
+	// The node knows mined transactions too, so an unknown one will never land
+	known, err := s.client.TransactionKnown(ctx, result.TxHash)
+	if err != nil || known {
+		return err
+	}
+	s.untrack(result.TxHash)
+	s.nonceMu.Lock()
+	s.nextNonce = 0
+	s.nonceMu.Unlock()
+	result.Status = "failed"
+	result.Error = "transaction dropped"
+	return nil
+}
+
+// settle fills the result from a final receipt. The swap is nil when it was
+// sent before a restart, and slippage is then not measured.
+func (s *evmSwapper) settle(swap *evmSwap, receipt *evm.Receipt, result *models.ExecutionResult) {
+	result.GasUsed = receipt.GasUsed
+	result.Error = ""
+	if receipt.Status != 1 {
+		result.Status = "failed"
+		result.Error = "transaction reverted"
+		return
+	}
+	result.Status = "confirmed"
+	if swap != nil {
+		result.SlippageActual = s.slippage(swap, receipt)
+	}
+}
+
+// track remembers a swap that is still pending so it can be settled later
+func (s *evmSwapper) track(hash string, swap *evmSwap) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	if s.pending == nil {
+		s.pending = make(map[string]*evmSwap)
+	}
+	s.pending[hash] = swap
+}
+
+// untrack forgets a pending swap, returning it if it was known
+func (s *evmSwapper) untrack(hash string) *evmSwap {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	swap := s.pending[hash]
+	delete(s.pending, hash)
+	return swap
+}
+
+// prepare quotes the swap and encodes the router call. Only the WETH pools
+// of the V2 and V3 routers are traded.
+func (s *evmSwapper) prepare(ctx context.Context, candidate *models.CandidateToken) (*evmSwap, error) {
+	token, err := evm.ParseAddress(candidate.Token.TokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	pool := candidate.OffChainMetrics.Pool
+	if pool == nil {
+		return nil, errors.New("no pool to route the swap through")
+	}
+	if !quotedInETH(pool) {
+		return nil, fmt.Errorf("pool %s quotes %s, not WETH", pool.Address, pool.QuoteSymbol)
+	}
+	if pool.Version == "v4" || candidate.Token.Metadata[models.MetadataDEX] == "uniswap_v4" {
+		return nil, fmt.Errorf("pool %s is a Uniswap v4 pool, which the routers cannot trade", pool.Address)
+	}
+	ethUSD, err := s.ethPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value := ethToWei(candidate.StrategyDecision.SuggestedAmountUSD / ethUSD)
+	if value.Sign() <= 0 {
+		return nil, errors.New("swap amount is zero")
+	}
+
+	swap := &evmSwap{value: value, token: token}
+	tax := candidate.SafetyReport.OwnerControls.TaxFee
+	me := s.key.Address()
+	if usesV3(candidate) {
+		fee, err := s.poolFee(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		out, err := s.client.Call(ctx, me, s.quoterV3, nil, evm.Call(selectorQuoteExactInput,
+			evm.AddressWord(s.weth), evm.AddressWord(token), evm.Uint(value), evm.Uint64(fee), evm.Uint64(0)))
+		if err != nil {
+			return nil, fmt.Errorf("quote: %w", err)
+		}
+		if swap.quote, err = evm.WordAt(out, 0); err != nil {
+			return nil, fmt.Errorf("quote: %w", err)
+		}
+		swap.net, swap.minOut = minAmountOut(swap.quote, tax, s.maxSlippage)
+		swap.router = s.routerV3
+		// exactInputSingle on SwapRouter02 takes no deadline; multicall checks one first
+		deadline := uint64(s.now().Add(swapDeadline).Unix())
+		swap.data = evm.CallWithBytesList(selectorMulticallDeadline, []evm.Word{evm.Uint64(deadline)}, 1, [][]byte{
+			evm.Call(selectorExactInputSingle,
+				evm.AddressWord(s.weth), evm.AddressWord(token), evm.Uint64(fee), evm.AddressWord(me),
+				evm.Uint(value), evm.Uint(swap.minOut), evm.Uint64(0)),
+		})
+	} else {
+		path := []evm.Address{s.weth, token}
+		out, err := s.client.Call(ctx, me, s.routerV2, nil,
+			evm.CallWithAddresses(selectorGetAmountsOut, []evm.Word{evm.Uint(value)}, 1, path))
+		if err != nil {
+			return nil, fmt.Errorf("quote: %w", err)
+		}
+		// amounts[] is returned as offset, length, amounts[0], amounts[1]
+		if swap.quote, err = evm.WordAt(out, 3); err != nil {
+			return nil, fmt.Errorf("quote: %w", err)
+		}
+		swap.net, swap.minOut = minAmountOut(swap.quote, tax, s.maxSlippage)
+		swap.router = s.routerV2
+		deadline := uint64(s.now().Add(swapDeadline).Unix())
+		swap.data = evm.CallWithAddresses(selectorSwapExactETHForFoT,
+			[]evm.Word{evm.Uint(swap.minOut), evm.AddressWord(me), evm.Uint64(deadline)}, 1, path)
+	}
+	if swap.net.Sign() == 0 {
+		return nil, errors.New("quote returned no tokens")
+	}
+	return swap, nil
+}
+
+// ethPrice returns the USD price of WETH to size a swap in ETH
+func (s *evmSwapper) ethPrice(ctx context.Context) (float64, error) {
+	if s.prices == nil {
+		return 0, errors.New("no ETH price source to size the swap")
+	}
+	price, err := s.prices.Price(ctx, models.TokenFound{Chain: models.ChainBase, TokenAddress: s.weth.Hex()})
+	if err != nil {
+		return 0, fmt.Errorf("ETH price: %w", err)
+	}
+	if price <= 0 {
+		return 0, errors.New("ETH price: none reported")
+	}
+	return price, nil
+}
+
+// poolFee reads a V3 pool's fee tier, in hundredths of a basis point
+func (s *evmSwapper) poolFee(ctx context.Context, pool *models.PoolState) (uint64, error) {
+	address, err := evm.ParseAddress(pool.Address)
+	if err != nil {
+		return 0, fmt.Errorf("pool address: %w", err)
+	}
+	out, err := s.client.Call(ctx, s.key.Address(), address, nil, evm.Call(selectorFee))
+	if err != nil {
+		return 0, fmt.Errorf("pool fee: %w", err)
+	}
+	fee, err := evm.WordAt(out, 0)
+	if err != nil {
+		return 0, fmt.Errorf("pool fee: %w", err)
+	}
+	if fee.Sign() <= 0 || !fee.IsUint64() || fee.Uint64() >= 1_000_000 {
+		return 0, fmt.Errorf("pool fee: unexpected tier %s", fee)
+	}
+	return fee.Uint64(), nil
+}
+
+// send prices, signs and broadcasts the swap. Nonces are handed out under a
+// lock so concurrent swaps never reuse one; after a failed send the next
+// nonce is taken from the node again.
+func (s *evmSwapper) send(ctx context.Context, swap *evmSwap) (string, error) {
+	me := s.key.Address()
+	gas, err := s.client.EstimateGas(ctx, me, swap.router, swap.value, swap.data)
+	if err != nil {
+		return "", fmt.Errorf("estimate gas: %w", err)
+	}
+	tip, err := s.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return "", fmt.Errorf("priority fee: %w", err)
+	}
+	baseFee, err := s.client.BaseFee(ctx)
+	if err != nil {
+		return "", fmt.Errorf("base fee: %w", err)
+	}
+	// Twice the base fee stays valid through several full blocks
+	feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+
+	nonce, err := s.client.PendingNonce(ctx, me)
+	if err != nil {
+		return "", fmt.Errorf("nonce: %w", err)
+	}
+	if s.nextNonce > nonce {
+		nonce = s.nextNonce
+	}
+
+	tx := &evm.DynamicFeeTx{
+		ChainID:   s.chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       uint64(float64(gas) * gasHeadroom),
+		To:        swap.router,
+		Value:     swap.value,
+		Data:      swap.data,
+	}
+	raw, hash := tx.Sign(s.key)
+	if _, err := s.client.SendRawTransaction(ctx, raw); err != nil {
+		s.nextNonce = 0
+		return "", fmt.Errorf("send: %w", err)
+	}
+	s.nextNonce = nonce + 1
+	return hash, nil
+}
+
+// waitForReceipt polls until the transaction has the configured number of
+// confirmations; a reverted transaction is returned as soon as it is mined
+func (s *evmSwapper) waitForReceipt(ctx context.Context, hash string) (*evm.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		if receipt, err := s.settled(ctx, hash); err == nil && receipt != nil {
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("not confirmed after %s", s.timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// settled returns a transaction's receipt once it reverted or has the
+// configured number of confirmations, and nil before then
+func (s *evmSwapper) settled(ctx context.Context, hash string) (*evm.Receipt, error) {
+	receipt, err := s.client.TransactionReceipt(ctx, hash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	if receipt.Status != 1 {
+		return receipt, nil
+	}
+	head, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head+1 >= receipt.BlockNumber+uint64(s.confirmations) {
+		return receipt, nil
+	}
+	return nil, nil
+}
+
+// slippage compares the tokens received with the quote less the known
+// transfer tax; any tax beyond it counts as slippage
+func (s *evmSwapper) slippage(swap *evmSwap, receipt *evm.Receipt) float64 {
+	me := evm.AddressWord(s.key.Address())
+	recipient := "0x" + hex.EncodeToString(me[:])
+
+	received := new(big.Int)
+	for _, l := range receipt.Logs {
+		if !strings.EqualFold(l.Address, swap.token.Hex()) || len(l.Topics) != 3 ||
+			l.Topics[0] != transferTopic || !strings.EqualFold(l.Topics[2], recipient) {
+			continue
+		}
+		if amount, ok := new(big.Int).SetString(strings.TrimPrefix(l.Data, "0x"), 16); ok {
+			received.Add(received, amount)
+		}
+	}
+
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(received), new(big.Float).SetInt(swap.net)).Float64()
+	return math.Max(0, 1-ratio)
+}
+
+// usesV3 reports whether the candidate's pool trades through the V3 router
+func usesV3(candidate *models.CandidateToken) bool {
+	if pool := candidate.OffChainMetrics.Pool; pool != nil && pool.Kind == models.PoolConcentrated {
+		return true
+	}
+	return candidate.Token.Metadata[models.MetadataDEX] == "uniswap_v3"
+}
+
+// quotedInETH reports whether a pool pairs the token with WETH
+func quotedInETH(pool *models.PoolState) bool {
+	switch strings.ToUpper(pool.QuoteSymbol) {
+	case "WETH", "ETH":
+		return true
+	}
+	return false
+}
+
+// minAmountOut takes the token's transfer tax off a router quote, which does
+// not know about it, and applies the slippage tolerance to what is left. It
+// returns the tokens expected to arrive and the minimum accepted.
+func minAmountOut(quote *big.Int, tax, maxSlippage float64) (*big.Int, *big.Int) {
+	net := applyBps(quote, 1-tax)
+	return net, applyBps(net, 1-maxSlippage)
+}
+
+// applyBps scales an amount by a share, rounded to a basis point and floored at zero
+func applyBps(amount *big.Int, share float64) *big.Int {
+	bps := int64(math.Round(share * 10000))
+	if bps < 0 {
+		bps = 0
+	}
+	out := new(big.Int).Mul(amount, big.NewInt(bps))
+	return out.Quo(out, big.NewInt(10000))
+}
+
+// ethToWei converts an ETH amount to wei
+func ethToWei(eth float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(eth), big.NewFloat(1e18)).Int(nil)
+	return wei
+}
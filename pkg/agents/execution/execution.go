@@ -2,27 +2,191 @@ package execution
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/mumugogoing/meme_bot/pkg/config"
 	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
 )
 
+// reconcileInterval is how often trades still pending after their
+// confirmation timeout are checked on
+const reconcileInterval = 15 * time.Second
+
+// PriceSource returns a token's current USD price
+type PriceSource interface {
+	Price(ctx context.Context, token models.TokenFound) (float64, error)
+}
+
 // ExecutionAgent handles trade execution
 type ExecutionAgent struct {
 	config *config.Config
+	store  storage.ExecutionRepository
+	prices PriceSource // sizes swaps in ETH and SOL
+	
+	evmOnce sync.Once // the EVM swapper is set up on the first live Base trade
+	evm     *evmSwapper
+	evmErr  error
+	
+	solanaOnce sync.Once // likewise for Solana
+	solana     *solanaSwapper
+	solanaErr  error
+	
+	pendingMu sync.Mutex
+	pending   map[models.CandidateKey]*models.ExecutionResult // sent trades not yet settled
 }
 
 // NewExecutionAgent creates a new execution agent
 func NewExecutionAgent(cfg *config.Config) *ExecutionAgent {
 	return &ExecutionAgent{
-		config: cfg,
+		config:  cfg,
+		pending: make(map[models.CandidateKey]*models.ExecutionResult),
 	}
 }
 
-// Execute performs a trade execution
+// SetStore records every execution result in repo
+func (e *ExecutionAgent) SetStore(repo storage.ExecutionRepository) {
+	e.store = repo
+}
+
+// SetPriceSource sets where the ETH and SOL prices that size swaps are read from
+func (e *ExecutionAgent) SetPriceSource(prices PriceSource) {
+	e.prices = prices
+}
+
+// Execute performs a trade execution and records its result. A trade still
+// pending when its confirmation times out is tracked until it settles.
 func (e *ExecutionAgent) Execute(ctx context.Context, candidate *models.CandidateToken) (*models.ExecutionResult, error) {
+	result, err := e.execute(ctx, candidate)
+	if result != nil {
+		e.save(ctx, result)
+		if result.Status == "pending" {
+			e.Track(result)
+		}
+	}
+	return result, err
+}
+
+// save records an execution result
+func (e *ExecutionAgent) save(ctx context.Context, result *models.ExecutionResult) {
+	if e.store == nil {
+		return
+	}
+	if err := e.store.SaveExecution(ctx, result); err != nil {
+		log.Printf("ExecutionAgent: Failed to save execution for %s: %v\n", result.TokenAddress, err)
+	}
+}
+
+// Track adds a pending trade, such as one sent before a restart, to those
+// the reconciler checks on
+func (e *ExecutionAgent) Track(result *models.ExecutionResult) {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	
+	e.pending[models.CandidateKey{Chain: result.Chain, TokenAddress: result.TokenAddress}] = result
+}
+
+// Pending returns the trades that were sent but have not settled
+func (e *ExecutionAgent) Pending() []*models.ExecutionResult {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	
+	pending := make([]*models.ExecutionResult, 0, len(e.pending))
+	for _, result := range e.pending {
+		pending = append(pending, result)
+	}
+	return pending
+}
+
+// StartReconciler checks on pending trades periodically and hands each one to
+// settle once it is confirmed or failed
+func (e *ExecutionAgent) StartReconciler(settle func(*models.ExecutionResult)) chan struct{} {
+	stopChan := make(chan struct{})
+	
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-ticker.C:
+				for _, result := range e.ReconcilePending(context.Background()) {
+					settle(result)
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+	
+	return stopChan
+}
+
+// ReconcilePending checks on every pending trade and returns those that
+// settled, which are no longer tracked
+func (e *ExecutionAgent) ReconcilePending(ctx context.Context) []*models.ExecutionResult {
+	var settled []*models.ExecutionResult
+	for _, pending := range e.Pending() {
+		result, err := e.Reconcile(ctx, pending)
+		if err != nil {
+			log.Printf("ExecutionAgent: Failed to check pending trade %s: %v\n", pending.TxHash, err)
+			continue
+		}
+		if result.Status == "pending" {
+			continue
+		}
+		
+		e.pendingMu.Lock()
+		delete(e.pending, models.CandidateKey{Chain: result.Chain, TokenAddress: result.TokenAddress})
+		e.pendingMu.Unlock()
+		settled = append(settled, result)
+	}
+	return settled
+}
+
+// Reconcile checks on a pending trade by its transaction hash and returns its
+// current result, recorded once it has settled
+func (e *ExecutionAgent) Reconcile(ctx context.Context, pending *models.ExecutionResult) (*models.ExecutionResult, error) {
+	result := *pending
+	if result.Status != "pending" {
+		return &result, nil
+	}
+	
+	var err error
+	switch result.Chain {
+	case models.ChainBase:
+		e.evmOnce.Do(func() {
+			e.evm, e.evmErr = newEVMSwapper(e.config, e.prices)
+		})
+		if err = e.evmErr; err == nil {
+			err = e.evm.Reconcile(ctx, &result)
+		}
+	case models.ChainSolana:
+		e.solanaOnce.Do(func() {
+			e.solana, e.solanaErr = newSolanaSwapper(e.config, e.prices)
+		})
+		if err = e.solanaErr; err == nil {
+			err = e.solana.Reconcile(ctx, &result)
+		}
+	default:
+		err = fmt.Errorf("unsupported chain %s", result.Chain)
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	if result.Status != "pending" {
+		log.Printf("ExecutionAgent: Pending trade %s %s\n", result.TxHash, result.Status)
+		e.save(ctx, &result)
+	}
+	return &result, nil
+}
+
+// execute performs a trade execution on the candidate's chain
+func (e *ExecutionAgent) execute(ctx context.Context, candidate *models.CandidateToken) (*models.ExecutionResult, error) {
 	log.Printf("ExecutionAgent: Executing trade for %s on %s\n", 
 		candidate.Token.TokenAddress, candidate.Token.Chain)
 	
@@ -55,60 +219,60 @@ func (e *ExecutionAgent) Execute(ctx context.Context, candidate *models.Candidat
 	return result, nil
 }
 
-// executeEVM executes a trade on EVM chains (Base)
+// executeEVM swaps ETH for the token through a Uniswap router on Base
 func (e *ExecutionAgent) executeEVM(ctx context.Context, candidate *models.CandidateToken, result *models.ExecutionResult) (*models.ExecutionResult, error) {
-	// TODO: Implement actual EVM trade execution
-	// 1. Dry-run simulation first
-	// 2. Calculate gas price and limits
-	// 3. Approve token spending if needed (minimal amount)
-	// 4. Execute swap through router contract
-	// 5. Sign transaction (OKX Wallet SDK or private key)
-	// 6. Broadcast transaction
-	// 7. Wait for confirmations
-	
 	log.Printf("ExecutionAgent: Executing EVM trade for %s\n", candidate.Token.TokenAddress)
 	
-	// Placeholder implementation
-	// In production, this would:
-	// - Use go-ethereum or ethers-go
-	// - Call router.swapExactETHForTokens or similar
-	// - Manage nonce, gas, and signing
+	e.evmOnce.Do(func() {
+		e.evm, e.evmErr = newEVMSwapper(e.config, e.prices)
+	})
+	if e.evmErr != nil {
+		result.Status = "failed"
+		result.Error = e.evmErr.Error()
+		return result, nil
+	}
 	
-	result.Status = "confirmed"
-	result.TxHash = "0x" + candidate.Token.TokenAddress[:40]
-	result.GasUsed = 150000
-	result.SlippageActual = 0.02
+	if err := e.evm.Buy(ctx, candidate, result); err != nil {
+		// A sent transaction may still confirm later
+		result.Status = "failed"
+		if result.TxHash != "" {
+			result.Status = "pending"
+		}
+		result.Error = err.Error()
+		log.Printf("ExecutionAgent: EVM trade for %s %s: %v\n", candidate.Token.TokenAddress, result.Status, err)
+		return result, nil
+	}
 	
-	log.Printf("ExecutionAgent: EVM trade executed - TX: %s\n", result.TxHash)
+	log.Printf("ExecutionAgent: EVM trade %s - TX: %s\n", result.Status, result.TxHash)
 	
 	return result, nil
 }
 
-// executeSolana executes a trade on Solana
+// executeSolana swaps SOL for the token through its Raydium pool
 func (e *ExecutionAgent) executeSolana(ctx context.Context, candidate *models.CandidateToken, result *models.ExecutionResult) (*models.ExecutionResult, error) {
-	// TODO: Implement actual Solana trade execution
-	// 1. Dry-run simulation using simulateTransaction
-	// 2. Prepare swap instruction (Raydium, Orca, Jupiter)
-	// 3. Create and fund associated token accounts if needed
-	// 4. Build transaction with proper compute budget
-	// 5. Sign transaction (OKX Wallet SDK or private key)
-	// 6. Send transaction with preflight checks
-	// 7. Confirm transaction
-	
 	log.Printf("ExecutionAgent: Executing Solana trade for %s\n", candidate.Token.TokenAddress)
 	
-	// Placeholder implementation
-	// In production, this would:
-	// - Use solana-go SDK
-	// - Create swap instruction for Jupiter/Raydium
-	// - Handle account creation and rent
-	// - Sign and send transaction
+	e.solanaOnce.Do(func() {
+		e.solana, e.solanaErr = newSolanaSwapper(e.config, e.prices)
+	})
+	if e.solanaErr != nil {
+		result.Status = "failed"
+		result.Error = e.solanaErr.Error()
+		return result, nil
+	}
 	
-	result.Status = "confirmed"
-	result.TxHash = candidate.Token.TokenAddress[:44]
-	result.SlippageActual = 0.015
+	if err := e.solana.Buy(ctx, candidate, result); err != nil {
+		// A sent transaction may still confirm later
+		result.Status = "failed"
+		if result.TxHash != "" {
+			result.Status = "pending"
+		}
+		result.Error = err.Error()
+		log.Printf("ExecutionAgent: Solana trade for %s %s: %v\n", candidate.Token.TokenAddress, result.Status, err)
+		return result, nil
+	}
 	
-	log.Printf("ExecutionAgent: Solana trade executed - TX: %s\n", result.TxHash)
+	log.Printf("ExecutionAgent: Solana trade %s - TX: %s\n", result.Status, result.TxHash)
 	
 	return result, nil
 }
@@ -0,0 +1,657 @@
+package execution
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/evm"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/solana"
+)
+
+const (
+	testKey   = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testToken = "0x00000000000000000000000000000000000000bb"
+	testPool  = "0x00000000000000000000000000000000000000cc"
+)
+
+// fixedPrice prices every token, WETH and wrapped SOL included, at a fixed USD price
+type fixedPrice float64
+
+func (p fixedPrice) Price(context.Context, models.TokenFound) (float64, error) {
+	return float64(p), nil
+}
+
+// evmStub is a JSON-RPC node that quotes, accepts one swap and mines it
+type evmStub struct {
+	t        *testing.T
+	cfg      *config.Config
+	quote    *big.Int
+	received *big.Int
+	status   string
+
+	mu       sync.Mutex
+	head     uint64
+	sent     []byte // calldata of the accepted swap
+	to       evm.Address
+	receipts int
+	unmined  bool // the swap waits in the mempool
+	dropped  bool // the swap was dropped from the mempool
+}
+
+func (s *evmStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.t.Errorf("decode request: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result interface{}
+	switch req.Method {
+	case "eth_call":
+		// getAmountsOut and quoteExactInputSingle both start with the quote's
+		// amount in a word the swapper reads
+		words := []string{"20", "2", "0", s.quote.Text(16)}
+		var call map[string]string
+		json.Unmarshal(req.Params[0], &call)
+		switch {
+		case strings.HasPrefix(call["data"], "0x"+hex.EncodeToString(selectorQuoteExactInput)):
+			words = []string{s.quote.Text(16), "0", "0", "0"}
+		case call["data"] == "0x"+hex.EncodeToString(selectorFee):
+			if call["to"] != testPool {
+				s.t.Errorf("fee read from %s, want the pool", call["to"])
+			}
+			words = []string{"2710"} // the 1% tier
+		}
+		out := "0x"
+		for _, w := range words {
+			out += fmt.Sprintf("%064s", w)
+		}
+		result = out
+	case "eth_estimateGas":
+		result = "0x30d40"
+	case "eth_maxPriorityFeePerGas":
+		result = "0xf4240"
+	case "eth_getBlockByNumber":
+		result = map[string]string{"baseFeePerGas": "0x2faf080"}
+	case "eth_getTransactionCount":
+		result = "0x7"
+	case "eth_sendRawTransaction":
+		var rawHex string
+		json.Unmarshal(req.Params[0], &rawHex)
+		raw, _ := hex.DecodeString(strings.TrimPrefix(rawHex, "0x"))
+		sender, to, data, err := evm.RecoverSender(raw, big.NewInt(s.cfg.BaseChainID))
+		if err != nil {
+			s.t.Errorf("decode transaction: %v", err)
+		}
+		key, _ := evm.ParsePrivateKey(testKey)
+		if sender != key.Address() {
+			s.t.Errorf("transaction signed by %s, want %s", sender.Hex(), key.Address().Hex())
+		}
+		s.sent, s.to = data, to
+		s.head = 16
+		result = "0x" + hex.EncodeToString(evm.Keccak256(raw))
+	case "eth_getTransactionReceipt":
+		s.receipts++
+		if s.receipts == 1 || s.unmined {
+			result = nil // not mined yet
+			break
+		}
+		key, _ := evm.ParsePrivateKey(testKey)
+		me := evm.AddressWord(key.Address())
+		result = map[string]interface{}{
+			"status":      s.status,
+			"blockNumber": "0x10",
+			"gasUsed":     "0x1d4c0",
+			"logs": []evm.Log{{
+				Address: testToken,
+				Topics:  []string{transferTopic, "0x" + strings.Repeat("0", 64), "0x" + hex.EncodeToString(me[:])},
+				Data:    "0x" + fmt.Sprintf("%064s", s.received.Text(16)),
+			}},
+		}
+	case "eth_getTransactionByHash":
+		result = map[string]string{"blockNumber": ""}
+		if s.dropped {
+			result = nil
+		}
+	case "eth_blockNumber":
+		s.head++
+		result = fmt.Sprintf("0x%x", s.head)
+	default:
+		s.t.Errorf("unexpected method %s", req.Method)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func newStubAgent(t *testing.T, stub *evmStub) *ExecutionAgent {
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		BaseRPCURL:        srv.URL,
+		BaseChainID:       8453,
+		BaseRouterV2:      "0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24",
+		BaseRouterV3:      "0x2626664c2603336E57B271c5C0b26F421741e481",
+		BaseQuoterV3:      "0x3d4e44Eb1374240CE5F1B871ab261CD16335B76a",
+		BaseWETH:          "0x4200000000000000000000000000000000000006",
+		MaxSlippage:       0.05,
+		ConfirmationsWait: 2,
+		ConfirmTimeout:    5 * time.Second,
+		PrivateKey:        testKey,
+	}
+	stub.cfg = cfg
+
+	agent := NewExecutionAgent(cfg)
+	swapper, err := newEVMSwapper(cfg, fixedPrice(2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapper.pollInterval = time.Millisecond
+	agent.evmOnce.Do(func() { agent.evm = swapper })
+	return agent
+}
+
+func testCandidate(kind string) *models.CandidateToken {
+	return &models.CandidateToken{
+		Token: models.TokenFound{Chain: models.ChainBase, TokenAddress: testToken},
+		OffChainMetrics: models.OffChainMetrics{
+			Pool: &models.PoolState{Kind: kind, Address: testPool, QuoteSymbol: "WETH", QuotePriceUSD: 1800, FeeBps: 30},
+		},
+		StrategyDecision: models.StrategyDecision{SuggestedAmountUSD: 100},
+	}
+}
+
+func TestExecuteEVMSwapsThroughV2Router(t *testing.T) {
+	stub := &evmStub{t: t, quote: big.NewInt(1_000_000), received: big.NewInt(980_000), status: "0x1"}
+	agent := newStubAgent(t, stub)
+
+	result, err := agent.executeEVM(context.Background(), testCandidate(models.PoolConstantProduct), &models.ExecutionResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "confirmed" || result.Error != "" {
+		t.Fatalf("expected a confirmed swap, got %s (%s)", result.Status, result.Error)
+	}
+	if !strings.HasPrefix(result.TxHash, "0x") || len(result.TxHash) != 66 {
+		t.Errorf("unexpected tx hash %q", result.TxHash)
+	}
+	if result.GasUsed != 120_000 {
+		t.Errorf("gas used %d, want 120000", result.GasUsed)
+	}
+	if result.SlippageActual < 0.0199 || result.SlippageActual > 0.0201 {
+		t.Errorf("slippage %.4f, want 0.02", result.SlippageActual)
+	}
+	if stub.head < 17 {
+		t.Errorf("returned at block %d before the second confirmation", stub.head)
+	}
+
+	if stub.to.Hex() != strings.ToLower(stub.cfg.BaseRouterV2) {
+		t.Errorf("sent to %s, want the V2 router", stub.to.Hex())
+	}
+	if !strings.HasPrefix(hex.EncodeToString(stub.sent), hex.EncodeToString(selectorSwapExactETHForFoT)) {
+		t.Fatalf("unexpected selector %x", stub.sent[:4])
+	}
+	if minOut, _ := evm.WordAt(stub.sent[4:], 0); minOut.Int64() != 950_000 {
+		t.Errorf("amountOutMin %s, want 950000", minOut)
+	}
+
+	// The next swap uses the next nonce even though the node still reports 7
+	stub.receipts = 0
+	if _, err := agent.executeEVM(context.Background(), testCandidate(models.PoolConstantProduct), &models.ExecutionResult{}); err != nil {
+		t.Fatal(err)
+	}
+	if agent.evm.nextNonce != 9 {
+		t.Errorf("next nonce %d, want 9", agent.evm.nextNonce)
+	}
+}
+
+func TestExecuteEVMReportsRevertedV3Swap(t *testing.T) {
+	stub := &evmStub{t: t, quote: big.NewInt(2_000_000), received: big.NewInt(0), status: "0x0"}
+	agent := newStubAgent(t, stub)
+
+	// A 10% transfer tax is taken off the quote before the slippage tolerance
+	candidate := testCandidate(models.PoolConcentrated)
+	candidate.SafetyReport.OwnerControls.TaxFee = 0.10
+	now := time.Unix(1_700_000_000, 0)
+	agent.evm.now = func() time.Time { return now }
+	result, err := agent.executeEVM(context.Background(), candidate, &models.ExecutionResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "failed" || result.Error != "transaction reverted" || result.TxHash == "" {
+		t.Errorf("expected a reverted swap with its hash, got %+v", result)
+	}
+	if stub.to.Hex() != strings.ToLower(stub.cfg.BaseRouterV3) {
+		t.Errorf("sent to %s, want the V3 router", stub.to.Hex())
+	}
+	// multicall(deadline, [exactInputSingle(...)]): deadline, offset, length, item offset, item length, item
+	if !strings.HasPrefix(hex.EncodeToString(stub.sent), hex.EncodeToString(selectorMulticallDeadline)) {
+		t.Fatalf("unexpected selector %x, want multicall with a deadline", stub.sent[:4])
+	}
+	outer := stub.sent[4:]
+	if deadline, _ := evm.WordAt(outer, 0); deadline.Int64() != now.Add(swapDeadline).Unix() {
+		t.Errorf("deadline %s, want %d", deadline, now.Add(swapDeadline).Unix())
+	}
+	if count, _ := evm.WordAt(outer, 2); count.Int64() != 1 {
+		t.Fatalf("multicall wraps %s calls, want 1", count)
+	}
+	size, _ := evm.WordAt(outer, 4)
+	inner := outer[5*32 : 5*32+int(size.Int64())]
+	if !strings.HasPrefix(hex.EncodeToString(inner), hex.EncodeToString(selectorExactInputSingle)) {
+		t.Fatalf("multicall wraps %x, want exactInputSingle", inner[:4])
+	}
+	// exactInputSingle((tokenIn, tokenOut, fee, recipient, amountIn, amountOutMinimum, sqrtPriceLimitX96))
+	args := inner[4:]
+	if fee, _ := evm.WordAt(args, 2); fee.Int64() != 10000 {
+		t.Errorf("fee tier %s, want 10000", fee)
+	}
+	if amountIn, _ := evm.WordAt(args, 4); amountIn.String() != "50000000000000000" {
+		t.Errorf("amountIn %s, want 0.05 ETH", amountIn)
+	}
+	if minOut, _ := evm.WordAt(args, 5); minOut.Int64() != 1_710_000 {
+		t.Errorf("amountOutMinimum %s, want 1710000", minOut)
+	}
+}
+
+func TestPendingEVMSwapIsReconciledByHash(t *testing.T) {
+	stub := &evmStub{t: t, quote: big.NewInt(1_000_000), received: big.NewInt(980_000), status: "0x1", unmined: true}
+	agent := newStubAgent(t, stub)
+	agent.evm.timeout = 20 * time.Millisecond
+
+	result, err := agent.Execute(context.Background(), testCandidate(models.PoolConstantProduct))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "pending" || result.TxHash == "" {
+		t.Fatalf("expected a pending swap with its hash, got %+v", result)
+	}
+	if len(agent.Pending()) != 1 {
+		t.Fatalf("%d pending trades tracked, want 1", len(agent.Pending()))
+	}
+
+	// Still in the mempool
+	if settled := agent.ReconcilePending(context.Background()); len(settled) != 0 {
+		t.Fatalf("settled %+v while the swap was unmined", settled[0])
+	}
+
+	stub.mu.Lock()
+	stub.unmined = false
+	stub.mu.Unlock()
+	settled := agent.ReconcilePending(context.Background())
+	if len(settled) != 1 || settled[0].Status != "confirmed" || settled[0].TxHash != result.TxHash || settled[0].Error != "" {
+		t.Fatalf("expected the swap to confirm, got %+v", settled)
+	}
+	if settled[0].SlippageActual < 0.0199 || settled[0].SlippageActual > 0.0201 {
+		t.Errorf("slippage %.4f, want 0.02", settled[0].SlippageActual)
+	}
+	if len(agent.Pending()) != 0 {
+		t.Error("a settled trade is still tracked")
+	}
+}
+
+func TestDroppedEVMSwapFails(t *testing.T) {
+	stub := &evmStub{t: t, quote: big.NewInt(1_000_000), received: big.NewInt(980_000), status: "0x1", unmined: true}
+	agent := newStubAgent(t, stub)
+	agent.evm.timeout = 20 * time.Millisecond
+
+	if _, err := agent.Execute(context.Background(), testCandidate(models.PoolConstantProduct)); err != nil {
+		t.Fatal(err)
+	}
+	stub.mu.Lock()
+	stub.dropped = true
+	stub.mu.Unlock()
+
+	settled := agent.ReconcilePending(context.Background())
+	if len(settled) != 1 || settled[0].Status != "failed" || settled[0].Error != "transaction dropped" {
+		t.Fatalf("expected the dropped swap to fail, got %+v", settled)
+	}
+	// The dropped swap's nonce is free again
+	if agent.evm.nextNonce != 0 {
+		t.Errorf("next nonce %d, want it taken from the node", agent.evm.nextNonce)
+	}
+}
+
+func TestExecuteEVMRefusesPoolsTheRoutersCannotTrade(t *testing.T) {
+	stub := &evmStub{t: t, quote: big.NewInt(1_000_000), received: big.NewInt(1_000_000), status: "0x1"}
+	agent := newStubAgent(t, stub)
+
+	usdc := testCandidate(models.PoolConstantProduct)
+	usdc.OffChainMetrics.Pool.QuoteSymbol = "USDC"
+	v4 := testCandidate(models.PoolConcentrated)
+	v4.OffChainMetrics.Pool.Version = "v4"
+	for name, candidate := range map[string]*models.CandidateToken{"USDC pool": usdc, "v4 pool": v4} {
+		result, err := agent.executeEVM(context.Background(), candidate, &models.ExecutionResult{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != "failed" || result.TxHash != "" {
+			t.Errorf("%s: expected the swap to be refused before sending, got %+v", name, result)
+		}
+	}
+	if stub.sent != nil {
+		t.Error("a swap was sent through a pool the routers cannot trade")
+	}
+}
+
+func TestExecuteEVMRequiresPrivateKey(t *testing.T) {
+	agent := NewExecutionAgent(&config.Config{UseOKXWallet: true})
+	result, err := agent.executeEVM(context.Background(), testCandidate(models.PoolConstantProduct), &models.ExecutionResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "failed" || result.Error != errOKXWalletEVM.Error() {
+		t.Errorf("expected the OKX wallet to be refused, got %+v", result)
+	}
+}
+
+// solanaStub is a JSON-RPC node serving one Raydium pool. The first swap it
+// receives never lands, so the swapper has to re-sign it once the blockhash
+// expires.
+type solanaStub struct {
+	t        *testing.T
+	key      *solana.PrivateKey
+	accounts map[solana.PublicKey][]byte
+	balances map[solana.PublicKey]uint64
+	mint     solana.PublicKey
+	quote    uint64
+
+	mu         sync.Mutex
+	height     uint64
+	blockhash  int
+	sent       []*solana.ParsedTransaction
+	signatures []string
+}
+
+func (s *solanaStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.t.Errorf("decode request: %v", err)
+		return
+	}
+	param := func(i int) solana.PublicKey {
+		var address string
+		json.Unmarshal(req.Params[i], &address)
+		return solana.MustPublicKey(address)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result interface{}
+	switch req.Method {
+	case "getAccountInfo":
+		data, ok := s.accounts[param(0)]
+		if !ok {
+			result = map[string]interface{}{"value": nil}
+			break
+		}
+		result = map[string]interface{}{"value": map[string]interface{}{
+			"data": []string{base64.StdEncoding.EncodeToString(data), "base64"},
+		}}
+	case "getTokenAccountBalance":
+		result = map[string]interface{}{"value": map[string]string{"amount": fmt.Sprint(s.balances[param(0)])}}
+	case "getLatestBlockhash":
+		s.blockhash++
+		hash := make([]byte, 32)
+		hash[0] = byte(s.blockhash)
+		result = map[string]interface{}{"value": map[string]interface{}{
+			"blockhash":            solana.Base58Encode(hash),
+			"lastValidBlockHeight": s.height + 2,
+		}}
+	case "sendTransaction":
+		var encoded string
+		json.Unmarshal(req.Params[0], &encoded)
+		raw, _ := base64.StdEncoding.DecodeString(encoded)
+		tx, err := solana.ParseTransaction(raw)
+		if err != nil {
+			s.t.Errorf("decode transaction: %v", err)
+			break
+		}
+		s.sent = append(s.sent, tx)
+		s.signatures = append(s.signatures, solana.Base58Encode(tx.Signatures[0]))
+		result = s.signatures[len(s.signatures)-1]
+	case "getBlockHeight":
+		s.height++
+		result = s.height
+	case "getSignatureStatuses":
+		var signatures []string
+		json.Unmarshal(req.Params[0], &signatures)
+		if len(s.sent) < 2 || signatures[0] != s.signatures[1] {
+			result = map[string]interface{}{"value": []interface{}{nil}}
+			break
+		}
+		result = map[string]interface{}{"value": []interface{}{map[string]interface{}{
+			"slot": 100, "confirmations": 3, "err": nil, "confirmationStatus": "confirmed",
+		}}}
+	case "getTransaction":
+		owner := s.key.PublicKey().String()
+		balance := func(amount uint64) []map[string]interface{} {
+			return []map[string]interface{}{{
+				"mint": s.mint.String(), "owner": owner,
+				"uiTokenAmount": map[string]string{"amount": fmt.Sprint(amount)},
+			}}
+		}
+		result = map[string]interface{}{"meta": map[string]interface{}{
+			"fee":               15000,
+			"preTokenBalances":  []interface{}{},
+			"postTokenBalances": balance(s.quote * 99 / 100),
+		}}
+	default:
+		s.t.Errorf("unexpected method %s", req.Method)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func testSolanaKey(seed byte) *solana.PrivateKey {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	key, err := solana.ParsePrivateKey(solana.Base58Encode(ed25519.NewKeyFromSeed(s)))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// newSolanaStub lays out a Raydium pool pairing a new token with SOL
+func newSolanaStub(t *testing.T) *solanaStub {
+	key := testSolanaKey(1)
+	pool := testSolanaKey(2).PublicKey()
+	market := testSolanaKey(3).PublicKey()
+	marketProgram := testSolanaKey(4).PublicKey()
+	mint := testSolanaKey(5).PublicKey()
+	baseVault := testSolanaKey(6).PublicKey()
+	quoteVault := testSolanaKey(7).PublicKey()
+
+	amm := make([]byte, ammAccountSize)
+	binary.LittleEndian.PutUint64(amm[ammSwapFeeNumerator:], 25)
+	binary.LittleEndian.PutUint64(amm[ammSwapFeeDenominator:], 10000)
+	copy(amm[ammBaseVault:], baseVault[:])
+	copy(amm[ammQuoteVault:], quoteVault[:])
+	copy(amm[ammBaseMint:], mint[:])
+	copy(amm[ammQuoteMint:], solana.WrappedSOLMint[:])
+	copy(amm[ammMarket:], market[:])
+	copy(amm[ammMarketProgram:], marketProgram[:])
+
+	marketData := make([]byte, marketAccountSize+39)
+	for nonce := uint64(0); ; nonce++ {
+		binary.LittleEndian.PutUint64(marketData[marketVaultSignerNonce:], nonce)
+		if _, err := solana.CreateProgramAddress([][]byte{market[:], marketData[marketVaultSignerNonce : marketVaultSignerNonce+8]}, marketProgram); err == nil {
+			break
+		}
+	}
+
+	wsolATA, _ := solana.AssociatedTokenAddress(key.PublicKey(), solana.WrappedSOLMint)
+	return &solanaStub{
+		t:   t,
+		key: key,
+		accounts: map[solana.PublicKey][]byte{
+			pool:    amm,
+			market:  marketData,
+			wsolATA: make([]byte, 165), // the token ATA is missing
+		},
+		balances: map[solana.PublicKey]uint64{
+			baseVault:  1_000_000_000_000_000,
+			quoteVault: 1_000_000_000_000, // 1000 SOL
+		},
+		mint:  mint,
+		quote: 498_501_372_440,
+	}
+}
+
+func TestExecuteSolanaSwapsThroughRaydium(t *testing.T) {
+	stub := newSolanaStub(t)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	cfg := &config.Config{
+		SolanaRPCURL:           srv.URL,
+		RaydiumAMMProgram:      "675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8",
+		SolanaComputeUnitLimit: 200_000,
+		SolanaComputeUnitPrice: 50_000,
+		SolanaPrivateKey:       solana.Base58Encode(ed25519.NewKeyFromSeed(append([]byte{1}, make([]byte, 31)...))),
+		MaxSlippage:            0.05,
+		ConfirmationsWait:      2,
+		ConfirmTimeout:         5 * time.Second,
+	}
+	agent := NewExecutionAgent(cfg)
+	swapper, err := newSolanaSwapper(cfg, fixedPrice(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapper.pollInterval = time.Millisecond
+	agent.solanaOnce.Do(func() { agent.solana = swapper })
+
+	var pool solana.PublicKey
+	for k, data := range stub.accounts {
+		if len(data) == ammAccountSize {
+			pool = k
+		}
+	}
+	candidate := &models.CandidateToken{
+		Token: models.TokenFound{
+			Chain:            models.ChainSolana,
+			TokenAddress:     stub.mint.String(),
+			InitialLiquidity: models.InitialLiquidity{Pair: pool.String()},
+		},
+		OffChainMetrics: models.OffChainMetrics{
+			// The pool's own SOL price is easy to move and is not used for sizing
+			Pool: &models.PoolState{Kind: models.PoolConstantProduct, QuotePriceUSD: 50},
+		},
+		StrategyDecision: models.StrategyDecision{SuggestedAmountUSD: 100},
+	}
+
+	result, err := agent.executeSolana(context.Background(), candidate, &models.ExecutionResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "confirmed" || result.Error != "" {
+		t.Fatalf("expected a confirmed swap, got %s (%s)", result.Status, result.Error)
+	}
+	if len(stub.sent) != 2 || result.TxHash != stub.signatures[1] {
+		t.Fatalf("expected the expired swap to be re-signed once, got %d sends", len(stub.sent))
+	}
+	if stub.sent[0].Blockhash == stub.sent[1].Blockhash {
+		t.Error("the retry should use a fresh blockhash")
+	}
+	if result.GasUsed != 15000 {
+		t.Errorf("gas used %d, want the 15000 lamport fee", result.GasUsed)
+	}
+	if result.SlippageActual < 0.0099 || result.SlippageActual > 0.0101 {
+		t.Errorf("slippage %.4f, want 0.01", result.SlippageActual)
+	}
+
+	tx := stub.sent[1]
+	if tx.AccountKeys[0] != stub.key.PublicKey() {
+		t.Errorf("fee payer %s, want the configured key", tx.AccountKeys[0])
+	}
+	programs := make([]solana.PublicKey, len(tx.Instructions))
+	for i, ix := range tx.Instructions {
+		programs[i] = ix.ProgramID
+	}
+	want := []solana.PublicKey{
+		solana.ComputeBudgetProgram, solana.ComputeBudgetProgram,
+		solana.SystemProgram, solana.TokenProgram, // wrap SOL
+		solana.AssociatedTokenProgram, // create the missing token account
+		swapper.program,
+		solana.TokenProgram, // unwrap what is left
+	}
+	if fmt.Sprint(programs) != fmt.Sprint(want) {
+		t.Fatalf("instructions call %v, want %v", programs, want)
+	}
+	if price := tx.Instructions[1].Data; binary.LittleEndian.Uint64(price[1:]) != 50_000 {
+		t.Errorf("compute unit price %d, want 50000", binary.LittleEndian.Uint64(price[1:]))
+	}
+	if create := tx.Instructions[4]; create.Accounts[3] != stub.mint {
+		t.Errorf("created an account for %s, want the token mint", create.Accounts[3])
+	}
+
+	swap := tx.Instructions[5]
+	if len(swap.Accounts) != 18 || swap.Data[0] != raydiumSwapBaseIn {
+		t.Fatalf("unexpected swap instruction with %d accounts", len(swap.Accounts))
+	}
+	if amountIn := binary.LittleEndian.Uint64(swap.Data[1:]); amountIn != 500_000_000 {
+		t.Errorf("amount in %d lamports, want 0.5 SOL", amountIn)
+	}
+	if minOut := binary.LittleEndian.Uint64(swap.Data[9:]); minOut != 473_576_303_818 {
+		t.Errorf("minimum out %d, want 473576303818", minOut)
+	}
+	if authority := swap.Accounts[2].String(); authority != "5Q544fKrFoe6tsEbD7S8EmxGTJYAKtTVhAW5Q5pge4j1" {
+		t.Errorf("amm authority %s", authority)
+	}
+}
+
+func TestExecuteSolanaRequiresSOLPrice(t *testing.T) {
+	stub := newSolanaStub(t)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	cfg := &config.Config{
+		SolanaRPCURL:      srv.URL,
+		RaydiumAMMProgram: "675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8",
+		SolanaPrivateKey:  solana.Base58Encode(ed25519.NewKeyFromSeed(append([]byte{1}, make([]byte, 31)...))),
+	}
+	agent := NewExecutionAgent(cfg)
+	agent.SetPriceSource(fixedPrice(0))
+
+	candidate := &models.CandidateToken{
+		Token: models.TokenFound{Chain: models.ChainSolana, TokenAddress: stub.mint.String()},
+		OffChainMetrics: models.OffChainMetrics{
+			Pool: &models.PoolState{Kind: models.PoolConstantProduct, QuotePriceUSD: 200},
+		},
+		StrategyDecision: models.StrategyDecision{SuggestedAmountUSD: 100},
+	}
+	result, err := agent.executeSolana(context.Background(), candidate, &models.ExecutionResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "failed" || result.Error != "SOL price: none reported" || result.TxHash != "" {
+		t.Errorf("expected the swap to fail without a SOL price, got %+v", result)
+	}
+	if len(stub.sent) != 0 {
+		t.Error("a swap was sent without a SOL price")
+	}
+}
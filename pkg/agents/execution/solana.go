@@ -0,0 +1,544 @@
+package execution
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/solana"
+)
+
+const (
+	// raydiumSwapBaseIn is the Raydium AMM v4 instruction swapping an exact input
+	raydiumSwapBaseIn = 9
+	// lamportsPerSignature is the base fee of each signature
+	lamportsPerSignature = 5000
+	// maxBlockhashAttempts bounds how often a swap is re-signed after its
+	// blockhash expired
+	maxBlockhashAttempts = 3
+	// blockhashLifetime is longer than a blockhash stays valid, about 150
+	// blocks; a swap sent before a restart that has not landed by then never will
+	blockhashLifetime = 2 * time.Minute
+	// swapSignatures is the number of signatures on a swap, the wallet's alone
+	swapSignatures = 1
+)
+
+// Offsets into Raydium AMM v4 pool and OpenBook market accounts
+const (
+	ammSwapFeeNumerator   = 176
+	ammSwapFeeDenominator = 184
+	ammBaseNeedTakePnl    = 192
+	ammQuoteNeedTakePnl   = 200
+	ammBaseVault          = 336
+	ammQuoteVault         = 368
+	ammBaseMint           = 400
+	ammQuoteMint          = 432
+	ammOpenOrders         = 496
+	ammMarket             = 528
+	ammMarketProgram      = 560
+	ammTargetOrders       = 592
+	ammAccountSize        = 752
+
+	marketVaultSignerNonce = 45
+	marketBaseVault        = 117
+	marketQuoteVault       = 165
+	marketEventQueue       = 253
+	marketBids             = 285
+	marketAsks             = 317
+	marketAccountSize      = 349
+)
+
+var (
+	errOKXWalletSolana   = errors.New("Solana swaps are signed with SOLANA_PRIVATE_KEY; set USE_OKX_WALLET=false")
+	errBlockhashExpired  = errors.New("blockhash expired before the transaction landed")
+	raydiumAuthoritySeed = []byte("amm authority")
+)
+
+// raydiumPool is what a swap needs from a Raydium AMM v4 pool
+type raydiumPool struct {
+	address      solana.PublicKey
+	baseVault    solana.PublicKey
+	quoteVault   solana.PublicKey
+	baseMint     solana.PublicKey
+	quoteMint    solana.PublicKey
+	openOrders   solana.PublicKey
+	targetOrders solana.PublicKey
+	feeNum       uint64
+	feeDen       uint64
+	basePnl      uint64
+	quotePnl     uint64
+
+	marketProgram solana.PublicKey
+	market        solana.PublicKey
+	bids          solana.PublicKey
+	asks          solana.PublicKey
+	eventQueue    solana.PublicKey
+	marketBase    solana.PublicKey
+	marketQuote   solana.PublicKey
+	vaultSigner   solana.PublicKey
+}
+
+// solanaSwap is a buy of a token with SOL through a Raydium pool
+type solanaSwap struct {
+	pool       *raydiumPool
+	mint       solana.PublicKey
+	amountIn   uint64 // lamports
+	quote      uint64 // expected tokens out
+	minOut     uint64
+	wsolATA    solana.PublicKey
+	tokenATA   solana.PublicKey
+	createWSOL bool
+	createATA  bool
+}
+
+// sentSolanaSwap is a swap sent but not confirmed in time
+type sentSolanaSwap struct {
+	swap      *solanaSwap
+	lastValid uint64 // block height after which it can no longer land
+}
+
+// solanaSwapper builds, signs and sends Raydium swaps
+type solanaSwapper struct {
+	client        *solana.Client
+	prices        PriceSource // prices wrapped SOL to size swaps
+	key           *solana.PrivateKey
+	program       solana.PublicKey
+	unitLimit     uint32
+	unitPrice     uint64
+	maxSlippage   float64
+	confirmations int
+	timeout       time.Duration
+	pollInterval  time.Duration
+	now           func() time.Time
+
+	pendingMu sync.Mutex
+	pending   map[string]sentSolanaSwap // by signature
+}
+
+// newSolanaSwapper creates a swapper from the Solana settings of cfg
+func newSolanaSwapper(cfg *config.Config, prices PriceSource) (*solanaSwapper, error) {
+	if cfg.UseOKXWallet {
+		return nil, errOKXWalletSolana
+	}
+	if cfg.SolanaPrivateKey == "" {
+		return nil, errors.New("SOLANA_PRIVATE_KEY is not set")
+	}
+	key, err := solana.ParsePrivateKey(cfg.SolanaPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	program, err := solana.ParsePublicKey(cfg.RaydiumAMMProgram)
+	if err != nil {
+		return nil, fmt.Errorf("RAYDIUM_AMM_PROGRAM: %w", err)
+	}
+	return &solanaSwapper{
+		client:        solana.NewClient(cfg.SolanaRPCURL),
+		prices:        prices,
+		key:           key,
+		program:       program,
+		unitLimit:     cfg.SolanaComputeUnitLimit,
+		unitPrice:     cfg.SolanaComputeUnitPrice,
+		maxSlippage:   cfg.MaxSlippage,
+		confirmations: cfg.ConfirmationsWait,
+		timeout:       cfg.ConfirmTimeout,
+		pollInterval:  receiptPollInterval,
+		now:           time.Now,
+	}, nil
+}
+
+// Buy swaps the candidate's suggested amount of SOL for the token and waits
+// for the configured confirmations, re-signing with a fresh blockhash if the
+// transaction expires before landing. Errors before broadcast leave the
+// result without a TxHash.
+func (s *solanaSwapper) Buy(ctx context.Context, candidate *models.CandidateToken, result *models.ExecutionResult) error {
+	swap, err := s.prepare(ctx, candidate)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		blockhash, lastValid, err := s.client.LatestBlockhash(ctx)
+		if err != nil {
+			return fmt.Errorf("blockhash: %w", err)
+		}
+		tx, err := solana.NewTransaction(s.instructions(swap), blockhash, s.key)
+		if err != nil {
+			return err
+		}
+
+		signature, err := s.client.SendTransaction(ctx, tx)
+		if err == nil {
+			result.TxHash = signature
+			var status *solana.SignatureStatus
+			if status, err = s.waitForStatus(ctx, signature, lastValid); err == nil {
+				return s.finish(ctx, swap, status.Err, len(tx.Signatures), result)
+			}
+		}
+		if !errors.Is(err, errBlockhashExpired) && !solana.IsBlockhashExpired(err) {
+			if result.TxHash != "" {
+				s.track(result.TxHash, sentSolanaSwap{swap: swap, lastValid: lastValid})
+			}
+			return err
+		}
+		// An expired transaction can no longer land
+		result.TxHash = ""
+		if attempt == maxBlockhashAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		log.Printf("ExecutionAgent: Blockhash expired for %s, re-signing (attempt %d)\n",
+			candidate.Token.TokenAddress, attempt+1)
+	}
+}
+
+// prepare loads the pool, quotes the swap and checks the token accounts. The
+// SOL in is sized at the price source's wrapped SOL price rather than the
+// traded pool's, which a thin pool lets anyone move.
+func (s *solanaSwapper) prepare(ctx context.Context, candidate *models.CandidateToken) (*solanaSwap, error) {
+	mint, err := solana.ParsePublicKey(candidate.Token.TokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	solUSD, err := s.solPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	amountIn := uint64(math.Round(candidate.StrategyDecision.SuggestedAmountUSD / solUSD * 1e9))
+	if amountIn == 0 {
+		return nil, errors.New("swap amount is zero")
+	}
+
+	var poolAddress string
+	if state := candidate.OffChainMetrics.Pool; state != nil {
+		poolAddress = state.Address
+	}
+	if poolAddress == "" {
+		poolAddress = candidate.Token.InitialLiquidity.Pair
+	}
+	address, err := solana.ParsePublicKey(poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("pool: %w", err)
+	}
+	pool, err := s.loadPool(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// The swap direction follows from which vault the SOL goes into
+	inVault, outVault, inPnl, outPnl := pool.quoteVault, pool.baseVault, pool.quotePnl, pool.basePnl
+	switch {
+	case pool.quoteMint == solana.WrappedSOLMint && pool.baseMint == mint:
+	case pool.baseMint == solana.WrappedSOLMint && pool.quoteMint == mint:
+		inVault, outVault, inPnl, outPnl = outVault, inVault, outPnl, inPnl
+	default:
+		return nil, fmt.Errorf("pool %s does not pair the token with SOL", address)
+	}
+	reserveIn, err := s.client.TokenAccountBalance(ctx, inVault)
+	if err != nil {
+		return nil, fmt.Errorf("pool reserves: %w", err)
+	}
+	reserveOut, err := s.client.TokenAccountBalance(ctx, outVault)
+	if err != nil {
+		return nil, fmt.Errorf("pool reserves: %w", err)
+	}
+
+	swap := &solanaSwap{pool: pool, mint: mint, amountIn: amountIn}
+	swap.quote = constantProductOut(amountIn, saturatingSub(reserveIn, inPnl), saturatingSub(reserveOut, outPnl), pool.feeNum, pool.feeDen)
+	if swap.quote == 0 {
+		return nil, errors.New("quote returned no tokens")
+	}
+	swap.minOut = applyBps(new(big.Int).SetUint64(swap.quote), 1-s.maxSlippage).Uint64()
+
+	me := s.key.PublicKey()
+	if swap.wsolATA, err = solana.AssociatedTokenAddress(me, solana.WrappedSOLMint); err != nil {
+		return nil, err
+	}
+	if swap.tokenATA, err = solana.AssociatedTokenAddress(me, mint); err != nil {
+		return nil, err
+	}
+	for _, a := range []struct {
+		account solana.PublicKey
+		create  *bool
+	}{{swap.wsolATA, &swap.createWSOL}, {swap.tokenATA, &swap.createATA}} {
+		data, err := s.client.AccountData(ctx, a.account)
+		if err != nil {
+			return nil, fmt.Errorf("token account: %w", err)
+		}
+		*a.create = data == nil
+	}
+	return swap, nil
+}
+
+// solPrice returns the USD price of wrapped SOL to size a swap in SOL
+func (s *solanaSwapper) solPrice(ctx context.Context) (float64, error) {
+	if s.prices == nil {
+		return 0, errors.New("no SOL price source to size the swap")
+	}
+	price, err := s.prices.Price(ctx, models.TokenFound{Chain: models.ChainSolana, TokenAddress: solana.WrappedSOLMint.String()})
+	if err != nil {
+		return 0, fmt.Errorf("SOL price: %w", err)
+	}
+	if price <= 0 {
+		return 0, errors.New("SOL price: none reported")
+	}
+	return price, nil
+}
+
+// loadPool reads a Raydium AMM v4 pool and its OpenBook market
+func (s *solanaSwapper) loadPool(ctx context.Context, address solana.PublicKey) (*raydiumPool, error) {
+	amm, err := s.client.AccountData(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("pool: %w", err)
+	}
+	if len(amm) < ammAccountSize {
+		return nil, fmt.Errorf("pool %s is not a Raydium AMM v4 pool", address)
+	}
+	key := func(data []byte, offset int) solana.PublicKey {
+		var k solana.PublicKey
+		copy(k[:], data[offset:offset+32])
+		return k
+	}
+	u64 := func(data []byte, offset int) uint64 {
+		return binary.LittleEndian.Uint64(data[offset:])
+	}
+
+	pool := &raydiumPool{
+		address:       address,
+		baseVault:     key(amm, ammBaseVault),
+		quoteVault:    key(amm, ammQuoteVault),
+		baseMint:      key(amm, ammBaseMint),
+		quoteMint:     key(amm, ammQuoteMint),
+		openOrders:    key(amm, ammOpenOrders),
+		targetOrders:  key(amm, ammTargetOrders),
+		feeNum:        u64(amm, ammSwapFeeNumerator),
+		feeDen:        u64(amm, ammSwapFeeDenominator),
+		basePnl:       u64(amm, ammBaseNeedTakePnl),
+		quotePnl:      u64(amm, ammQuoteNeedTakePnl),
+		market:        key(amm, ammMarket),
+		marketProgram: key(amm, ammMarketProgram),
+	}
+
+	market, err := s.client.AccountData(ctx, pool.market)
+	if err != nil {
+		return nil, fmt.Errorf("market: %w", err)
+	}
+	if len(market) < marketAccountSize {
+		return nil, fmt.Errorf("market %s is not an OpenBook market", pool.market)
+	}
+	pool.bids = key(market, marketBids)
+	pool.asks = key(market, marketAsks)
+	pool.eventQueue = key(market, marketEventQueue)
+	pool.marketBase = key(market, marketBaseVault)
+	pool.marketQuote = key(market, marketQuoteVault)
+	nonce := market[marketVaultSignerNonce : marketVaultSignerNonce+8]
+	if pool.vaultSigner, err = solana.CreateProgramAddress([][]byte{pool.market[:], nonce}, pool.marketProgram); err != nil {
+		return nil, fmt.Errorf("market vault signer: %w", err)
+	}
+	return pool, nil
+}
+
+// instructions returns the swap transaction: compute budget, SOL wrapped
+// into a token account, the token account created if missing, the Raydium
+// swap, then the wrapped SOL account closed to unwrap what is left
+func (s *solanaSwapper) instructions(swap *solanaSwap) []solana.Instruction {
+	me := s.key.PublicKey()
+	ixs := []solana.Instruction{
+		solana.SetComputeUnitLimit(s.unitLimit),
+		solana.SetComputeUnitPrice(s.unitPrice),
+	}
+	if swap.createWSOL {
+		ixs = append(ixs, solana.CreateAssociatedTokenAccount(me, swap.wsolATA, me, solana.WrappedSOLMint))
+	}
+	ixs = append(ixs,
+		solana.Transfer(me, swap.wsolATA, swap.amountIn),
+		solana.SyncNative(swap.wsolATA),
+	)
+	if swap.createATA {
+		ixs = append(ixs, solana.CreateAssociatedTokenAccount(me, swap.tokenATA, me, swap.mint))
+	}
+
+	pool := swap.pool
+	authority, _, _ := solana.FindProgramAddress([][]byte{raydiumAuthoritySeed}, s.program)
+	data := []byte{raydiumSwapBaseIn}
+	data = binary.LittleEndian.AppendUint64(data, swap.amountIn)
+	data = binary.LittleEndian.AppendUint64(data, swap.minOut)
+	w := func(k solana.PublicKey) solana.AccountMeta { return solana.AccountMeta{PublicKey: k, IsWritable: true} }
+	r := func(k solana.PublicKey) solana.AccountMeta { return solana.AccountMeta{PublicKey: k} }
+	ixs = append(ixs, solana.Instruction{
+		ProgramID: s.program,
+		Accounts: []solana.AccountMeta{
+			r(solana.TokenProgram),
+			w(pool.address),
+			r(authority),
+			w(pool.openOrders),
+			w(pool.targetOrders),
+			w(pool.baseVault),
+			w(pool.quoteVault),
+			r(pool.marketProgram),
+			w(pool.market),
+			w(pool.bids),
+			w(pool.asks),
+			w(pool.eventQueue),
+			w(pool.marketBase),
+			w(pool.marketQuote),
+			r(pool.vaultSigner),
+			w(swap.wsolATA),
+			w(swap.tokenATA),
+			{PublicKey: me, IsSigner: true},
+		},
+		Data: data,
+	})
+
+	return append(ixs, solana.CloseAccount(swap.wsolATA, me, me))
+}
+
+// waitForStatus polls until the transaction has the configured number of
+// confirmations or failed, or its blockhash expires without it landing
+func (s *solanaSwapper) waitForStatus(ctx context.Context, signature string, lastValid uint64) (*solana.SignatureStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := s.client.SignatureStatus(ctx, signature)
+		if err == nil && s.settled(status) {
+			return status, nil
+		}
+		if err == nil && status == nil {
+			if height, err := s.client.BlockHeight(ctx); err == nil && height > lastValid {
+				return nil, errBlockhashExpired
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("not confirmed after %s", s.timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// settled reports whether a transaction failed or has the configured number
+// of confirmations
+func (s *solanaSwapper) settled(status *solana.SignatureStatus) bool {
+	return status != nil && (status.Err != nil || status.ConfirmationStatus == "finalized" ||
+		(status.Confirmations != nil && *status.Confirmations >= uint64(s.confirmations)))
+}
+
+// Reconcile checks on a swap that was sent but not confirmed in time. It is
+// settled once it landed and failed once its blockhash expired; otherwise the
+// result is left pending.
+func (s *solanaSwapper) Reconcile(ctx context.Context, result *models.ExecutionResult) error {
+	s.pendingMu.Lock()
+	sent := s.pending[result.TxHash]
+	s.pendingMu.Unlock()
+
+	status, err := s.client.SignatureStatus(ctx, result.TxHash)
+	if err != nil {
+		return err
+	}
+	if status != nil {
+		if !s.settled(status) {
+			return nil
+		}
+		s.untrack(result.TxHash)
+		return s.finish(ctx, sent.swap, status.Err, swapSignatures, result)
+	}
+
+	// Only recent statuses are kept; older transactions are looked up in full
+	meta, err := s.client.TransactionMeta(ctx, result.TxHash)
+	if err != nil {
+		return err
+	}
+	if meta != nil {
+		s.untrack(result.TxHash)
+		return s.finish(ctx, sent.swap, meta.Err, swapSignatures, result)
+	}
+
+	expired := s.now().Sub(result.Timestamp) > blockhashLifetime
+	if sent.lastValid > 0 {
+		height, err := s.client.BlockHeight(ctx)
+		if err != nil {
+			return err
+		}
+		expired = height > sent.lastValid
+	}
+	if expired {
+		s.untrack(result.TxHash)
+		result.Status = "failed"
+		result.Error = errBlockhashExpired.Error()
+	}
+	return nil
+}
+
+// track remembers a swap that is still pending so it can be settled later
+func (s *solanaSwapper) track(signature string, sent sentSolanaSwap) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	if s.pending == nil {
+		s.pending = make(map[string]sentSolanaSwap)
+	}
+	s.pending[signature] = sent
+}
+
+// untrack forgets a pending swap
+func (s *solanaSwapper) untrack(signature string) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	delete(s.pending, signature)
+}
+
+// finish fills the result from a landed transaction, failed when txErr is
+// set; GasUsed is the fee in lamports, which failed transactions pay too. The
+// swap is nil when it was sent before a restart, and slippage is then not
+// measured.
+func (s *solanaSwapper) finish(ctx context.Context, swap *solanaSwap, txErr interface{}, signatures int, result *models.ExecutionResult) error {
+	meta, err := s.client.TransactionMeta(ctx, result.TxHash)
+	if err != nil || meta == nil {
+		log.Printf("ExecutionAgent: No transaction details for %s, estimating its fee: %v\n", result.TxHash, err)
+		priority := (uint64(s.unitLimit)*s.unitPrice + 999_999) / 1_000_000
+		result.GasUsed = uint64(signatures)*lamportsPerSignature + priority
+	} else {
+		result.GasUsed = meta.Fee
+	}
+
+	result.Error = ""
+	if txErr != nil {
+		result.Status = "failed"
+		result.Error = fmt.Sprintf("transaction failed: %v", txErr)
+		return nil
+	}
+	result.Status = "confirmed"
+	if meta != nil && swap != nil {
+		received := meta.TokenDelta(s.key.PublicKey(), swap.mint)
+		result.SlippageActual = math.Max(0, 1-float64(received)/float64(swap.quote))
+	}
+	return nil
+}
+
+// constantProductOut is the output of an x*y=k swap after the pool fee
+func constantProductOut(amountIn, reserveIn, reserveOut, feeNum, feeDen uint64) uint64 {
+	if reserveIn == 0 || reserveOut == 0 || feeNum >= feeDen {
+		return 0
+	}
+	in := new(big.Int).SetUint64(amountIn)
+	in.Mul(in, new(big.Int).SetUint64(feeDen-feeNum)).Quo(in, new(big.Int).SetUint64(feeDen))
+	out := new(big.Int).Mul(in, new(big.Int).SetUint64(reserveOut))
+	return out.Quo(out, in.Add(in, new(big.Int).SetUint64(reserveIn))).Uint64()
+}
+
+// saturatingSub returns a-b, or 0 if b is larger
+func saturatingSub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
@@ -0,0 +1,69 @@
+package listing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
+)
+
+// Archiver stores candidates evicted from memory
+type Archiver interface {
+	Archive(candidates []*models.CandidateToken) error
+}
+
+// FileArchiver appends evicted candidates to a JSONL file, one per line
+type FileArchiver struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileArchiver creates an archiver writing to path
+func NewFileArchiver(path string) *FileArchiver {
+	return &FileArchiver{path: path}
+}
+
+// Archive appends candidates to the file, creating it if needed
+func (a *FileArchiver) Archive(candidates []*models.CandidateToken) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if dir := filepath.Dir(a.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create archive directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+
+	encoder := json.NewEncoder(f)
+	for _, candidate := range candidates {
+		if err := encoder.Encode(candidate); err != nil {
+			f.Close()
+			return fmt.Errorf("write archive: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+// RepositoryArchiver saves evicted candidates to a candidate repository
+type RepositoryArchiver struct {
+	Repo storage.CandidateRepository
+}
+
+// Archive saves each candidate's final state
+func (a RepositoryArchiver) Archive(candidates []*models.CandidateToken) error {
+	for _, candidate := range candidates {
+		if err := a.Repo.SaveCandidate(context.Background(), candidate); err != nil {
+			return fmt.Errorf("archive %s: %w", candidate.Key(), err)
+		}
+	}
+	return nil
+}
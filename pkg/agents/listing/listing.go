@@ -1,29 +1,233 @@
 package listing
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/mumugogoing/meme_bot/pkg/config"
 	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
 )
 
+// ErrCandidateNotFound is returned for a token that was never listed
+var ErrCandidateNotFound = errors.New("candidate not found")
+
+// ErrIllegalTransition is returned when a candidate cannot move to the requested status
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// ErrAmbiguousAddress is returned when a bare token address is listed on more than one chain
+var ErrAmbiguousAddress = errors.New("address is listed on more than one chain")
+
+// ErrApprovalExpired is returned when a candidate was listed too long ago to approve
+var ErrApprovalExpired = errors.New("approval window expired")
+
+// DefaultRetention keeps finished candidates for an hour and executed ones for a day
+var DefaultRetention = []string{"rejected=60", "failed=60", "closed=60", "executed=1440"}
+
+// retentionInterval is how often candidates past their retention are evicted
+const retentionInterval = time.Minute
+
+// ParseRetention parses a "status=minutes" retention rule
+func ParseRetention(rule string) (models.CandidateStatus, time.Duration, error) {
+	status, minutes, found := strings.Cut(rule, "=")
+	if !found {
+		return "", 0, fmt.Errorf("retention %q is not status=minutes", rule)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(minutes))
+	if err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("retention %q needs a positive number of minutes", rule)
+	}
+	s := models.CandidateStatus(strings.TrimSpace(status))
+	switch s {
+	case models.CandidateRejected, models.CandidateExecuted, models.CandidateFailed, models.CandidateClosed:
+	default:
+		return "", 0, fmt.Errorf("retention %q: only rejected, executed, failed and closed candidates can be evicted", rule)
+	}
+	return s, time.Duration(n) * time.Minute, nil
+}
+
+// CandidateFilter selects candidates; zero fields match everything
+type CandidateFilter struct {
+	Statuses          []models.CandidateStatus
+	Chain             models.Chain
+	Since             time.Time // listed at or after
+	Until             time.Time // listed before
+	MinWinProbability float64
+	Offset            int
+	Limit             int // 0 returns every match
+}
+
+// matches reports whether a candidate passes the filter
+func (f *CandidateFilter) matches(candidate *models.CandidateToken) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if candidate.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Chain != "" && candidate.Token.Chain != f.Chain {
+		return false
+	}
+	if !f.Since.IsZero() && candidate.ListedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !candidate.ListedAt.Before(f.Until) {
+		return false
+	}
+	return candidate.StrategyDecision.WinProbability >= f.MinWinProbability
+}
+
 // CandidateListingAgent manages the candidate token queue
 type CandidateListingAgent struct {
-	candidates map[string]*models.CandidateToken
-	queue      chan *models.CandidateToken
+	config     *config.Config
+	candidates map[models.CandidateKey]*models.CandidateToken
+	queue      *ExecutionQueue
+	retention  map[models.CandidateStatus]time.Duration
+	archive    Archiver
+	store      storage.CandidateRepository
+	now        func() time.Time
 	mu         sync.RWMutex
 }
 
 // NewCandidateListingAgent creates a new listing agent
-func NewCandidateListingAgent() *CandidateListingAgent {
-	return &CandidateListingAgent{
-		candidates: make(map[string]*models.CandidateToken),
-		queue:      make(chan *models.CandidateToken, 100),
+func NewCandidateListingAgent(cfg *config.Config) *CandidateListingAgent {
+	c := &CandidateListingAgent{
+		config:     cfg,
+		candidates: make(map[models.CandidateKey]*models.CandidateToken),
+		queue:      NewExecutionQueue(cfg.ExecutionQueueSize, cfg.ExecutionQueueHalfLife, cfg.ExecutionQueueDeadline),
+		retention:  make(map[models.CandidateStatus]time.Duration),
+		now:        time.Now,
+	}
+	
+	rules := cfg.CandidateRetention
+	if len(rules) == 0 {
+		rules = DefaultRetention
+	}
+	for _, rule := range rules {
+		status, age, err := ParseRetention(rule)
+		if err != nil {
+			log.Printf("CandidateListingAgent: Ignoring retention: %v\n", err)
+			continue
+		}
+		c.retention[status] = age
+	}
+	
+	if cfg.CandidateArchiveFile != "" {
+		c.archive = NewFileArchiver(cfg.CandidateArchiveFile)
 	}
+	return c
+}
+
+// SetArchiver sets where evicted candidates are stored; nil discards them
+func (c *CandidateListingAgent) SetArchiver(archive Archiver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.archive = archive
 }
 
-// AddCandidate adds a token to the candidate list
+// SetStore writes every candidate change through to repo and restores the
+// candidates that had not traded when the bot last stopped. Restored pending
+// candidates are not queued again; they wait for an operator or expire.
+// Trades that were in flight cannot be resumed: approved candidates are
+// rejected, and submitted ones become executed if executions recorded a
+// confirmed trade for them. Submitted candidates whose transaction was still
+// pending stay submitted until it settles; the rest fail.
+func (c *CandidateListingAgent) SetStore(repo storage.CandidateRepository, executions storage.ExecutionRepository) {
+	ctx := context.Background()
+	active, err := repo.ActiveCandidates(ctx)
+	if err != nil {
+		log.Printf("CandidateListingAgent: Failed to restore candidates: %v\n", err)
+	}
+	
+	latest := make(map[models.CandidateKey]*models.ExecutionResult)
+	for _, candidate := range active {
+		if candidate.Status == models.CandidateSubmitted {
+			latest[candidate.Key()] = LatestExecution(ctx, executions, candidate)
+		}
+	}
+	
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	c.store = repo
+	c.archive = RepositoryArchiver{Repo: repo}
+	restored := 0
+	for _, candidate := range active {
+		if _, exists := c.candidates[candidate.Key()]; exists {
+			continue
+		}
+		c.candidates[candidate.Key()] = candidate
+		restored++
+		
+		result := latest[candidate.Key()]
+		switch {
+		case candidate.Status == models.CandidateApproved:
+			c.transition(candidate, models.CandidateRejected, models.ActorAuto, restartReason)
+		case candidate.Status == models.CandidateSubmitted && result != nil && result.Status == "confirmed":
+			c.transition(candidate, models.CandidateExecuted, models.ActorAuto, "execution confirmed before restart")
+		case candidate.Status == models.CandidateSubmitted && result != nil && result.Status == "pending" && result.TxHash != "":
+			continue
+		case candidate.Status == models.CandidateSubmitted:
+			c.transition(candidate, models.CandidateFailed, models.ActorAuto, restartReason)
+		default:
+			continue
+		}
+		c.persist(candidate)
+	}
+	if restored > 0 {
+		log.Printf("CandidateListingAgent: Restored %d active candidates\n", restored)
+	}
+}
+
+// restartReason is recorded on candidates whose trade a restart interrupted
+const restartReason = "interrupted by restart"
+
+// LatestExecution returns the last execution recorded for a submitted
+// candidate since it was submitted, or nil if there is none
+func LatestExecution(ctx context.Context, executions storage.ExecutionRepository, candidate *models.CandidateToken) *models.ExecutionResult {
+	if executions == nil {
+		return nil
+	}
+	results, err := executions.Executions(ctx, candidate.Key())
+	if err != nil {
+		log.Printf("CandidateListingAgent: Failed to reconcile %s: %v\n", candidate.Key(), err)
+		return nil
+	}
+	var latest *models.ExecutionResult
+	for _, result := range results {
+		if !result.Timestamp.Before(candidate.StatusSince()) {
+			latest = result
+		}
+	}
+	return latest
+}
+
+// persist writes a candidate through to the store; the caller holds the lock
+// so rows are written in the order the candidate changed
+func (c *CandidateListingAgent) persist(candidate *models.CandidateToken) {
+	if c.store == nil {
+		return
+	}
+	if err := c.store.SaveCandidate(context.Background(), candidate); err != nil {
+		log.Printf("CandidateListingAgent: Failed to save %s: %v\n", candidate.Key(), err)
+	}
+}
+
+// AddCandidate adds a token to the candidate list. A token already listed on
+// the same chain keeps its candidate until that candidate is finished.
 func (c *CandidateListingAgent) AddCandidate(
 	token models.TokenFound,
 	safety models.SafetyReport,
@@ -31,52 +235,145 @@ func (c *CandidateListingAgent) AddCandidate(
 	decision models.StrategyDecision,
 ) *models.CandidateToken {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	
+	key := token.Key()
+	previous, exists := c.candidates[key]
+	if exists && !previous.Status.Final() {
+		log.Printf("CandidateListingAgent: %s already listed as %s\n", key, previous.Status)
+		existing := snapshot(previous)
+		c.mu.Unlock()
+		return existing
+	}
+	
+	now := c.now()
+	discoveredAt := now
+	if token.FirstSeenTS > 0 {
+		discoveredAt = time.Unix(token.FirstSeenTS, 0)
+	}
 	candidate := &models.CandidateToken{
 		Token:            token,
 		SafetyReport:     safety,
 		OffChainMetrics:  offchain,
 		StrategyDecision: decision,
-		ListedAt:         time.Now(),
-		Status:           "pending",
+		ListedAt:         now,
+		Status:           models.CandidatePending,
+		History: []models.StatusTransition{
+			{To: models.CandidateDiscovered, At: discoveredAt, Actor: models.ActorAuto, Reason: "token found on " + string(token.Chain)},
+			{
+				From:   models.CandidateDiscovered,
+				To:     models.CandidatePending,
+				At:     now,
+				Actor:  models.ActorAuto,
+				Reason: fmt.Sprintf("%s: %s at win probability %.2f", decision.Strategy, decision.Action, decision.WinProbability),
+			},
+		},
 	}
 	
-	c.candidates[token.TokenAddress] = candidate
+	c.candidates[key] = candidate
+	c.persist(candidate)
+	archive := c.archive
 	
 	log.Printf("CandidateListingAgent: Added candidate %s - WinProb: %.2f, Action: %s\n",
-		token.TokenAddress, decision.WinProbability, decision.Action)
+		key, decision.WinProbability, decision.Action)
 	
-	// Send to queue for execution consideration
-	select {
-	case c.queue <- candidate:
-	default:
-		log.Println("CandidateListingAgent: Warning - queue full, candidate not queued")
+	// Buys are queued for automatic execution; everything else waits for an operator
+	if c.config.AutoExecute && decision.Action == "buy" {
+		c.queue.Push(snapshot(candidate))
 	}
 	
-	return candidate
+	added := snapshot(candidate)
+	c.mu.Unlock()
+	
+	// The finished candidate it replaces is archived as if it had been evicted
+	if exists && archive != nil {
+		if err := archive.Archive([]*models.CandidateToken{previous}); err != nil {
+			log.Printf("CandidateListingAgent: Failed to archive replaced candidate %s: %v\n", key, err)
+		}
+	}
+	
+	return added
+}
+
+// snapshot copies a candidate so callers can read it while its status changes
+func snapshot(candidate *models.CandidateToken) *models.CandidateToken {
+	copied := *candidate
+	copied.History = append([]models.StatusTransition(nil), candidate.History...)
+	return &copied
 }
 
-// GetCandidate retrieves a candidate by token address
-func (c *CandidateListingAgent) GetCandidate(tokenAddress string) (*models.CandidateToken, bool) {
+// GetCandidate retrieves a candidate by chain and token address
+func (c *CandidateListingAgent) GetCandidate(key models.CandidateKey) (*models.CandidateToken, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	
-	candidate, exists := c.candidates[tokenAddress]
-	return candidate, exists
+	candidate, exists := c.candidates[key]
+	if !exists {
+		return nil, false
+	}
+	return snapshot(candidate), true
 }
 
-// GetAllCandidates returns all candidates
-func (c *CandidateListingAgent) GetAllCandidates() []*models.CandidateToken {
+// Resolve finds the key of the candidate listed under a token address on any
+// chain, for callers that do not name the chain
+func (c *CandidateListingAgent) Resolve(tokenAddress string) (models.CandidateKey, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	
-	candidates := make([]*models.CandidateToken, 0, len(c.candidates))
+	var found []string
+	var key models.CandidateKey
+	for candidate := range c.candidates {
+		if candidate.TokenAddress == tokenAddress {
+			key = candidate
+			found = append(found, string(candidate.Chain))
+		}
+	}
+	switch len(found) {
+	case 0:
+		return key, fmt.Errorf("%w: %s", ErrCandidateNotFound, tokenAddress)
+	case 1:
+		return key, nil
+	default:
+		sort.Strings(found)
+		return models.CandidateKey{}, fmt.Errorf("%w: %s on %s", ErrAmbiguousAddress, tokenAddress, strings.Join(found, ", "))
+	}
+}
+
+// GetAllCandidates returns one page of the candidates matching filter, most
+// recently listed first, and how many match in total
+func (c *CandidateListingAgent) GetAllCandidates(filter CandidateFilter) ([]*models.CandidateToken, int) {
+	c.mu.RLock()
+	matched := make([]*models.CandidateToken, 0, len(c.candidates))
 	for _, candidate := range c.candidates {
-		candidates = append(candidates, candidate)
+		if filter.matches(candidate) {
+			matched = append(matched, candidate)
+		}
 	}
 	
-	return candidates
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !a.ListedAt.Equal(b.ListedAt) {
+			return a.ListedAt.After(b.ListedAt)
+		}
+		return a.Key().String() < b.Key().String()
+	})
+	
+	total := len(matched)
+	start := filter.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	
+	page := make([]*models.CandidateToken, 0, end-start)
+	for _, candidate := range matched[start:end] {
+		page = append(page, snapshot(candidate))
+	}
+	c.mu.RUnlock()
+	
+	return page, total
 }
 
 // GetPendingCandidates returns candidates with pending status
@@ -86,27 +383,224 @@ func (c *CandidateListingAgent) GetPendingCandidates() []*models.CandidateToken
 	
 	candidates := make([]*models.CandidateToken, 0)
 	for _, candidate := range c.candidates {
-		if candidate.Status == "pending" {
-			candidates = append(candidates, candidate)
+		if candidate.Status == models.CandidatePending {
+			candidates = append(candidates, snapshot(candidate))
 		}
 	}
 	
 	return candidates
 }
 
-// UpdateStatus updates the status of a candidate
-func (c *CandidateListingAgent) UpdateStatus(tokenAddress, status string) {
+// Transition moves a candidate to status and records who moved it and why.
+// Transitions the lifecycle does not allow are rejected with ErrIllegalTransition.
+func (c *CandidateListingAgent) Transition(
+	key models.CandidateKey,
+	status models.CandidateStatus,
+	actor string,
+	reason string,
+) (*models.CandidateToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	candidate, exists := c.candidates[key]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCandidateNotFound, key)
+	}
+	if err := c.transition(candidate, status, actor, reason); err != nil {
+		return nil, err
+	}
+	c.persist(candidate)
+	
+	return snapshot(candidate), nil
+}
+
+// transition moves a candidate to status; the caller holds the lock
+func (c *CandidateListingAgent) transition(
+	candidate *models.CandidateToken,
+	status models.CandidateStatus,
+	actor string,
+	reason string,
+) error {
+	key := candidate.Key()
+	if !candidate.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s is %s, cannot become %s", ErrIllegalTransition, key, candidate.Status, status)
+	}
+	
+	candidate.History = append(candidate.History, models.StatusTransition{
+		From:   candidate.Status,
+		To:     status,
+		At:     c.now(),
+		Actor:  actor,
+		Reason: reason,
+	})
+	log.Printf("CandidateListingAgent: %s %s -> %s by %s: %s\n", key, candidate.Status, status, actor, reason)
+	candidate.Status = status
+	return nil
+}
+
+// expired reports whether a candidate was listed longer than the approval TTL ago
+func (c *CandidateListingAgent) expired(candidate *models.CandidateToken) bool {
+	ttl := c.config.ApprovalTTL
+	return ttl > 0 && c.now().Sub(candidate.ListedAt) > ttl
+}
+
+// Approve approves a pending candidate for execution, optionally overriding
+// its position size. A candidate listed more than CANDIDATE_APPROVAL_TTL_SEC
+// ago is rejected instead, so stale tokens are never bought.
+func (c *CandidateListingAgent) Approve(
+	key models.CandidateKey,
+	sizeUSD float64,
+	actor string,
+	reason string,
+) (*models.CandidateToken, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
-	if candidate, exists := c.candidates[tokenAddress]; exists {
-		candidate.Status = status
-		log.Printf("CandidateListingAgent: Updated %s status to %s\n", tokenAddress, status)
+	candidate, exists := c.candidates[key]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCandidateNotFound, key)
+	}
+	if candidate.Status == models.CandidatePending && c.expired(candidate) {
+		age := c.now().Sub(candidate.ListedAt).Round(time.Second)
+		if err := c.transition(candidate, models.CandidateRejected, actor, fmt.Sprintf("approval window expired after %s", age)); err != nil {
+			return nil, err
+		}
+		c.persist(candidate)
+		return snapshot(candidate), fmt.Errorf("%w: %s was listed %s ago", ErrApprovalExpired, key, age)
+	}
+	
+	previous := candidate.StrategyDecision.SuggestedAmountUSD
+	if sizeUSD > 0 {
+		reason = fmt.Sprintf("%s (size $%.2f, suggested $%.2f)", reason, sizeUSD, previous)
 	}
+	if err := c.transition(candidate, models.CandidateApproved, actor, reason); err != nil {
+		return nil, err
+	}
+	if sizeUSD > 0 {
+		candidate.StrategyDecision.SuggestedAmountUSD = sizeUSD
+	}
+	c.persist(candidate)
+	
+	return snapshot(candidate), nil
+}
+
+// ExpirePending rejects pending candidates whose approval window has passed
+func (c *CandidateListingAgent) ExpirePending() []*models.CandidateToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	var expired []*models.CandidateToken
+	for _, candidate := range c.candidates {
+		if candidate.Status != models.CandidatePending || !c.expired(candidate) {
+			continue
+		}
+		if err := c.transition(candidate, models.CandidateRejected, models.ActorAuto, "approval window expired"); err == nil {
+			c.persist(candidate)
+			expired = append(expired, snapshot(candidate))
+		}
+	}
+	return expired
+}
+
+// StartExpiry rejects stale pending candidates until the returned channel is closed
+func (c *CandidateListingAgent) StartExpiry() chan struct{} {
+	stopChan := make(chan struct{})
+	ttl := c.config.ApprovalTTL
+	if ttl <= 0 {
+		log.Println("CandidateListingAgent: Approval expiry disabled")
+		return stopChan
+	}
+	
+	go func() {
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-ticker.C:
+				for _, candidate := range c.ExpirePending() {
+					log.Printf("CandidateListingAgent: %s not approved within %s\n", candidate.Key(), ttl)
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+	
+	return stopChan
+}
+
+// Evict removes candidates that have been in a status longer than its
+// retention and archives them. If archiving fails they stay in memory and are
+// tried again on the next call.
+func (c *CandidateListingAgent) Evict() ([]*models.CandidateToken, error) {
+	c.mu.RLock()
+	now := c.now()
+	archive := c.archive
+	var evicted []*models.CandidateToken
+	for _, candidate := range c.candidates {
+		age, retained := c.retention[candidate.Status]
+		if retained && now.Sub(candidate.StatusSince()) > age {
+			evicted = append(evicted, snapshot(candidate))
+		}
+	}
+	c.mu.RUnlock()
+	
+	if len(evicted) == 0 {
+		return nil, nil
+	}
+	sort.Slice(evicted, func(i, j int) bool {
+		return evicted[i].StatusSince().Before(evicted[j].StatusSince())
+	})
+	if archive != nil {
+		if err := archive.Archive(evicted); err != nil {
+			return nil, err
+		}
+	}
+	
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, candidate := range evicted {
+		// Leave candidates that moved on or were replaced while archiving
+		if current, exists := c.candidates[candidate.Key()]; exists && len(current.History) == len(candidate.History) && current.ListedAt.Equal(candidate.ListedAt) {
+			delete(c.candidates, candidate.Key())
+		}
+	}
+	return evicted, nil
+}
+
+// StartRetention evicts candidates past their retention until the returned channel is closed
+func (c *CandidateListingAgent) StartRetention() chan struct{} {
+	stopChan := make(chan struct{})
+	if len(c.retention) == 0 {
+		log.Println("CandidateListingAgent: Candidate retention disabled")
+		return stopChan
+	}
+	
+	go func() {
+		ticker := time.NewTicker(retentionInterval)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-ticker.C:
+				evicted, err := c.Evict()
+				if err != nil {
+					log.Printf("CandidateListingAgent: Failed to archive candidates: %v\n", err)
+				} else if len(evicted) > 0 {
+					log.Printf("CandidateListingAgent: Archived %d candidates\n", len(evicted))
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+	
+	return stopChan
 }
 
-// GetQueue returns the candidate queue channel
-func (c *CandidateListingAgent) GetQueue() <-chan *models.CandidateToken {
+// GetQueue returns the queue of candidates awaiting automatic execution
+func (c *CandidateListingAgent) GetQueue() *ExecutionQueue {
 	return c.queue
 }
 
@@ -0,0 +1,331 @@
+package listing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
+)
+
+func TestCandidateLifecycle(t *testing.T) {
+	c := NewCandidateListingAgent(&config.Config{})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	token := models.TokenFound{Chain: models.ChainSolana, TokenAddress: "tokenA", FirstSeenTS: now.Add(-time.Minute).Unix()}
+	keyA := models.CandidateKey{Chain: models.ChainSolana, TokenAddress: "tokenA"}
+	candidate := c.AddCandidate(token, models.SafetyReport{}, models.OffChainMetrics{},
+		models.StrategyDecision{Strategy: "heuristic", Action: "buy", WinProbability: 0.9})
+	if candidate.Status != models.CandidatePending || len(candidate.History) != 2 {
+		t.Fatalf("expected a pending candidate with discovery history, got %+v", candidate)
+	}
+	if first := candidate.History[0]; first.To != models.CandidateDiscovered || !first.At.Equal(now.Add(-time.Minute)) {
+		t.Errorf("unexpected discovery %+v", first)
+	}
+
+	if _, err := c.Transition(keyA, models.CandidateExecuted, models.ActorAuto, "skip ahead"); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("expected pending -> executed to be illegal, got %v", err)
+	}
+	if _, err := c.Transition(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "tokenA"}, models.CandidateApproved, models.ActorAuto, ""); !errors.Is(err, ErrCandidateNotFound) {
+		t.Errorf("expected unknown token to be not found, got %v", err)
+	}
+
+	steps := []models.CandidateStatus{models.CandidateApproved, models.CandidateSubmitted, models.CandidateExecuted, models.CandidateClosed}
+	for _, status := range steps {
+		now = now.Add(time.Second)
+		if _, err := c.Transition(keyA, status, models.ActorOperator, "step"); err != nil {
+			t.Fatalf("transition to %s: %v", status, err)
+		}
+	}
+
+	candidate, _ = c.GetCandidate(keyA)
+	if candidate.Status != models.CandidateClosed || len(candidate.History) != 6 {
+		t.Fatalf("expected closed candidate with full history, got %+v", candidate)
+	}
+	last := candidate.History[5]
+	if last.From != models.CandidateExecuted || last.Actor != models.ActorOperator || !last.At.Equal(now) {
+		t.Errorf("unexpected last transition %+v", last)
+	}
+	if _, err := c.Transition(keyA, models.CandidatePending, models.ActorAuto, ""); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("closed should be terminal, got %v", err)
+	}
+	if !models.CandidateFailed.CanTransitionTo(models.CandidateClosed) {
+		t.Error("a failed candidate that left a position should be closable")
+	}
+
+	// Callers get copies, so later transitions do not change what they hold
+	candidate.History[0].Reason = "edited"
+	if again, _ := c.GetCandidate(keyA); again.History[0].Reason == "edited" {
+		t.Error("GetCandidate returned the stored history")
+	}
+}
+
+func TestApprovalWindow(t *testing.T) {
+	c := NewCandidateListingAgent(&config.Config{ApprovalTTL: 5 * time.Minute})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	decision := models.StrategyDecision{Action: "list", SuggestedAmountUSD: 100}
+	for _, address := range []string{"fresh", "stale", "late"} {
+		c.AddCandidate(models.TokenFound{TokenAddress: address}, models.SafetyReport{}, models.OffChainMetrics{}, decision)
+	}
+
+	approved, err := c.Approve(models.CandidateKey{TokenAddress: "fresh"}, 40, models.ActorOperator, "looks good")
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if approved.Status != models.CandidateApproved || approved.StrategyDecision.SuggestedAmountUSD != 40 {
+		t.Errorf("expected approval at the overridden size, got %+v", approved)
+	}
+	if _, err := c.Approve(models.CandidateKey{TokenAddress: "fresh"}, 0, models.ActorOperator, "again"); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("expected a second approval to be refused, got %v", err)
+	}
+
+	now = now.Add(6 * time.Minute)
+	rejected, err := c.Approve(models.CandidateKey{TokenAddress: "stale"}, 0, models.ActorOperator, "too late")
+	if !errors.Is(err, ErrApprovalExpired) || rejected.Status != models.CandidateRejected {
+		t.Errorf("expected a stale approval to reject the candidate, got %v, %+v", err, rejected)
+	}
+
+	expired := c.ExpirePending()
+	if len(expired) != 1 || expired[0].Token.TokenAddress != "late" || expired[0].Status != models.CandidateRejected {
+		t.Errorf("expected only the remaining pending candidate to expire, got %+v", expired)
+	}
+	if pending := c.GetPendingCandidates(); len(pending) != 0 {
+		t.Errorf("expected no pending candidates, got %d", len(pending))
+	}
+}
+
+func TestCandidatesKeyedByChain(t *testing.T) {
+	c := NewCandidateListingAgent(&config.Config{})
+	decision := models.StrategyDecision{Action: "list"}
+
+	c.AddCandidate(models.TokenFound{Chain: models.ChainSolana, TokenAddress: "0xabc"}, models.SafetyReport{}, models.OffChainMetrics{}, decision)
+	c.AddCandidate(models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc"}, models.SafetyReport{}, models.OffChainMetrics{}, decision)
+	if c.GetCandidateCount() != 2 {
+		t.Fatalf("expected the same address on two chains to be two candidates, got %d", c.GetCandidateCount())
+	}
+
+	solana := models.CandidateKey{Chain: models.ChainSolana, TokenAddress: "0xabc"}
+	if _, err := c.Transition(solana, models.CandidateRejected, models.ActorOperator, ""); err != nil {
+		t.Fatal(err)
+	}
+	if base, _ := c.GetCandidate(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "0xabc"}); base.Status != models.CandidatePending {
+		t.Errorf("rejecting on solana changed the base candidate to %s", base.Status)
+	}
+
+	// An active candidate is kept; a finished one is replaced by a fresh listing
+	again := c.AddCandidate(models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc"}, models.SafetyReport{}, models.OffChainMetrics{}, decision)
+	if len(again.History) != 2 || again.Status != models.CandidatePending {
+		t.Errorf("expected the pending base candidate back, got %+v", again)
+	}
+	relisted := c.AddCandidate(models.TokenFound{Chain: models.ChainSolana, TokenAddress: "0xabc"}, models.SafetyReport{}, models.OffChainMetrics{}, decision)
+	if relisted.Status != models.CandidatePending || c.GetCandidateCount() != 2 {
+		t.Errorf("expected the rejected solana candidate to be relisted, got %+v", relisted)
+	}
+}
+
+func TestGetAllCandidatesFilter(t *testing.T) {
+	c := NewCandidateListingAgent(&config.Config{})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	for i, chain := range []models.Chain{models.ChainSolana, models.ChainBase, models.ChainSolana, models.ChainBase} {
+		token := models.TokenFound{Chain: chain, TokenAddress: string(rune('a' + i))}
+		c.AddCandidate(token, models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{Action: "list", WinProbability: 0.7 + 0.05*float64(i)})
+		now = now.Add(time.Minute)
+	}
+	c.Transition(models.CandidateKey{Chain: models.ChainSolana, TokenAddress: "c"}, models.CandidateRejected, models.ActorOperator, "")
+
+	addresses := func(candidates []*models.CandidateToken) string {
+		var s string
+		for _, candidate := range candidates {
+			s += candidate.Token.TokenAddress
+		}
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		filter CandidateFilter
+		want   string
+		total  int
+	}{
+		{"all newest first", CandidateFilter{}, "dcba", 4},
+		{"chain", CandidateFilter{Chain: models.ChainSolana}, "ca", 2},
+		{"status", CandidateFilter{Statuses: []models.CandidateStatus{models.CandidatePending}}, "dba", 3},
+		{"time range", CandidateFilter{Since: now.Add(-3 * time.Minute), Until: now.Add(-time.Minute)}, "cb", 2},
+		{"win probability", CandidateFilter{MinWinProbability: 0.79}, "dc", 2},
+		{"page", CandidateFilter{Offset: 1, Limit: 2}, "cb", 4},
+		{"past the end", CandidateFilter{Offset: 10, Limit: 2}, "", 4},
+	}
+	for _, tt := range tests {
+		page, total := c.GetAllCandidates(tt.filter)
+		if got := addresses(page); got != tt.want || total != tt.total {
+			t.Errorf("%s: got %q of %d, want %q of %d", tt.name, got, total, tt.want, tt.total)
+		}
+	}
+}
+
+func TestEvictArchivesFinishedCandidates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive", "candidates.jsonl")
+	c := NewCandidateListingAgent(&config.Config{
+		CandidateRetention:   []string{"rejected=10", "executed=60", "pending=5", "bogus"},
+		CandidateArchiveFile: path,
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	decision := models.StrategyDecision{Action: "list"}
+	for _, address := range []string{"rejected", "executed", "pending"} {
+		c.AddCandidate(models.TokenFound{Chain: models.ChainBase, TokenAddress: address}, models.SafetyReport{}, models.OffChainMetrics{}, decision)
+	}
+	c.Transition(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "rejected"}, models.CandidateRejected, models.ActorAuto, "")
+	for _, status := range []models.CandidateStatus{models.CandidateApproved, models.CandidateSubmitted, models.CandidateExecuted} {
+		c.Transition(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "executed"}, status, models.ActorAuto, "")
+	}
+
+	now = now.Add(30 * time.Minute)
+	evicted, err := c.Evict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 1 || evicted[0].Token.TokenAddress != "rejected" || c.GetCandidateCount() != 2 {
+		t.Fatalf("expected only the rejected candidate to be evicted, got %d and %d left", len(evicted), c.GetCandidateCount())
+	}
+
+	now = now.Add(time.Hour)
+	if evicted, _ := c.Evict(); len(evicted) != 1 || evicted[0].Token.TokenAddress != "executed" {
+		t.Fatalf("expected the executed candidate to be evicted after its retention, got %+v", evicted)
+	}
+	if _, found := c.GetCandidate(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "pending"}); !found {
+		t.Error("pending candidates must never be evicted")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var archived []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var candidate models.CandidateToken
+		if err := json.Unmarshal(scanner.Bytes(), &candidate); err != nil {
+			t.Fatal(err)
+		}
+		archived = append(archived, candidate.Key().String()+"/"+string(candidate.Status))
+	}
+	if len(archived) != 2 || archived[0] != "base:rejected/rejected" || archived[1] != "base:executed/executed" {
+		t.Errorf("unexpected archive %v", archived)
+	}
+}
+
+// failingArchiver refuses to store anything
+type failingArchiver struct{}
+
+func (failingArchiver) Archive([]*models.CandidateToken) error { return errors.New("disk full") }
+
+func TestEvictKeepsCandidatesWhenArchiveFails(t *testing.T) {
+	c := NewCandidateListingAgent(&config.Config{CandidateRetention: []string{"rejected=1"}})
+	c.SetArchiver(failingArchiver{})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.AddCandidate(models.TokenFound{TokenAddress: "tokenA"}, models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{})
+	c.Transition(models.CandidateKey{TokenAddress: "tokenA"}, models.CandidateRejected, models.ActorAuto, "")
+
+	now = now.Add(time.Hour)
+	if _, err := c.Evict(); err == nil {
+		t.Error("expected the archive error")
+	}
+	if c.GetCandidateCount() != 1 {
+		t.Error("a candidate that could not be archived was dropped")
+	}
+}
+
+func TestCandidatesSurviveRestart(t *testing.T) {
+	store, err := storage.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	first := NewCandidateListingAgent(&config.Config{})
+	first.SetStore(store, store)
+	for _, address := range []string{"kept", "approved", "traded", "lost", "inflight", "rejected"} {
+		first.AddCandidate(models.TokenFound{Chain: models.ChainBase, TokenAddress: address}, models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{Action: "list", SuggestedAmountUSD: 25})
+	}
+	key := func(address string) models.CandidateKey {
+		return models.CandidateKey{Chain: models.ChainBase, TokenAddress: address}
+	}
+	for _, address := range []string{"approved", "traded", "lost", "inflight"} {
+		first.Approve(key(address), 10, models.ActorOperator, "")
+	}
+	for _, address := range []string{"traded", "lost", "inflight"} {
+		first.Transition(key(address), models.CandidateSubmitted, models.ActorAuto, "")
+	}
+	first.Transition(key("rejected"), models.CandidateRejected, models.ActorOperator, "")
+	store.SaveExecution(context.Background(), &models.ExecutionResult{Chain: models.ChainBase, TokenAddress: "traded", Status: "confirmed", Timestamp: time.Now()})
+	store.SaveExecution(context.Background(), &models.ExecutionResult{Chain: models.ChainBase, TokenAddress: "lost", Status: "failed", Timestamp: time.Now()})
+	store.SaveExecution(context.Background(), &models.ExecutionResult{Chain: models.ChainBase, TokenAddress: "inflight", TxHash: "0xabc", Status: "pending", Timestamp: time.Now()})
+
+	second := NewCandidateListingAgent(&config.Config{})
+	second.SetStore(store, store)
+	if second.GetCandidateCount() != 5 {
+		t.Fatalf("expected only the untraded candidates to be restored, got %d", second.GetCandidateCount())
+	}
+	kept, _ := second.GetCandidate(key("kept"))
+	if kept.Status != models.CandidatePending || kept.StrategyDecision.SuggestedAmountUSD != 25 || len(kept.History) != 2 {
+		t.Errorf("restored candidate lost its state: %+v", kept)
+	}
+	for address, want := range map[string]models.CandidateStatus{
+		"approved": models.CandidateRejected,
+		"traded":   models.CandidateExecuted,
+		"lost":     models.CandidateFailed,
+		"inflight": models.CandidateSubmitted, // settled by the execution agent
+	} {
+		candidate, _ := second.GetCandidate(key(address))
+		if candidate.Status != want {
+			t.Errorf("%s: expected %s after restart, got %s", address, want, candidate.Status)
+		}
+	}
+	if lost, _ := second.GetCandidate(key("lost")); lost.History[len(lost.History)-1].Reason != restartReason {
+		t.Errorf("expected the interrupted trade to record why it failed, got %+v", lost.History)
+	}
+
+	third := NewCandidateListingAgent(&config.Config{})
+	third.SetStore(store, store)
+	if third.GetCandidateCount() != 2 {
+		t.Errorf("executed and resolved candidates were restored again: %d", third.GetCandidateCount())
+	}
+}
+
+func TestResolveBareAddress(t *testing.T) {
+	c := NewCandidateListingAgent(&config.Config{})
+	for _, token := range []models.TokenFound{
+		{Chain: models.ChainBase, TokenAddress: "tokenA"},
+		{Chain: models.ChainBase, TokenAddress: "shared"},
+		{Chain: models.ChainSolana, TokenAddress: "shared"},
+	} {
+		c.AddCandidate(token, models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{Action: "list"})
+	}
+
+	if key, err := c.Resolve("tokenA"); err != nil || key.Chain != models.ChainBase {
+		t.Errorf("expected tokenA on base, got %s, %v", key, err)
+	}
+	if _, err := c.Resolve("shared"); !errors.Is(err, ErrAmbiguousAddress) {
+		t.Errorf("expected an address on two chains to be ambiguous, got %v", err)
+	}
+	if _, err := c.Resolve("unknown"); !errors.Is(err, ErrCandidateNotFound) {
+		t.Errorf("expected an unknown address not to be found, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package listing
+
+import (
+	"container/heap"
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// Reasons a candidate leaves the execution queue without being executed
+const (
+	DropOverflow = "overflow"
+	DropExpired  = "expired"
+)
+
+// QueueObserver is told the queue depth after every change and about every drop
+type QueueObserver interface {
+	RecordQueueDepth(depth int)
+	RecordQueueDrop(reason string)
+}
+
+// queueItem is a queued candidate and its priority inputs
+type queueItem struct {
+	candidate  *models.CandidateToken
+	value      float64 // expected value in USD before decay
+	enqueuedAt time.Time
+	deadline   time.Time
+}
+
+// ExecutionQueue holds candidates waiting for execution, strongest first.
+// Strength is the candidate's expected value halved every halfLife since it
+// was queued, so a fresh candidate can overtake a slightly better stale one.
+// Candidates not executed before their deadline are dropped, and when the
+// queue is full the weakest candidate makes room.
+type ExecutionQueue struct {
+	capacity int
+	halfLife time.Duration
+	deadline time.Duration
+	items    []*queueItem
+	ready    chan struct{}
+	observer QueueObserver
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// NewExecutionQueue creates a queue; a zero halfLife or deadline disables decay or expiry
+func NewExecutionQueue(capacity int, halfLife, deadline time.Duration) *ExecutionQueue {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &ExecutionQueue{
+		capacity: capacity,
+		halfLife: halfLife,
+		deadline: deadline,
+		ready:    make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// SetObserver sets where depth and drops are reported
+func (q *ExecutionQueue) SetObserver(observer QueueObserver) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.observer = observer
+}
+
+// expectedValue is the USD a candidate is expected to make
+func expectedValue(candidate *models.CandidateToken) float64 {
+	decision := candidate.StrategyDecision
+	return math.Max(decision.SuggestedAmountUSD*decision.ExpectedROI, 0)
+}
+
+// stronger reports whether a should be executed before b. Comparing a's value
+// decayed relative to b's enqueue time is the same at any moment, so the
+// order never changes while items wait.
+func (q *ExecutionQueue) stronger(a, b *queueItem) bool {
+	av := a.value
+	if q.halfLife > 0 {
+		av *= math.Exp2(float64(a.enqueuedAt.Sub(b.enqueuedAt)) / float64(q.halfLife))
+	}
+	if av != b.value {
+		return av > b.value
+	}
+	return a.enqueuedAt.After(b.enqueuedAt)
+}
+
+// Push queues a candidate and reports whether it was kept. A full queue
+// drops its weakest candidate, which may be the new one.
+func (q *ExecutionQueue) Push(candidate *models.CandidateToken) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.expire(now)
+
+	item := &queueItem{candidate: candidate, value: expectedValue(candidate), enqueuedAt: now}
+	if q.deadline > 0 {
+		item.deadline = now.Add(q.deadline)
+	}
+
+	if len(q.items) >= q.capacity {
+		weakest := 0
+		for i, other := range q.items {
+			if q.stronger(q.items[weakest], other) {
+				weakest = i
+			}
+		}
+		if !q.stronger(item, q.items[weakest]) {
+			q.drop(item, DropOverflow)
+			q.reportDepth()
+			return false
+		}
+		q.drop(heap.Remove((*queueHeap)(q), weakest).(*queueItem), DropOverflow)
+	}
+
+	heap.Push((*queueHeap)(q), item)
+	q.reportDepth()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Pop waits for the strongest unexpired candidate, or for ctx to be done
+func (q *ExecutionQueue) Pop(ctx context.Context) (*models.CandidateToken, error) {
+	for {
+		q.mu.Lock()
+		q.expire(q.now())
+		if len(q.items) > 0 {
+			item := heap.Pop((*queueHeap)(q)).(*queueItem)
+			q.reportDepth()
+			q.mu.Unlock()
+			return item.candidate, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Len returns the number of queued candidates
+func (q *ExecutionQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// expire drops candidates past their deadline; the caller holds the lock
+func (q *ExecutionQueue) expire(now time.Time) {
+	kept := make([]*queueItem, 0, len(q.items))
+	for _, item := range q.items {
+		if !item.deadline.IsZero() && !now.Before(item.deadline) {
+			q.drop(item, DropExpired)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if len(kept) < len(q.items) {
+		q.items = kept
+		heap.Init((*queueHeap)(q))
+		q.reportDepth()
+	}
+}
+
+// drop logs and reports a candidate leaving the queue unexecuted
+func (q *ExecutionQueue) drop(item *queueItem, reason string) {
+	log.Printf("CandidateListingAgent: Dropped %s from execution queue (%s, expected value $%.2f)\n",
+		item.candidate.Token.TokenAddress, reason, item.value)
+	if q.observer != nil {
+		q.observer.RecordQueueDrop(reason)
+	}
+}
+
+// reportDepth reports the current depth; the caller holds the lock
+func (q *ExecutionQueue) reportDepth() {
+	if q.observer != nil {
+		q.observer.RecordQueueDepth(len(q.items))
+	}
+}
+
+// queueHeap adapts the queue's items to container/heap
+type queueHeap ExecutionQueue
+
+func (h *queueHeap) Len() int { return len(h.items) }
+
+func (h *queueHeap) Less(i, j int) bool {
+	return (*ExecutionQueue)(h).stronger(h.items[i], h.items[j])
+}
+
+func (h *queueHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *queueHeap) Push(x interface{}) { h.items = append(h.items, x.(*queueItem)) }
+
+func (h *queueHeap) Pop() interface{} {
+	last := len(h.items) - 1
+	item := h.items[last]
+	h.items[last] = nil
+	h.items = h.items[:last]
+	return item
+}
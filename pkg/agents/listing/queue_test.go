@@ -0,0 +1,121 @@
+package listing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// countingObserver records what the queue reports
+type countingObserver struct {
+	depth   int
+	dropped map[string]int
+}
+
+func (o *countingObserver) RecordQueueDepth(depth int)    { o.depth = depth }
+func (o *countingObserver) RecordQueueDrop(reason string) { o.dropped[reason]++ }
+
+// queued builds a candidate expected to make sizeUSD * roi
+func queued(address string, sizeUSD, roi float64) *models.CandidateToken {
+	return &models.CandidateToken{
+		Token:            models.TokenFound{TokenAddress: address},
+		StrategyDecision: models.StrategyDecision{Action: "buy", SuggestedAmountUSD: sizeUSD, ExpectedROI: roi},
+	}
+}
+
+// popAll drains the queue without blocking
+func popAll(t *testing.T, q *ExecutionQueue) []string {
+	t.Helper()
+	var addresses []string
+	for q.Len() > 0 {
+		candidate, err := q.Pop(context.Background())
+		if err != nil {
+			t.Fatalf("Pop: %v", err)
+		}
+		addresses = append(addresses, candidate.Token.TokenAddress)
+	}
+	return addresses
+}
+
+func TestExecutionQueueOrder(t *testing.T) {
+	q := NewExecutionQueue(10, time.Minute, 0)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	q.Push(queued("small", 100, 0.1))  // $10
+	q.Push(queued("large", 100, 0.5))  // $50
+	q.Push(queued("medium", 100, 0.3)) // $30
+	now = now.Add(time.Minute)
+	q.Push(queued("fresh", 100, 0.2)) // $20, worth $40 against items a half-life older
+
+	got := popAll(t, q)
+	want := []string{"large", "fresh", "medium", "small"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestExecutionQueueOverflowAndExpiry(t *testing.T) {
+	q := NewExecutionQueue(2, 0, time.Minute)
+	observer := &countingObserver{dropped: make(map[string]int)}
+	q.SetObserver(observer)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	q.Push(queued("weak", 100, 0.1))
+	q.Push(queued("strong", 100, 0.5))
+	if !q.Push(queued("middle", 100, 0.3)) {
+		t.Fatal("expected a stronger candidate to displace the weakest")
+	}
+	if q.Push(queued("weaker", 100, 0.05)) {
+		t.Error("expected a candidate weaker than everything queued to be dropped")
+	}
+	if observer.dropped[DropOverflow] != 2 || observer.depth != 2 {
+		t.Errorf("unexpected reports: depth %d, drops %v", observer.depth, observer.dropped)
+	}
+
+	now = now.Add(30 * time.Second)
+	q.Push(queued("late", 100, 0.4)) // displaces middle
+	now = now.Add(40 * time.Second)  // strong has expired, late has not
+	got := popAll(t, q)
+	if len(got) != 1 || got[0] != "late" {
+		t.Errorf("expected only late to survive, got %v", got)
+	}
+	if observer.dropped[DropExpired] != 1 || observer.dropped[DropOverflow] != 3 || observer.depth != 0 {
+		t.Errorf("unexpected reports: depth %d, drops %v", observer.depth, observer.dropped)
+	}
+}
+
+func TestExecutionQueuePopWaits(t *testing.T) {
+	q := NewExecutionQueue(10, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Pop(ctx); err == nil {
+		t.Error("expected Pop on an empty queue to return when ctx is done")
+	}
+
+	popped := make(chan string)
+	go func() {
+		candidate, err := q.Pop(context.Background())
+		if err != nil {
+			close(popped)
+			return
+		}
+		popped <- candidate.Token.TokenAddress
+	}()
+	q.Push(queued("tokenA", 100, 0.2))
+
+	select {
+	case address := <-popped:
+		if address != "tokenA" {
+			t.Errorf("popped %q", address)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Pop did not wake up for a pushed candidate")
+	}
+}
@@ -0,0 +1,70 @@
+package offchain
+
+import (
+	"context"
+
+	"github.com/mumugogoing/meme_bot/pkg/clients/dex"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// dexTokenClient is implemented by the DexScreener and GeckoTerminal clients
+type dexTokenClient interface {
+	GetToken(ctx context.Context, chain models.Chain, tokenAddress string) (*dex.TokenData, error)
+}
+
+// dexProvider reports DEX volume, price, market cap and pool liquidity
+type dexProvider struct {
+	name   string
+	client dexTokenClient
+}
+
+func newDexScreenerProvider(cfg *config.Config) (interface{}, error) {
+	return &dexProvider{
+		name:   "dexscreener",
+		client: dex.NewDexScreenerClient("", cfg.MarketDataCacheTTL),
+	}, nil
+}
+
+func newGeckoTerminalProvider(cfg *config.Config) (interface{}, error) {
+	return &dexProvider{
+		name:   "geckoterminal",
+		client: dex.NewGeckoTerminalClient("", cfg.MarketDataCacheTTL),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *dexProvider) Name() string {
+	return p.name
+}
+
+// FetchVolume returns 24h DEX volume, market cap and liquidity
+func (p *dexProvider) FetchVolume(ctx context.Context, token models.TokenFound) (*VolumeData, error) {
+	data, err := p.client.GetToken(ctx, token.Chain, token.TokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	volume := &VolumeData{
+		Venue:        VenueDEX,
+		Volume24h:    data.Volume24h,
+		Trades24h:    data.Trades24h,
+		MarketCap:    data.MarketCap,
+		LiquidityUSD: data.LiquidityUSD,
+	}
+	if top := data.TopPair(); top != nil {
+		volume.Pool = top.Pool()
+	}
+	return volume, nil
+}
+
+// FetchPrice returns the USD price on the deepest pool
+func (p *dexProvider) FetchPrice(ctx context.Context, token models.TokenFound) (*PriceData, error) {
+	data, err := p.client.GetToken(ctx, token.Chain, token.TokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &PriceData{
+		Venue:    VenueDEX,
+		PriceUSD: data.PriceUSD,
+	}, nil
+}
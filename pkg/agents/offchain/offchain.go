@@ -2,29 +2,90 @@ package offchain
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"math"
+	"sync"
 	"time"
 
+	"github.com/mumugogoing/meme_bot/pkg/agents/tradeflow"
 	"github.com/mumugogoing/meme_bot/pkg/config"
 	"github.com/mumugogoing/meme_bot/pkg/models"
 )
 
 // OffChainDataAgent gathers off-chain metrics and signals
 type OffChainDataAgent struct {
-	config *config.Config
+	config    *config.Config
+	providers *ProviderSet
+	series    *seriesStore
+	flow      *tradeflow.TradeFlowTracker
+	tracked   map[string]trackedToken // tokens the sampler keeps observing
+	trackMu   sync.Mutex
 }
 
 // NewOffChainDataAgent creates a new off-chain data agent
 func NewOffChainDataAgent(cfg *config.Config) *OffChainDataAgent {
+	providers, errs := DefaultRegistry().Build(cfg, cfg.OffChainProviders)
+	for name, err := range errs {
+		log.Printf("OffChainDataAgent: Provider %s disabled: %v\n", name, err)
+	}
+	
+	agent := NewOffChainDataAgentWithProviders(cfg, providers)
+	if cfg.TradeFlowEnabled {
+		agent.SetTradeFlowTracker(tradeflow.NewTradeFlowTracker(cfg))
+	}
+	
+	return agent
+}
+
+// NewOffChainDataAgentWithProviders creates an off-chain data agent backed by an explicit provider set
+func NewOffChainDataAgentWithProviders(cfg *config.Config, providers *ProviderSet) *OffChainDataAgent {
+	if providers == nil {
+		providers = &ProviderSet{}
+	}
+	
+	log.Printf("OffChainDataAgent: %d volume, %d price, %d social providers enabled\n",
+		len(providers.Volume), len(providers.Price), len(providers.Social))
+	
 	return &OffChainDataAgent{
-		config: cfg,
+		config:    cfg,
+		providers: providers,
+		series:    newSeriesStore(cfg.ObservationWindow1h),
+		tracked:   make(map[string]trackedToken),
 	}
 }
 
-// Gather collects off-chain metrics for a token
+// SetTradeFlowTracker enables on-chain trade flow metrics
+func (o *OffChainDataAgent) SetTradeFlowTracker(flow *tradeflow.TradeFlowTracker) {
+	o.flow = flow
+}
+
+// Close stops background work started by the providers
+func (o *OffChainDataAgent) Close() error {
+	return o.providers.Close()
+}
+
+// Gather collects off-chain metrics for a token and keeps sampling it in the
+// background so later decisions see a time series
 func (o *OffChainDataAgent) Gather(ctx context.Context, token models.PreFilteredToken) (*models.OffChainMetrics, error) {
 	log.Printf("OffChainDataAgent: Gathering metrics for token %s\n", token.Token.TokenAddress)
 	
+	o.track(token)
+	metrics := o.collect(ctx, token)
+	
+	log.Printf("OffChainDataAgent: Token %s - DEX Volume: %.2f, CEX Volume: %.2f, Velocity: %s\n",
+		token.Token.TokenAddress, metrics.Volume24hDEX, metrics.Volume24hCEX, metrics.Velocity)
+	
+	if len(metrics.ProviderErrors) > 0 {
+		log.Printf("OffChainDataAgent: Token %s - degraded metrics, %d provider(s) failed\n",
+			token.Token.TokenAddress, len(metrics.ProviderErrors))
+	}
+	
+	return metrics, nil
+}
+
+// collect queries every provider for a token and records the result in its time series
+func (o *OffChainDataAgent) collect(ctx context.Context, token models.PreFilteredToken) *models.OffChainMetrics {
 	metrics := &models.OffChainMetrics{
 		TokenAddress:   token.Token.TokenAddress,
 		SocialMentions: make(map[string]int),
@@ -37,107 +98,239 @@ func (o *OffChainDataAgent) Gather(ctx context.Context, token models.PreFiltered
 	// Gather social metrics
 	o.gatherSocialMetrics(ctx, token, metrics)
 	
+	// Gather on-chain trade flow
+	o.gatherTradeFlow(ctx, token, metrics)
+	
 	// Determine velocity
+	o.recordSample(token, metrics)
 	metrics.Velocity = o.determineVelocity(metrics)
 	
-	log.Printf("OffChainDataAgent: Token %s - DEX Volume: %.2f, CEX Volume: %.2f, Velocity: %s\n",
-		token.Token.TokenAddress, metrics.Volume24hDEX, metrics.Volume24hCEX, metrics.Velocity)
-	
-	return metrics, nil
+	return metrics
 }
 
-// gatherVolumeData collects trading volume from various sources
+// gatherVolumeData collects trading volume and prices from the enabled providers
 func (o *OffChainDataAgent) gatherVolumeData(ctx context.Context, token models.PreFilteredToken, metrics *models.OffChainMetrics) {
-	// TODO: Implement actual API calls
-	// 1. Query DEX aggregators (TheGraph, DexScreener, DexTools)
-	// 2. Query CEX APIs (OKX, others if listed)
-	// 3. Query CoinGecko for aggregated data
-	
 	log.Printf("OffChainDataAgent: Fetching volume data for %s\n", token.Token.TokenAddress)
 	
-	// Placeholder: Would query actual APIs
-	// For DEX on respective chain
-	metrics.Volume24hDEX = o.queryDEXVolume(ctx, token)
+	var (
+		volumes []providerResult[*VolumeData]
+		prices  []providerResult[*PriceData]
+		wg      sync.WaitGroup
+	)
 	
-	// For CEX (if token is listed)
-	metrics.Volume24hCEX = o.queryCEXVolume(ctx, token)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		volumes = fanOut(ctx, o.config.ProviderTimeout, o.providers.Volume,
+			func(ctx context.Context, p VolumeProvider) (*VolumeData, error) {
+				return p.FetchVolume(ctx, token.Token)
+			})
+	}()
+	go func() {
+		defer wg.Done()
+		prices = fanOut(ctx, o.config.ProviderTimeout, o.providers.Price,
+			func(ctx context.Context, p PriceProvider) (*PriceData, error) {
+				return p.FetchPrice(ctx, token.Token)
+			})
+	}()
+	wg.Wait()
 	
-	// Price data
-	metrics.PriceOnDEX = o.queryDEXPrice(ctx, token)
-	metrics.PriceOnCEX = o.queryCEXPrice(ctx, token)
+	// Several providers may cover the same venue; keep the largest figure
+	// rather than summing so that overlapping sources are not double counted
+	for _, result := range volumes {
+		if !o.recordResult(metrics, result.provider, result.err, result.value == nil) {
+			continue
+		}
+		switch result.value.Venue {
+		case VenueCEX:
+			metrics.Volume24hCEX = math.Max(metrics.Volume24hCEX, result.value.Volume24h)
+			if result.value.Market != "" {
+				metrics.CEXListings = append(metrics.CEXListings, result.value.Market)
+			}
+		default:
+			metrics.Volume24hDEX = math.Max(metrics.Volume24hDEX, result.value.Volume24h)
+		}
+		metrics.MarketCap = math.Max(metrics.MarketCap, result.value.MarketCap)
+		metrics.LiquidityUSD = math.Max(metrics.LiquidityUSD, result.value.LiquidityUSD)
+		if pool := result.value.Pool; pool != nil && (metrics.Pool == nil || poolDepth(pool) > poolDepth(metrics.Pool)) {
+			metrics.Pool = pool
+		}
+		if result.value.Trades24h > metrics.TradeCount24h {
+			metrics.TradeCount24h = result.value.Trades24h
+		}
+	}
 	
-	// Market cap
-	metrics.MarketCap = o.queryMarketCap(ctx, token)
+	// Prices are taken from the first provider (in configured order) that answered
+	for _, result := range prices {
+		if !o.recordResult(metrics, result.provider, result.err, result.value == nil) {
+			continue
+		}
+		switch result.value.Venue {
+		case VenueCEX:
+			if metrics.PriceOnCEX == 0 {
+				metrics.PriceOnCEX = result.value.PriceUSD
+			}
+		default:
+			if metrics.PriceOnDEX == 0 {
+				metrics.PriceOnDEX = result.value.PriceUSD
+			}
+		}
+	}
 }
 
-// gatherSocialMetrics collects social media signals
-func (o *OffChainDataAgent) gatherSocialMetrics(ctx context.Context, token models.PreFilteredToken, metrics *models.OffChainMetrics) {
-	// TODO: Implement actual social media API calls
-	// 1. Twitter/X API for mentions and engagement
-	// 2. Telegram group/channel activity
-	// 3. Reddit mentions if available
-	
-	log.Printf("OffChainDataAgent: Fetching social metrics for %s\n", token.Token.TokenAddress)
+// Price returns the token's current price, preferring DEX providers over CEX ones
+func (o *OffChainDataAgent) Price(ctx context.Context, token models.TokenFound) (float64, error) {
+	prices := fanOut(ctx, o.config.ProviderTimeout, o.providers.Price,
+		func(ctx context.Context, p PriceProvider) (*PriceData, error) {
+			return p.FetchPrice(ctx, token)
+		})
 	
-	// Placeholder: Would query actual APIs
-	metrics.SocialMentions["twitter"] = o.queryTwitterMentions(ctx, token)
-	metrics.SocialMentions["telegram"] = o.queryTelegramActivity(ctx, token)
-	metrics.SocialMentions["reddit"] = o.queryRedditMentions(ctx, token)
-}
-
-// queryDEXVolume queries DEX trading volume
-func (o *OffChainDataAgent) queryDEXVolume(ctx context.Context, token models.PreFilteredToken) float64 {
-	// TODO: Implement actual DEX volume query
-	// Use TheGraph, DexScreener API, or direct DEX queries
-	return 0.0
-}
-
-// queryCEXVolume queries CEX trading volume
-func (o *OffChainDataAgent) queryCEXVolume(ctx context.Context, token models.PreFilteredToken) float64 {
-	// TODO: Implement actual CEX volume query
-	// Use OKX API or other CEX APIs
-	return 0.0
-}
-
-// queryDEXPrice queries current DEX price
-func (o *OffChainDataAgent) queryDEXPrice(ctx context.Context, token models.PreFilteredToken) float64 {
-	// TODO: Implement actual price query from DEX
-	return 0.0
+	var cex float64
+	var lastErr error
+	for _, result := range prices {
+		if result.err != nil {
+			lastErr = result.err
+			continue
+		}
+		if result.value == nil || result.value.PriceUSD <= 0 {
+			continue
+		}
+		if result.value.Venue != VenueCEX {
+			return result.value.PriceUSD, nil
+		}
+		if cex == 0 {
+			cex = result.value.PriceUSD
+		}
+	}
+	if cex > 0 {
+		return cex, nil
+	}
+	if lastErr != nil {
+		return 0, lastErr
+	}
+	return 0, fmt.Errorf("no price for %s", token.TokenAddress)
 }
 
-// queryCEXPrice queries current CEX price
-func (o *OffChainDataAgent) queryCEXPrice(ctx context.Context, token models.PreFilteredToken) float64 {
-	// TODO: Implement actual price query from CEX
-	return 0.0
+// gatherSocialMetrics collects social media signals from the enabled providers
+func (o *OffChainDataAgent) gatherSocialMetrics(ctx context.Context, token models.PreFilteredToken, metrics *models.OffChainMetrics) {
+	log.Printf("OffChainDataAgent: Fetching social metrics for %s\n", token.Token.TokenAddress)
+	
+	results := fanOut(ctx, o.config.ProviderTimeout, o.providers.Social,
+		func(ctx context.Context, p SocialProvider) (*SocialData, error) {
+			return p.FetchSocial(ctx, token.Token)
+		})
+	
+	for _, result := range results {
+		if !o.recordResult(metrics, result.provider, result.err, result.value == nil) {
+			continue
+		}
+		for platform, count := range result.value.Mentions {
+			if count > metrics.SocialMentions[platform] {
+				metrics.SocialMentions[platform] = count
+			}
+		}
+		for platform, growth := range result.value.Growth {
+			if metrics.SocialGrowth == nil {
+				metrics.SocialGrowth = make(map[string]models.SocialGrowth)
+			}
+			if existing, exists := metrics.SocialGrowth[platform]; !exists || growth.Members > existing.Members {
+				metrics.SocialGrowth[platform] = growth
+			}
+		}
+	}
 }
 
-// queryMarketCap queries market capitalization
-func (o *OffChainDataAgent) queryMarketCap(ctx context.Context, token models.PreFilteredToken) float64 {
-	// TODO: Implement actual market cap query
-	// Use CoinGecko or calculate from supply and price
-	return 0.0
+// gatherTradeFlow follows the token's pool and copies the current swap flow onto the metrics
+func (o *OffChainDataAgent) gatherTradeFlow(ctx context.Context, token models.PreFilteredToken, metrics *models.OffChainMetrics) {
+	if o.flow == nil {
+		return
+	}
+	
+	o.flow.Follow(ctx, token.Token)
+	if flow, ok := o.flow.Snapshot(token.Token); ok {
+		metrics.TradeFlow = flow
+		metrics.UniqueBuyers = flow.UniqueBuyers
+	}
 }
 
-// queryTwitterMentions queries Twitter/X for mentions
-func (o *OffChainDataAgent) queryTwitterMentions(ctx context.Context, token models.PreFilteredToken) int {
-	// TODO: Implement actual Twitter API query
-	return 0
+// recordResult notes a provider's outcome on the metrics and reports whether its value can be used.
+// A failing provider degrades the metrics rather than failing the stage; a provider with nothing
+// to report for the token is skipped.
+func (o *OffChainDataAgent) recordResult(metrics *models.OffChainMetrics, provider string, err error, empty bool) bool {
+	if err != nil {
+		if metrics.ProviderErrors == nil {
+			metrics.ProviderErrors = make(map[string]string)
+		}
+		metrics.ProviderErrors[provider] = err.Error()
+		log.Printf("OffChainDataAgent: Provider %s failed for %s: %v\n", provider, metrics.TokenAddress, err)
+		return false
+	}
+	
+	if empty {
+		return false
+	}
+	
+	for _, source := range metrics.Sources {
+		if source == provider {
+			return true
+		}
+	}
+	metrics.Sources = append(metrics.Sources, provider)
+	return true
 }
 
-// queryTelegramActivity queries Telegram activity
-func (o *OffChainDataAgent) queryTelegramActivity(ctx context.Context, token models.PreFilteredToken) int {
-	// TODO: Implement actual Telegram activity tracking
-	return 0
+// recordSample adds the current observation to the token's time series and
+// computes trend statistics over each observation window
+func (o *OffChainDataAgent) recordSample(token models.PreFilteredToken, metrics *models.OffChainMetrics) {
+	mentions := 0
+	for _, count := range metrics.SocialMentions {
+		mentions += count
+	}
+	
+	samples := o.series.add(seriesKey(token), sample{
+		at:       metrics.EvaluatedAt,
+		volume:   metrics.Volume24hDEX + metrics.Volume24hCEX,
+		trades:   float64(metrics.TradeCount24h),
+		buyers:   float64(metrics.UniqueBuyers),
+		mentions: float64(mentions),
+	})
+	
+	metrics.VelocityWindows = metrics.VelocityWindows[:0]
+	for _, window := range []time.Duration{
+		o.config.ObservationWindow5m,
+		o.config.ObservationWindow15m,
+		o.config.ObservationWindow1h,
+	} {
+		if window <= 0 {
+			continue
+		}
+		metrics.VelocityWindows = append(metrics.VelocityWindows, computeWindow(samples, metrics.EvaluatedAt, window))
+	}
 }
 
-// queryRedditMentions queries Reddit for mentions
-func (o *OffChainDataAgent) queryRedditMentions(ctx context.Context, token models.PreFilteredToken) int {
-	// TODO: Implement actual Reddit API query
-	return 0
+// determineVelocity determines the velocity trend from the shortest window
+// with enough samples, falling back to a single-snapshot heuristic for tokens
+// seen for the first time
+func (o *OffChainDataAgent) determineVelocity(metrics *models.OffChainMetrics) string {
+	var best *models.VelocityWindow
+	for i := range metrics.VelocityWindows {
+		window := &metrics.VelocityWindows[i]
+		if window.Samples >= 3 {
+			return classifyVelocity(*window)
+		}
+		if window.Samples >= 2 {
+			best = window
+		}
+	}
+	if best != nil {
+		return classifyVelocity(*best)
+	}
+	
+	return o.snapshotVelocity(metrics)
 }
 
-// determineVelocity determines the velocity trend
-func (o *OffChainDataAgent) determineVelocity(metrics *models.OffChainMetrics) string {
+// snapshotVelocity classifies a single observation against the volume thresholds
+func (o *OffChainDataAgent) snapshotVelocity(metrics *models.OffChainMetrics) string {
 	// Simple heuristic based on volume and social activity
 	totalActivity := metrics.Volume24hDEX + metrics.Volume24hCEX
 	
@@ -155,3 +348,8 @@ func (o *OffChainDataAgent) determineVelocity(metrics *models.OffChainMetrics) s
 	
 	return "falling"
 }
+
+// poolDepth is the USD value of a pool's quote reserve
+func poolDepth(pool *models.PoolState) float64 {
+	return pool.ReserveQuote * pool.QuotePriceUSD
+}
@@ -0,0 +1,437 @@
+package offchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/clients/dex"
+	"github.com/mumugogoing/meme_bot/pkg/clients/okx"
+	"github.com/mumugogoing/meme_bot/pkg/clients/telegram"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+type fakeProvider struct {
+	name   string
+	volume *VolumeData
+	price  *PriceData
+	social *SocialData
+	err    error
+	delay  time.Duration
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) wait(ctx context.Context) error {
+	if f.delay == 0 {
+		return f.err
+	}
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeProvider) FetchVolume(ctx context.Context, token models.TokenFound) (*VolumeData, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.volume, nil
+}
+
+func (f *fakeProvider) FetchPrice(ctx context.Context, token models.TokenFound) (*PriceData, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.price, nil
+}
+
+func (f *fakeProvider) FetchSocial(ctx context.Context, token models.TokenFound) (*SocialData, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.social, nil
+}
+
+func testToken() models.PreFilteredToken {
+	return models.PreFilteredToken{
+		Token: models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc"},
+	}
+}
+
+func TestGatherMergesProviders(t *testing.T) {
+	cfg := &config.Config{MinVolumeDEX: 10000, ProviderTimeout: time.Second}
+
+	set := &ProviderSet{}
+	set.Add(&fakeProvider{
+		name:   "dex",
+		volume: &VolumeData{Venue: VenueDEX, Volume24h: 25000, MarketCap: 1e6},
+		price:  &PriceData{Venue: VenueDEX, PriceUSD: 0.01},
+	})
+	set.Add(&fakeProvider{
+		name:   "cex",
+		volume: &VolumeData{Venue: VenueCEX, Volume24h: 5000},
+		price:  &PriceData{Venue: VenueCEX, PriceUSD: 0.011},
+	})
+	set.Add(&fakeProvider{
+		name:   "social",
+		social: &SocialData{Mentions: map[string]int{"telegram": 42}},
+	})
+
+	agent := NewOffChainDataAgentWithProviders(cfg, set)
+	metrics, err := agent.Gather(context.Background(), testToken())
+	if err != nil {
+		t.Fatalf("Gather returned error: %v", err)
+	}
+
+	if metrics.Volume24hDEX != 25000 || metrics.Volume24hCEX != 5000 {
+		t.Errorf("unexpected volumes: dex=%f cex=%f", metrics.Volume24hDEX, metrics.Volume24hCEX)
+	}
+	if metrics.PriceOnDEX != 0.01 || metrics.PriceOnCEX != 0.011 {
+		t.Errorf("unexpected prices: dex=%f cex=%f", metrics.PriceOnDEX, metrics.PriceOnCEX)
+	}
+	if metrics.SocialMentions["telegram"] != 42 {
+		t.Errorf("expected 42 telegram mentions, got %d", metrics.SocialMentions["telegram"])
+	}
+	if len(metrics.ProviderErrors) != 0 {
+		t.Errorf("expected no provider errors, got %v", metrics.ProviderErrors)
+	}
+	if len(metrics.Sources) != 3 {
+		t.Errorf("expected 3 sources, got %v", metrics.Sources)
+	}
+}
+
+func TestGatherDegradesOnPartialFailure(t *testing.T) {
+	cfg := &config.Config{MinVolumeDEX: 10000, ProviderTimeout: 50 * time.Millisecond}
+
+	set := &ProviderSet{}
+	set.Add(&fakeProvider{
+		name:   "good",
+		volume: &VolumeData{Venue: VenueDEX, Volume24h: 12000},
+	})
+	set.Add(&fakeProvider{name: "broken", err: errors.New("boom")})
+	set.Add(&fakeProvider{
+		name:   "slow",
+		volume: &VolumeData{Venue: VenueDEX, Volume24h: 99999},
+		delay:  time.Second,
+	})
+
+	agent := NewOffChainDataAgentWithProviders(cfg, set)
+
+	start := time.Now()
+	metrics, err := agent.Gather(context.Background(), testToken())
+	if err != nil {
+		t.Fatalf("partial failure should not fail the stage: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("slow provider was not bounded by the timeout (took %v)", elapsed)
+	}
+
+	if metrics.Volume24hDEX != 12000 {
+		t.Errorf("expected volume from the healthy provider, got %f", metrics.Volume24hDEX)
+	}
+	if _, ok := metrics.ProviderErrors["broken"]; !ok {
+		t.Errorf("expected broken provider to be recorded, got %v", metrics.ProviderErrors)
+	}
+	if _, ok := metrics.ProviderErrors["slow"]; !ok {
+		t.Errorf("expected slow provider to be recorded, got %v", metrics.ProviderErrors)
+	}
+}
+
+func TestRegistryBuild(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("fake", func(cfg *config.Config) (interface{}, error) {
+		return &fakeProvider{name: "fake"}, nil
+	})
+	registry.Register("broken", func(cfg *config.Config) (interface{}, error) {
+		return nil, errors.New("missing credentials")
+	})
+
+	set, errs := registry.Build(&config.Config{}, []string{"fake", "broken", "unknown"})
+	if len(set.Volume) != 1 || len(set.Price) != 1 || len(set.Social) != 1 {
+		t.Errorf("expected fake provider in every slot, got %+v", set)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected 2 build errors, got %v", errs)
+	}
+}
+
+func TestVelocityFromTimeSeries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	build := func(volume func(minute int) float64) []sample {
+		var samples []sample
+		for minute := 0; minute <= 15; minute++ {
+			samples = append(samples, sample{
+				at:     now.Add(time.Duration(minute-15) * time.Minute),
+				volume: volume(minute),
+				trades: 100,
+			})
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name   string
+		volume func(minute int) float64
+		want   string
+	}{
+		{"accelerating", func(m int) float64 { return 10000 + 50*float64(m*m) }, "rising"},
+		{"flat", func(m int) float64 { return 10000 }, "stable"},
+		{"draining", func(m int) float64 { return 20000 - 400*float64(m) }, "falling"},
+	}
+
+	for _, tt := range tests {
+		window := computeWindow(build(tt.volume), now, 15*time.Minute)
+		if window.Samples != 16 {
+			t.Errorf("%s: expected 16 samples, got %d", tt.name, window.Samples)
+		}
+		if got := classifyVelocity(window); got != tt.want {
+			t.Errorf("%s: velocity = %s, want %s (window %+v)", tt.name, got, tt.want, window)
+		}
+	}
+
+	accelerating := computeWindow(build(tests[0].volume), now, 15*time.Minute)
+	if accelerating.VolumeSlope <= 0 || accelerating.VolumeAccel <= 0 {
+		t.Errorf("expected positive slope and acceleration, got %+v", accelerating)
+	}
+	if accelerating.TradesSlope != 0 {
+		t.Errorf("constant trade count should have zero slope, got %f", accelerating.TradesSlope)
+	}
+}
+
+func TestSeriesStoreRetention(t *testing.T) {
+	store := newSeriesStore(time.Hour)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store.add("base:0xold", sample{at: start})
+	store.add("base:0xabc", sample{at: start})
+	samples := store.add("base:0xabc", sample{at: start.Add(90 * time.Minute)})
+
+	if len(samples) != 1 {
+		t.Errorf("expected samples older than retention to be dropped, got %d", len(samples))
+	}
+	if _, exists := store.series["base:0xold"]; exists {
+		t.Error("expected stale token series to be evicted")
+	}
+}
+
+type fakeLinks []dex.Social
+
+func (f fakeLinks) GetToken(ctx context.Context, chain models.Chain, tokenAddress string) (*dex.TokenData, error) {
+	return &dex.TokenData{Socials: f}, nil
+}
+
+func TestTelegramProviderTracksGrowthAndMessages(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var clock, members, ready int64
+	atomic.StoreInt64(&clock, start.Unix())
+	atomic.StoreInt64(&members, 1000)
+
+	// Local stub of the Bot API: @pepe_portal exists, @pepenews does not
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		chatID := r.PostForm.Get("chat_id")
+
+		switch {
+		case chatID != "" && chatID != "@pepe_portal":
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+		case method == "getChat":
+			fmt.Fprint(w, `{"ok":true,"result":{"id":-100,"type":"supergroup","username":"pepe_portal"}}`)
+		case method == "getChatMemberCount":
+			fmt.Fprintf(w, `{"ok":true,"result":%d}`, atomic.LoadInt64(&members))
+		case method == "getUpdates" && r.PostForm.Get("offset") == "0" && atomic.LoadInt64(&ready) == 1:
+			date := start.Add(time.Minute).Unix()
+			fmt.Fprintf(w, `{"ok":true,"result":[
+				{"update_id":1,"message":{"message_id":1,"date":%d,"chat":{"id":-100,"type":"supergroup"}}},
+				{"update_id":2,"message":{"message_id":2,"date":%d,"chat":{"id":-100,"type":"supergroup"}}},
+				{"update_id":3,"message":{"message_id":3,"date":%d,"chat":{"id":-555,"type":"group"}}},
+				{"update_id":4,"message":{"message_id":4,"date":%d,"chat":{"id":-100,"type":"supergroup"}}}
+			]}`, date, date, date, date)
+		case method == "getUpdates":
+			time.Sleep(20 * time.Millisecond)
+			fmt.Fprint(w, `{"ok":true,"result":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	links := fakeLinks{
+		{Type: "telegram", URL: "https://t.me/pepe_portal"},
+		{Type: "telegram", URL: "https://t.me/joinchat/AAAAAE"},
+		{Type: "twitter", URL: "https://twitter.com/pepe"},
+	}
+	provider := newTelegramProvider(telegram.NewClient(server.URL, "123:TEST"), links, time.Hour)
+	provider.now = func() time.Time { return time.Unix(atomic.LoadInt64(&clock), 0) }
+
+	token := models.TokenFound{
+		Chain:        models.ChainBase,
+		TokenAddress: "0xpepe",
+		Metadata: map[string]string{
+			"telegram":    "@PepeNews",
+			"description": "Join us at t.me/Pepe_Portal or https://t.me/+AbCdEfGh",
+		},
+	}
+	handles := provider.telegramHandles(context.Background(), token)
+	sort.Strings(handles)
+	if strings.Join(handles, ",") != "pepe_portal,pepenews" {
+		t.Fatalf("unexpected handles %v", handles)
+	}
+
+	if _, err := provider.FetchSocial(context.Background(), token); err != nil {
+		t.Fatalf("FetchSocial: %v", err)
+	}
+	atomic.StoreInt64(&ready, 1)
+
+	// Half an hour later the chat has grown by 100 members
+	atomic.StoreInt64(&clock, start.Add(30*time.Minute).Unix())
+	atomic.StoreInt64(&members, 1100)
+
+	deadline := time.Now().Add(2 * time.Second)
+	var data *SocialData
+	for {
+		var err error
+		if data, err = provider.FetchSocial(context.Background(), token); err != nil {
+			t.Fatalf("FetchSocial: %v", err)
+		}
+		if data.Growth["telegram"].MessagesPerHour > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	growth := data.Growth["telegram"]
+	if len(growth.Channels) != 1 || growth.Channels[0] != "@pepe_portal" {
+		t.Errorf("expected only the resolvable chat, got %v", growth.Channels)
+	}
+	if growth.Members != 1100 || growth.MemberGrowthPerHour != 200 {
+		t.Errorf("expected 1100 members growing 200/h, got %+v", growth)
+	}
+	// Three messages from the tracked chat over the 30 minutes it has been tracked
+	if growth.MessagesPerHour != 6 {
+		t.Errorf("expected 6 messages per hour, got %f", growth.MessagesPerHour)
+	}
+	if data.Mentions["telegram"] != 206 {
+		t.Errorf("expected 206 hourly telegram activity, got %d", data.Mentions["telegram"])
+	}
+
+	// Close must stop the long-poll goroutine rather than leave it running
+	set := &ProviderSet{}
+	set.Add(provider)
+	closed := make(chan error, 1)
+	go func() { closed <- set.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not stop the update poller")
+	}
+}
+
+func TestSamplerBuildsSeriesBetweenGathers(t *testing.T) {
+	cfg := &config.Config{
+		MinVolumeDEX:         10000,
+		ProviderTimeout:      time.Second,
+		ObservationWindow5m:  time.Minute,
+		ObservationWindow15m: 3 * time.Minute,
+		ObservationWindow1h:  time.Hour,
+	}
+	set := &ProviderSet{}
+	set.Add(&fakeProvider{name: "dex", volume: &VolumeData{Venue: VenueDEX, Volume24h: 25000}})
+	agent := NewOffChainDataAgentWithProviders(cfg, set)
+
+	if got := agent.SampleInterval(); got != 12*time.Second {
+		t.Errorf("expected the 1m window to be sampled every 12s, got %v", got)
+	}
+
+	if _, err := agent.Gather(context.Background(), testToken()); err != nil {
+		t.Fatal(err)
+	}
+	agent.Sample(context.Background())
+	agent.Sample(context.Background())
+
+	metrics, err := agent.Gather(context.Background(), testToken())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if window := metrics.VelocityWindows[0]; window.Samples != 4 {
+		t.Errorf("expected background samples in the window, got %d", window.Samples)
+	}
+	if metrics.Velocity != "stable" {
+		t.Errorf("flat volume sampled over time should be stable, got %s", metrics.Velocity)
+	}
+
+	// Tokens not gathered within the longest window stop being sampled
+	if tokens := agent.trackedTokens(time.Now().Add(2 * time.Hour)); len(tokens) != 0 {
+		t.Errorf("expected stale tokens to be forgotten, got %d", len(tokens))
+	}
+}
+
+func TestOKXProviderVerifiesContractAndSharesTicker(t *testing.T) {
+	const brett = "0x532f27101965dd16442E59d40670FaF5eBB142E4"
+	var tickerHits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/asset/currencies", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":"0","msg":"","data":[
+			{"ccy":"BRETT","chain":"BRETT-Base","ctAddr":"0x532f27101965dd16442e59d40670faf5ebb142e4"},
+			{"ccy":"PEPE","chain":"PEPE-ERC20","ctAddr":"0x6982508145454ce325ddbe47a25d4ec3d2311933"}
+		]}`)
+	})
+	mux.HandleFunc("/api/v5/public/instruments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":"0","msg":"","data":[
+			{"instId":"BRETT-USDT","baseCcy":"BRETT","quoteCcy":"USDT","state":"live"},
+			{"instId":"PEPE-USDT","baseCcy":"PEPE","quoteCcy":"USDT","state":"live"}
+		]}`)
+	})
+	mux.HandleFunc("/api/v5/market/ticker", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tickerHits, 1)
+		fmt.Fprintf(w, `{"code":"0","msg":"","data":[{"instId":%q,"last":"0.1","volCcy24h":"2500000","ts":"1700000000000"}]}`,
+			r.URL.Query().Get("instId"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := okx.NewClient(server.URL, okx.Credentials{APIKey: "key", Secret: "secret", Passphrase: "pass"})
+	provider := newOKXProviderWithClient(client)
+
+	token := models.TokenFound{
+		Chain:        models.ChainBase,
+		TokenAddress: brett,
+		Metadata:     map[string]string{models.MetadataSymbol: "BRETT"},
+	}
+	volume, err := provider.FetchVolume(context.Background(), token)
+	if err != nil || volume == nil || volume.Market != "okx:BRETT-USDT" || volume.Volume24h != 2500000 {
+		t.Fatalf("unexpected volume %+v (%v)", volume, err)
+	}
+	price, err := provider.FetchPrice(context.Background(), token)
+	if err != nil || price == nil || price.PriceUSD != 0.1 {
+		t.Fatalf("unexpected price %+v (%v)", price, err)
+	}
+	if n := atomic.LoadInt32(&tickerHits); n != 1 {
+		t.Errorf("expected volume and price to share one ticker request, got %d", n)
+	}
+
+	// A Base token reusing the PEPE symbol is not the listed Ethereum PEPE
+	impostor := models.TokenFound{
+		Chain:        models.ChainBase,
+		TokenAddress: "0x00000000000000000000000000000000000000aa",
+		Metadata:     map[string]string{models.MetadataSymbol: "PEPE"},
+	}
+	if volume, err := provider.FetchVolume(context.Background(), impostor); err != nil || volume != nil {
+		t.Errorf("expected no data for a symbol clash, got %+v (%v)", volume, err)
+	}
+}
@@ -0,0 +1,185 @@
+package offchain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/clients/okx"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// okxTickerTTL is how long a token's ticker is reused, long enough that the
+// volume and price lookups of one Gather share a single request
+const okxTickerTTL = 10 * time.Second
+
+// okxNetworks maps chains to the network names OKX uses in currency data
+var okxNetworks = map[models.Chain]string{
+	models.ChainBase:   "Base",
+	models.ChainSolana: "Solana",
+}
+
+// okxProvider reports CEX listing, price and volume from OKX spot markets
+type okxProvider struct {
+	client *okx.Client
+	now    func() time.Time
+
+	tickers map[string]*okxTickerCall
+	mu      sync.Mutex
+}
+
+// okxTickerCall is a ticker lookup shared by concurrent callers
+type okxTickerCall struct {
+	done   chan struct{}
+	ticker *okx.Ticker
+	err    error
+	at     time.Time
+}
+
+func newOKXProvider(cfg *config.Config) (interface{}, error) {
+	credentials := okx.Credentials{
+		APIKey:     cfg.OKXAPIKey,
+		Secret:     cfg.OKXAPISecret,
+		Passphrase: cfg.OKXAPIPassphrase,
+	}
+	// Listings are matched by contract address, which only the signed
+	// currencies endpoint reports
+	if !credentials.Valid() {
+		return nil, fmt.Errorf("OKX_API_KEY, OKX_API_SECRET and OKX_API_PASSPHRASE not set")
+	}
+	return newOKXProviderWithClient(okx.NewClient("", credentials)), nil
+}
+
+func newOKXProviderWithClient(client *okx.Client) *okxProvider {
+	return &okxProvider{
+		client:  client,
+		now:     time.Now,
+		tickers: make(map[string]*okxTickerCall),
+	}
+}
+
+// Name returns the provider name
+func (p *okxProvider) Name() string {
+	return "okx"
+}
+
+// ticker returns the token's spot ticker, shared between the lookups of one
+// Gather. Tokens without a known symbol, whose symbol OKX lists for a
+// different contract, or without a USD stablecoin market yield no data
+// rather than an error.
+func (p *okxProvider) ticker(ctx context.Context, token models.TokenFound) (*okx.Ticker, error) {
+	key := string(token.Chain) + ":" + token.TokenAddress
+
+	p.mu.Lock()
+	call, exists := p.tickers[key]
+	if exists && !call.at.IsZero() && p.now().Sub(call.at) >= okxTickerTTL {
+		exists = false
+	}
+	if !exists {
+		call = &okxTickerCall{done: make(chan struct{})}
+		p.tickers[key] = call
+		for k, c := range p.tickers {
+			if !c.at.IsZero() && p.now().Sub(c.at) >= okxTickerTTL {
+				delete(p.tickers, k)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if !exists {
+		ticker, err := p.lookup(ctx, token)
+		p.mu.Lock()
+		call.ticker, call.err, call.at = ticker, err, p.now()
+		if err != nil {
+			// Failures are not cached; only the callers already waiting share them
+			delete(p.tickers, key)
+		}
+		p.mu.Unlock()
+		close(call.done)
+	}
+
+	select {
+	case <-call.done:
+		return call.ticker, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lookup finds the token's OKX market and fetches its ticker
+func (p *okxProvider) lookup(ctx context.Context, token models.TokenFound) (*okx.Ticker, error) {
+	symbol := strings.TrimSpace(token.Metadata[models.MetadataSymbol])
+	if symbol == "" {
+		return nil, nil
+	}
+
+	verified, err := p.sameContract(ctx, symbol, token)
+	if err != nil || !verified {
+		return nil, err
+	}
+
+	inst, listed, err := p.client.Listing(ctx, symbol)
+	if err != nil || !listed {
+		return nil, err
+	}
+
+	return p.client.Ticker(ctx, inst.InstID)
+}
+
+// sameContract reports whether OKX's currency for symbol is the token's
+// contract on the token's chain; symbols alone are routinely reused
+func (p *okxProvider) sameContract(ctx context.Context, symbol string, token models.TokenFound) (bool, error) {
+	network, known := okxNetworks[token.Chain]
+	if !known {
+		return false, nil
+	}
+
+	currencies, err := p.client.Currencies(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ccy := range currencies[strings.ToUpper(symbol)] {
+		if !strings.EqualFold(ccy.Network(), network) {
+			continue
+		}
+		// EVM addresses are case-insensitive; Solana's base58 is not
+		if token.Chain == models.ChainSolana {
+			if ccy.CtAddr == token.TokenAddress {
+				return true, nil
+			}
+		} else if strings.EqualFold(ccy.CtAddr, token.TokenAddress) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FetchVolume returns the 24h quote volume on OKX
+func (p *okxProvider) FetchVolume(ctx context.Context, token models.TokenFound) (*VolumeData, error) {
+	ticker, err := p.ticker(ctx, token)
+	if err != nil || ticker == nil {
+		return nil, err
+	}
+	return &VolumeData{
+		Venue:     VenueCEX,
+		Market:    "okx:" + ticker.InstID,
+		Volume24h: ticker.Volume24hQuote,
+	}, nil
+}
+
+// FetchPrice returns the last traded price on OKX. Only USDT and USDC
+// markets are used, so the quote price is taken as the USD price.
+func (p *okxProvider) FetchPrice(ctx context.Context, token models.TokenFound) (*PriceData, error) {
+	ticker, err := p.ticker(ctx, token)
+	if err != nil || ticker == nil {
+		return nil, err
+	}
+	return &PriceData{
+		Venue:    VenueCEX,
+		PriceUSD: ticker.Last,
+	}, nil
+}
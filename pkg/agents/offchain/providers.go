@@ -0,0 +1,254 @@
+package offchain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// Venue identifies where market data was observed
+type Venue string
+
+const (
+	VenueDEX Venue = "dex"
+	VenueCEX Venue = "cex"
+)
+
+// VolumeData is a provider's view of a token's trading activity
+type VolumeData struct {
+	Venue        Venue
+	Market       string // exchange market the token is listed on, e.g. "okx:PEPE-USDT"
+	Volume24h    float64
+	Trades24h    int
+	MarketCap    float64
+	LiquidityUSD float64
+	Pool         *models.PoolState // deepest pool, when reserves are known
+}
+
+// PriceData is a provider's view of a token's price
+type PriceData struct {
+	Venue    Venue
+	PriceUSD float64
+}
+
+// SocialData holds social signals reported by a provider, keyed by platform
+type SocialData struct {
+	Mentions map[string]int
+	Growth   map[string]models.SocialGrowth
+}
+
+// VolumeProvider reports trading volume for a token
+type VolumeProvider interface {
+	Name() string
+	FetchVolume(ctx context.Context, token models.TokenFound) (*VolumeData, error)
+}
+
+// PriceProvider reports the current price of a token
+type PriceProvider interface {
+	Name() string
+	FetchPrice(ctx context.Context, token models.TokenFound) (*PriceData, error)
+}
+
+// SocialProvider reports social media activity for a token
+type SocialProvider interface {
+	Name() string
+	FetchSocial(ctx context.Context, token models.TokenFound) (*SocialData, error)
+}
+
+// ProviderFactory builds a provider from configuration. The returned value
+// may implement any combination of VolumeProvider, PriceProvider and
+// SocialProvider.
+type ProviderFactory func(cfg *config.Config) (interface{}, error)
+
+// ProviderSet holds the providers enabled for an agent
+type ProviderSet struct {
+	Volume []VolumeProvider
+	Price  []PriceProvider
+	Social []SocialProvider
+}
+
+// Add registers a provider under every interface it implements
+func (s *ProviderSet) Add(provider interface{}) bool {
+	added := false
+	if p, ok := provider.(VolumeProvider); ok {
+		s.Volume = append(s.Volume, p)
+		added = true
+	}
+	if p, ok := provider.(PriceProvider); ok {
+		s.Price = append(s.Price, p)
+		added = true
+	}
+	if p, ok := provider.(SocialProvider); ok {
+		s.Social = append(s.Social, p)
+		added = true
+	}
+	return added
+}
+
+// Close releases providers that hold background resources. A provider
+// registered under several interfaces is closed once.
+func (s *ProviderSet) Close() error {
+	closed := make(map[interface{}]bool)
+	var firstErr error
+	closeOne := func(provider interface{}) {
+		closer, ok := provider.(io.Closer)
+		if !ok || closed[provider] {
+			return
+		}
+		closed[provider] = true
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, p := range s.Volume {
+		closeOne(p)
+	}
+	for _, p := range s.Price {
+		closeOne(p)
+	}
+	for _, p := range s.Social {
+		closeOne(p)
+	}
+	return firstErr
+}
+
+// Registry maps provider names to factories
+type Registry struct {
+	factories map[string]ProviderFactory
+	mu        sync.RWMutex
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]ProviderFactory),
+	}
+}
+
+// DefaultRegistry returns a registry with all built-in providers
+func DefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register("dexscreener", newDexScreenerProvider)
+	registry.Register("geckoterminal", newGeckoTerminalProvider)
+	registry.Register("okx", newOKXProvider)
+	registry.Register("telegram", newTelegramProviderFromConfig)
+	return registry
+}
+
+// Register adds a provider factory under the given name
+func (r *Registry) Register(name string, factory ProviderFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(name)] = factory
+}
+
+// Names returns the registered provider names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build instantiates the named providers. An empty list enables every
+// registered provider. Providers that fail to build are skipped and reported
+// in the returned error map so that one misconfigured source does not disable
+// the others.
+func (r *Registry) Build(cfg *config.Config, names []string) (*ProviderSet, map[string]error) {
+	if len(names) == 0 {
+		names = r.Names()
+	}
+
+	set := &ProviderSet{}
+	errs := make(map[string]error)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		factory, exists := r.factories[name]
+		if !exists {
+			errs[name] = fmt.Errorf("unknown provider")
+			continue
+		}
+
+		provider, err := factory(cfg)
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+
+		if !set.Add(provider) {
+			errs[name] = fmt.Errorf("provider implements no data interface")
+		}
+	}
+
+	return set, errs
+}
+
+// providerResult is the outcome of a single provider call
+type providerResult[R any] struct {
+	provider string
+	value    R
+	err      error
+}
+
+// fanOut calls every provider in parallel, each bounded by timeout, and
+// returns the results in provider order
+func fanOut[P interface{ Name() string }, R any](
+	ctx context.Context,
+	timeout time.Duration,
+	providers []P,
+	call func(context.Context, P) (R, error),
+) []providerResult[R] {
+	results := make([]providerResult[R], len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider P) {
+			defer wg.Done()
+
+			callCtx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				callCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			// Providers that ignore ctx must not hold up the whole stage
+			done := make(chan providerResult[R], 1)
+			go func() {
+				value, err := call(callCtx, provider)
+				done <- providerResult[R]{provider: provider.Name(), value: value, err: err}
+			}()
+
+			select {
+			case result := <-done:
+				results[i] = result
+			case <-callCtx.Done():
+				results[i] = providerResult[R]{provider: provider.Name(), err: callCtx.Err()}
+			}
+		}(i, provider)
+	}
+	wg.Wait()
+
+	return results
+}
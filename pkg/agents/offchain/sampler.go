@@ -0,0 +1,106 @@
+package offchain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// samplesPerWindow is how many samples the sampler aims to take within the
+// shortest observation window, enough for a slope and an acceleration
+const samplesPerWindow = 5
+
+// trackedToken is a token the sampler observes until it ages out
+type trackedToken struct {
+	token      models.PreFilteredToken
+	gatheredAt time.Time
+}
+
+// seriesKey identifies a token's time series
+func seriesKey(token models.PreFilteredToken) string {
+	return string(token.Token.Chain) + ":" + token.Token.TokenAddress
+}
+
+// track registers a token for background sampling
+func (o *OffChainDataAgent) track(token models.PreFilteredToken) {
+	o.trackMu.Lock()
+	defer o.trackMu.Unlock()
+	o.tracked[seriesKey(token)] = trackedToken{token: token, gatheredAt: time.Now()}
+}
+
+// trackedTokens returns the tokens gathered within the longest observation
+// window and forgets the rest
+func (o *OffChainDataAgent) trackedTokens(now time.Time) []models.PreFilteredToken {
+	o.trackMu.Lock()
+	defer o.trackMu.Unlock()
+
+	cutoff := now.Add(-o.series.retention)
+	var tokens []models.PreFilteredToken
+	for key, tracked := range o.tracked {
+		if tracked.gatheredAt.Before(cutoff) {
+			delete(o.tracked, key)
+			continue
+		}
+		tokens = append(tokens, tracked.token)
+	}
+	return tokens
+}
+
+// SampleInterval is how often tracked tokens are sampled: often enough that
+// the shortest observation window holds samplesPerWindow samples
+func (o *OffChainDataAgent) SampleInterval() time.Duration {
+	var shortest time.Duration
+	for _, window := range []time.Duration{
+		o.config.ObservationWindow5m,
+		o.config.ObservationWindow15m,
+		o.config.ObservationWindow1h,
+	} {
+		if window > 0 && (shortest == 0 || window < shortest) {
+			shortest = window
+		}
+	}
+	return shortest / samplesPerWindow
+}
+
+// Sample takes one observation of every tracked token
+func (o *OffChainDataAgent) Sample(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, token := range o.trackedTokens(time.Now()) {
+		wg.Add(1)
+		go func(token models.PreFilteredToken) {
+			defer wg.Done()
+			o.collect(ctx, token)
+		}(token)
+	}
+	wg.Wait()
+}
+
+// StartSampler samples tracked tokens every SampleInterval until the
+// returned channel is closed
+func (o *OffChainDataAgent) StartSampler() chan struct{} {
+	stopChan := make(chan struct{})
+	interval := o.SampleInterval()
+	if interval <= 0 {
+		return stopChan
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				o.Sample(ctx)
+				cancel()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return stopChan
+}
@@ -0,0 +1,185 @@
+package offchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// velocityEpsilon is the relative slope (fraction of the window mean per
+// minute) below which a series is considered flat; 0.2%/min is ~12%/hour
+const velocityEpsilon = 0.002
+
+// sample is one observation of a token's activity
+type sample struct {
+	at       time.Time
+	volume   float64
+	trades   float64
+	buyers   float64
+	mentions float64
+}
+
+// seriesStore keeps a rolling time series of samples per token
+type seriesStore struct {
+	retention time.Duration
+	series    map[string][]sample
+	mu        sync.Mutex
+}
+
+func newSeriesStore(retention time.Duration) *seriesStore {
+	return &seriesStore{
+		retention: retention,
+		series:    make(map[string][]sample),
+	}
+}
+
+// add appends a sample and returns a copy of the token's retained series.
+// Samples older than the retention window are dropped, as are tokens that
+// have not been sampled within it.
+func (s *seriesStore) add(key string, smp sample) []sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := smp.at.Add(-s.retention)
+	for k, samples := range s.series {
+		if k != key && len(samples) > 0 && samples[len(samples)-1].at.Before(cutoff) {
+			delete(s.series, k)
+		}
+	}
+
+	// Background samples and Gather calls can finish out of order
+	samples := append(s.series[key], smp)
+	for i := len(samples) - 1; i > 0 && samples[i].at.Before(samples[i-1].at); i-- {
+		samples[i], samples[i-1] = samples[i-1], samples[i]
+	}
+	start := 0
+	for start < len(samples) && samples[start].at.Before(cutoff) {
+		start++
+	}
+	samples = samples[start:]
+	s.series[key] = samples
+
+	return append([]sample(nil), samples...)
+}
+
+// windowLabel renders an observation window as "5m", "15m", "1h"
+func windowLabel(window time.Duration) string {
+	if window >= time.Hour && window%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(window/time.Hour))
+	}
+	return fmt.Sprintf("%dm", int(window/time.Minute))
+}
+
+// computeWindow derives slopes and accelerations over the samples inside window.
+// Slopes are per minute; acceleration is the change in slope between the older
+// and newer half of the window, per minute.
+func computeWindow(samples []sample, now time.Time, window time.Duration) models.VelocityWindow {
+	result := models.VelocityWindow{Window: windowLabel(window)}
+
+	var inWindow []sample
+	for _, smp := range samples {
+		if !smp.at.Before(now.Add(-window)) {
+			inWindow = append(inWindow, smp)
+		}
+	}
+	result.Samples = len(inWindow)
+	if len(inWindow) < 2 {
+		return result
+	}
+
+	mid := now.Add(-window / 2)
+	var older, newer []sample
+	for _, smp := range inWindow {
+		if smp.at.Before(mid) {
+			older = append(older, smp)
+		} else {
+			newer = append(newer, smp)
+		}
+	}
+	halfMinutes := (window / 2).Minutes()
+
+	fields := []struct {
+		value        func(sample) float64
+		slope, accel *float64
+	}{
+		{func(s sample) float64 { return s.volume }, &result.VolumeSlope, &result.VolumeAccel},
+		{func(s sample) float64 { return s.trades }, &result.TradesSlope, &result.TradesAccel},
+		{func(s sample) float64 { return s.buyers }, &result.BuyersSlope, &result.BuyersAccel},
+		{func(s sample) float64 { return s.mentions }, &result.MentionsSlope, &result.MentionsAccel},
+	}
+
+	var relSlopes, relAccels []float64
+	for _, f := range fields {
+		slope, mean := regress(inWindow, f.value)
+		*f.slope = slope
+
+		if len(older) >= 2 && len(newer) >= 2 && halfMinutes > 0 {
+			olderSlope, _ := regress(older, f.value)
+			newerSlope, _ := regress(newer, f.value)
+			*f.accel = (newerSlope - olderSlope) / halfMinutes
+		}
+
+		// Metrics with no data (e.g. no social provider) do not vote
+		if mean > 0 {
+			relSlopes = append(relSlopes, slope/mean)
+			relAccels = append(relAccels, *f.accel/mean)
+		}
+	}
+
+	result.RelativeSlope = average(relSlopes)
+	result.RelativeAccel = average(relAccels)
+	return result
+}
+
+// regress fits value = a + b*minutes by least squares and returns the slope b and the mean value
+func regress(samples []sample, value func(sample) float64) (float64, float64) {
+	n := float64(len(samples))
+	if n == 0 {
+		return 0, 0
+	}
+
+	origin := samples[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, smp := range samples {
+		x := smp.at.Sub(origin).Minutes()
+		y := value(smp)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	mean := sumY / n
+	denominator := n*sumXX - sumX*sumX
+	if n < 2 || denominator == 0 {
+		return 0, mean
+	}
+	return (n*sumXY - sumX*sumY) / denominator, mean
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// classifyVelocity labels a window's trend. Rising needs a positive slope that
+// is not decelerating, unless the slope alone is strong.
+func classifyVelocity(window models.VelocityWindow) string {
+	switch {
+	case window.RelativeSlope > velocityEpsilon &&
+		(window.RelativeAccel >= 0 || window.RelativeSlope > 2*velocityEpsilon):
+		return "rising"
+	case window.RelativeSlope < -velocityEpsilon:
+		return "falling"
+	default:
+		return "stable"
+	}
+}
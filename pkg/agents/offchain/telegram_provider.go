@@ -0,0 +1,359 @@
+package offchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/clients/dex"
+	"github.com/mumugogoing/meme_bot/pkg/clients/telegram"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+const (
+	// memberSampleInterval limits getChatMemberCount calls per chat
+	memberSampleInterval = 30 * time.Second
+	// minMessageWindow keeps the message rate of newly tracked chats from being inflated
+	minMessageWindow = 10 * time.Minute
+	// updatesPollTimeout is the getUpdates long-poll timeout
+	updatesPollTimeout = 25 * time.Second
+)
+
+// telegramLinkPattern matches public t.me links; invite links (t.me/+..., t.me/joinchat/...)
+// cannot be resolved through the Bot API and are ignored
+var telegramLinkPattern = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?(?:t\.me|telegram\.me|telegram\.dog)/(?:s/)?([a-z0-9_]{5,32})\b`)
+
+// telegramHandlePattern matches a bare "@handle" or "handle" metadata value
+var telegramHandlePattern = regexp.MustCompile(`^@?([A-Za-z0-9_]{5,32})$`)
+
+// reservedTelegramPaths are t.me paths that are not chats
+var reservedTelegramPaths = map[string]bool{
+	"joinchat": true, "addstickers": true, "addemoji": true, "share": true,
+	"proxy": true, "socks": true, "setlanguage": true, "addtheme": true,
+}
+
+// socialLinkSource lists the project links published for a token
+type socialLinkSource interface {
+	GetToken(ctx context.Context, chain models.Chain, tokenAddress string) (*dex.TokenData, error)
+}
+
+// memberSample is a member count observed at a point in time
+type memberSample struct {
+	at      time.Time
+	members int
+}
+
+// chatActivity is the tracked history of one Telegram chat
+type chatActivity struct {
+	chatID    int64
+	members   []memberSample
+	messages  []time.Time
+	trackedAt time.Time
+	lastUsed  time.Time
+}
+
+// telegramProvider tracks member counts and message rate of a token's Telegram chats.
+// Member counts work for any public chat; messages are only seen in chats the bot has joined.
+type telegramProvider struct {
+	client    *telegram.Client
+	links     socialLinkSource
+	retention time.Duration
+	now       func() time.Time
+
+	chats   map[string]*chatActivity // keyed by lower-case username
+	byID    map[int64]string
+	mu      sync.Mutex
+	polling sync.Once
+	poller  sync.WaitGroup
+	ctx     context.Context
+	stop    context.CancelFunc
+}
+
+func newTelegramProviderFromConfig(cfg *config.Config) (interface{}, error) {
+	if cfg.TelegramBotToken == "" {
+		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN not set")
+	}
+	client := telegram.NewClient("", cfg.TelegramBotToken)
+	return newTelegramProvider(client, dex.NewDexScreenerClient("", cfg.MarketDataCacheTTL), cfg.ObservationWindow1h), nil
+}
+
+func newTelegramProvider(client *telegram.Client, links socialLinkSource, retention time.Duration) *telegramProvider {
+	if retention <= 0 {
+		retention = time.Hour
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &telegramProvider{
+		client:    client,
+		links:     links,
+		retention: retention,
+		now:       time.Now,
+		chats:     make(map[string]*chatActivity),
+		byID:      make(map[int64]string),
+		ctx:       ctx,
+		stop:      stop,
+	}
+}
+
+// Name returns the provider name
+func (p *telegramProvider) Name() string {
+	return "telegram"
+}
+
+// FetchSocial reports member count, member growth and message rate across the
+// token's Telegram chats. Tokens without a resolvable chat yield no data.
+func (p *telegramProvider) FetchSocial(ctx context.Context, token models.TokenFound) (*SocialData, error) {
+	p.polling.Do(func() {
+		p.poller.Add(1)
+		go p.pollUpdates()
+	})
+
+	handles := p.telegramHandles(ctx, token)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	growth := models.SocialGrowth{}
+	var lastErr error
+	for _, handle := range handles {
+		chat, err := p.observe(ctx, handle)
+		if err != nil {
+			var apiErr *telegram.APIError
+			if errors.As(err, &apiErr) && apiErr.NotFound() {
+				continue
+			}
+			lastErr = err
+			continue
+		}
+
+		members, perHour, messages := p.stats(chat)
+		growth.Channels = append(growth.Channels, "@"+handle)
+		growth.Members += members
+		growth.MemberGrowthPerHour += perHour
+		growth.MessagesPerHour += messages
+	}
+
+	if len(growth.Channels) == 0 {
+		return nil, lastErr
+	}
+
+	// Mentions count hourly activity: messages plus newly joined members
+	activity := growth.MessagesPerHour + math.Max(growth.MemberGrowthPerHour, 0)
+	return &SocialData{
+		Mentions: map[string]int{"telegram": int(math.Round(activity))},
+		Growth:   map[string]models.SocialGrowth{"telegram": growth},
+	}, nil
+}
+
+// telegramHandles collects chat usernames from token metadata and published socials
+func (p *telegramProvider) telegramHandles(ctx context.Context, token models.TokenFound) []string {
+	seen := make(map[string]bool)
+	var handles []string
+	add := func(handle string) {
+		handle = strings.ToLower(handle)
+		if reservedTelegramPaths[handle] || strings.HasSuffix(handle, "bot") || seen[handle] {
+			return
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+
+	for key, value := range token.Metadata {
+		if strings.EqualFold(key, "telegram") {
+			if m := telegramHandlePattern.FindStringSubmatch(strings.TrimSpace(value)); m != nil {
+				add(m[1])
+				continue
+			}
+		}
+		for _, m := range telegramLinkPattern.FindAllStringSubmatch(value, -1) {
+			add(m[1])
+		}
+	}
+
+	if p.links != nil {
+		if data, err := p.links.GetToken(ctx, token.Chain, token.TokenAddress); err == nil {
+			for _, social := range data.Socials {
+				for _, m := range telegramLinkPattern.FindAllStringSubmatch(social.URL, -1) {
+					add(m[1])
+				}
+			}
+		}
+	}
+
+	return handles
+}
+
+// observe starts tracking a chat if needed and samples its member count
+func (p *telegramProvider) observe(ctx context.Context, handle string) (*chatActivity, error) {
+	now := p.now()
+
+	p.mu.Lock()
+	p.evictStale(now)
+	chat, tracked := p.chats[handle]
+	p.mu.Unlock()
+
+	if !tracked {
+		info, err := p.client.GetChat(ctx, "@"+handle)
+		if err != nil {
+			return nil, err
+		}
+		chat = &chatActivity{chatID: info.ID, trackedAt: now}
+
+		p.mu.Lock()
+		if existing, ok := p.chats[handle]; ok {
+			chat = existing
+		} else {
+			p.chats[handle] = chat
+			p.byID[info.ID] = handle
+			log.Printf("OffChainDataAgent: Tracking Telegram chat @%s\n", handle)
+		}
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	chat.lastUsed = now
+	due := len(chat.members) == 0 || now.Sub(chat.members[len(chat.members)-1].at) >= memberSampleInterval
+	p.mu.Unlock()
+
+	if due {
+		count, err := p.client.GetChatMemberCount(ctx, "@"+handle)
+		if err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		chat.members = append(chat.members, memberSample{at: now, members: count})
+		chat.members = trimMemberSamples(chat.members, now.Add(-p.retention))
+		p.mu.Unlock()
+	}
+
+	return chat, nil
+}
+
+// stats returns the latest member count, member growth per hour over the
+// retention window and messages per hour over the last hour
+func (p *telegramProvider) stats(chat *chatActivity) (int, float64, float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if len(chat.members) == 0 {
+		return 0, 0, 0
+	}
+
+	first, last := chat.members[0], chat.members[len(chat.members)-1]
+	growth := 0.0
+	if elapsed := last.at.Sub(first.at); elapsed >= time.Minute {
+		growth = float64(last.members-first.members) / elapsed.Hours()
+	}
+
+	// Rate over the last hour, or since tracking began for newer chats
+	window := time.Hour
+	if since := now.Sub(chat.trackedAt); since < window {
+		window = since
+		if window < minMessageWindow {
+			window = minMessageWindow
+		}
+	}
+	cutoff := now.Add(-window)
+	recent := 0
+	for _, at := range chat.messages {
+		if at.After(cutoff) {
+			recent++
+		}
+	}
+
+	return last.members, growth, float64(recent) / window.Hours()
+}
+
+// Close stops the update poller and waits for it to exit
+func (p *telegramProvider) Close() error {
+	// Claim the Once so a concurrent FetchSocial cannot start a poller afterwards
+	p.polling.Do(func() {})
+	p.stop()
+	p.poller.Wait()
+	return nil
+}
+
+// pollUpdates long-polls getUpdates and records messages from tracked chats
+func (p *telegramProvider) pollUpdates() {
+	defer p.poller.Done()
+	var offset int64
+	for {
+		updates, err := p.client.GetUpdates(p.ctx, offset, updatesPollTimeout)
+		if p.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			delay := 5 * time.Second
+			var apiErr *telegram.APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+				delay = apiErr.RetryAfter
+			}
+			log.Printf("OffChainDataAgent: Telegram updates unavailable: %v\n", err)
+
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			if update.UpdateID >= offset {
+				offset = update.UpdateID + 1
+			}
+			if post := update.Post(); post != nil {
+				p.recordMessage(post)
+			}
+		}
+	}
+}
+
+// recordMessage counts a message against its chat if the chat is tracked
+func (p *telegramProvider) recordMessage(message *telegram.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handle, known := p.byID[message.Chat.ID]
+	if !known {
+		handle = strings.ToLower(message.Chat.Username)
+	}
+	chat, tracked := p.chats[handle]
+	if !tracked {
+		return
+	}
+
+	chat.messages = append(chat.messages, message.Time())
+
+	cutoff := p.now().Add(-time.Hour)
+	for len(chat.messages) > 0 && chat.messages[0].Before(cutoff) {
+		chat.messages = chat.messages[1:]
+	}
+}
+
+// evictStale stops tracking chats that no token has asked about for two retention windows; p.mu must be held
+func (p *telegramProvider) evictStale(now time.Time) {
+	for handle, chat := range p.chats {
+		if now.Sub(chat.lastUsed) > 2*p.retention {
+			delete(p.chats, handle)
+			delete(p.byID, chat.chatID)
+		}
+	}
+}
+
+// trimMemberSamples drops samples older than cutoff, keeping at least the latest one
+func trimMemberSamples(samples []memberSample, cutoff time.Time) []memberSample {
+	drop := 0
+	for drop < len(samples)-1 && samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	return samples[drop:]
+}
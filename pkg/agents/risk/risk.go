@@ -1,25 +1,38 @@
 package risk
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/mumugogoing/meme_bot/pkg/config"
 	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
 )
 
+// ErrNoOpenPosition is returned when closing a token that holds no open position
+var ErrNoOpenPosition = errors.New("no open position")
+
 // RiskManagerAgent manages risk controls and circuit breakers
 type RiskManagerAgent struct {
-	config  *config.Config
-	control *models.RiskControl
-	mu      sync.RWMutex
+	config    *config.Config
+	control   *models.RiskControl
+	open      []*models.Position
+	reserved  float64 // exposure held for trades in flight
+	state     storage.RiskStateRepository
+	positions storage.PositionRepository
+	now       func() time.Time
+	mu        sync.RWMutex
 }
 
 // NewRiskManagerAgent creates a new risk manager
 func NewRiskManagerAgent(cfg *config.Config) *RiskManagerAgent {
 	return &RiskManagerAgent{
 		config: cfg,
+		now:    time.Now,
 		control: &models.RiskControl{
 			SinglePositionPct: cfg.SinglePositionPct,
 			TotalExposurePct:  cfg.TotalExposurePct,
@@ -32,11 +45,112 @@ func NewRiskManagerAgent(cfg *config.Config) *RiskManagerAgent {
 	}
 }
 
+// SetClock replaces the wall clock, e.g. with a backtest's virtual clock, and
+// restarts the daily window from the clock's current time
+func (r *RiskManagerAgent) SetClock(now func() time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.now = now
+	r.control.LastResetTime = now()
+}
+
+// SetStore writes risk state and positions through to the given repositories
+// and restores exposure, daily loss, the circuit breaker and open positions
+// from the last run. Limits always come from the current config.
+func (r *RiskManagerAgent) SetStore(state storage.RiskStateRepository, positions storage.PositionRepository) {
+	ctx := context.Background()
+	saved, err := state.LoadRiskState(ctx)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("RiskManager: Failed to restore risk state: %v\n", err)
+	}
+	open, err := positions.OpenPositions(ctx)
+	if err != nil {
+		log.Printf("RiskManager: Failed to restore open positions: %v\n", err)
+	}
+	
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.state = state
+	r.positions = positions
+	r.open = open
+	if saved != nil {
+		r.control.CurrentExposure = saved.CurrentExposure
+		r.control.DailyLoss = saved.DailyLoss
+		r.control.TradingHalted = saved.TradingHalted
+		r.control.LastResetTime = saved.LastResetTime
+		log.Printf("RiskManager: Restored exposure %.2f USD, daily loss %.2f USD, halted=%v, %d open positions\n",
+			saved.CurrentExposure, saved.DailyLoss, saved.TradingHalted, len(open))
+	}
+}
+
+// persist writes the risk state through to the store; the caller holds the lock
+func (r *RiskManagerAgent) persist() {
+	if r.state == nil {
+		return
+	}
+	control := *r.control
+	if err := r.state.SaveRiskState(context.Background(), &control); err != nil {
+		log.Printf("RiskManager: Failed to save risk state: %v\n", err)
+	}
+}
+
+// savePosition writes a position through to the store; the caller holds the lock
+func (r *RiskManagerAgent) savePosition(position *models.Position) {
+	if r.positions == nil {
+		return
+	}
+	if err := r.positions.SavePosition(context.Background(), position); err != nil {
+		log.Printf("RiskManager: Failed to save position in %s: %v\n", position.TokenAddress, err)
+	}
+}
+
 // CanExecute checks if a trade can be executed based on risk controls
 func (r *RiskManagerAgent) CanExecute(decision *models.StrategyDecision) (bool, string) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	
+	return r.check(decision)
+}
+
+// Reserve checks a trade like CanExecute and, if it may go ahead, holds its
+// size against the total exposure limit until Unreserve, so trades executing
+// at the same time cannot overspend the limit between them
+func (r *RiskManagerAgent) Reserve(decision *models.StrategyDecision) (bool, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	ok, reason := r.check(decision)
+	if ok {
+		r.reserved += decision.SuggestedAmountUSD
+	}
+	return ok, reason
+}
+
+// Unreserve gives back exposure held by Reserve once the trade has failed or
+// its execution has been recorded
+func (r *RiskManagerAgent) Unreserve(amount float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.reserved -= amount
+	if r.reserved < 0 {
+		r.reserved = 0
+	}
+}
+
+// Hold reserves exposure for a trade that was already sent, such as one
+// still pending when the bot restarted, without checking the limits again
+func (r *RiskManagerAgent) Hold(amount float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.reserved += amount
+}
+
+// check applies the risk controls to a trade; the caller holds the lock
+func (r *RiskManagerAgent) check(decision *models.StrategyDecision) (bool, string) {
 	// Check if trading is halted
 	if r.control.TradingHalted {
 		return false, "trading_halted"
@@ -50,11 +164,11 @@ func (r *RiskManagerAgent) CanExecute(decision *models.StrategyDecision) (bool,
 		return false, "exceeds_single_position_limit"
 	}
 	
-	// Check total exposure limit
+	// Check total exposure limit, counting trades still in flight
 	maxTotalExposure := r.config.AccountBalance * r.config.TotalExposurePct
-	if r.control.CurrentExposure+decision.SuggestedAmountUSD > maxTotalExposure {
+	if r.control.CurrentExposure+r.reserved+decision.SuggestedAmountUSD > maxTotalExposure {
 		log.Printf("RiskManager: Trade rejected - exceeds total exposure limit (%.2f > %.2f)\n",
-			r.control.CurrentExposure+decision.SuggestedAmountUSD, maxTotalExposure)
+			r.control.CurrentExposure+r.reserved+decision.SuggestedAmountUSD, maxTotalExposure)
 		return false, "exceeds_total_exposure_limit"
 	}
 	
@@ -72,6 +186,19 @@ func (r *RiskManagerAgent) CanExecute(decision *models.StrategyDecision) (bool,
 	return true, ""
 }
 
+// RemainingExposure returns how much more exposure in USD the total exposure
+// limit allows, after open positions and trades in flight
+func (r *RiskManagerAgent) RemainingExposure() float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	remaining := r.config.AccountBalance*r.config.TotalExposurePct - r.control.CurrentExposure - r.reserved
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 // RecordExecution records a trade execution
 func (r *RiskManagerAgent) RecordExecution(result *models.ExecutionResult) {
 	r.mu.Lock()
@@ -81,14 +208,74 @@ func (r *RiskManagerAgent) RecordExecution(result *models.ExecutionResult) {
 		r.control.CurrentExposure += result.AmountUSD
 		log.Printf("RiskManager: Recorded execution - Current exposure: %.2f USD\n",
 			r.control.CurrentExposure)
+		
+		position := &models.Position{
+			TokenAddress: result.TokenAddress,
+			Chain:        result.Chain,
+			EntryTxHash:  result.TxHash,
+			SizeUSD:      result.AmountUSD,
+			OpenedAt:     result.Timestamp,
+		}
+		r.open = append(r.open, position)
+		r.savePosition(position)
+		r.persist()
 	}
 }
 
 // RecordProfit records profit/loss from a closed position
-func (r *RiskManagerAgent) RecordProfit(tokenAddress string, profitLoss float64) {
+func (r *RiskManagerAgent) RecordProfit(key models.CandidateKey, profitLoss float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.recordProfit(profitLoss)
+	
+	// The oldest open position in the token on that chain is the one closed
+	for i, position := range r.open {
+		if position.Chain == key.Chain && position.TokenAddress == key.TokenAddress {
+			r.closeAt(i, profitLoss)
+			break
+		}
+	}
+	r.persist()
+}
+
+// ClosePosition closes the oldest open position in a token with the realized
+// profit/loss and releases its exposure
+func (r *RiskManagerAgent) ClosePosition(key models.CandidateKey, profitLoss float64) (*models.Position, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	
+	for i, position := range r.open {
+		if position.Chain == key.Chain && position.TokenAddress == key.TokenAddress {
+			r.recordProfit(profitLoss)
+			r.closeAt(i, profitLoss)
+			r.control.CurrentExposure -= position.SizeUSD
+			if r.control.CurrentExposure < 0 {
+				r.control.CurrentExposure = 0
+			}
+			log.Printf("RiskManager: Closed position in %s - Current exposure: %.2f USD\n",
+				key, r.control.CurrentExposure)
+			r.persist()
+			closed := *position
+			return &closed, nil
+		}
+	}
+	return nil, fmt.Errorf("%w in %s", ErrNoOpenPosition, key)
+}
+
+// closeAt marks the i-th open position closed; the caller holds the lock
+func (r *RiskManagerAgent) closeAt(i int, profitLoss float64) {
+	position := r.open[i]
+	closedAt := r.now()
+	position.ClosedAt = &closedAt
+	position.PnLUSD = profitLoss
+	r.savePosition(position)
+	r.open = append(r.open[:i], r.open[i+1:]...)
+}
+
+// recordProfit adds a loss to the daily loss and trips the circuit breaker
+// at the limit; the caller holds the lock
+func (r *RiskManagerAgent) recordProfit(profitLoss float64) {
 	if profitLoss < 0 {
 		r.control.DailyLoss += -profitLoss
 		log.Printf("RiskManager: Recorded loss of %.2f - Daily loss: %.2f\n",
@@ -115,12 +302,14 @@ func (r *RiskManagerAgent) ReleaseExposure(amount float64) {
 	
 	log.Printf("RiskManager: Released exposure - Current exposure: %.2f USD\n",
 		r.control.CurrentExposure)
+	r.persist()
 }
 
 // haltTrading triggers the circuit breaker
 func (r *RiskManagerAgent) haltTrading() {
 	r.control.TradingHalted = true
 	log.Println("RiskManager: CIRCUIT BREAKER TRIGGERED - Trading halted!")
+	r.persist()
 }
 
 // ResumeTrading resumes trading (manual override)
@@ -130,6 +319,7 @@ func (r *RiskManagerAgent) ResumeTrading() {
 	
 	r.control.TradingHalted = false
 	log.Println("RiskManager: Trading resumed")
+	r.persist()
 }
 
 // ResetDaily resets daily counters (should be called at start of each day)
@@ -138,18 +328,20 @@ func (r *RiskManagerAgent) ResetDaily() {
 	defer r.mu.Unlock()
 	
 	r.control.DailyLoss = 0
-	r.control.LastResetTime = time.Now()
+	r.control.LastResetTime = r.now()
 	log.Println("RiskManager: Daily counters reset")
+	r.persist()
 }
 
 // CheckDailyReset checks if daily reset is needed
 func (r *RiskManagerAgent) CheckDailyReset() {
 	r.mu.RLock()
 	lastReset := r.control.LastResetTime
+	now := r.now()
 	r.mu.RUnlock()
 	
 	// Reset if it's a new day
-	if time.Since(lastReset) > 24*time.Hour {
+	if now.Sub(lastReset) > 24*time.Hour {
 		r.ResetDaily()
 	}
 }
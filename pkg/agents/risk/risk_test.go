@@ -0,0 +1,71 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func TestClosePositionReleasesExposure(t *testing.T) {
+	r := NewRiskManagerAgent(&config.Config{AccountBalance: 1000, TotalExposurePct: 0.5, DailyLossLimit: 100})
+	r.RecordExecution(&models.ExecutionResult{Chain: models.ChainBase, TokenAddress: "tokenA", Status: "confirmed", AmountUSD: 40, Timestamp: time.Now()})
+	r.RecordExecution(&models.ExecutionResult{Chain: models.ChainSolana, TokenAddress: "tokenA", Status: "confirmed", AmountUSD: 25, Timestamp: time.Now()})
+
+	position, err := r.ClosePosition(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "tokenA"}, -15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position.ClosedAt == nil || position.SizeUSD != 40 || position.PnLUSD != -15 {
+		t.Errorf("unexpected closed position: %+v", position)
+	}
+	status := r.GetStatus()
+	if status.CurrentExposure != 25 || status.DailyLoss != 15 {
+		t.Errorf("expected 25 USD exposure and a 15 USD loss, got %+v", status)
+	}
+	if _, err := r.ClosePosition(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "tokenA"}, 0); !errors.Is(err, ErrNoOpenPosition) {
+		t.Errorf("expected no open position left on base, got %v", err)
+	}
+}
+
+func TestRecordProfitClosesThePositionOnItsChain(t *testing.T) {
+	r := NewRiskManagerAgent(&config.Config{AccountBalance: 1000, TotalExposurePct: 0.5, DailyLossLimit: 100})
+	r.RecordExecution(&models.ExecutionResult{Chain: models.ChainBase, TokenAddress: "tokenA", Status: "confirmed", AmountUSD: 40, Timestamp: time.Now()})
+	r.RecordExecution(&models.ExecutionResult{Chain: models.ChainSolana, TokenAddress: "tokenA", Status: "confirmed", AmountUSD: 25, Timestamp: time.Now()})
+
+	r.RecordProfit(models.CandidateKey{Chain: models.ChainSolana, TokenAddress: "tokenA"}, 5)
+	if _, err := r.ClosePosition(models.CandidateKey{Chain: models.ChainSolana, TokenAddress: "tokenA"}, 0); !errors.Is(err, ErrNoOpenPosition) {
+		t.Errorf("expected the solana position to be closed, got %v", err)
+	}
+	if _, err := r.ClosePosition(models.CandidateKey{Chain: models.ChainBase, TokenAddress: "tokenA"}, 0); err != nil {
+		t.Errorf("expected the base position to stay open, got %v", err)
+	}
+}
+
+func TestReserveHoldsExposureForTradesInFlight(t *testing.T) {
+	r := NewRiskManagerAgent(&config.Config{AccountBalance: 1000, SinglePositionPct: 0.1, TotalExposurePct: 0.15, DailyLossLimit: 100})
+	decision := &models.StrategyDecision{SuggestedAmountUSD: 100}
+
+	if ok, reason := r.Reserve(decision); !ok {
+		t.Fatalf("first trade blocked: %s", reason)
+	}
+	if ok, _ := r.Reserve(decision); ok {
+		t.Error("a second trade in flight overspent the exposure limit")
+	}
+	if remaining := r.RemainingExposure(); remaining != 50 {
+		t.Errorf("expected 50 USD left beside the trade in flight, got %.2f", remaining)
+	}
+
+	r.Unreserve(100)
+	if ok, reason := r.Reserve(decision); !ok {
+		t.Errorf("trade blocked after the failed one gave its exposure back: %s", reason)
+	}
+
+	// A trade still pending from before a restart is held even past the limit
+	r.Hold(100)
+	if remaining := r.RemainingExposure(); remaining != 0 {
+		t.Errorf("expected no exposure left beside the held trade, got %.2f", remaining)
+	}
+}
@@ -0,0 +1,295 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/impact"
+	"github.com/mumugogoing/meme_bot/pkg/model"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// baseStrategy holds the configuration, the strategy weights and the decision
+// steps shared by all strategies
+type baseStrategy struct {
+	config      *config.Config
+	weights     *WeightsStore
+	calibration *model.Calibration
+	exposure    ExposureProvider
+}
+
+// ExposureProvider reports how much more exposure in USD the risk manager will accept
+type ExposureProvider interface {
+	RemainingExposure() float64
+}
+
+// SetExposureProvider sets the source of remaining exposure used to cap position sizes.
+// It must be called before the strategy is used.
+func (s *baseStrategy) SetExposureProvider(exposure ExposureProvider) {
+	s.exposure = exposure
+}
+
+// SetCalibration sets the calibration applied to win probabilities; nil disables it.
+// It must be called before the strategy is used.
+func (s *baseStrategy) SetCalibration(calibration *model.Calibration) {
+	s.calibration = calibration
+}
+
+// calibrate maps the scored win probability through the strategy's calibrator
+// and records the adjustment in the rationale
+func (s *baseStrategy) calibrate(decision *models.StrategyDecision, strategy string) {
+	if s.calibration == nil {
+		return
+	}
+	raw := decision.WinProbability
+	decision.RawWinProbability = raw
+	decision.CalibrationVersion = s.calibration.Version
+	decision.WinProbability = s.calibration.Apply(strategy, raw)
+	if decision.WinProbability != raw {
+		decision.Rationale = append(decision.Rationale, models.RationaleEntry{
+			Factor: "calibration", Value: raw, Delta: decision.WinProbability - raw,
+		})
+	}
+}
+
+// newDecision creates an empty decision for a token stamped with the weights version
+func (s *baseStrategy) newDecision(token models.PreFilteredToken, w *Weights) *models.StrategyDecision {
+	return &models.StrategyDecision{
+		TokenAddress:  token.Token.TokenAddress,
+		Chain:         token.Token.Chain,
+		EvaluatedAt:   time.Now(),
+		Rationale:     []models.RationaleEntry{},
+		ParamsVersion: w.Version,
+	}
+}
+
+// determineConfidence determines confidence level
+func (s *baseStrategy) determineConfidence(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	winProb float64,
+	w *Weights,
+) string {
+	// High confidence requires multiple factors
+	if winProb >= w.Confidence.HighWinProbability &&
+		safety.HoneypotScore < w.Confidence.HighMaxHoneypotScore &&
+		offchain.Volume24hDEX > s.config.MinVolumeDEX {
+		return "high"
+	}
+
+	if winProb >= w.Confidence.MediumWinProbability && safety.HoneypotScore < w.Confidence.MediumMaxHoneypotScore {
+		return "medium"
+	}
+
+	return "low"
+}
+
+// determineAction determines the recommended action
+func (s *baseStrategy) determineAction(decision *models.StrategyDecision) string {
+	// Check if meets threshold for listing/buying
+	if decision.WinProbability >= s.config.WinProbabilityThreshold &&
+		decision.Confidence != "low" {
+		if s.config.AutoExecute {
+			return "buy"
+		}
+		return "list"
+	}
+
+	if decision.WinProbability >= 0.60 {
+		return "monitor"
+	}
+
+	return "skip"
+}
+
+// sizePosition sets the position size from fractional Kelly, scaled by the
+// strategy's own multiplier and capped by the single position limit, pool depth
+// and the risk manager's remaining exposure. Price impact and taxes are
+// subtracted from ExpectedROI at the chosen size. Buy and list decisions that
+// end up below the minimum position are downgraded to monitor.
+func (s *baseStrategy) sizePosition(
+	decision *models.StrategyDecision,
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	scale float64,
+) {
+	gross := decision.ExpectedROI
+	s.limitSize(decision, offchain, scale)
+
+	// Costs grow with size and shrink the Kelly stake, so alternate a few
+	// times; sizes only decrease, and the final costs match the final size
+	for i := 0; i < 3; i++ {
+		costs := s.tradeCosts(decision.SuggestedAmountUSD, gross, safety, offchain)
+		decision.Costs = &costs
+		decision.ExpectedROI = impact.NetROI(gross, costs)
+		s.limitSize(decision, offchain, scale)
+	}
+	costs := s.tradeCosts(decision.SuggestedAmountUSD, gross, safety, offchain)
+	decision.Costs = &costs
+	decision.ExpectedROI = impact.NetROI(gross, costs)
+
+	if decision.SuggestedAmountUSD < s.config.MinPositionUSD {
+		decision.SuggestedAmountUSD = 0
+		if decision.Action == "buy" || decision.Action == "list" {
+			decision.Action = "monitor"
+		}
+	}
+}
+
+// limitSize sets SuggestedAmountUSD to the capped fractional Kelly stake
+func (s *baseStrategy) limitSize(decision *models.StrategyDecision, offchain *models.OffChainMetrics, scale float64) {
+	size := s.config.AccountBalance * s.config.KellyFraction * kellyFraction(decision) * scale
+	decision.SizedBy = "kelly"
+
+	limit := func(max float64, reason string) {
+		if max < size {
+			size, decision.SizedBy = math.Max(max, 0), reason
+		}
+	}
+	limit(s.config.AccountBalance*s.config.SinglePositionPct, "single_position")
+
+	// Keep the buy's price impact within the slippage budget
+	if offchain.Pool != nil {
+		if max, err := impact.MaxBuy(*offchain.Pool, s.config.MaxSlippage); err == nil {
+			limit(max, "liquidity")
+		}
+	} else if depth := offchain.LiquidityUSD / 2; depth > 0 && s.config.MaxSlippage > 0 && s.config.MaxSlippage < 1 {
+		// Without pool reserves, assume a constant-product pool holding half its liquidity in the quote asset
+		limit(depth*s.config.MaxSlippage/(1-s.config.MaxSlippage), "liquidity")
+	}
+	if s.exposure != nil {
+		limit(s.exposure.RemainingExposure(), "exposure")
+	}
+
+	// Round down so the size never exceeds the cap that produced it
+	decision.SuggestedAmountUSD = math.Floor(size*100) / 100
+}
+
+// tradeCosts estimates price impact and taxes for a position. Without pool
+// reserves only the tax is known; a pool too shallow for the size loses everything.
+func (s *baseStrategy) tradeCosts(
+	sizeUSD, grossROI float64,
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+) models.TradeCosts {
+	tax := safety.OwnerControls.TaxFee
+	costs := models.TradeCosts{SizeUSD: sizeUSD, Tax: tax, RoundTrip: 1 - (1-tax)*(1-tax)}
+	if offchain.Pool != nil && sizeUSD > 0 {
+		estimate, err := impact.Estimate(*offchain.Pool, sizeUSD, tax)
+		if err != nil {
+			estimate = models.TradeCosts{SizeUSD: sizeUSD, Tax: tax, EntryImpact: 1, ExitImpact: 1, RoundTrip: 1}
+		}
+		costs = estimate
+	}
+	costs.GrossROI = grossROI
+	return costs
+}
+
+// kellyFraction returns the Kelly-optimal share of the account for a decision,
+// taking the more conservative of the binary stop-loss/take-profit bet and the
+// continuous mean/variance estimate. It is 0 when there is no edge.
+func kellyFraction(decision *models.StrategyDecision) float64 {
+	p := decision.WinProbability
+	fraction := math.Inf(1)
+
+	// Win TakeProfitPct with probability p, otherwise lose StopLossPct; both net of costs
+	if decision.StopLossPct > 0 && decision.TakeProfitPct > 0 {
+		win, loss := decision.TakeProfitPct, decision.StopLossPct
+		if decision.Costs != nil {
+			keep := 1 - decision.Costs.RoundTrip
+			win, loss = (1+win)*keep-1, 1-(1-loss)*keep
+		}
+		if win <= 0 {
+			return 0
+		}
+		fraction = p/loss - (1-p)/win
+	}
+	// f = mu / sigma^2
+	if decision.ExpectedROIStd > 0 {
+		fraction = math.Min(fraction, decision.ExpectedROI/(decision.ExpectedROIStd*decision.ExpectedROIStd))
+	}
+
+	if math.IsInf(fraction, 1) || fraction <= 0 {
+		return 0
+	}
+	return fraction
+}
+
+// calculateStopLoss calculates stop loss percentage
+func (s *baseStrategy) calculateStopLoss(decision *models.StrategyDecision, w *RiskTable) float64 {
+	// Tighter stop loss for lower confidence
+	if sl, exists := w.StopLoss[decision.Confidence]; exists {
+		return sl
+	}
+	return w.StopLoss["medium"]
+}
+
+// calculateTakeProfit calculates take profit percentage
+func (s *baseStrategy) calculateTakeProfit(decision *models.StrategyDecision, w *RiskTable) float64 {
+	// Higher take profit for higher expected ROI
+	baseTP := decision.ExpectedROI * w.TakeProfit.ROIMultiple
+
+	if baseTP < w.TakeProfit.Min {
+		baseTP = w.TakeProfit.Min
+	}
+	if baseTP > w.TakeProfit.Max {
+		baseTP = w.TakeProfit.Max
+	}
+
+	return math.Round(baseTP*100) / 100
+}
+
+// calculateTimeHorizon calculates recommended holding period
+func (s *baseStrategy) calculateTimeHorizon(decision *models.StrategyDecision, w *RiskTable) int {
+	// Meme coins are typically short-term trades; hold longer when more confident
+	if minutes, exists := w.TimeHorizonMinutes[decision.Confidence]; exists {
+		return minutes
+	}
+	return w.TimeHorizonMinutes["low"]
+}
+
+// totalMentions sums social mentions across platforms
+func totalMentions(offchain *models.OffChainMetrics) int {
+	total := 0
+	for _, count := range offchain.SocialMentions {
+		total += count
+	}
+	return total
+}
+
+// scorer accumulates a win probability from additive factors and records
+// each factor's input and contribution as the decision rationale
+type scorer struct {
+	prob      float64
+	rationale []models.RationaleEntry
+}
+
+// newScorer starts scoring from a base probability
+func newScorer(base float64) *scorer {
+	return &scorer{
+		prob:      base,
+		rationale: []models.RationaleEntry{{Factor: "base", Value: base, Delta: base}},
+	}
+}
+
+// add applies a factor's delta to the probability
+func (s *scorer) add(factor string, value, delta float64) {
+	s.prob += delta
+	s.rationale = append(s.rationale, models.RationaleEntry{Factor: factor, Value: value, Delta: delta})
+}
+
+// reject zeroes the probability for a disqualifying factor
+func (s *scorer) reject(factor string) (float64, []models.RationaleEntry) {
+	s.add(factor, 0, -s.prob)
+	return 0, s.rationale
+}
+
+// result returns the probability bounded to [0, 1]; any clamping is recorded as its own entry
+func (s *scorer) result() (float64, []models.RationaleEntry) {
+	clamped := math.Max(0, math.Min(1, s.prob))
+	if clamped != s.prob {
+		s.rationale = append(s.rationale, models.RationaleEntry{Factor: "clamp", Value: s.prob, Delta: clamped - s.prob})
+	}
+	return clamped, s.rationale
+}
@@ -0,0 +1,153 @@
+package strategy
+
+import (
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// HeuristicStrategy is the general-purpose additive scoring strategy used for
+// tokens no specialised strategy is routed to
+type HeuristicStrategy struct {
+	baseStrategy
+}
+
+// NewHeuristicStrategy creates the heuristic strategy
+func NewHeuristicStrategy(cfg *config.Config, weights *WeightsStore) *HeuristicStrategy {
+	return &HeuristicStrategy{baseStrategy{config: cfg, weights: weights}}
+}
+
+// Name returns the strategy name
+func (s *HeuristicStrategy) Name() string {
+	return "heuristic"
+}
+
+// Evaluate scores the token from safety, volume, social and velocity factors
+func (s *HeuristicStrategy) Evaluate(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+) (*models.StrategyDecision, error) {
+	// Use one parameter set for the whole decision even if a reload lands mid-evaluation
+	w := s.weights.Current()
+	decision := s.newDecision(token, w)
+
+	// Calculate win probability
+	decision.WinProbability, decision.Rationale = s.calculateWinProbability(safety, offchain, token, w)
+	s.calibrate(decision, s.Name())
+
+	// Calculate expected ROI
+	decision.ExpectedROI, decision.ExpectedROIStd = s.calculateExpectedROI(safety, offchain, token)
+
+	// Determine confidence level
+	decision.Confidence = s.determineConfidence(safety, offchain, decision.WinProbability, w)
+
+	// Determine action
+	decision.Action = s.determineAction(decision)
+
+	// Set risk parameters
+	decision.StopLossPct = s.calculateStopLoss(decision, &w.RiskTable)
+	decision.TakeProfitPct = s.calculateTakeProfit(decision, &w.RiskTable)
+	decision.TimeHorizonMinutes = s.calculateTimeHorizon(decision, &w.RiskTable)
+
+	// Calculate suggested position size
+	s.sizePosition(decision, safety, offchain, 1)
+
+	return decision, nil
+}
+
+// calculateWinProbability calculates the probability of a profitable trade
+// and the contribution of each factor to it
+func (s *HeuristicStrategy) calculateWinProbability(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+	w *Weights,
+) (float64, []models.RationaleEntry) {
+	score := newScorer(w.Factor("base"))
+
+	// Safety factors (most important)
+	if !safety.CanBuy || !safety.CanSell {
+		return score.reject("cannot_trade") // Cannot trade = 0% win probability
+	}
+	score.add("can_trade", 1, w.Factor("can_trade"))
+
+	if safety.HoneypotScore < w.Thresholds.LowHoneypotScore {
+		score.add("low_honeypot_score", safety.HoneypotScore, w.Factor("low_honeypot_score"))
+	} else if safety.HoneypotScore > s.config.MaxHoneypotScore {
+		score.add("high_honeypot_score", safety.HoneypotScore, w.Factor("high_honeypot_score"))
+	}
+
+	if safety.LiquidityLocked {
+		score.add("liquidity_locked", 1, w.Factor("liquidity_locked"))
+	}
+
+	if safety.OwnerControls.Renounced {
+		score.add("owner_renounced", 1, w.Factor("owner_renounced"))
+	}
+
+	if !safety.OwnerControls.HasBlacklist && !safety.OwnerControls.HasTransferHook {
+		score.add("no_transfer_restrictions", 1, w.Factor("no_transfer_restrictions"))
+	}
+
+	// Volume factors
+	if offchain.Volume24hDEX >= s.config.MinVolumeDEX {
+		score.add("good_dex_volume", offchain.Volume24hDEX, w.Factor("good_dex_volume"))
+	}
+
+	// Social factors
+	if mentions := totalMentions(offchain); mentions > w.Thresholds.SocialMentions {
+		score.add("social_activity", float64(mentions), w.Factor("social_activity"))
+	}
+
+	// Velocity factor
+	if offchain.Velocity == "rising" {
+		score.add("rising_velocity", 1, w.Factor("rising_velocity"))
+	} else if offchain.Velocity == "falling" {
+		score.add("falling_velocity", -1, w.Factor("falling_velocity"))
+	}
+
+	// Liquidity concentration
+	liquidityRatio := token.Token.InitialLiquidity.ReserveNative / (token.Token.InitialLiquidity.ReserveNative + token.Token.InitialLiquidity.ReserveToken)
+	if liquidityRatio < w.Thresholds.LiquidityBalanceMin || liquidityRatio > w.Thresholds.LiquidityBalanceMax {
+		score.add("liquidity_imbalance", liquidityRatio, w.Factor("liquidity_imbalance"))
+	}
+
+	// Priority adjustment
+	if token.Priority == "high" {
+		score.add("high_priority", 1, w.Factor("high_priority"))
+	} else if token.Priority == "low" {
+		score.add("low_priority", -1, w.Factor("low_priority"))
+	}
+
+	// Clamp between 0 and 1
+	return score.result()
+}
+
+// calculateExpectedROI calculates expected return and standard deviation
+func (s *HeuristicStrategy) calculateExpectedROI(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+) (float64, float64) {
+	// Base expected return for meme coins
+	baseROI := 0.15
+
+	// Adjust based on volume and liquidity
+	if offchain.Volume24hDEX > s.config.MinVolumeDEX*2 {
+		baseROI += 0.10
+	}
+
+	if token.Token.InitialLiquidity.ReserveNative > s.config.MinLiquidity*2 {
+		baseROI += 0.08
+	}
+
+	// Adjust for velocity
+	if offchain.Velocity == "rising" {
+		baseROI += 0.12
+	}
+
+	// Standard deviation (volatility measure)
+	stdDev := 0.25 // High volatility for meme coins
+
+	return baseROI, stdDev
+}
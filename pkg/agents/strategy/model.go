@@ -0,0 +1,83 @@
+package strategy
+
+import (
+	"math"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/model"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// ModelStrategy scores tokens with a win probability model trained on our
+// own trade history (see the train subcommand)
+type ModelStrategy struct {
+	baseStrategy
+	model *model.Model
+}
+
+// NewModelStrategy creates a strategy backed by a trained model
+func NewModelStrategy(cfg *config.Config, weights *WeightsStore, m *model.Model) *ModelStrategy {
+	return &ModelStrategy{baseStrategy: baseStrategy{config: cfg, weights: weights}, model: m}
+}
+
+// Name returns the strategy name
+func (s *ModelStrategy) Name() string {
+	return "model"
+}
+
+// Evaluate scores the token with the model; confidence, sizing and risk
+// parameters follow the strategy weights
+func (s *ModelStrategy) Evaluate(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+) (*models.StrategyDecision, error) {
+	w := s.weights.Current()
+	decision := s.newDecision(token, w)
+	decision.ParamsVersion = "model:" + s.model.Version
+
+	decision.WinProbability, decision.Rationale = s.calculateWinProbability(safety, offchain, token)
+	s.calibrate(decision, s.Name())
+	decision.ExpectedROI, decision.ExpectedROIStd = s.model.ExpectedROI(decision.WinProbability)
+
+	decision.Confidence = s.determineConfidence(safety, offchain, decision.WinProbability, w)
+	decision.Action = s.determineAction(decision)
+
+	decision.StopLossPct = s.calculateStopLoss(decision, &w.RiskTable)
+	decision.TakeProfitPct = s.calculateTakeProfit(decision, &w.RiskTable)
+	decision.TimeHorizonMinutes = s.calculateTimeHorizon(decision, &w.RiskTable)
+	s.sizePosition(decision, safety, offchain, 1)
+
+	return decision, nil
+}
+
+// calculateWinProbability runs the model and converts each feature's log-odds
+// contribution into a probability delta. Contributions are applied in turn, so
+// the deltas sum to the final probability.
+func (s *ModelStrategy) calculateWinProbability(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+) (float64, []models.RationaleEntry) {
+	if !safety.CanBuy || !safety.CanSell {
+		return newScorer(0).reject("cannot_trade")
+	}
+
+	base, contributions := s.model.Explain(model.Features(safety, offchain, token.Token))
+
+	logOdds := base
+	prob := sigmoid(logOdds)
+	score := newScorer(prob)
+	for _, c := range contributions {
+		logOdds += c.LogOdds
+		next := sigmoid(logOdds)
+		score.add(c.Feature, c.Value, next-prob)
+		prob = next
+	}
+	return score.result()
+}
+
+// sigmoid maps log-odds to a probability
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
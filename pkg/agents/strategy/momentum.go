@@ -0,0 +1,146 @@
+package strategy
+
+import (
+	"math"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// MomentumStrategy trades established pools whose activity is accelerating.
+// It scores the velocity windows rather than absolute volume so that a token
+// gaining traction ranks above a larger one that is fading.
+type MomentumStrategy struct {
+	baseStrategy
+}
+
+// NewMomentumStrategy creates the momentum strategy
+func NewMomentumStrategy(cfg *config.Config, weights *WeightsStore) *MomentumStrategy {
+	return &MomentumStrategy{baseStrategy{config: cfg, weights: weights}}
+}
+
+// Name returns the strategy name
+func (s *MomentumStrategy) Name() string {
+	return "momentum"
+}
+
+// Evaluate scores the token from its activity trend, liquidity and buy pressure
+func (s *MomentumStrategy) Evaluate(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+) (*models.StrategyDecision, error) {
+	w := s.weights.Current()
+	decision := s.newDecision(token, w)
+
+	sw := &w.Strategies.Momentum
+	decision.WinProbability, decision.Rationale = s.calculateWinProbability(safety, offchain, token, w)
+	s.calibrate(decision, s.Name())
+
+	// Expected return scales with the trend, capped so one hot window cannot dominate
+	decision.ExpectedROI, decision.ExpectedROIStd = sw.ROI["base"], sw.ROI["std"]
+	if window := trendWindow(offchain, sw.Thresholds); window != nil && window.RelativeSlope > 0 {
+		decision.ExpectedROI += math.Min(window.RelativeSlope*sw.ROI["trend_multiple"], sw.ROI["trend_max"])
+	}
+
+	decision.Confidence = s.determineConfidence(safety, offchain, decision.WinProbability, w)
+	decision.Action = s.determineAction(decision)
+
+	// Ride the trend with a tight stop; exit once it has had time to play out
+	decision.StopLossPct = s.calculateStopLoss(decision, &sw.RiskTable)
+	decision.TakeProfitPct = s.calculateTakeProfit(decision, &sw.RiskTable)
+	decision.TimeHorizonMinutes = s.calculateTimeHorizon(decision, &sw.RiskTable)
+	s.sizePosition(decision, safety, offchain, sw.SizeScale)
+
+	return decision, nil
+}
+
+// calculateWinProbability scores safety, liquidity and the activity trend
+func (s *MomentumStrategy) calculateWinProbability(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+	w *Weights,
+) (float64, []models.RationaleEntry) {
+	f, t := w.Strategies.Momentum.Factors, w.Strategies.Momentum.Thresholds
+	score := newScorer(f["base"])
+
+	if !safety.CanBuy || !safety.CanSell {
+		return score.reject("cannot_trade")
+	}
+
+	if safety.HoneypotScore < w.Thresholds.LowHoneypotScore {
+		score.add("low_honeypot_score", safety.HoneypotScore, f["low_honeypot_score"])
+	} else if safety.HoneypotScore > s.config.MaxHoneypotScore {
+		score.add("high_honeypot_score", safety.HoneypotScore, f["high_honeypot_score"])
+	}
+	if safety.LiquidityLocked {
+		score.add("liquidity_locked", 1, f["liquidity_locked"])
+	}
+	if safety.OwnerControls.Renounced {
+		score.add("owner_renounced", 1, f["owner_renounced"])
+	}
+	if safety.OwnerControls.TaxFee > t["high_tax"] {
+		score.add("high_tax", safety.OwnerControls.TaxFee, f["high_tax"])
+	}
+
+	liquidity := offchain.LiquidityUSD
+	if liquidity == 0 {
+		liquidity = token.Token.InitialLiquidity.ReserveNative
+	}
+	if liquidity >= s.config.MinLiquidity {
+		score.add("good_liquidity", liquidity, f["good_liquidity"])
+	} else {
+		score.add("thin_liquidity", liquidity, f["thin_liquidity"])
+	}
+
+	if offchain.Volume24hDEX >= s.config.MinVolumeDEX {
+		score.add("good_dex_volume", offchain.Volume24hDEX, f["good_dex_volume"])
+	}
+
+	if window := trendWindow(offchain, t); window != nil {
+		switch {
+		case window.RelativeSlope > t["strong_uptrend"]:
+			score.add("strong_uptrend", window.RelativeSlope, f["strong_uptrend"])
+		case window.RelativeSlope > 0:
+			score.add("uptrend", window.RelativeSlope, f["uptrend"])
+		case window.RelativeSlope < 0:
+			score.add("downtrend", window.RelativeSlope, f["downtrend"])
+		}
+		if window.RelativeAccel > 0 {
+			score.add("accelerating", window.RelativeAccel, f["accelerating"])
+		} else if window.RelativeAccel < 0 {
+			score.add("decelerating", window.RelativeAccel, f["decelerating"])
+		}
+	} else if offchain.Velocity == "rising" {
+		score.add("rising_velocity", 1, f["rising_velocity"])
+	} else if offchain.Velocity == "falling" {
+		score.add("falling_velocity", -1, f["falling_velocity"])
+	}
+
+	if flow := offchain.TradeFlow; flow != nil && flow.Swaps > 0 {
+		if flow.BuySellRatio > t["buy_pressure"] {
+			score.add("buy_pressure", flow.BuySellRatio, f["buy_pressure"])
+		} else if flow.BuySellRatio < t["sell_pressure"] {
+			score.add("sell_pressure", flow.BuySellRatio, f["sell_pressure"])
+		}
+	}
+
+	if token.Priority == "high" {
+		score.add("high_priority", 1, f["high_priority"])
+	} else if token.Priority == "low" {
+		score.add("low_priority", -1, f["low_priority"])
+	}
+
+	return score.result()
+}
+
+// trendWindow returns the shortest velocity window with enough samples for a trend
+func trendWindow(offchain *models.OffChainMetrics, thresholds map[string]float64) *models.VelocityWindow {
+	for i := range offchain.VelocityWindows {
+		if float64(offchain.VelocityWindows[i].Samples) >= thresholds["trend_min_samples"] {
+			return &offchain.VelocityWindows[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package strategy
+
+import (
+	"math"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// pumpFunGraduationSOL is the real SOL a pump.fun bonding curve holds when it graduates to Raydium
+const pumpFunGraduationSOL = 85.0
+
+// PumpFunSniperStrategy trades pump.fun bonding-curve tokens in their first
+// minutes. Those tokens have no 24h volume or locked liquidity yet, so it leans
+// on early trade flow: buyer breadth, buy pressure, sniper concentration and
+// how far the curve has filled.
+type PumpFunSniperStrategy struct {
+	baseStrategy
+}
+
+// NewPumpFunSniperStrategy creates the pump.fun sniper strategy
+func NewPumpFunSniperStrategy(cfg *config.Config, weights *WeightsStore) *PumpFunSniperStrategy {
+	return &PumpFunSniperStrategy{baseStrategy{config: cfg, weights: weights}}
+}
+
+// Name returns the strategy name
+func (s *PumpFunSniperStrategy) Name() string {
+	return "pumpfun_sniper"
+}
+
+// Evaluate scores a bonding-curve token from its early trade flow
+func (s *PumpFunSniperStrategy) Evaluate(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+) (*models.StrategyDecision, error) {
+	w := s.weights.Current()
+	decision := s.newDecision(token, w)
+
+	sw := &w.Strategies.PumpFun
+	decision.WinProbability, decision.Rationale = s.calculateWinProbability(safety, offchain, token, w)
+	s.calibrate(decision, s.Name())
+
+	// Curve tokens are either rugged or run multiples; model a wide distribution
+	decision.ExpectedROI, decision.ExpectedROIStd = sw.ROI["base"], sw.ROI["std"]
+	if flow := offchain.TradeFlow; flow != nil && float64(flow.UniqueBuyers) >= sw.Thresholds["many_unique_buyers"] {
+		decision.ExpectedROI += sw.ROI["many_unique_buyers"]
+	}
+	if curveFilling(curveProgress(token), sw.Thresholds) {
+		decision.ExpectedROI += sw.ROI["curve_filling"]
+	}
+
+	decision.Confidence = s.determineConfidence(safety, offchain, decision.WinProbability, w)
+	decision.Action = s.determineAction(decision)
+
+	// Snipes are small, quick and use a wide stop to survive curve volatility
+	decision.StopLossPct = s.calculateStopLoss(decision, &sw.RiskTable)
+	decision.TakeProfitPct = s.calculateTakeProfit(decision, &sw.RiskTable)
+	decision.TimeHorizonMinutes = s.calculateTimeHorizon(decision, &sw.RiskTable)
+	s.sizePosition(decision, safety, offchain, sw.SizeScale)
+
+	return decision, nil
+}
+
+// calculateWinProbability scores safety and the token's early trade flow
+func (s *PumpFunSniperStrategy) calculateWinProbability(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+	w *Weights,
+) (float64, []models.RationaleEntry) {
+	f, t := w.Strategies.PumpFun.Factors, w.Strategies.PumpFun.Thresholds
+	score := newScorer(f["base"])
+
+	if !safety.CanBuy || !safety.CanSell {
+		return score.reject("cannot_trade")
+	}
+
+	if safety.HoneypotScore < w.Thresholds.LowHoneypotScore {
+		score.add("low_honeypot_score", safety.HoneypotScore, f["low_honeypot_score"])
+	} else if safety.HoneypotScore > s.config.MaxHoneypotScore {
+		score.add("high_honeypot_score", safety.HoneypotScore, f["high_honeypot_score"])
+	}
+
+	// Pump.fun mints have no mint or freeze authority; either one is a red flag
+	if safety.OwnerControls.Renounced {
+		score.add("owner_renounced", 1, f["owner_renounced"])
+	}
+	if safety.OwnerControls.HasBlacklist {
+		score.add("freeze_authority", 1, f["freeze_authority"])
+	}
+
+	if flow := offchain.TradeFlow; flow != nil {
+		buyers := float64(flow.UniqueBuyers)
+		switch {
+		case buyers >= t["many_unique_buyers"]:
+			score.add("many_unique_buyers", buyers, f["many_unique_buyers"])
+		case buyers >= t["unique_buyers"]:
+			score.add("unique_buyers", buyers, f["unique_buyers"])
+		case buyers < t["few_unique_buyers"]:
+			score.add("few_unique_buyers", buyers, f["few_unique_buyers"])
+		}
+
+		if flow.BuySellRatio >= t["buy_pressure"] {
+			score.add("buy_pressure", flow.BuySellRatio, f["buy_pressure"])
+		} else if flow.Swaps > 0 && flow.BuySellRatio < t["sell_pressure"] {
+			score.add("sell_pressure", flow.BuySellRatio, f["sell_pressure"])
+		}
+
+		// Supply concentrated in the launch blocks is usually a bundle that will dump
+		switch {
+		case flow.SniperSupplyShare > t["heavy_sniper_share"]:
+			score.add("heavy_sniper_share", flow.SniperSupplyShare, f["heavy_sniper_share"])
+		case flow.SniperSupplyShare > t["sniper_share"]:
+			score.add("sniper_share", flow.SniperSupplyShare, f["sniper_share"])
+		default:
+			score.add("low_sniper_share", flow.SniperSupplyShare, f["low_sniper_share"])
+		}
+
+		// Buyers with brand-new wallets are typically the deployer's own wallets
+		if float64(flow.BuyersAged) >= t["fresh_wallet_min_buyers"] && flow.MedianBuyerWalletAgeHours < t["fresh_wallet_age_hours"] {
+			score.add("fresh_buyer_wallets", flow.MedianBuyerWalletAgeHours, f["fresh_buyer_wallets"])
+		}
+	} else {
+		// Without trade flow the strategy is blind to its main signal
+		score.add("no_trade_flow", 0, f["no_trade_flow"])
+	}
+
+	progress := curveProgress(token)
+	if curveFilling(progress, t) {
+		score.add("curve_filling", progress, f["curve_filling"])
+	} else if progress > t["curve_near_graduation"] {
+		score.add("curve_near_graduation", progress, f["curve_near_graduation"])
+	}
+
+	if growth, exists := offchain.SocialGrowth["telegram"]; exists && growth.MemberGrowthPerHour > 0 {
+		score.add("telegram_growth", growth.MemberGrowthPerHour, f["telegram_growth"])
+	}
+
+	if offchain.Velocity == "rising" {
+		score.add("rising_velocity", 1, f["rising_velocity"])
+	} else if offchain.Velocity == "falling" {
+		score.add("falling_velocity", -1, f["falling_velocity"])
+	}
+
+	if token.Priority == "high" {
+		score.add("high_priority", 1, f["high_priority"])
+	} else if token.Priority == "low" {
+		score.add("low_priority", -1, f["low_priority"])
+	}
+
+	return score.result()
+}
+
+// curveProgress estimates how far the bonding curve has filled towards graduation
+func curveProgress(token models.PreFilteredToken) float64 {
+	return math.Min(1, token.Token.InitialLiquidity.ReserveNative/pumpFunGraduationSOL)
+}
+
+// curveFilling reports whether the curve is in the window where it is filling
+// steadily, which both the win probability and the ROI prior reward
+func curveFilling(progress float64, thresholds map[string]float64) bool {
+	return progress >= thresholds["curve_filling_min"] && progress <= thresholds["curve_filling_max"]
+}
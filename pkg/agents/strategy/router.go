@@ -0,0 +1,133 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// pumpFunMintSuffix is the vanity suffix of mints created through pump.fun
+const pumpFunMintSuffix = "pump"
+
+// DefaultRoutes send pump.fun bonding-curve tokens to the sniper and Base V2 pairs to momentum
+var DefaultRoutes = []string{
+	"solana:pumpfun=pumpfun_sniper",
+	"base:uniswap_v2=momentum",
+}
+
+// RouteRule selects a strategy for tokens on a chain, optionally narrowed to a
+// launchpad or DEX as reported in the token's metadata
+type RouteRule struct {
+	Chain    models.Chain
+	Venue    string // launchpad or DEX, e.g. "pumpfun", "uniswap_v2"; empty matches any
+	Strategy string
+}
+
+// ParseRouteRule parses a "chain[:venue]=strategy" rule
+func ParseRouteRule(rule string) (RouteRule, error) {
+	target, strategy, found := strings.Cut(strings.TrimSpace(rule), "=")
+	if !found || strings.TrimSpace(strategy) == "" || strings.TrimSpace(target) == "" {
+		return RouteRule{}, fmt.Errorf("invalid route %q, want chain[:venue]=strategy", rule)
+	}
+
+	chain, venue, _ := strings.Cut(target, ":")
+	return RouteRule{
+		Chain:    models.Chain(strings.ToLower(strings.TrimSpace(chain))),
+		Venue:    strings.ToLower(strings.TrimSpace(venue)),
+		Strategy: strings.ToLower(strings.TrimSpace(strategy)),
+	}, nil
+}
+
+// Matches reports whether the rule applies to the token
+func (r RouteRule) Matches(token models.TokenFound) bool {
+	if r.Chain != "" && r.Chain != "*" && r.Chain != token.Chain {
+		return false
+	}
+	if r.Venue == "" {
+		return true
+	}
+	for _, venue := range tokenVenues(token) {
+		if venue == r.Venue {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenVenues lists the launchpad and DEX the token trades on
+func tokenVenues(token models.TokenFound) []string {
+	var venues []string
+	for _, key := range []string{models.MetadataLaunchpad, models.MetadataDEX} {
+		if venue := strings.ToLower(strings.TrimSpace(token.Metadata[key])); venue != "" {
+			venues = append(venues, venue)
+		}
+	}
+	// Scanners that do not tag the launchpad still expose pump.fun's vanity mint suffix
+	if token.Chain == models.ChainSolana && strings.HasSuffix(token.TokenAddress, pumpFunMintSuffix) {
+		venues = append(venues, "pumpfun")
+	}
+	return venues
+}
+
+// Router picks a strategy for each token from an ordered list of rules
+type Router struct {
+	rules    []RouteRule
+	fallback string
+}
+
+// NewRouter creates a router; the first matching rule wins and unmatched tokens use fallback
+func NewRouter(rules []RouteRule, fallback string) *Router {
+	return &Router{
+		rules:    rules,
+		fallback: fallback,
+	}
+}
+
+// Route returns the name of the strategy for a token
+func (r *Router) Route(token models.TokenFound) string {
+	for _, rule := range r.rules {
+		if rule.Matches(token) {
+			return rule.Strategy
+		}
+	}
+	return r.fallback
+}
+
+// Rules returns the routing rules in evaluation order
+func (r *Router) Rules() []RouteRule {
+	return append([]RouteRule(nil), r.rules...)
+}
+
+// ConfiguredRoutes parses STRATEGY_ROUTES, or DefaultRoutes when none are
+// set. Invalid rules are returned as errors and left out.
+func ConfiguredRoutes(cfg *config.Config) ([]RouteRule, []error) {
+	routes := cfg.StrategyRoutes
+	if len(routes) == 0 {
+		routes = DefaultRoutes
+	}
+
+	var rules []RouteRule
+	var errs []error
+	for _, route := range routes {
+		rule, err := ParseRouteRule(route)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, errs
+}
+
+// RoutedStrategies returns the names of the strategies a configuration's
+// routes and default strategy can send tokens to
+func RoutedStrategies(cfg *config.Config) map[string]bool {
+	rules, _ := ConfiguredRoutes(cfg)
+	routed := map[string]bool{cfg.DefaultStrategy: true}
+	for _, rule := range rules {
+		routed[rule.Strategy] = true
+	}
+	return routed
+}
@@ -0,0 +1,374 @@
+package strategy
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/impact"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// LiveStrategy is the name under which the routed live decisions are reported
+const LiveStrategy = "live"
+
+// shadowRetention is how long a token whose trades have all closed stays in the report
+const shadowRetention = 24 * time.Hour
+
+// PriceSource reports a token's current price in USD
+type PriceSource interface {
+	Price(ctx context.Context, token models.TokenFound) (float64, error)
+}
+
+// shadowToken is the live and shadow decisions made on one token
+type shadowToken struct {
+	token      models.TokenFound
+	firstSeen  time.Time
+	firstPrice float64
+	lastPrice  float64
+	trades     map[string]*models.ShadowTrade // by strategy, LiveStrategy for the live decision
+}
+
+// open reports whether any hypothetical trade on the token is still open
+func (t *shadowToken) open() bool {
+	for _, trade := range t.trades {
+		if trade.EntryPrice > 0 && trade.ClosedAt.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// ShadowTracker records live and shadow decisions side by side and tracks the
+// PnL each would have made from subsequent prices
+type ShadowTracker struct {
+	prices         PriceSource
+	defaultHorizon time.Duration
+	tokens         map[models.CandidateKey]*shadowToken
+	now            func() time.Time
+	mu             sync.Mutex
+}
+
+// NewShadowTracker creates a tracker; trades without a time horizon close after defaultHorizon
+func NewShadowTracker(prices PriceSource, defaultHorizon time.Duration) *ShadowTracker {
+	return &ShadowTracker{
+		prices:         prices,
+		defaultHorizon: defaultHorizon,
+		tokens:         make(map[models.CandidateKey]*shadowToken),
+		now:            time.Now,
+	}
+}
+
+// SetPriceSource sets where subsequent prices are read from
+func (t *ShadowTracker) SetPriceSource(prices PriceSource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prices = prices
+}
+
+// Record stores the live decision and the shadow decisions made on a token at
+// price. A strategy's first list/buy decision opens its hypothetical trade;
+// later decisions on the same token replace earlier ones only until then.
+func (t *ShadowTracker) Record(
+	token models.TokenFound,
+	price float64,
+	live *models.StrategyDecision,
+	shadows []*models.StrategyDecision,
+) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	entry, exists := t.tokens[token.Key()]
+	if !exists {
+		entry = &shadowToken{
+			token:      token,
+			firstSeen:  now,
+			firstPrice: price,
+			trades:     make(map[string]*models.ShadowTrade),
+		}
+		t.tokens[token.Key()] = entry
+	}
+	if entry.firstPrice == 0 {
+		entry.firstPrice = price
+	}
+	if price > 0 {
+		entry.lastPrice = price
+	}
+
+	t.recordDecision(entry, LiveStrategy, live, false, price, now)
+	for _, decision := range shadows {
+		t.recordDecision(entry, decision.Strategy, decision, true, price, now)
+	}
+}
+
+// recordDecision stores a decision and opens its trade if it is the first to trade
+func (t *ShadowTracker) recordDecision(
+	entry *shadowToken,
+	name string,
+	decision *models.StrategyDecision,
+	shadow bool,
+	price float64,
+	now time.Time,
+) {
+	trade, exists := entry.trades[name]
+	if exists && trade.EntryPrice > 0 {
+		return
+	}
+	trade = &models.ShadowTrade{Decision: *decision, Shadow: shadow}
+	entry.trades[name] = trade
+
+	if trades(decision) && price > 0 {
+		trade.EntryPrice = price
+		trade.OpenedAt = now
+		trade.LastPrice = price
+		t.mark(trade, price, now)
+	}
+}
+
+// trades reports whether a decision would have opened a position
+func trades(decision *models.StrategyDecision) bool {
+	return (decision.Action == "buy" || decision.Action == "list") && decision.SuggestedAmountUSD > 0
+}
+
+// mark values an open trade at price and closes it on its take profit, stop
+// loss or time horizon
+func (t *ShadowTracker) mark(trade *models.ShadowTrade, price float64, now time.Time) {
+	decision := trade.Decision
+	gross := price/trade.EntryPrice - 1
+	trade.LastPrice = price
+
+	horizon := time.Duration(decision.TimeHorizonMinutes) * time.Minute
+	if horizon <= 0 {
+		horizon = t.defaultHorizon
+	}
+
+	// Exits are assumed to fill at the level that triggered them
+	switch {
+	case decision.TakeProfitPct > 0 && gross >= decision.TakeProfitPct:
+		gross = decision.TakeProfitPct
+		trade.ExitReason = "take_profit"
+	case decision.StopLossPct > 0 && gross <= -decision.StopLossPct:
+		gross = -decision.StopLossPct
+		trade.ExitReason = "stop_loss"
+	case horizon > 0 && now.Sub(trade.OpenedAt) >= horizon:
+		trade.ExitReason = "time_horizon"
+	}
+	if trade.ExitReason != "" {
+		trade.ClosedAt = now
+	}
+
+	trade.ROI = gross
+	if decision.Costs != nil {
+		trade.ROI = impact.NetROI(gross, *decision.Costs)
+	}
+	trade.PnLUSD = decision.SuggestedAmountUSD * trade.ROI
+}
+
+// Mark fetches the current price of every token with an open trade, or still
+// inside its first default horizon, and updates its trades
+func (t *ShadowTracker) Mark(ctx context.Context) {
+	t.mu.Lock()
+	prices := t.prices
+	now := t.now()
+	var pending []models.TokenFound
+	for key, entry := range t.tokens {
+		switch {
+		case entry.open() || now.Sub(entry.firstSeen) < t.defaultHorizon:
+			pending = append(pending, entry.token)
+		case now.Sub(entry.firstSeen) > shadowRetention:
+			delete(t.tokens, key)
+		}
+	}
+	t.mu.Unlock()
+
+	if prices == nil {
+		return
+	}
+	for _, token := range pending {
+		price, err := prices.Price(ctx, token)
+		if err != nil || price <= 0 {
+			log.Printf("StrategyEvaluatorAgent: No shadow price for %s: %v\n", token.TokenAddress, err)
+			continue
+		}
+		t.update(token.Key(), price)
+	}
+}
+
+// update marks a token's open trades at price
+func (t *ShadowTracker) update(key models.CandidateKey, price float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, exists := t.tokens[key]
+	if !exists {
+		return
+	}
+	if entry.firstPrice == 0 {
+		entry.firstPrice = price
+	}
+	entry.lastPrice = price
+
+	now := t.now()
+	for _, trade := range entry.trades {
+		if trade.EntryPrice > 0 && trade.ClosedAt.IsZero() {
+			t.mark(trade, price, now)
+		}
+	}
+}
+
+// StartMarking marks open trades every interval until the returned channel is closed
+func (t *ShadowTracker) StartMarking(interval time.Duration) chan struct{} {
+	stopChan := make(chan struct{})
+	if interval <= 0 {
+		return stopChan
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				t.Mark(ctx)
+				cancel()
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return stopChan
+}
+
+// ShadowStats summarizes one strategy's recorded decisions
+type ShadowStats struct {
+	Strategy       string  `json:"strategy"`
+	Shadow         bool    `json:"shadow"`
+	Decisions      int     `json:"decisions"`
+	Trades         int     `json:"trades"`
+	Open           int     `json:"open"`
+	Closed         int     `json:"closed"`
+	Wins           int     `json:"wins"`
+	HitRate        float64 `json:"hit_rate"` // wins / closed
+	RealizedPnLUSD float64 `json:"realized_pnl_usd"`
+	PnLUSD         float64 `json:"pnl_usd"` // realized plus open trades at their last price
+}
+
+// ShadowDisagreement is a token on which a shadow strategy chose a different action than live
+type ShadowDisagreement struct {
+	TokenAddress string             `json:"token_address"`
+	Chain        models.Chain       `json:"chain"`
+	Strategy     string             `json:"strategy"`
+	Live         models.ShadowTrade `json:"live"`
+	Shadow       models.ShadowTrade `json:"shadow"`
+	PriceMove    float64            `json:"price_move"` // last price relative to the first recorded price
+}
+
+// ShadowReport compares live and shadow strategies over the recorded tokens
+type ShadowReport struct {
+	GeneratedAt   time.Time            `json:"generated_at"`
+	Tokens        int                  `json:"tokens"`
+	Strategies    []ShadowStats        `json:"strategies"` // live first, then shadows by name
+	Disagreements []ShadowDisagreement `json:"disagreements"`
+}
+
+// Report compares live and shadow decisions, most recent disagreements first
+func (t *ShadowTracker) Report() *ShadowReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := &ShadowReport{GeneratedAt: t.now(), Tokens: len(t.tokens), Strategies: make([]ShadowStats, 0)}
+	stats := make(map[string]*ShadowStats)
+	type dated struct {
+		disagreement ShadowDisagreement
+		seen         time.Time
+	}
+	var disagreements []dated
+
+	for _, entry := range t.tokens {
+		for name, trade := range entry.trades {
+			s, exists := stats[name]
+			if !exists {
+				s = &ShadowStats{Strategy: name, Shadow: trade.Shadow}
+				stats[name] = s
+			}
+			s.add(trade)
+		}
+
+		live, exists := entry.trades[LiveStrategy]
+		if !exists {
+			continue
+		}
+		for name, trade := range entry.trades {
+			if !trade.Shadow || trade.Decision.Action == live.Decision.Action {
+				continue
+			}
+			disagreement := ShadowDisagreement{
+				TokenAddress: entry.token.TokenAddress,
+				Chain:        entry.token.Chain,
+				Strategy:     name,
+				Live:         *live,
+				Shadow:       *trade,
+			}
+			if entry.firstPrice > 0 && entry.lastPrice > 0 {
+				disagreement.PriceMove = entry.lastPrice/entry.firstPrice - 1
+			}
+			disagreements = append(disagreements, dated{disagreement, entry.firstSeen})
+		}
+	}
+
+	for _, s := range stats {
+		if s.Closed > 0 {
+			s.HitRate = float64(s.Wins) / float64(s.Closed)
+		}
+		report.Strategies = append(report.Strategies, *s)
+	}
+	sort.Slice(report.Strategies, func(i, j int) bool {
+		a, b := report.Strategies[i], report.Strategies[j]
+		if a.Shadow != b.Shadow {
+			return !a.Shadow
+		}
+		return a.Strategy < b.Strategy
+	})
+
+	sort.Slice(disagreements, func(i, j int) bool {
+		a, b := disagreements[i], disagreements[j]
+		if !a.seen.Equal(b.seen) {
+			return a.seen.After(b.seen)
+		}
+		if a.disagreement.TokenAddress != b.disagreement.TokenAddress {
+			return a.disagreement.TokenAddress < b.disagreement.TokenAddress
+		}
+		return a.disagreement.Strategy < b.disagreement.Strategy
+	})
+	report.Disagreements = make([]ShadowDisagreement, 0, len(disagreements))
+	for _, d := range disagreements {
+		report.Disagreements = append(report.Disagreements, d.disagreement)
+	}
+
+	return report
+}
+
+// add counts one recorded decision
+func (s *ShadowStats) add(trade *models.ShadowTrade) {
+	s.Decisions++
+	if trade.EntryPrice == 0 {
+		return
+	}
+	s.Trades++
+	s.PnLUSD += trade.PnLUSD
+	if trade.ClosedAt.IsZero() {
+		s.Open++
+		return
+	}
+	s.Closed++
+	s.RealizedPnLUSD += trade.PnLUSD
+	if trade.ROI > 0 {
+		s.Wins++
+	}
+}
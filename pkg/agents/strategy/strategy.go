@@ -1,289 +1,322 @@
 package strategy
 
 import (
+	"fmt"
 	"log"
-	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/model"
 	"github.com/mumugogoing/meme_bot/pkg/models"
 )
 
+// Strategy turns the evaluation of a token into a trading decision
+type Strategy interface {
+	Name() string
+	Evaluate(safety *models.SafetyReport, offchain *models.OffChainMetrics, token models.PreFilteredToken) (*models.StrategyDecision, error)
+}
+
+// Registry holds the strategies available for routing, keyed by name
+type Registry struct {
+	strategies map[string]Strategy
+	mu         sync.RWMutex
+}
+
+// NewRegistry creates an empty strategy registry
+func NewRegistry() *Registry {
+	return &Registry{
+		strategies: make(map[string]Strategy),
+	}
+}
+
+// DefaultRegistry returns a registry with all built-in strategies sharing one weights store
+func DefaultRegistry(cfg *config.Config, weights *WeightsStore) *Registry {
+	registry := NewRegistry()
+	registry.Register(NewHeuristicStrategy(cfg, weights))
+	registry.Register(NewPumpFunSniperStrategy(cfg, weights))
+	registry.Register(NewMomentumStrategy(cfg, weights))
+	
+	if cfg.StrategyModelFile != "" {
+		m, err := model.Load(cfg.StrategyModelFile)
+		if err != nil {
+			log.Printf("StrategyEvaluatorAgent: Model strategy disabled: %v\n", err)
+		} else {
+			log.Printf("StrategyEvaluatorAgent: Loaded %s model %s (%d examples)\n", m.Type, m.Version, m.Examples)
+			registry.Register(NewModelStrategy(cfg, weights, m))
+		}
+	}
+	return registry
+}
+
+// Register adds a strategy under its name, replacing any previous one
+func (r *Registry) Register(strategy Strategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strategies[strings.ToLower(strategy.Name())] = strategy
+}
+
+// Get returns the named strategy
+func (r *Registry) Get(name string) (Strategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	strategy, exists := r.strategies[strings.ToLower(name)]
+	return strategy, exists
+}
+
+// Names returns the registered strategy names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.strategies))
+	for name := range r.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // StrategyEvaluatorAgent evaluates trading opportunities
 type StrategyEvaluatorAgent struct {
-	config *config.Config
+	config   *config.Config
+	registry *Registry
+	router      *Router
+	weights     *WeightsStore
+	calibration *model.Calibration
+	shadows     []string
+	shadow      *ShadowTracker
 }
 
 // NewStrategyEvaluatorAgent creates a new strategy evaluator
 func NewStrategyEvaluatorAgent(cfg *config.Config) *StrategyEvaluatorAgent {
-	return &StrategyEvaluatorAgent{
-		config: cfg,
+	weights, err := NewWeightsStore(cfg.StrategyWeightsFile)
+	if err != nil {
+		log.Printf("StrategyEvaluatorAgent: Using builtin strategy weights: %v\n", err)
 	}
+	return NewStrategyEvaluatorAgentWithWeights(cfg, weights)
 }
 
-// Evaluate performs comprehensive strategy evaluation
-func (s *StrategyEvaluatorAgent) Evaluate(
-	safety *models.SafetyReport,
-	offchain *models.OffChainMetrics,
-	token models.PreFilteredToken,
-) (*models.StrategyDecision, error) {
-	log.Printf("StrategyEvaluatorAgent: Evaluating token %s\n", token.Token.TokenAddress)
-	
-	decision := &models.StrategyDecision{
-		TokenAddress:   token.Token.TokenAddress,
-		Chain:          token.Token.Chain,
-		EvaluatedAt:    time.Now(),
-		Rationale:      []string{},
+// NewStrategyEvaluatorAgentWithWeights creates a strategy evaluator whose strategies share an explicit weights store
+func NewStrategyEvaluatorAgentWithWeights(cfg *config.Config, weights *WeightsStore) *StrategyEvaluatorAgent {
+	rules, errs := ConfiguredRoutes(cfg)
+	for _, err := range errs {
+		log.Printf("StrategyEvaluatorAgent: Ignoring route: %v\n", err)
 	}
 	
-	// Calculate win probability
-	decision.WinProbability = s.calculateWinProbability(safety, offchain, token)
-	
-	// Calculate expected ROI
-	decision.ExpectedROI, decision.ExpectedROIStd = s.calculateExpectedROI(safety, offchain, token)
-	
-	// Determine confidence level
-	decision.Confidence = s.determineConfidence(safety, offchain, decision.WinProbability)
-	
-	// Determine action
-	decision.Action = s.determineAction(decision)
-	
-	// Calculate suggested position size
-	decision.SuggestedAmountUSD = s.calculatePositionSize(decision)
-	
-	// Set risk parameters
-	decision.StopLossPct = s.calculateStopLoss(decision)
-	decision.TakeProfitPct = s.calculateTakeProfit(decision)
-	decision.TimeHorizonMinutes = s.calculateTimeHorizon(decision)
-	
-	log.Printf("StrategyEvaluatorAgent: Token %s - WinProb: %.2f, Action: %s, Confidence: %s\n",
-		token.Token.TokenAddress, decision.WinProbability, decision.Action, decision.Confidence)
-	
-	return decision, nil
+	agent := NewStrategyEvaluatorAgentWithRouter(cfg, DefaultRegistry(cfg, weights), NewRouter(rules, cfg.DefaultStrategy))
+	agent.weights = weights
+	agent.SetShadowStrategies(cfg.ShadowStrategies)
+	
+	if cfg.CalibrationFile != "" {
+		calibration, err := model.LoadCalibration(cfg.CalibrationFile)
+		if err != nil {
+			log.Printf("StrategyEvaluatorAgent: Win probabilities uncalibrated: %v\n", err)
+		} else {
+			log.Printf("StrategyEvaluatorAgent: Loaded calibration %s\n", calibration.Version)
+			agent.SetCalibration(calibration)
+		}
+	}
+	return agent
 }
 
-// calculateWinProbability calculates the probability of a profitable trade
-func (s *StrategyEvaluatorAgent) calculateWinProbability(
-	safety *models.SafetyReport,
-	offchain *models.OffChainMetrics,
-	token models.PreFilteredToken,
-) float64 {
-	baseProb := 0.5 // Start at 50%
-	
-	// Safety factors (most important)
-	if safety.CanBuy && safety.CanSell {
-		baseProb += 0.15
-		token.Reasons = append(token.Reasons, "can_trade")
-	} else {
-		return 0.0 // Cannot trade = 0% win probability
-	}
-	
-	if safety.HoneypotScore < 0.1 {
-		baseProb += 0.10
-		token.Reasons = append(token.Reasons, "low_honeypot_score")
-	} else if safety.HoneypotScore > s.config.MaxHoneypotScore {
-		baseProb -= 0.20
-		token.Reasons = append(token.Reasons, "high_honeypot_score")
-	}
-	
-	if safety.LiquidityLocked {
-		baseProb += 0.08
-		token.Reasons = append(token.Reasons, "liquidity_locked")
+// NewStrategyEvaluatorAgentWithRouter creates a strategy evaluator with an explicit registry and router
+func NewStrategyEvaluatorAgentWithRouter(cfg *config.Config, registry *Registry, router *Router) *StrategyEvaluatorAgent {
+	for _, rule := range router.Rules() {
+		if _, exists := registry.Get(rule.Strategy); !exists {
+			log.Printf("StrategyEvaluatorAgent: Route %s:%s points to unknown strategy %s\n", rule.Chain, rule.Venue, rule.Strategy)
+		}
 	}
 	
-	if safety.OwnerControls.Renounced {
-		baseProb += 0.07
-		token.Reasons = append(token.Reasons, "owner_renounced")
+	return &StrategyEvaluatorAgent{
+		config:   cfg,
+		registry: registry,
+		router:   router,
 	}
-	
-	if !safety.OwnerControls.HasBlacklist && !safety.OwnerControls.HasTransferHook {
-		baseProb += 0.05
-		token.Reasons = append(token.Reasons, "no_transfer_restrictions")
+}
+
+// Weights returns the strategy weights currently in effect
+func (s *StrategyEvaluatorAgent) Weights() *Weights {
+	return s.weights.Current()
+}
+
+// StartWeightsReload watches the weights file until the returned channel is closed
+func (s *StrategyEvaluatorAgent) StartWeightsReload(interval time.Duration) chan struct{} {
+	if s.weights == nil {
+		return make(chan struct{})
 	}
-	
-	// Volume factors
-	if offchain.Volume24hDEX >= s.config.MinVolumeDEX {
-		baseProb += 0.10
-		token.Reasons = append(token.Reasons, "good_dex_volume")
+	return s.weights.StartReload(interval)
+}
+
+// calibratedStrategy is implemented by strategies whose win probability can be calibrated
+type calibratedStrategy interface {
+	SetCalibration(calibration *model.Calibration)
+}
+
+// SetCalibration applies a calibration to every registered strategy that supports it
+func (s *StrategyEvaluatorAgent) SetCalibration(calibration *model.Calibration) {
+	s.calibration = calibration
+	for _, name := range s.registry.Names() {
+		strategy, _ := s.registry.Get(name)
+		if calibrated, ok := strategy.(calibratedStrategy); ok {
+			calibrated.SetCalibration(calibration)
+		}
 	}
-	
-	// Social factors
-	totalMentions := 0
-	for _, count := range offchain.SocialMentions {
-		totalMentions += count
+}
+
+// exposureLimitedStrategy is implemented by strategies that cap sizes by remaining exposure
+type exposureLimitedStrategy interface {
+	SetExposureProvider(exposure ExposureProvider)
+}
+
+// SetExposureProvider caps every registered strategy's position sizes by the provider's remaining exposure
+func (s *StrategyEvaluatorAgent) SetExposureProvider(exposure ExposureProvider) {
+	for _, name := range s.registry.Names() {
+		strategy, _ := s.registry.Get(name)
+		if limited, ok := strategy.(exposureLimitedStrategy); ok {
+			limited.SetExposureProvider(exposure)
+		}
 	}
-	if totalMentions > 50 {
-		baseProb += 0.08
-		token.Reasons = append(token.Reasons, "social_activity")
+}
+
+// Calibration returns the calibration in effect, or nil
+func (s *StrategyEvaluatorAgent) Calibration() *model.Calibration {
+	return s.calibration
+}
+
+// SetShadowStrategies runs the named strategies in shadow on every token the
+// live strategy evaluates. Their decisions are recorded but never returned.
+func (s *StrategyEvaluatorAgent) SetShadowStrategies(names []string) {
+	s.shadows = nil
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, exists := s.registry.Get(name); !exists {
+			log.Printf("StrategyEvaluatorAgent: Shadow strategy %s not registered\n", name)
+			continue
+		}
+		s.shadows = append(s.shadows, name)
 	}
 	
-	// Velocity factor
-	if offchain.Velocity == "rising" {
-		baseProb += 0.07
-		token.Reasons = append(token.Reasons, "rising_velocity")
-	} else if offchain.Velocity == "falling" {
-		baseProb -= 0.10
+	if len(s.shadows) == 0 {
+		s.shadow = nil
+		return
 	}
-	
-	// Liquidity concentration
-	liquidityRatio := token.Token.InitialLiquidity.ReserveNative / (token.Token.InitialLiquidity.ReserveNative + token.Token.InitialLiquidity.ReserveToken)
-	if liquidityRatio < 0.3 || liquidityRatio > 0.7 {
-		token.Reasons = append(token.Reasons, "liquidity_imbalance")
-		baseProb -= 0.05
+	if s.shadow == nil {
+		s.shadow = NewShadowTracker(nil, s.config.DefaultTimeWindow)
 	}
-	
-	// Priority adjustment
-	if token.Priority == "high" {
-		baseProb += 0.05
-	} else if token.Priority == "low" {
-		baseProb -= 0.05
+	log.Printf("StrategyEvaluatorAgent: Shadow strategies: %s\n", strings.Join(s.shadows, ", "))
+}
+
+// SetPriceSource sets where shadow trades read subsequent prices from
+func (s *StrategyEvaluatorAgent) SetPriceSource(prices PriceSource) {
+	if s.shadow != nil {
+		s.shadow.SetPriceSource(prices)
 	}
-	
-	// Clamp between 0 and 1
-	if baseProb > 1.0 {
-		baseProb = 1.0
+}
+
+// StartShadowMarking marks hypothetical trades until the returned channel is closed
+func (s *StrategyEvaluatorAgent) StartShadowMarking(interval time.Duration) chan struct{} {
+	if s.shadow == nil {
+		return make(chan struct{})
 	}
-	if baseProb < 0.0 {
-		baseProb = 0.0
+	return s.shadow.StartMarking(interval)
+}
+
+// ShadowReport compares live and shadow decisions, or returns nil when no shadow strategy runs
+func (s *StrategyEvaluatorAgent) ShadowReport() *ShadowReport {
+	if s.shadow == nil {
+		return nil
 	}
-	
-	return baseProb
+	return s.shadow.Report()
+}
+
+// Registry returns the strategies available to the evaluator
+func (s *StrategyEvaluatorAgent) Registry() *Registry {
+	return s.registry
 }
 
-// calculateExpectedROI calculates expected return and standard deviation
-func (s *StrategyEvaluatorAgent) calculateExpectedROI(
+// Evaluate routes the token to a strategy and returns its decision
+func (s *StrategyEvaluatorAgent) Evaluate(
 	safety *models.SafetyReport,
 	offchain *models.OffChainMetrics,
 	token models.PreFilteredToken,
-) (float64, float64) {
-	// Base expected return for meme coins
-	baseROI := 0.15
-	
-	// Adjust based on volume and liquidity
-	if offchain.Volume24hDEX > s.config.MinVolumeDEX*2 {
-		baseROI += 0.10
+) (*models.StrategyDecision, error) {
+	strategy, err := s.route(token)
+	if err != nil {
+		return nil, err
 	}
 	
-	if token.Token.InitialLiquidity.ReserveNative > s.config.MinLiquidity*2 {
-		baseROI += 0.08
-	}
+	log.Printf("StrategyEvaluatorAgent: Evaluating token %s with %s strategy\n", token.Token.TokenAddress, strategy.Name())
 	
-	// Adjust for velocity
-	if offchain.Velocity == "rising" {
-		baseROI += 0.12
+	decision, err := strategy.Evaluate(safety, offchain, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s strategy: %w", strategy.Name(), err)
 	}
+	decision.Strategy = strategy.Name()
 	
-	// Standard deviation (volatility measure)
-	stdDev := 0.25 // High volatility for meme coins
+	log.Printf("StrategyEvaluatorAgent: Token %s - Strategy: %s, Params: %s, WinProb: %.2f, Action: %s, Confidence: %s\n",
+		token.Token.TokenAddress, decision.Strategy, decision.ParamsVersion, decision.WinProbability, decision.Action, decision.Confidence)
 	
-	return baseROI, stdDev
-}
-
-// determineConfidence determines confidence level
-func (s *StrategyEvaluatorAgent) determineConfidence(
-	safety *models.SafetyReport,
-	offchain *models.OffChainMetrics,
-	winProb float64,
-) string {
-	// High confidence requires multiple factors
-	if winProb >= 0.85 && 
-	   safety.HoneypotScore < 0.1 && 
-	   offchain.Volume24hDEX > s.config.MinVolumeDEX {
-		return "high"
-	}
-	
-	if winProb >= 0.70 && safety.HoneypotScore < 0.2 {
-		return "medium"
+	if s.shadow != nil {
+		s.evaluateShadows(safety, offchain, token, decision)
 	}
 	
-	return "low"
+	return decision, nil
 }
 
-// determineAction determines the recommended action
-func (s *StrategyEvaluatorAgent) determineAction(decision *models.StrategyDecision) string {
-	// Check if meets threshold for listing/buying
-	if decision.WinProbability >= s.config.WinProbabilityThreshold && 
-	   decision.Confidence != "low" {
-		if s.config.AutoExecute {
-			return "buy"
+// evaluateShadows runs the shadow strategies on a token and records their
+// decisions next to the live one
+func (s *StrategyEvaluatorAgent) evaluateShadows(
+	safety *models.SafetyReport,
+	offchain *models.OffChainMetrics,
+	token models.PreFilteredToken,
+	live *models.StrategyDecision,
+) {
+	shadows := make([]*models.StrategyDecision, 0, len(s.shadows))
+	for _, name := range s.shadows {
+		strategy, exists := s.registry.Get(name)
+		if !exists {
+			continue
+		}
+		decision, err := strategy.Evaluate(safety, offchain, token)
+		if err != nil {
+			log.Printf("StrategyEvaluatorAgent: Shadow %s failed on %s: %v\n", name, token.Token.TokenAddress, err)
+			continue
+		}
+		decision.Strategy = strategy.Name()
+		shadows = append(shadows, decision)
+		
+		if decision.Action != live.Action {
+			log.Printf("StrategyEvaluatorAgent: Shadow %s disagrees on %s - %s (%.2f) vs live %s (%.2f)\n",
+				decision.Strategy, token.Token.TokenAddress, decision.Action, decision.WinProbability,
+				live.Action, live.WinProbability)
 		}
-		return "list"
-	}
-	
-	if decision.WinProbability >= 0.60 {
-		return "monitor"
-	}
-	
-	return "skip"
-}
-
-// calculatePositionSize calculates suggested position size
-func (s *StrategyEvaluatorAgent) calculatePositionSize(decision *models.StrategyDecision) float64 {
-	// Kelly Criterion simplified: f = (p * b - q) / b
-	// where p = win probability, q = 1-p, b = odds (ROI)
-	
-	maxPosition := s.config.AccountBalance * s.config.SinglePositionPct
-	
-	// Adjust based on confidence
-	multiplier := 1.0
-	switch decision.Confidence {
-	case "high":
-		multiplier = 1.0
-	case "medium":
-		multiplier = 0.7
-	case "low":
-		multiplier = 0.4
 	}
 	
-	suggestedAmount := maxPosition * multiplier
-	
-	// Minimum viable position
-	if suggestedAmount < 100 {
-		suggestedAmount = 100
+	price := offchain.PriceOnDEX
+	if price == 0 {
+		price = offchain.PriceOnCEX
 	}
-	
-	return math.Round(suggestedAmount*100) / 100
+	s.shadow.Record(token.Token, price, live, shadows)
 }
 
-// calculateStopLoss calculates stop loss percentage
-func (s *StrategyEvaluatorAgent) calculateStopLoss(decision *models.StrategyDecision) float64 {
-	// Tighter stop loss for lower confidence
-	switch decision.Confidence {
-	case "high":
-		return 0.20
-	case "medium":
-		return 0.15
-	case "low":
-		return 0.10
+// route picks the strategy for a token, falling back to the default strategy
+// when a rule names a strategy that is not registered
+func (s *StrategyEvaluatorAgent) route(token models.PreFilteredToken) (Strategy, error) {
+	name := s.router.Route(token.Token)
+	if strategy, exists := s.registry.Get(name); exists {
+		return strategy, nil
 	}
-	return 0.15
-}
-
-// calculateTakeProfit calculates take profit percentage
-func (s *StrategyEvaluatorAgent) calculateTakeProfit(decision *models.StrategyDecision) float64 {
-	// Higher take profit for higher expected ROI
-	baseTP := decision.ExpectedROI * 1.5
 	
-	if baseTP < 0.20 {
-		baseTP = 0.20
-	}
-	if baseTP > 1.00 {
-		baseTP = 1.00
+	if strategy, exists := s.registry.Get(s.config.DefaultStrategy); exists {
+		log.Printf("StrategyEvaluatorAgent: Strategy %s not registered, using %s\n", name, strategy.Name())
+		return strategy, nil
 	}
 	
-	return math.Round(baseTP*100) / 100
-}
-
-// calculateTimeHorizon calculates recommended holding period
-func (s *StrategyEvaluatorAgent) calculateTimeHorizon(decision *models.StrategyDecision) int {
-	// Meme coins are typically short-term trades
-	// Base on confidence and expected ROI
-	
-	if decision.Confidence == "high" {
-		return 60 // 1 hour
-	}
-	if decision.Confidence == "medium" {
-		return 30 // 30 minutes
-	}
-	return 15 // 15 minutes for low confidence
+	return nil, fmt.Errorf("no strategy registered for %q", name)
 }
@@ -0,0 +1,614 @@
+package strategy
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/model"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		WinProbabilityThreshold: 0.8,
+		MinVolumeDEX:            10000,
+		MinLiquidity:            5000,
+		MaxHoneypotScore:        0.2,
+		AccountBalance:          10000,
+		SinglePositionPct:       0.01,
+		KellyFraction:           0.25,
+		MinPositionUSD:          10,
+		MaxSlippage:             0.05,
+		DefaultStrategy:         "heuristic",
+	}
+}
+
+func TestRouterPicksStrategyByChainAndVenue(t *testing.T) {
+	var rules []RouteRule
+	for _, route := range DefaultRoutes {
+		rule, err := ParseRouteRule(route)
+		if err != nil {
+			t.Fatalf("ParseRouteRule(%q): %v", route, err)
+		}
+		rules = append(rules, rule)
+	}
+	router := NewRouter(rules, "heuristic")
+
+	tests := []struct {
+		name  string
+		token models.TokenFound
+		want  string
+	}{
+		{
+			name:  "pump.fun launchpad tag",
+			token: models.TokenFound{Chain: models.ChainSolana, TokenAddress: "Mint111", Metadata: map[string]string{models.MetadataLaunchpad: "PumpFun"}},
+			want:  "pumpfun_sniper",
+		},
+		{
+			name:  "pump.fun vanity mint",
+			token: models.TokenFound{Chain: models.ChainSolana, TokenAddress: "9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFpump"},
+			want:  "pumpfun_sniper",
+		},
+		{
+			name:  "base v2 pair",
+			token: models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc", Metadata: map[string]string{models.MetadataDEX: "uniswap_v2"}},
+			want:  "momentum",
+		},
+		{
+			name:  "base v3 pair",
+			token: models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc", Metadata: map[string]string{models.MetadataDEX: "uniswap_v3"}},
+			want:  "heuristic",
+		},
+		{
+			name:  "raydium pool",
+			token: models.TokenFound{Chain: models.ChainSolana, TokenAddress: "Mint222", Metadata: map[string]string{models.MetadataDEX: "raydium"}},
+			want:  "heuristic",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := router.Route(tt.token); got != tt.want {
+			t.Errorf("%s: routed to %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRouteRule(t *testing.T) {
+	rule, err := ParseRouteRule(" Base = Momentum ")
+	if err != nil {
+		t.Fatalf("ParseRouteRule: %v", err)
+	}
+	if rule.Chain != models.ChainBase || rule.Venue != "" || rule.Strategy != "momentum" {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+
+	for _, invalid := range []string{"base", "base=", "=momentum"} {
+		if _, err := ParseRouteRule(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestEvaluatorRecordsStrategy(t *testing.T) {
+	cfg := testConfig()
+	router := NewRouter([]RouteRule{
+		{Chain: models.ChainSolana, Venue: "pumpfun", Strategy: "pumpfun_sniper"},
+		{Chain: models.ChainBase, Strategy: "missing"},
+	}, cfg.DefaultStrategy)
+	agent := NewStrategyEvaluatorAgentWithRouter(cfg, DefaultRegistry(cfg, nil), router)
+
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.05}
+	offchain := &models.OffChainMetrics{
+		Velocity: "stable",
+		TradeFlow: &models.TradeFlow{
+			Swaps:             120,
+			UniqueBuyers:      80,
+			BuySellRatio:      2.5,
+			SniperSupplyShare: 0.04,
+		},
+	}
+
+	pump := models.PreFilteredToken{Token: models.TokenFound{
+		Chain:            models.ChainSolana,
+		TokenAddress:     "Mint111pump",
+		InitialLiquidity: models.InitialLiquidity{ReserveNative: 30},
+	}}
+	decision, err := agent.Evaluate(safety, offchain, pump)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if decision.Strategy != "pumpfun_sniper" {
+		t.Errorf("expected pumpfun_sniper decision, got %s", decision.Strategy)
+	}
+	if decision.TimeHorizonMinutes != 10 {
+		t.Errorf("expected sniper time horizon, got %d", decision.TimeHorizonMinutes)
+	}
+
+	// Bundled launches are penalised by the sniper strategy
+	bundled := *offchain
+	bundled.TradeFlow = &models.TradeFlow{Swaps: 120, UniqueBuyers: 80, BuySellRatio: 2.5, SniperSupplyShare: 0.4}
+	bundledDecision, err := agent.Evaluate(safety, &bundled, pump)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if bundledDecision.WinProbability >= decision.WinProbability {
+		t.Errorf("expected sniper share to lower win probability: %.2f >= %.2f",
+			bundledDecision.WinProbability, decision.WinProbability)
+	}
+
+	// A route to an unregistered strategy falls back to the default
+	base := models.PreFilteredToken{Token: models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc"}}
+	decision, err = agent.Evaluate(safety, offchain, base)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if decision.Strategy != "heuristic" {
+		t.Errorf("expected fallback to heuristic, got %s", decision.Strategy)
+	}
+}
+
+func TestHeuristicRationale(t *testing.T) {
+	cfg := testConfig()
+	strategy := NewHeuristicStrategy(cfg, nil)
+
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.05, LiquidityLocked: true}
+	offchain := &models.OffChainMetrics{Volume24hDEX: 25000, Velocity: "falling"}
+	token := models.PreFilteredToken{Token: models.TokenFound{
+		InitialLiquidity: models.InitialLiquidity{ReserveNative: 50, ReserveToken: 50},
+	}}
+
+	decision, err := strategy.Evaluate(safety, offchain, token)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	deltas := make(map[string]float64)
+	sum := 0.0
+	for _, entry := range decision.Rationale {
+		deltas[entry.Factor] = entry.Delta
+		sum += entry.Delta
+	}
+	for factor, want := range map[string]float64{
+		"base": 0.5, "can_trade": 0.15, "low_honeypot_score": 0.10, "liquidity_locked": 0.08,
+		"no_transfer_restrictions": 0.05, "good_dex_volume": 0.10, "falling_velocity": -0.10,
+	} {
+		if got, exists := deltas[factor]; !exists || math.Abs(got-want) > 1e-9 {
+			t.Errorf("factor %s: delta %v (present %v), want %v", factor, got, exists, want)
+		}
+	}
+	if math.Abs(sum-decision.WinProbability) > 1e-9 {
+		t.Errorf("rationale deltas sum to %f, win probability is %f", sum, decision.WinProbability)
+	}
+
+	// Untradeable tokens explain the zero probability
+	decision, _ = strategy.Evaluate(&models.SafetyReport{CanBuy: true}, offchain, token)
+	last := decision.Rationale[len(decision.Rationale)-1]
+	if decision.WinProbability != 0 || last.Factor != "cannot_trade" || last.Delta != -0.5 {
+		t.Errorf("unexpected rejection rationale %+v", decision.Rationale)
+	}
+}
+
+func TestExampleWeightsMatchBuiltin(t *testing.T) {
+	weights, err := LoadWeights("../../../strategy_weights.example.json")
+	if err != nil {
+		t.Fatalf("LoadWeights: %v", err)
+	}
+	builtin := DefaultWeights()
+	builtin.Version = weights.Version
+	if !reflect.DeepEqual(weights, builtin) {
+		t.Errorf("example weights differ from builtin:\n%+v\n%+v", weights, builtin)
+	}
+}
+
+func TestWeightsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	write(`{"version": "v1", "factors": {"can_trade": 0.30}}`, now.Add(-time.Minute))
+	store, err := NewWeightsStore(path)
+	if err != nil {
+		t.Fatalf("NewWeightsStore: %v", err)
+	}
+
+	cfg := testConfig()
+	strategy := NewHeuristicStrategy(cfg, store)
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.15}
+	token := models.PreFilteredToken{Token: models.TokenFound{
+		InitialLiquidity: models.InitialLiquidity{ReserveNative: 50, ReserveToken: 50},
+	}}
+
+	decision, _ := strategy.Evaluate(safety, &models.OffChainMetrics{}, token)
+	if decision.ParamsVersion != "v1" {
+		t.Errorf("expected params version v1, got %q", decision.ParamsVersion)
+	}
+	// Unspecified factors keep their builtin values
+	if math.Abs(decision.WinProbability-(0.5+0.30+0.05)) > 1e-9 {
+		t.Errorf("unexpected win probability %f", decision.WinProbability)
+	}
+
+	// An invalid file is rejected and the previous weights stay in effect
+	write(`{"version": "v2", "factors": {"made_up": 1}}`, now)
+	if _, err := store.Reload(); err == nil {
+		t.Error("expected unknown factor to be rejected")
+	}
+	if got := store.Current().Version; got != "v1" {
+		t.Errorf("expected v1 to remain after a bad reload, got %s", got)
+	}
+
+	write(`{"version": "v3", "stop_loss": {"high": 0.3, "medium": 0.25, "low": 0.2}}`, now.Add(time.Minute))
+	if changed, err := store.Reload(); !changed || err != nil {
+		t.Fatalf("Reload: changed %v, err %v", changed, err)
+	}
+	decision, _ = strategy.Evaluate(safety, &models.OffChainMetrics{}, token)
+	if decision.ParamsVersion != "v3" || decision.Confidence != "medium" || decision.StopLossPct != 0.25 {
+		t.Errorf("expected v3 medium-confidence stop loss, got %s %v", decision.ParamsVersion, decision.StopLossPct)
+	}
+
+	// Unchanged files are not reloaded
+	if changed, _ := store.Reload(); changed {
+		t.Error("expected no reload for an unchanged file")
+	}
+}
+
+func TestStrategySectionsDriveSpecialisedStrategies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	content := `{"version": "v7", "strategies": {"momentum": {
+		"factors": {"thin_liquidity": -0.30},
+		"stop_loss": {"high": 0.2, "medium": 0.2, "low": 0.2},
+		"time_horizon_minutes": {"low": 45}
+	}}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	weights, err := LoadWeights(path)
+	if err != nil {
+		t.Fatalf("LoadWeights: %v", err)
+	}
+
+	momentum := NewMomentumStrategy(testConfig(), NewStaticWeightsStore(weights))
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.15}
+	decision, _ := momentum.Evaluate(safety, &models.OffChainMetrics{}, models.PreFilteredToken{})
+	if decision.ParamsVersion != "v7" || decision.StopLossPct != 0.2 || decision.TimeHorizonMinutes != 45 {
+		t.Errorf("expected v7 momentum risk table, got %s %v %d", decision.ParamsVersion, decision.StopLossPct, decision.TimeHorizonMinutes)
+	}
+	// Factors left out of the section keep their builtin values
+	if math.Abs(decision.WinProbability-(0.5-0.30)) > 1e-9 {
+		t.Errorf("unexpected win probability %f", decision.WinProbability)
+	}
+
+	weights.Strategies.PumpFun.Factors["can_trade"] = 0.1
+	if err := weights.Validate(); err == nil || !strings.Contains(err.Error(), "strategies.pumpfun_sniper.factors") {
+		t.Errorf("expected a factor the sniper does not read to be rejected, got %v", err)
+	}
+}
+
+func TestSpecialisedStrategiesReadThresholds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	content := `{"version": "v8", "thresholds": {"low_honeypot_score": 0.2}, "strategies": {
+		"momentum": {"thresholds": {"high_tax": 0.2}},
+		"pumpfun_sniper": {"thresholds": {"curve_filling_min": 0.5}}
+	}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	weights, err := LoadWeights(path)
+	if err != nil {
+		t.Fatalf("LoadWeights: %v", err)
+	}
+	factors := func(decision *models.StrategyDecision) map[string]bool {
+		present := make(map[string]bool)
+		for _, entry := range decision.Rationale {
+			present[entry.Factor] = true
+		}
+		return present
+	}
+
+	// The shared honeypot cut-off applies to momentum as it does to the heuristic
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.15}
+	safety.OwnerControls.TaxFee = 0.1
+	momentum := NewMomentumStrategy(testConfig(), NewStaticWeightsStore(weights))
+	decision, _ := momentum.Evaluate(safety, &models.OffChainMetrics{}, models.PreFilteredToken{})
+	if got := factors(decision); !got["low_honeypot_score"] || got["high_tax"] {
+		t.Errorf("momentum ignored the weights file's thresholds: %+v", decision.Rationale)
+	}
+
+	// 30% into the curve is filling by default, but not from the file's window,
+	// and the ROI prior follows the same window as the win probability
+	token := models.PreFilteredToken{Token: models.TokenFound{
+		InitialLiquidity: models.InitialLiquidity{ReserveNative: 0.3 * pumpFunGraduationSOL},
+	}}
+	safety.OwnerControls.TaxFee = 0 // costs would scale the ROI prior
+	builtin, _ := NewPumpFunSniperStrategy(testConfig(), nil).Evaluate(safety, &models.OffChainMetrics{}, token)
+	tuned, _ := NewPumpFunSniperStrategy(testConfig(), NewStaticWeightsStore(weights)).Evaluate(safety, &models.OffChainMetrics{}, token)
+	if !factors(builtin)["curve_filling"] || factors(tuned)["curve_filling"] {
+		t.Errorf("curve window not read from the weights file: builtin %+v, tuned %+v", builtin.Rationale, tuned.Rationale)
+	}
+	if roi := weights.Strategies.PumpFun.ROI["curve_filling"]; math.Abs(builtin.ExpectedROI-tuned.ExpectedROI-roi) > 1e-9 {
+		t.Errorf("expected the ROI prior to drop by %v outside the window, got %v -> %v", roi, builtin.ExpectedROI, tuned.ExpectedROI)
+	}
+
+	weights.Strategies.Momentum.Thresholds["made_up"] = 1
+	if err := weights.Validate(); err == nil || !strings.Contains(err.Error(), "strategies.momentum.thresholds") {
+		t.Errorf("expected an unknown momentum threshold to be rejected, got %v", err)
+	}
+}
+
+func TestModelStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.json")
+	trained := &model.Model{
+		Version:     "v1",
+		Type:        model.TypeLogistic,
+		Features:    []string{"honeypot_score", "log_volume_dex", "velocity"},
+		MeanWinROI:  0.4,
+		MeanLossROI: -0.15,
+		ROIStd:      0.3,
+		Logistic:    &model.Logistic{Bias: -2, Weights: []float64{-5, 0.4, 0.5}},
+	}
+	if err := trained.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.StrategyModelFile = path
+	strategy, exists := DefaultRegistry(cfg, nil).Get("model")
+	if !exists {
+		t.Fatal("expected model strategy to be registered")
+	}
+
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.05}
+	offchain := &models.OffChainMetrics{Volume24hDEX: 50000, Velocity: "rising"}
+	decision, err := strategy.Evaluate(safety, offchain, models.PreFilteredToken{})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	if decision.ParamsVersion != "model:v1" {
+		t.Errorf("unexpected params version %q", decision.ParamsVersion)
+	}
+	sum := 0.0
+	for _, entry := range decision.Rationale {
+		sum += entry.Delta
+	}
+	if math.Abs(sum-decision.WinProbability) > 1e-9 || len(decision.Rationale) != 4 {
+		t.Errorf("rationale %+v does not explain win probability %f", decision.Rationale, decision.WinProbability)
+	}
+	wantROI := decision.WinProbability*0.4 - (1-decision.WinProbability)*0.15
+	if math.Abs(decision.ExpectedROI-wantROI) > 1e-9 {
+		t.Errorf("expected ROI %f, want %f", decision.ExpectedROI, wantROI)
+	}
+}
+
+func TestEvaluatorAppliesCalibration(t *testing.T) {
+	cfg := testConfig()
+	router := NewRouter(nil, cfg.DefaultStrategy)
+	agent := NewStrategyEvaluatorAgentWithRouter(cfg, DefaultRegistry(cfg, nil), router)
+	agent.SetCalibration(&model.Calibration{
+		Version: "c1",
+		Calibrators: map[string]*model.Calibrator{
+			model.DefaultCalibrator: {Method: model.MethodIsotonic, Thresholds: []float64{0, 0.7}, Values: []float64{0.2, 0.6}},
+		},
+	})
+
+	safety := &models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: 0.05, LiquidityLocked: true}
+	offchain := &models.OffChainMetrics{Volume24hDEX: 25000}
+	token := models.PreFilteredToken{Token: models.TokenFound{
+		InitialLiquidity: models.InitialLiquidity{ReserveNative: 50, ReserveToken: 50},
+	}}
+	decision, err := agent.Evaluate(safety, offchain, token)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	if decision.RawWinProbability < 0.7 || decision.WinProbability != 0.6 || decision.CalibrationVersion != "c1" {
+		t.Errorf("unexpected calibration: raw %f, calibrated %f, version %q",
+			decision.RawWinProbability, decision.WinProbability, decision.CalibrationVersion)
+	}
+	// Confidence and action follow the calibrated probability
+	if decision.Action != "monitor" {
+		t.Errorf("expected calibrated probability to downgrade the action, got %s", decision.Action)
+	}
+	sum := 0.0
+	for _, entry := range decision.Rationale {
+		sum += entry.Delta
+	}
+	if math.Abs(sum-decision.WinProbability) > 1e-9 {
+		t.Errorf("rationale sums to %f, win probability is %f", sum, decision.WinProbability)
+	}
+}
+
+type fixedExposure float64
+
+func (e fixedExposure) RemainingExposure() float64 { return float64(e) }
+
+func TestKellySizing(t *testing.T) {
+	decision := &models.StrategyDecision{WinProbability: 0.6, StopLossPct: 0.1, TakeProfitPct: 0.3, ExpectedROI: 0.1, ExpectedROIStd: 0.5}
+	// Binary bet: 0.6/0.1 - 0.4/0.3 = 4.67; mean/variance: 0.1/0.25 = 0.4
+	if got := kellyFraction(decision); math.Abs(got-0.4) > 1e-9 {
+		t.Errorf("kellyFraction = %f, want 0.4", got)
+	}
+	losing := &models.StrategyDecision{WinProbability: 0.3, StopLossPct: 0.2, TakeProfitPct: 0.2}
+	if got := kellyFraction(losing); got != 0 {
+		t.Errorf("expected no stake without an edge, got %f", got)
+	}
+
+	cfg := testConfig()
+	cfg.SinglePositionPct = 0.5
+	base := &baseStrategy{config: cfg}
+	tests := []struct {
+		name      string
+		liquidity float64
+		exposure  ExposureProvider
+		wantSize  float64
+		wantBy    string
+		wantAct   string
+	}{
+		// 10000 * 0.25 * 0.4 = 1000
+		{name: "kelly", wantSize: 1000, wantBy: "kelly", wantAct: "list"},
+		// Depth 10000 at 5% slippage allows 10000 * 0.05 / 0.95
+		{name: "liquidity", liquidity: 20000, wantSize: 526.31, wantBy: "liquidity", wantAct: "list"},
+		{name: "exposure", exposure: fixedExposure(250), wantSize: 250, wantBy: "exposure", wantAct: "list"},
+		{name: "exhausted", exposure: fixedExposure(5), wantSize: 0, wantBy: "exposure", wantAct: "monitor"},
+	}
+	for _, tt := range tests {
+		base.exposure = tt.exposure
+		d := *decision
+		d.Action = "list"
+		base.sizePosition(&d, &models.SafetyReport{}, &models.OffChainMetrics{LiquidityUSD: tt.liquidity}, 1)
+		if d.SuggestedAmountUSD != tt.wantSize || d.SizedBy != tt.wantBy || d.Action != tt.wantAct {
+			t.Errorf("%s: size %.2f by %s action %s, want %.2f by %s action %s",
+				tt.name, d.SuggestedAmountUSD, d.SizedBy, d.Action, tt.wantSize, tt.wantBy, tt.wantAct)
+		}
+	}
+
+	// Sizes never exceed the single position limit the risk manager enforces
+	cfg.SinglePositionPct = 0.01
+	base.exposure = nil
+	d := *decision
+	base.sizePosition(&d, &models.SafetyReport{}, &models.OffChainMetrics{}, 1)
+	if d.SuggestedAmountUSD != 100 || d.SizedBy != "single_position" {
+		t.Errorf("expected single position cap, got %.2f by %s", d.SuggestedAmountUSD, d.SizedBy)
+	}
+}
+
+func TestSizingSubtractsTradeCosts(t *testing.T) {
+	cfg := testConfig()
+	cfg.SinglePositionPct = 0.5
+	base := &baseStrategy{config: cfg}
+	pool := &models.PoolState{
+		Kind:          models.PoolConstantProduct,
+		QuotePriceUSD: 1,
+		ReserveToken:  5000,
+		ReserveQuote:  5000,
+		FeeBps:        30,
+	}
+	safety := &models.SafetyReport{}
+	safety.OwnerControls.TaxFee = 0.02
+
+	d := &models.StrategyDecision{WinProbability: 0.6, StopLossPct: 0.1, TakeProfitPct: 0.6, ExpectedROI: 0.5, ExpectedROIStd: 1, Action: "list"}
+	base.sizePosition(d, safety, &models.OffChainMetrics{Pool: pool}, 1)
+
+	if d.Costs == nil || d.Costs.SizeUSD != d.SuggestedAmountUSD || d.Costs.GrossROI != 0.5 {
+		t.Fatalf("expected costs at the chosen size, got %+v", d.Costs)
+	}
+	// Tax alone takes about 4% of a round trip; impact and fees add to it
+	if d.Costs.RoundTrip <= 1-0.98*0.98 {
+		t.Errorf("round trip %f should exceed the tax", d.Costs.RoundTrip)
+	}
+	if want := 1.5*(1-d.Costs.RoundTrip) - 1; math.Abs(d.ExpectedROI-want) > 1e-9 {
+		t.Errorf("expected ROI %f, want %f net of costs", d.ExpectedROI, want)
+	}
+	// The pool caps the entry at the 5% slippage budget, well below the Kelly stake
+	if d.SizedBy != "liquidity" || d.Costs.EntryImpact > cfg.MaxSlippage+1e-9 {
+		t.Errorf("expected size capped by pool impact, got %.2f by %s (impact %f)",
+			d.SuggestedAmountUSD, d.SizedBy, d.Costs.EntryImpact)
+	}
+}
+
+// fixedStrategy returns the same decision for every token
+type fixedStrategy struct {
+	name     string
+	decision models.StrategyDecision
+}
+
+func (f *fixedStrategy) Name() string { return f.name }
+
+func (f *fixedStrategy) Evaluate(*models.SafetyReport, *models.OffChainMetrics, models.PreFilteredToken) (*models.StrategyDecision, error) {
+	decision := f.decision
+	return &decision, nil
+}
+
+type fixedPrice float64
+
+func (p *fixedPrice) Price(context.Context, models.TokenFound) (float64, error) {
+	return float64(*p), nil
+}
+
+func TestShadowStrategiesAreRecordedNotReturned(t *testing.T) {
+	cfg := testConfig()
+	cfg.DefaultTimeWindow = 15 * time.Minute
+	registry := NewRegistry()
+	registry.Register(&fixedStrategy{name: "heuristic", decision: models.StrategyDecision{
+		Action: "list", SuggestedAmountUSD: 100, TakeProfitPct: 0.5, StopLossPct: 0.2, TimeHorizonMinutes: 60,
+	}})
+	registry.Register(&fixedStrategy{name: "eager", decision: models.StrategyDecision{
+		Action: "list", SuggestedAmountUSD: 100, TakeProfitPct: 0.2, StopLossPct: 0.2, TimeHorizonMinutes: 60,
+	}})
+	registry.Register(&fixedStrategy{name: "cautious", decision: models.StrategyDecision{Action: "skip"}})
+
+	agent := NewStrategyEvaluatorAgentWithRouter(cfg, registry, NewRouter(nil, cfg.DefaultStrategy))
+	agent.SetShadowStrategies([]string{"eager", "cautious", "missing"})
+	price := fixedPrice(1.25)
+	agent.SetPriceSource(&price)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	agent.shadow.now = func() time.Time { return now }
+
+	token := models.PreFilteredToken{Token: models.TokenFound{TokenAddress: "tokenA", Chain: models.ChainBase}}
+	decision, err := agent.Evaluate(&models.SafetyReport{}, &models.OffChainMetrics{PriceOnDEX: 1}, token)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if decision.Strategy != "heuristic" || decision.Action != "list" {
+		t.Fatalf("live decision replaced by a shadow: %+v", decision)
+	}
+
+	// Up 25%: eager takes profit at 20%, live stays open
+	now = now.Add(time.Minute)
+	agent.shadow.Mark(context.Background())
+	// Down 30% from entry: live stops out at -20%
+	price = 0.7
+	now = now.Add(time.Minute)
+	agent.shadow.Mark(context.Background())
+
+	report := agent.ShadowReport()
+	if len(report.Strategies) != 3 || report.Strategies[0].Strategy != LiveStrategy {
+		t.Fatalf("unexpected strategies %+v", report.Strategies)
+	}
+	stats := make(map[string]ShadowStats)
+	for _, s := range report.Strategies {
+		stats[s.Strategy] = s
+	}
+	if live := stats[LiveStrategy]; live.Closed != 1 || live.Wins != 0 || math.Abs(live.RealizedPnLUSD+20) > 1e-9 {
+		t.Errorf("unexpected live stats %+v", live)
+	}
+	if eager := stats["eager"]; !eager.Shadow || eager.HitRate != 1 || math.Abs(eager.PnLUSD-20) > 1e-9 {
+		t.Errorf("unexpected eager stats %+v", eager)
+	}
+	if cautious := stats["cautious"]; cautious.Decisions != 1 || cautious.Trades != 0 {
+		t.Errorf("unexpected cautious stats %+v", cautious)
+	}
+
+	if len(report.Disagreements) != 1 {
+		t.Fatalf("expected one disagreement, got %+v", report.Disagreements)
+	}
+	d := report.Disagreements[0]
+	if d.Strategy != "cautious" || d.Live.ExitReason != "stop_loss" || math.Abs(d.PriceMove+0.3) > 1e-9 {
+		t.Errorf("unexpected disagreement %+v", d)
+	}
+}
+
+func TestShadowTrackerKeepsChainsApart(t *testing.T) {
+	tracker := NewShadowTracker(nil, time.Hour)
+	decision := &models.StrategyDecision{Action: "list", SuggestedAmountUSD: 100}
+	tracker.Record(models.TokenFound{Chain: models.ChainBase, TokenAddress: "tokenA"}, 1, decision, nil)
+	tracker.Record(models.TokenFound{Chain: models.ChainSolana, TokenAddress: "tokenA"}, 2, decision, nil)
+
+	report := tracker.Report()
+	if report.Tokens != 2 || report.Strategies[0].Trades != 2 {
+		t.Errorf("expected a trade per chain for the shared address, got %+v", report)
+	}
+}
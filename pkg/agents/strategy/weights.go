@@ -0,0 +1,476 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BuiltinWeightsVersion identifies the compiled-in parameter set
+const BuiltinWeightsVersion = "builtin"
+
+// Weights is a versioned parameter set for scoring and sizing decisions
+type Weights struct {
+	Version string `json:"version"`
+
+	// Factors maps each win probability factor to its delta; "base" is the starting probability
+	Factors    map[string]float64 `json:"factors"`
+	Thresholds FactorThresholds   `json:"thresholds"`
+	Confidence ConfidenceLevels   `json:"confidence"`
+
+	// Risk tables used by the heuristic and model strategies
+	RiskTable
+
+	// Strategies holds the parameters of strategies that score with their own factors
+	Strategies StrategyTables `json:"strategies"`
+}
+
+// RiskTable sets stop loss and holding period by confidence level ("high",
+// "medium", "low") and derives take profit from expected ROI
+type RiskTable struct {
+	StopLoss           map[string]float64 `json:"stop_loss"`
+	TakeProfit         TakeProfitParams   `json:"take_profit"`
+	TimeHorizonMinutes map[string]int     `json:"time_horizon_minutes"`
+}
+
+// StrategyWeights are the factor deltas and cut-offs, ROI prior, risk table
+// and size scale of one strategy. The low honeypot cut-off is shared with the
+// other strategies through Weights.Thresholds.
+type StrategyWeights struct {
+	Factors map[string]float64 `json:"factors"`
+	// Thresholds holds the input cut-offs at which the factors apply
+	Thresholds map[string]float64 `json:"thresholds"`
+	// ROI holds the expected ROI prior: "base", "std" and strategy-specific adjustments
+	ROI map[string]float64 `json:"roi"`
+	RiskTable
+	// SizeScale multiplies the Kelly stake
+	SizeScale float64 `json:"size_scale"`
+}
+
+// StrategyTables are the per-strategy sections of a weights file
+type StrategyTables struct {
+	PumpFun  StrategyWeights `json:"pumpfun_sniper"`
+	Momentum StrategyWeights `json:"momentum"`
+}
+
+// FactorThresholds are the input cut-offs at which factors apply
+type FactorThresholds struct {
+	LowHoneypotScore    float64 `json:"low_honeypot_score"`
+	SocialMentions      int     `json:"social_mentions"`
+	LiquidityBalanceMin float64 `json:"liquidity_balance_min"`
+	LiquidityBalanceMax float64 `json:"liquidity_balance_max"`
+}
+
+// ConfidenceLevels are the minimum win probability and maximum honeypot score per confidence level
+type ConfidenceLevels struct {
+	HighWinProbability     float64 `json:"high_win_probability"`
+	HighMaxHoneypotScore   float64 `json:"high_max_honeypot_score"`
+	MediumWinProbability   float64 `json:"medium_win_probability"`
+	MediumMaxHoneypotScore float64 `json:"medium_max_honeypot_score"`
+}
+
+// TakeProfitParams derive take profit from expected ROI, bounded to [Min, Max]
+type TakeProfitParams struct {
+	ROIMultiple float64 `json:"roi_multiple"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+}
+
+// DefaultWeights returns the compiled-in parameter set
+func DefaultWeights() *Weights {
+	return &Weights{
+		Version: BuiltinWeightsVersion,
+		Factors: map[string]float64{
+			"base":                     0.50,
+			"can_trade":                0.15,
+			"low_honeypot_score":       0.10,
+			"high_honeypot_score":      -0.20,
+			"liquidity_locked":         0.08,
+			"owner_renounced":          0.07,
+			"no_transfer_restrictions": 0.05,
+			"good_dex_volume":          0.10,
+			"social_activity":          0.08,
+			"rising_velocity":          0.07,
+			"falling_velocity":         -0.10,
+			"liquidity_imbalance":      -0.05,
+			"high_priority":            0.05,
+			"low_priority":             -0.05,
+		},
+		Thresholds: FactorThresholds{
+			LowHoneypotScore:    0.1,
+			SocialMentions:      50,
+			LiquidityBalanceMin: 0.3,
+			LiquidityBalanceMax: 0.7,
+		},
+		Confidence: ConfidenceLevels{
+			HighWinProbability:     0.85,
+			HighMaxHoneypotScore:   0.1,
+			MediumWinProbability:   0.70,
+			MediumMaxHoneypotScore: 0.2,
+		},
+		RiskTable: RiskTable{
+			StopLoss: map[string]float64{
+				"high":   0.20,
+				"medium": 0.15,
+				"low":    0.10,
+			},
+			TakeProfit: TakeProfitParams{
+				ROIMultiple: 1.5,
+				Min:         0.20,
+				Max:         1.00,
+			},
+			TimeHorizonMinutes: map[string]int{
+				"high":   60,
+				"medium": 30,
+				"low":    15,
+			},
+		},
+		Strategies: StrategyTables{
+			PumpFun:  defaultPumpFunWeights(),
+			Momentum: defaultMomentumWeights(),
+		},
+	}
+}
+
+// defaultPumpFunWeights are the pump.fun sniper's compiled-in parameters:
+// small, quick snipes with a wide stop to survive curve volatility
+func defaultPumpFunWeights() StrategyWeights {
+	return StrategyWeights{
+		Factors: map[string]float64{
+			"base":                  0.50,
+			"low_honeypot_score":    0.10,
+			"high_honeypot_score":   -0.20,
+			"owner_renounced":       0.05,
+			"freeze_authority":      -0.15,
+			"many_unique_buyers":    0.15,
+			"unique_buyers":         0.08,
+			"few_unique_buyers":     -0.05,
+			"buy_pressure":          0.08,
+			"sell_pressure":         -0.10,
+			"heavy_sniper_share":    -0.20,
+			"sniper_share":          -0.08,
+			"low_sniper_share":      0.05,
+			"fresh_buyer_wallets":   -0.10,
+			"no_trade_flow":         -0.05,
+			"curve_filling":         0.05,
+			"curve_near_graduation": -0.05,
+			"telegram_growth":       0.05,
+			"rising_velocity":       0.05,
+			"falling_velocity":      -0.10,
+			"high_priority":         0.05,
+			"low_priority":          -0.05,
+		},
+		Thresholds: map[string]float64{
+			"many_unique_buyers":      50,
+			"unique_buyers":           20,
+			"few_unique_buyers":       5,
+			"buy_pressure":            2,
+			"sell_pressure":           1,
+			"heavy_sniper_share":      0.25,
+			"sniper_share":            0.10,
+			"fresh_wallet_age_hours":  24,
+			"fresh_wallet_min_buyers": 5,
+			// The curve is filling between min and max and about to graduate past near_graduation
+			"curve_filling_min":     0.15,
+			"curve_filling_max":     0.70,
+			"curve_near_graduation": 0.90,
+		},
+		// Curve tokens are either rugged or run multiples; model a wide distribution
+		ROI: map[string]float64{
+			"base":               0.30,
+			"std":                0.60,
+			"many_unique_buyers": 0.15,
+			"curve_filling":      0.10,
+		},
+		RiskTable: RiskTable{
+			StopLoss:           map[string]float64{"high": 0.25, "medium": 0.25, "low": 0.25},
+			TakeProfit:         TakeProfitParams{ROIMultiple: 2, Min: 0.20, Max: 1.00},
+			TimeHorizonMinutes: map[string]int{"high": 10, "medium": 10, "low": 10},
+		},
+		SizeScale: 0.5,
+	}
+}
+
+// defaultMomentumWeights are the momentum strategy's compiled-in parameters:
+// ride the trend with a tight stop and exit once it has had time to play out
+func defaultMomentumWeights() StrategyWeights {
+	return StrategyWeights{
+		Factors: map[string]float64{
+			"base":                0.50,
+			"low_honeypot_score":  0.10,
+			"high_honeypot_score": -0.20,
+			"liquidity_locked":    0.05,
+			"owner_renounced":     0.05,
+			"high_tax":            -0.10,
+			"good_liquidity":      0.05,
+			"thin_liquidity":      -0.10,
+			"good_dex_volume":     0.10,
+			"strong_uptrend":      0.12,
+			"uptrend":             0.06,
+			"downtrend":           -0.12,
+			"accelerating":        0.05,
+			"decelerating":        -0.05,
+			"rising_velocity":     0.07,
+			"falling_velocity":    -0.10,
+			"buy_pressure":        0.06,
+			"sell_pressure":       -0.08,
+			"high_priority":       0.05,
+			"low_priority":        -0.05,
+		},
+		Thresholds: map[string]float64{
+			"high_tax":          0.05,
+			"strong_uptrend":    0.01,
+			"buy_pressure":      1.5,
+			"sell_pressure":     0.8,
+			"trend_min_samples": 3,
+		},
+		// Expected return scales with the trend, capped so one hot window cannot dominate
+		ROI: map[string]float64{
+			"base":           0.10,
+			"std":            0.30,
+			"trend_multiple": 5,
+			"trend_max":      0.30,
+		},
+		RiskTable: RiskTable{
+			StopLoss:           map[string]float64{"high": 0.12, "medium": 0.12, "low": 0.12},
+			TakeProfit:         TakeProfitParams{ROIMultiple: 2, Min: 0.25, Max: 1.00},
+			TimeHorizonMinutes: map[string]int{"high": 120, "medium": 60, "low": 60},
+		},
+		SizeScale: 1,
+	}
+}
+
+// LoadWeights reads a weights file. Values missing from the file keep their
+// built-in defaults, so a file only needs the parameters it changes.
+func LoadWeights(path string) (*Weights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := DefaultWeights()
+	weights.Version = ""
+	if err := json.Unmarshal(data, weights); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := weights.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return weights, nil
+}
+
+// Validate rejects parameter sets that are unversioned, name unknown factors
+// or have out-of-range risk parameters
+func (w *Weights) Validate() error {
+	if w.Version == "" {
+		return fmt.Errorf("weights version is required")
+	}
+
+	builtin := DefaultWeights()
+	if err := checkKnown("factors", w.Factors, builtin.Factors); err != nil {
+		return err
+	}
+	if err := w.RiskTable.validate(""); err != nil {
+		return err
+	}
+	if w.Confidence.HighWinProbability < w.Confidence.MediumWinProbability {
+		return fmt.Errorf("confidence.high_win_probability must not be below medium_win_probability")
+	}
+
+	if err := w.Strategies.PumpFun.validate("strategies.pumpfun_sniper.", builtin.Strategies.PumpFun); err != nil {
+		return err
+	}
+	return w.Strategies.Momentum.validate("strategies.momentum.", builtin.Strategies.Momentum)
+}
+
+// validate checks a strategy section against the names its strategy reads
+func (s *StrategyWeights) validate(prefix string, builtin StrategyWeights) error {
+	if err := checkKnown(prefix+"factors", s.Factors, builtin.Factors); err != nil {
+		return err
+	}
+	if err := checkKnown(prefix+"thresholds", s.Thresholds, builtin.Thresholds); err != nil {
+		return err
+	}
+	if err := checkKnown(prefix+"roi", s.ROI, builtin.ROI); err != nil {
+		return err
+	}
+	if s.ROI["std"] < 0 {
+		return fmt.Errorf("%sroi.std must not be negative", prefix)
+	}
+	if s.SizeScale <= 0 || s.SizeScale > 1 {
+		return fmt.Errorf("%ssize_scale must be in (0, 1], got %v", prefix, s.SizeScale)
+	}
+	return s.RiskTable.validate(prefix)
+}
+
+// validate rejects out-of-range risk parameters
+func (r *RiskTable) validate(prefix string) error {
+	for _, level := range []string{"high", "medium", "low"} {
+		if sl := r.StopLoss[level]; sl <= 0 || sl >= 1 {
+			return fmt.Errorf("%sstop_loss.%s must be in (0, 1), got %v", prefix, level, sl)
+		}
+		if r.TimeHorizonMinutes[level] <= 0 {
+			return fmt.Errorf("%stime_horizon_minutes.%s must be positive", prefix, level)
+		}
+	}
+	if r.TakeProfit.Min <= 0 || r.TakeProfit.Max < r.TakeProfit.Min {
+		return fmt.Errorf("%stake_profit bounds are invalid: min %v, max %v", prefix, r.TakeProfit.Min, r.TakeProfit.Max)
+	}
+	return nil
+}
+
+// checkKnown rejects names the strategy does not read
+func checkKnown(section string, values, known map[string]float64) error {
+	var unknown []string
+	for name := range values {
+		if _, exists := known[name]; !exists {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown %s %v", section, unknown)
+	}
+	return nil
+}
+
+// Clone returns a deep copy that can be modified independently
+func (w *Weights) Clone() *Weights {
+	clone := *w
+	clone.Factors = cloneFloats(w.Factors)
+	clone.RiskTable = w.RiskTable.clone()
+	clone.Strategies.PumpFun = w.Strategies.PumpFun.clone()
+	clone.Strategies.Momentum = w.Strategies.Momentum.clone()
+	return &clone
+}
+
+func (s StrategyWeights) clone() StrategyWeights {
+	s.Factors = cloneFloats(s.Factors)
+	s.Thresholds = cloneFloats(s.Thresholds)
+	s.ROI = cloneFloats(s.ROI)
+	s.RiskTable = s.RiskTable.clone()
+	return s
+}
+
+func (r RiskTable) clone() RiskTable {
+	r.StopLoss = cloneFloats(r.StopLoss)
+	minutes := make(map[string]int, len(r.TimeHorizonMinutes))
+	for level, m := range r.TimeHorizonMinutes {
+		minutes[level] = m
+	}
+	r.TimeHorizonMinutes = minutes
+	return r
+}
+
+func cloneFloats(values map[string]float64) map[string]float64 {
+	clone := make(map[string]float64, len(values))
+	for name, v := range values {
+		clone[name] = v
+	}
+	return clone
+}
+
+// Factor returns the delta for a named factor
+func (w *Weights) Factor(name string) float64 {
+	return w.Factors[name]
+}
+
+// WeightsStore serves the current weights and reloads the file when it changes
+type WeightsStore struct {
+	path    string
+	current atomic.Pointer[Weights]
+	modTime time.Time
+	mu      sync.Mutex
+}
+
+// NewWeightsStore creates a store backed by path. An empty path serves the
+// built-in weights. If the file cannot be loaded the built-in weights are
+// served and the error is returned; later reloads keep retrying.
+func NewWeightsStore(path string) (*WeightsStore, error) {
+	store := &WeightsStore{path: path}
+	store.current.Store(DefaultWeights())
+
+	if path == "" {
+		return store, nil
+	}
+	_, err := store.Reload()
+	return store, err
+}
+
+// NewStaticWeightsStore creates a store that always serves weights
+func NewStaticWeightsStore(weights *Weights) *WeightsStore {
+	store := &WeightsStore{}
+	store.current.Store(weights)
+	return store
+}
+
+// Current returns the weights in effect
+func (s *WeightsStore) Current() *Weights {
+	if s == nil {
+		return DefaultWeights()
+	}
+	return s.current.Load()
+}
+
+// Reload loads the file if it changed since the last load and reports whether
+// new weights took effect. Invalid files leave the current weights in place.
+func (s *WeightsStore) Reload() (bool, error) {
+	if s.path == "" {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+
+	weights, err := LoadWeights(s.path)
+	if err != nil {
+		// Remember the broken file so it is not re-parsed until it changes again
+		s.modTime = info.ModTime()
+		return false, err
+	}
+
+	previous := s.current.Load()
+	s.current.Store(weights)
+	s.modTime = info.ModTime()
+	log.Printf("StrategyEvaluatorAgent: Loaded strategy weights %s (was %s)\n", weights.Version, previous.Version)
+	return true, nil
+}
+
+// StartReload polls the weights file for changes until the returned channel is closed
+func (s *WeightsStore) StartReload(interval time.Duration) chan struct{} {
+	stopChan := make(chan struct{})
+	if s.path == "" || interval <= 0 {
+		return stopChan
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Reload(); err != nil {
+					log.Printf("StrategyEvaluatorAgent: Keeping strategy weights %s: %v\n", s.Current().Version, err)
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return stopChan
+}
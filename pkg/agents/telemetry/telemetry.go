@@ -24,6 +24,7 @@ type Metrics struct {
 	// Strategy metrics
 	Evaluations        int64
 	CandidatesListed   int64
+	WatchlistPromoted  int64
 	TradesExecuted     int64
 	
 	// Execution metrics
@@ -31,6 +32,10 @@ type Metrics struct {
 	ExecutionFailed    int64
 	SimulationFailed   int64
 	
+	// Execution queue metrics
+	QueueDepth         int64
+	QueueDropped       map[string]int64 // by reason: "overflow", "expired"
+	
 	// Financial metrics
 	TotalInvested      float64
 	TotalProfit        float64
@@ -51,7 +56,7 @@ type TelemetryAgent struct {
 // NewTelemetryAgent creates a new telemetry agent
 func NewTelemetryAgent() *TelemetryAgent {
 	return &TelemetryAgent{
-		metrics: &Metrics{},
+		metrics: &Metrics{QueueDropped: make(map[string]int64)},
 	}
 }
 
@@ -106,6 +111,13 @@ func (t *TelemetryAgent) RecordCandidateListed() {
 	t.metrics.CandidatesListed++
 }
 
+// RecordWatchlistPromotion increments the count of watched tokens that were listed
+func (t *TelemetryAgent) RecordWatchlistPromotion() {
+	t.metrics.mu.Lock()
+	defer t.metrics.mu.Unlock()
+	t.metrics.WatchlistPromoted++
+}
+
 // RecordExecution records trade execution result
 func (t *TelemetryAgent) RecordExecution(success bool, amount float64) {
 	t.metrics.mu.Lock()
@@ -126,6 +138,20 @@ func (t *TelemetryAgent) RecordSimulationFailure() {
 	t.metrics.SimulationFailed++
 }
 
+// RecordQueueDepth records the number of candidates awaiting execution
+func (t *TelemetryAgent) RecordQueueDepth(depth int) {
+	t.metrics.mu.Lock()
+	defer t.metrics.mu.Unlock()
+	t.metrics.QueueDepth = int64(depth)
+}
+
+// RecordQueueDrop counts a candidate dropped from the execution queue
+func (t *TelemetryAgent) RecordQueueDrop(reason string) {
+	t.metrics.mu.Lock()
+	defer t.metrics.mu.Unlock()
+	t.metrics.QueueDropped[reason]++
+}
+
 // RecordProfit records profit/loss
 func (t *TelemetryAgent) RecordProfit(profitLoss float64) {
 	t.metrics.mu.Lock()
@@ -159,6 +185,10 @@ func (t *TelemetryAgent) GetMetrics() *Metrics {
 	defer t.metrics.mu.RUnlock()
 	
 	// Return a copy
+	dropped := make(map[string]int64, len(t.metrics.QueueDropped))
+	for reason, count := range t.metrics.QueueDropped {
+		dropped[reason] = count
+	}
 	return &Metrics{
 		TokensScanned:      t.metrics.TokensScanned,
 		TokensFound:        t.metrics.TokensFound,
@@ -169,10 +199,13 @@ func (t *TelemetryAgent) GetMetrics() *Metrics {
 		SafeTokens:         t.metrics.SafeTokens,
 		Evaluations:        t.metrics.Evaluations,
 		CandidatesListed:   t.metrics.CandidatesListed,
+		WatchlistPromoted:  t.metrics.WatchlistPromoted,
 		TradesExecuted:     t.metrics.TradesExecuted,
 		ExecutionSuccess:   t.metrics.ExecutionSuccess,
 		ExecutionFailed:    t.metrics.ExecutionFailed,
 		SimulationFailed:   t.metrics.SimulationFailed,
+		QueueDepth:         t.metrics.QueueDepth,
+		QueueDropped:       dropped,
 		TotalInvested:      t.metrics.TotalInvested,
 		TotalProfit:        t.metrics.TotalProfit,
 		TotalLoss:          t.metrics.TotalLoss,
@@ -190,9 +223,12 @@ func (t *TelemetryAgent) LogMetrics() {
 	log.Printf("Tokens Filtered: %d, Dropped: %d\n", metrics.TokensFiltered, metrics.TokensDropped)
 	log.Printf("Safety Checks: %d, Honeypots: %d, Safe: %d\n", 
 		metrics.SafetyChecks, metrics.HoneypotDetected, metrics.SafeTokens)
-	log.Printf("Evaluations: %d, Candidates: %d\n", metrics.Evaluations, metrics.CandidatesListed)
+	log.Printf("Evaluations: %d, Candidates: %d (from watchlist: %d)\n",
+		metrics.Evaluations, metrics.CandidatesListed, metrics.WatchlistPromoted)
 	log.Printf("Executions: %d (Success: %d, Failed: %d)\n", 
 		metrics.TradesExecuted, metrics.ExecutionSuccess, metrics.ExecutionFailed)
+	log.Printf("Execution Queue: %d queued, dropped %d (overflow), %d (expired)\n",
+		metrics.QueueDepth, metrics.QueueDropped["overflow"], metrics.QueueDropped["expired"])
 	log.Printf("Financial: Invested: $%.2f, Profit: $%.2f, Loss: $%.2f\n",
 		metrics.TotalInvested, metrics.TotalProfit, metrics.TotalLoss)
 	log.Printf("Performance: Avg Decision: %v, Avg Execution: %v\n",
@@ -0,0 +1,387 @@
+package tradeflow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/rpc"
+)
+
+const (
+	// swapTopic is keccak256("Swap(address,uint256,uint256,uint256,uint256,address)") emitted by Uniswap V2 pairs
+	swapTopic = "0xd78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822"
+
+	selectorToken0      = "0x0dfe1681"
+	selectorToken1      = "0xd21220a7"
+	selectorDecimals    = "0x313ce567"
+	selectorTotalSupply = "0x18160ddd"
+
+	// maxLogRange bounds the block range of a single eth_getLogs request
+	maxLogRange = 2000
+	// defaultLookback is used when the pool's launch block is unknown
+	defaultLookback = 1000
+)
+
+// evmPool caches static pair metadata
+type evmPool struct {
+	tokenIsToken0 bool
+	tokenDecimals int
+	quoteDecimals int
+}
+
+// EVMSwapSource reads Uniswap V2-style Swap logs from an EVM JSON-RPC endpoint
+type EVMSwapSource struct {
+	rpc   *rpc.Client
+	pools map[string]*evmPool
+	mu    sync.Mutex
+}
+
+// NewEVMSwapSource creates a swap source for the given RPC endpoint
+func NewEVMSwapSource(rpcURL string) *EVMSwapSource {
+	return &EVMSwapSource{
+		rpc:   rpc.NewClient(rpcURL),
+		pools: make(map[string]*evmPool),
+	}
+}
+
+// LaunchBlock returns the block of the token's discovery transaction
+func (s *EVMSwapSource) LaunchBlock(ctx context.Context, token models.TokenFound) (uint64, error) {
+	if token.TxHash == "" {
+		return 0, fmt.Errorf("no creation transaction")
+	}
+
+	var receipt struct {
+		BlockNumber string `json:"blockNumber"`
+	}
+	if err := s.rpc.Call(ctx, "eth_getTransactionReceipt", []interface{}{token.TxHash}, &receipt); err != nil {
+		return 0, err
+	}
+	if receipt.BlockNumber == "" {
+		return 0, fmt.Errorf("receipt not found")
+	}
+	return parseQuantity(receipt.BlockNumber)
+}
+
+// TotalSupply returns the ERC20 total supply in token units
+func (s *EVMSwapSource) TotalSupply(ctx context.Context, token models.TokenFound) (float64, error) {
+	pool, err := s.pool(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := s.call(ctx, token.TokenAddress, selectorTotalSupply)
+	if err != nil {
+		return 0, err
+	}
+	return scale(word(raw, 0), pool.tokenDecimals), nil
+}
+
+// Swaps returns swaps on the token's pair after the cursor block
+func (s *EVMSwapSource) Swaps(ctx context.Context, token models.TokenFound, cursor string) ([]models.SwapEvent, string, error) {
+	pool, err := s.pool(ctx, token)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	var latestHex string
+	if err := s.rpc.Call(ctx, "eth_blockNumber", nil, &latestHex); err != nil {
+		return nil, cursor, err
+	}
+	latest, err := parseQuantity(latestHex)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	var from uint64
+	if cursor != "" {
+		if from, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			return nil, cursor, fmt.Errorf("invalid cursor %q", cursor)
+		}
+	} else if from, err = s.LaunchBlock(ctx, token); err != nil || from == 0 {
+		from = 0
+		if latest > defaultLookback {
+			from = latest - defaultLookback
+		}
+	}
+	if from > latest {
+		return nil, cursor, nil
+	}
+	to := latest
+	if to-from >= maxLogRange {
+		to = from + maxLogRange - 1
+	}
+
+	var logs []struct {
+		Topics          []string `json:"topics"`
+		Data            string   `json:"data"`
+		BlockNumber     string   `json:"blockNumber"`
+		TransactionHash string   `json:"transactionHash"`
+	}
+	filter := map[string]interface{}{
+		"address":   token.InitialLiquidity.Pair,
+		"topics":    []string{swapTopic},
+		"fromBlock": formatQuantity(from),
+		"toBlock":   formatQuantity(to),
+	}
+	if err := s.rpc.Call(ctx, "eth_getLogs", []interface{}{filter}, &logs); err != nil {
+		return nil, cursor, err
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	senders := make(map[string]string)
+
+	events := make([]models.SwapEvent, 0, len(logs))
+	for _, l := range logs {
+		data := decodeHex(l.Data)
+		if len(data) < 128 {
+			continue
+		}
+		amount0In, amount1In := word(data, 0), word(data, 1)
+		amount0Out, amount1Out := word(data, 2), word(data, 3)
+
+		tokenIn, tokenOut, quoteIn, quoteOut := amount0In, amount0Out, amount1In, amount1Out
+		if !pool.tokenIsToken0 {
+			tokenIn, tokenOut, quoteIn, quoteOut = amount1In, amount1Out, amount0In, amount0Out
+		}
+
+		block, _ := parseQuantity(l.BlockNumber)
+		event := models.SwapEvent{
+			Chain:        token.Chain,
+			TokenAddress: token.TokenAddress,
+			Pool:         token.InitialLiquidity.Pair,
+			Block:        block,
+			TxHash:       l.TransactionHash,
+		}
+
+		if tokenOut.Sign() > 0 {
+			event.Side = "buy"
+			event.AmountToken = scale(tokenOut, pool.tokenDecimals)
+			event.AmountNative = scale(quoteIn, pool.quoteDecimals)
+		} else {
+			event.Side = "sell"
+			event.AmountToken = scale(tokenIn, pool.tokenDecimals)
+			event.AmountNative = scale(quoteOut, pool.quoteDecimals)
+		}
+
+		// Router swaps make the router the Swap sender, so attribute trades to the transaction origin
+		if event.Trader, err = s.sender(ctx, l.TransactionHash, senders); err != nil {
+			return nil, cursor, err
+		}
+		if event.Timestamp, err = s.blockTime(ctx, block, blockTimes); err != nil {
+			return nil, cursor, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, strconv.FormatUint(to+1, 10), nil
+}
+
+// pool loads and caches the pair's token ordering and decimals
+func (s *EVMSwapSource) pool(ctx context.Context, token models.TokenFound) (*evmPool, error) {
+	pair := strings.ToLower(token.InitialLiquidity.Pair)
+
+	s.mu.Lock()
+	cached, exists := s.pools[pair]
+	s.mu.Unlock()
+	if exists {
+		return cached, nil
+	}
+
+	raw0, err := s.call(ctx, pair, selectorToken0)
+	if err != nil {
+		return nil, err
+	}
+	raw1, err := s.call(ctx, pair, selectorToken1)
+	if err != nil {
+		return nil, err
+	}
+
+	token0, token1 := addressFromWord(raw0), addressFromWord(raw1)
+	pool := &evmPool{tokenIsToken0: strings.EqualFold(token0, token.TokenAddress)}
+	quote := token1
+	if !pool.tokenIsToken0 {
+		if !strings.EqualFold(token1, token.TokenAddress) {
+			return nil, fmt.Errorf("pair %s does not contain token %s", pair, token.TokenAddress)
+		}
+		quote = token0
+	}
+
+	if pool.tokenDecimals, err = s.decimals(ctx, token.TokenAddress); err != nil {
+		return nil, err
+	}
+	if pool.quoteDecimals, err = s.decimals(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.pools[pair] = pool
+	s.mu.Unlock()
+	return pool, nil
+}
+
+func (s *EVMSwapSource) decimals(ctx context.Context, address string) (int, error) {
+	raw, err := s.call(ctx, address, selectorDecimals)
+	if err != nil {
+		return 0, err
+	}
+	return int(word(raw, 0).Int64()), nil
+}
+
+// call performs an eth_call with no arguments beyond the selector
+func (s *EVMSwapSource) call(ctx context.Context, to, selector string) ([]byte, error) {
+	var result string
+	msg := map[string]string{"to": to, "data": selector}
+	if err := s.rpc.Call(ctx, "eth_call", []interface{}{msg, "latest"}, &result); err != nil {
+		return nil, err
+	}
+	raw := decodeHex(result)
+	if len(raw) < 32 {
+		return nil, fmt.Errorf("eth_call %s on %s returned %d bytes", selector, to, len(raw))
+	}
+	return raw, nil
+}
+
+func (s *EVMSwapSource) sender(ctx context.Context, txHash string, cache map[string]string) (string, error) {
+	if from, ok := cache[txHash]; ok {
+		return from, nil
+	}
+	var tx struct {
+		From string `json:"from"`
+	}
+	if err := s.rpc.Call(ctx, "eth_getTransactionByHash", []interface{}{txHash}, &tx); err != nil {
+		return "", err
+	}
+	from := strings.ToLower(tx.From)
+	cache[txHash] = from
+	return from, nil
+}
+
+func (s *EVMSwapSource) blockTime(ctx context.Context, block uint64, cache map[uint64]time.Time) (time.Time, error) {
+	if ts, ok := cache[block]; ok {
+		return ts, nil
+	}
+	ts, err := evmBlockTime(ctx, s.rpc, block)
+	if err != nil {
+		return time.Time{}, err
+	}
+	cache[block] = ts
+	return ts, nil
+}
+
+func evmBlockTime(ctx context.Context, client *rpc.Client, block uint64) (time.Time, error) {
+	var header struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := client.Call(ctx, "eth_getBlockByNumber", []interface{}{formatQuantity(block), false}, &header); err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := parseQuantity(header.Timestamp)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// EVMWalletAger finds a wallet's first outgoing transaction by binary searching
+// its nonce over historical blocks. It requires an archive node; lookups that
+// fail are skipped by the tracker.
+type EVMWalletAger struct {
+	rpc *rpc.Client
+}
+
+// NewEVMWalletAger creates a wallet ager for the given RPC endpoint
+func NewEVMWalletAger(rpcURL string) *EVMWalletAger {
+	return &EVMWalletAger{rpc: rpc.NewClient(rpcURL)}
+}
+
+// FirstSeen returns the time of the block containing the wallet's first transaction
+func (a *EVMWalletAger) FirstSeen(ctx context.Context, wallet string) (time.Time, error) {
+	nonceAt := func(block string) (uint64, error) {
+		var nonce string
+		if err := a.rpc.Call(ctx, "eth_getTransactionCount", []interface{}{wallet, block}, &nonce); err != nil {
+			return 0, err
+		}
+		return parseQuantity(nonce)
+	}
+
+	var latestHex string
+	if err := a.rpc.Call(ctx, "eth_blockNumber", nil, &latestHex); err != nil {
+		return time.Time{}, err
+	}
+	latest, err := parseQuantity(latestHex)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if nonce, err := nonceAt(formatQuantity(latest)); err != nil || nonce == 0 {
+		// Wallets that never sent a transaction have no age to report
+		return time.Time{}, err
+	}
+
+	lo, hi := uint64(0), latest
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		nonce, err := nonceAt(formatQuantity(mid))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if nonce > 0 {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return evmBlockTime(ctx, a.rpc, lo)
+}
+
+// parseQuantity decodes a hex-encoded JSON-RPC quantity
+func parseQuantity(value string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+}
+
+func formatQuantity(value uint64) string {
+	return "0x" + strconv.FormatUint(value, 16)
+}
+
+func decodeHex(value string) []byte {
+	value = strings.TrimPrefix(value, "0x")
+	if len(value)%2 == 1 {
+		value = "0" + value
+	}
+	out := make([]byte, len(value)/2)
+	for i := range out {
+		b, err := strconv.ParseUint(value[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil
+		}
+		out[i] = byte(b)
+	}
+	return out
+}
+
+// word returns the i-th 32-byte ABI word as an unsigned integer
+func word(data []byte, i int) *big.Int {
+	start := i * 32
+	if len(data) < start+32 {
+		return new(big.Int)
+	}
+	return new(big.Int).SetBytes(data[start : start+32])
+}
+
+func addressFromWord(data []byte) string {
+	return fmt.Sprintf("0x%x", data[12:32])
+}
+
+// scale converts a raw integer amount to units using the token decimals
+func scale(amount *big.Int, decimals int) float64 {
+	f, _ := new(big.Float).SetInt(amount).Float64()
+	return f / math.Pow10(decimals)
+}
@@ -0,0 +1,282 @@
+package tradeflow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/rpc"
+)
+
+const (
+	wrappedSOLMint = "So11111111111111111111111111111111111111112"
+	lamportsPerSOL = 1e9
+
+	// maxSignaturesPerPoll bounds the transactions decoded per poll; the rest are picked up next time
+	maxSignaturesPerPoll = 200
+	// signaturePageSize is the largest page getSignaturesForAddress returns
+	signaturePageSize = 1000
+	// maxAgePages bounds signature pages walked when dating a wallet
+	maxAgePages = 3
+)
+
+// SolanaSwapSource derives swaps from token balance changes of transactions touching the pool
+type SolanaSwapSource struct {
+	rpc *rpc.Client
+}
+
+// NewSolanaSwapSource creates a swap source for the given RPC endpoint
+func NewSolanaSwapSource(rpcURL string) *SolanaSwapSource {
+	return &SolanaSwapSource{rpc: rpc.NewClient(rpcURL)}
+}
+
+// signatureInfo is an entry of getSignaturesForAddress
+type signatureInfo struct {
+	Signature string      `json:"signature"`
+	Slot      uint64      `json:"slot"`
+	Err       interface{} `json:"err"`
+	BlockTime *int64      `json:"blockTime"`
+}
+
+// tokenBalance is an entry of pre/postTokenBalances
+type tokenBalance struct {
+	AccountIndex  int    `json:"accountIndex"`
+	Mint          string `json:"mint"`
+	Owner         string `json:"owner"`
+	UITokenAmount struct {
+		UIAmountString string `json:"uiAmountString"`
+	} `json:"uiTokenAmount"`
+}
+
+// parsedTransaction is the subset of a jsonParsed getTransaction result we need
+type parsedTransaction struct {
+	Slot      uint64 `json:"slot"`
+	BlockTime *int64 `json:"blockTime"`
+	Meta      *struct {
+		Err               interface{}    `json:"err"`
+		Fee               uint64         `json:"fee"`
+		PreBalances       []uint64       `json:"preBalances"`
+		PostBalances      []uint64       `json:"postBalances"`
+		PreTokenBalances  []tokenBalance `json:"preTokenBalances"`
+		PostTokenBalances []tokenBalance `json:"postTokenBalances"`
+	} `json:"meta"`
+	Transaction struct {
+		Message struct {
+			AccountKeys []struct {
+				Pubkey string `json:"pubkey"`
+				Signer bool   `json:"signer"`
+			} `json:"accountKeys"`
+		} `json:"message"`
+	} `json:"transaction"`
+}
+
+// LaunchBlock returns the slot of the token's discovery transaction
+func (s *SolanaSwapSource) LaunchBlock(ctx context.Context, token models.TokenFound) (uint64, error) {
+	if token.TxHash == "" {
+		return 0, fmt.Errorf("no creation transaction")
+	}
+	tx, err := s.transaction(ctx, token.TxHash)
+	if err != nil {
+		return 0, err
+	}
+	if tx == nil {
+		return 0, fmt.Errorf("transaction not found")
+	}
+	return tx.Slot, nil
+}
+
+// TotalSupply returns the SPL token supply in token units
+func (s *SolanaSwapSource) TotalSupply(ctx context.Context, token models.TokenFound) (float64, error) {
+	var supply struct {
+		Value struct {
+			UIAmountString string `json:"uiAmountString"`
+		} `json:"value"`
+	}
+	if err := s.rpc.Call(ctx, "getTokenSupply", []interface{}{token.TokenAddress}, &supply); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(supply.Value.UIAmountString, 64)
+}
+
+// Swaps returns swaps on the pool after the cursor signature. Without a
+// cursor the pool is read from the token's creation transaction onwards.
+func (s *SolanaSwapSource) Swaps(ctx context.Context, token models.TokenFound, cursor string) ([]models.SwapEvent, string, error) {
+	until := cursor
+	if until == "" {
+		until = token.TxHash
+	}
+	signatures, err := s.signaturesSince(ctx, token.InitialLiquidity.Pair, until)
+	if err != nil {
+		return nil, cursor, err
+	}
+	if len(signatures) == 0 {
+		return nil, cursor, nil
+	}
+
+	// Signatures come newest first; process the oldest batch in chronological order
+	start := len(signatures) - 1
+	end := 0
+	if len(signatures) > maxSignaturesPerPoll {
+		end = len(signatures) - maxSignaturesPerPoll
+	}
+
+	var events []models.SwapEvent
+	next := cursor
+	for i := start; i >= end; i-- {
+		sig := signatures[i]
+		next = sig.Signature
+		if sig.Err != nil {
+			continue
+		}
+
+		tx, err := s.transaction(ctx, sig.Signature)
+		if err != nil {
+			return events, next, err
+		}
+		if event, ok := decodeSolanaSwap(token, sig.Signature, tx); ok {
+			events = append(events, event)
+		}
+	}
+
+	return events, next, nil
+}
+
+// signaturesSince pages backwards through the address's signatures, newest
+// first, until it reaches the until signature (exclusive) or the start of
+// the address's history
+func (s *SolanaSwapSource) signaturesSince(ctx context.Context, address, until string) ([]signatureInfo, error) {
+	var all []signatureInfo
+	before := ""
+	for {
+		opts := map[string]interface{}{"limit": signaturePageSize, "commitment": "confirmed"}
+		if until != "" {
+			opts["until"] = until
+		}
+		if before != "" {
+			opts["before"] = before
+		}
+
+		var page []signatureInfo
+		if err := s.rpc.Call(ctx, "getSignaturesForAddress", []interface{}{address, opts}, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < signaturePageSize {
+			return all, nil
+		}
+		before = page[len(page)-1].Signature
+	}
+}
+
+func (s *SolanaSwapSource) transaction(ctx context.Context, signature string) (*parsedTransaction, error) {
+	var tx *parsedTransaction
+	opts := map[string]interface{}{
+		"encoding":                       "jsonParsed",
+		"maxSupportedTransactionVersion": 0,
+		"commitment":                     "confirmed",
+	}
+	if err := s.rpc.Call(ctx, "getTransaction", []interface{}{signature, opts}, &tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// decodeSolanaSwap classifies a transaction by the fee payer's balance changes:
+// gaining the token is a buy, losing it is a sell. The native amount is the
+// payer's SOL and wrapped SOL change net of the network fee.
+func decodeSolanaSwap(token models.TokenFound, signature string, tx *parsedTransaction) (models.SwapEvent, bool) {
+	if tx == nil || tx.Meta == nil || tx.Meta.Err != nil || len(tx.Transaction.Message.AccountKeys) == 0 {
+		return models.SwapEvent{}, false
+	}
+	trader := tx.Transaction.Message.AccountKeys[0].Pubkey
+
+	balanceOf := func(balances []tokenBalance, mint string) float64 {
+		total := 0.0
+		for _, b := range balances {
+			if b.Mint == mint && b.Owner == trader {
+				amount, _ := strconv.ParseFloat(b.UITokenAmount.UIAmountString, 64)
+				total += amount
+			}
+		}
+		return total
+	}
+
+	tokenDelta := balanceOf(tx.Meta.PostTokenBalances, token.TokenAddress) - balanceOf(tx.Meta.PreTokenBalances, token.TokenAddress)
+	if tokenDelta == 0 {
+		return models.SwapEvent{}, false
+	}
+
+	nativeDelta := balanceOf(tx.Meta.PostTokenBalances, wrappedSOLMint) - balanceOf(tx.Meta.PreTokenBalances, wrappedSOLMint)
+	if len(tx.Meta.PreBalances) > 0 && len(tx.Meta.PostBalances) > 0 {
+		lamports := int64(tx.Meta.PostBalances[0]) - int64(tx.Meta.PreBalances[0]) + int64(tx.Meta.Fee)
+		nativeDelta += float64(lamports) / lamportsPerSOL
+	}
+
+	event := models.SwapEvent{
+		Chain:        token.Chain,
+		TokenAddress: token.TokenAddress,
+		Pool:         token.InitialLiquidity.Pair,
+		Trader:       trader,
+		Side:         "buy",
+		AmountToken:  math.Abs(tokenDelta),
+		AmountNative: math.Abs(nativeDelta),
+		Block:        tx.Slot,
+		TxHash:       signature,
+	}
+	if tokenDelta < 0 {
+		event.Side = "sell"
+	}
+	if tx.BlockTime != nil {
+		event.Timestamp = time.Unix(*tx.BlockTime, 0)
+	}
+	return event, true
+}
+
+// SolanaWalletAger dates a wallet by its oldest transaction signature
+type SolanaWalletAger struct {
+	rpc *rpc.Client
+}
+
+// NewSolanaWalletAger creates a wallet ager for the given RPC endpoint
+func NewSolanaWalletAger(rpcURL string) *SolanaWalletAger {
+	return &SolanaWalletAger{rpc: rpc.NewClient(rpcURL)}
+}
+
+// FirstSeen returns the block time of the oldest signature found for the wallet.
+// Very active wallets are only walked back a few pages, so their age is a lower bound.
+func (a *SolanaWalletAger) FirstSeen(ctx context.Context, wallet string) (time.Time, error) {
+	var oldest *int64
+	before := ""
+
+	for page := 0; page < maxAgePages; page++ {
+		opts := map[string]interface{}{"limit": signaturePageSize}
+		if before != "" {
+			opts["before"] = before
+		}
+
+		var signatures []signatureInfo
+		if err := a.rpc.Call(ctx, "getSignaturesForAddress", []interface{}{wallet, opts}, &signatures); err != nil {
+			return time.Time{}, err
+		}
+		if len(signatures) == 0 {
+			break
+		}
+
+		last := signatures[len(signatures)-1]
+		if last.BlockTime != nil {
+			oldest = last.BlockTime
+		}
+		if len(signatures) < signaturePageSize {
+			break
+		}
+		before = last.Signature
+	}
+
+	if oldest == nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(*oldest, 0), nil
+}
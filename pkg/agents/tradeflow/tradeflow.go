@@ -0,0 +1,312 @@
+package tradeflow
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// maxAgedBuyers bounds how many buyer wallets per token are looked up for wallet age
+const maxAgedBuyers = 25
+
+// maxBuySellRatio is reported when a pool has buys but no sells yet
+const maxBuySellRatio = 100.0
+
+// SwapSource reads swap events for a token's pool from a chain
+type SwapSource interface {
+	// LaunchBlock returns the block (or slot) in which the pool was created
+	LaunchBlock(ctx context.Context, token models.TokenFound) (uint64, error)
+	// Swaps returns swaps after cursor in chronological order and the cursor to resume from.
+	// An empty cursor starts at the pool's launch.
+	Swaps(ctx context.Context, token models.TokenFound, cursor string) ([]models.SwapEvent, string, error)
+	// TotalSupply returns the token's total supply in token units
+	TotalSupply(ctx context.Context, token models.TokenFound) (float64, error)
+}
+
+// WalletAger reports when a wallet was first active on chain
+type WalletAger interface {
+	FirstSeen(ctx context.Context, wallet string) (time.Time, error)
+}
+
+// tokenFlow accumulates swap statistics for one token
+type tokenFlow struct {
+	token        models.TokenFound
+	launchBlock  uint64
+	supply       float64
+	cursor       string
+	buyers       map[string]bool
+	sellers      map[string]bool
+	buyVolume    float64
+	sellVolume   float64
+	sizes        []float64
+	swaps        int
+	sniperBought float64
+	buyerAges    []float64 // hours
+	agedWallets  int
+	expiresAt    time.Time
+	mu           sync.Mutex
+}
+
+// TradeFlowTracker follows swap events on token pools and summarizes the flow
+type TradeFlowTracker struct {
+	config  *config.Config
+	sources map[models.Chain]SwapSource
+	agers   map[models.Chain]WalletAger
+	flows   map[string]*tokenFlow
+	mu      sync.Mutex
+}
+
+// NewTradeFlowTracker creates a tracker backed by the chain RPC endpoints in cfg
+func NewTradeFlowTracker(cfg *config.Config) *TradeFlowTracker {
+	tracker := NewTradeFlowTrackerWithSources(cfg, map[models.Chain]SwapSource{
+		models.ChainBase:   NewEVMSwapSource(cfg.BaseRPCURL),
+		models.ChainSolana: NewSolanaSwapSource(cfg.SolanaRPCURL),
+	})
+	tracker.SetWalletAger(models.ChainBase, NewEVMWalletAger(cfg.BaseRPCURL))
+	tracker.SetWalletAger(models.ChainSolana, NewSolanaWalletAger(cfg.SolanaRPCURL))
+	return tracker
+}
+
+// NewTradeFlowTrackerWithSources creates a tracker with explicit swap sources
+func NewTradeFlowTrackerWithSources(cfg *config.Config, sources map[models.Chain]SwapSource) *TradeFlowTracker {
+	return &TradeFlowTracker{
+		config:  cfg,
+		sources: sources,
+		agers:   make(map[models.Chain]WalletAger),
+		flows:   make(map[string]*tokenFlow),
+	}
+}
+
+// SetWalletAger sets the wallet age lookup for a chain
+func (t *TradeFlowTracker) SetWalletAger(chain models.Chain, ager WalletAger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.agers[chain] = ager
+}
+
+func flowKey(token models.TokenFound) string {
+	return string(token.Chain) + ":" + token.TokenAddress
+}
+
+// Follow starts following a token's pool for the configured time window.
+// The first poll runs synchronously so a snapshot is available immediately;
+// later polls run in the background until ctx is done or the window closes.
+func (t *TradeFlowTracker) Follow(ctx context.Context, token models.TokenFound) {
+	source, exists := t.sources[token.Chain]
+	if !exists || token.InitialLiquidity.Pair == "" {
+		return
+	}
+
+	t.mu.Lock()
+	t.evictExpired()
+	if _, following := t.flows[flowKey(token)]; following {
+		t.mu.Unlock()
+		return
+	}
+	flow := &tokenFlow{
+		token:     token,
+		buyers:    make(map[string]bool),
+		sellers:   make(map[string]bool),
+		expiresAt: time.Now().Add(t.config.DefaultTimeWindow),
+	}
+	t.flows[flowKey(token)] = flow
+	ager := t.agers[token.Chain]
+	t.mu.Unlock()
+
+	log.Printf("TradeFlowTracker: Following swaps for %s on %s\n", token.TokenAddress, token.Chain)
+
+	timeout := t.config.ProviderTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	initCtx, cancel := context.WithTimeout(ctx, timeout)
+	t.initialize(initCtx, source, flow)
+	newBuyers := t.poll(initCtx, source, flow)
+	cancel()
+
+	// Wallet age lookups can take many RPC calls, so they never delay the first snapshot
+	go t.ageBuyers(ctx, ager, flow, newBuyers)
+
+	go func() {
+		interval := t.config.TradeFlowPollInterval
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if now.After(flow.expiresAt) {
+					log.Printf("TradeFlowTracker: Stopped following %s\n", token.TokenAddress)
+					return
+				}
+				t.ageBuyers(ctx, ager, flow, t.poll(ctx, source, flow))
+			}
+		}
+	}()
+}
+
+// evictExpired drops flows whose window closed more than a window ago; t.mu must be held
+func (t *TradeFlowTracker) evictExpired() {
+	now := time.Now()
+	for key, flow := range t.flows {
+		if now.After(flow.expiresAt.Add(t.config.DefaultTimeWindow)) {
+			delete(t.flows, key)
+		}
+	}
+}
+
+// initialize loads the launch block and total supply for a flow
+func (t *TradeFlowTracker) initialize(ctx context.Context, source SwapSource, flow *tokenFlow) {
+	launch, err := source.LaunchBlock(ctx, flow.token)
+	if err != nil {
+		log.Printf("TradeFlowTracker: Launch block unavailable for %s: %v\n", flow.token.TokenAddress, err)
+	}
+	supply, err := source.TotalSupply(ctx, flow.token)
+	if err != nil {
+		log.Printf("TradeFlowTracker: Total supply unavailable for %s: %v\n", flow.token.TokenAddress, err)
+	}
+
+	flow.mu.Lock()
+	flow.launchBlock = launch
+	flow.supply = supply
+	flow.mu.Unlock()
+}
+
+// poll fetches new swaps for a flow, folds them into its statistics and
+// returns the first swap of each new buyer that should be aged
+func (t *TradeFlowTracker) poll(ctx context.Context, source SwapSource, flow *tokenFlow) []models.SwapEvent {
+	flow.mu.Lock()
+	cursor := flow.cursor
+	flow.mu.Unlock()
+
+	events, next, err := source.Swaps(ctx, flow.token, cursor)
+	if err != nil {
+		log.Printf("TradeFlowTracker: Failed to fetch swaps for %s: %v\n", flow.token.TokenAddress, err)
+		return nil
+	}
+
+	var newBuyers []models.SwapEvent
+
+	flow.mu.Lock()
+	defer flow.mu.Unlock()
+
+	flow.cursor = next
+	for _, event := range events {
+		newBuyer := flow.ingest(event, t.config.SniperBlocks)
+		if newBuyer && flow.agedWallets < maxAgedBuyers {
+			flow.agedWallets++
+			newBuyers = append(newBuyers, event)
+		}
+	}
+	return newBuyers
+}
+
+// ageBuyers looks up the wallet age of new buyers at the time of their first buy
+func (t *TradeFlowTracker) ageBuyers(ctx context.Context, ager WalletAger, flow *tokenFlow, buyers []models.SwapEvent) {
+	if ager == nil {
+		return
+	}
+	for _, event := range buyers {
+		firstSeen, err := ager.FirstSeen(ctx, event.Trader)
+		if err != nil || firstSeen.IsZero() {
+			continue
+		}
+		age := event.Timestamp.Sub(firstSeen).Hours()
+		if age < 0 {
+			age = 0
+		}
+
+		flow.mu.Lock()
+		flow.buyerAges = append(flow.buyerAges, age)
+		flow.mu.Unlock()
+	}
+}
+
+// ingest folds one swap into the flow and reports whether it came from a new buyer; flow.mu must be held
+func (f *tokenFlow) ingest(event models.SwapEvent, sniperBlocks int) bool {
+	f.swaps++
+	f.sizes = append(f.sizes, event.AmountNative)
+
+	switch event.Side {
+	case "buy":
+		f.buyVolume += event.AmountNative
+		if f.launchBlock > 0 && event.Block < f.launchBlock+uint64(sniperBlocks) {
+			f.sniperBought += event.AmountToken
+		}
+		if !f.buyers[event.Trader] {
+			f.buyers[event.Trader] = true
+			return true
+		}
+	case "sell":
+		f.sellVolume += event.AmountNative
+		f.sellers[event.Trader] = true
+	}
+	return false
+}
+
+// Snapshot returns the current trade flow for a token, if it is being followed
+func (t *TradeFlowTracker) Snapshot(token models.TokenFound) (*models.TradeFlow, bool) {
+	t.mu.Lock()
+	flow, exists := t.flows[flowKey(token)]
+	t.mu.Unlock()
+	if !exists {
+		return nil, false
+	}
+
+	flow.mu.Lock()
+	defer flow.mu.Unlock()
+
+	snapshot := &models.TradeFlow{
+		Swaps:           flow.swaps,
+		UniqueBuyers:    len(flow.buyers),
+		UniqueSellers:   len(flow.sellers),
+		BuyVolume:       flow.buyVolume,
+		SellVolume:      flow.sellVolume,
+		MedianTradeSize: median(flow.sizes),
+		SniperBlocks:    t.config.SniperBlocks,
+		BuyersAged:      len(flow.buyerAges),
+	}
+
+	switch {
+	case flow.sellVolume > 0:
+		snapshot.BuySellRatio = flow.buyVolume / flow.sellVolume
+		if snapshot.BuySellRatio > maxBuySellRatio {
+			snapshot.BuySellRatio = maxBuySellRatio
+		}
+	case flow.buyVolume > 0:
+		snapshot.BuySellRatio = maxBuySellRatio
+	}
+
+	if flow.supply > 0 {
+		snapshot.SniperSupplyShare = flow.sniperBought / flow.supply
+	}
+	if len(flow.buyerAges) > 0 {
+		snapshot.MedianBuyerWalletAgeHours = median(flow.buyerAges)
+	}
+
+	return snapshot, true
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,240 @@
+package tradeflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+type fakeSource struct {
+	launch uint64
+	supply float64
+	events []models.SwapEvent
+}
+
+func (f *fakeSource) LaunchBlock(ctx context.Context, token models.TokenFound) (uint64, error) {
+	return f.launch, nil
+}
+
+func (f *fakeSource) TotalSupply(ctx context.Context, token models.TokenFound) (float64, error) {
+	return f.supply, nil
+}
+
+func (f *fakeSource) Swaps(ctx context.Context, token models.TokenFound, cursor string) ([]models.SwapEvent, string, error) {
+	if cursor != "" {
+		return nil, cursor, nil
+	}
+	return f.events, "done", nil
+}
+
+type fakeAger map[string]time.Time
+
+func (f fakeAger) FirstSeen(ctx context.Context, wallet string) (time.Time, error) {
+	return f[wallet], nil
+}
+
+func TestTradeFlowSnapshot(t *testing.T) {
+	launchTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	swap := func(trader, side string, block uint64, tokens, native float64) models.SwapEvent {
+		return models.SwapEvent{
+			Trader:       trader,
+			Side:         side,
+			Block:        block,
+			AmountToken:  tokens,
+			AmountNative: native,
+			Timestamp:    launchTime,
+		}
+	}
+
+	source := &fakeSource{
+		launch: 100,
+		supply: 1_000_000,
+		events: []models.SwapEvent{
+			swap("sniper1", "buy", 100, 50_000, 1.0),
+			swap("sniper2", "buy", 102, 30_000, 0.6),
+			swap("alice", "buy", 103, 10_000, 0.3),
+			swap("alice", "buy", 110, 5_000, 0.2),
+			swap("sniper1", "sell", 111, 50_000, 1.5),
+		},
+	}
+
+	cfg := &config.Config{SniperBlocks: 3, DefaultTimeWindow: time.Minute, ProviderTimeout: time.Second}
+	tracker := NewTradeFlowTrackerWithSources(cfg, map[models.Chain]SwapSource{models.ChainBase: source})
+	tracker.SetWalletAger(models.ChainBase, fakeAger{
+		"sniper1": launchTime.Add(-2 * time.Hour),
+		"sniper2": launchTime.Add(-4 * time.Hour),
+		"alice":   launchTime.Add(-1000 * time.Hour),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	token := models.TokenFound{
+		Chain:            models.ChainBase,
+		TokenAddress:     "0xtoken",
+		InitialLiquidity: models.InitialLiquidity{Pair: "0xpair"},
+	}
+	tracker.Follow(ctx, token)
+
+	flow, ok := tracker.Snapshot(token)
+	if !ok {
+		t.Fatal("expected token to be followed")
+	}
+
+	if flow.Swaps != 5 || flow.UniqueBuyers != 3 || flow.UniqueSellers != 1 {
+		t.Errorf("unexpected counts %+v", flow)
+	}
+	if math.Abs(flow.BuyVolume-2.1) > 1e-9 || math.Abs(flow.SellVolume-1.5) > 1e-9 {
+		t.Errorf("unexpected volumes buy=%f sell=%f", flow.BuyVolume, flow.SellVolume)
+	}
+	if math.Abs(flow.BuySellRatio-1.4) > 1e-9 {
+		t.Errorf("expected buy/sell ratio 1.4, got %f", flow.BuySellRatio)
+	}
+	if flow.MedianTradeSize != 0.6 {
+		t.Errorf("expected median trade size 0.6, got %f", flow.MedianTradeSize)
+	}
+	// Blocks 100 and 102 fall inside the first 3 blocks; block 103 does not
+	if math.Abs(flow.SniperSupplyShare-0.08) > 1e-9 {
+		t.Errorf("expected sniper share 0.08, got %f", flow.SniperSupplyShare)
+	}
+
+	// Wallet ages are looked up asynchronously
+	deadline := time.Now().Add(time.Second)
+	for flow.BuyersAged < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		flow, _ = tracker.Snapshot(token)
+	}
+	if flow.BuyersAged != 3 || flow.MedianBuyerWalletAgeHours != 4 {
+		t.Errorf("expected median wallet age of 4h over 3 buyers, got %+v", flow)
+	}
+}
+
+func TestTradeFlowIgnoresTokensWithoutPool(t *testing.T) {
+	cfg := &config.Config{DefaultTimeWindow: time.Minute}
+	tracker := NewTradeFlowTrackerWithSources(cfg, map[models.Chain]SwapSource{models.ChainBase: &fakeSource{}})
+
+	token := models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xtoken"}
+	tracker.Follow(context.Background(), token)
+
+	if _, ok := tracker.Snapshot(token); ok {
+		t.Error("tokens without a pool should not be followed")
+	}
+}
+
+func TestDecodeSolanaSwap(t *testing.T) {
+	blockTime := int64(1714564800)
+	tx := &parsedTransaction{Slot: 263000000, BlockTime: &blockTime}
+	tx.Transaction.Message.AccountKeys = append(tx.Transaction.Message.AccountKeys, struct {
+		Pubkey string `json:"pubkey"`
+		Signer bool   `json:"signer"`
+	}{Pubkey: "Trader111", Signer: true})
+
+	balance := func(mint, owner, amount string) tokenBalance {
+		b := tokenBalance{Mint: mint, Owner: owner}
+		b.UITokenAmount.UIAmountString = amount
+		return b
+	}
+
+	tx.Meta = &struct {
+		Err               interface{}    `json:"err"`
+		Fee               uint64         `json:"fee"`
+		PreBalances       []uint64       `json:"preBalances"`
+		PostBalances      []uint64       `json:"postBalances"`
+		PreTokenBalances  []tokenBalance `json:"preTokenBalances"`
+		PostTokenBalances []tokenBalance `json:"postTokenBalances"`
+	}{
+		Fee:               5000,
+		PreBalances:       []uint64{3_000_000_000},
+		PostBalances:      []uint64{1_999_995_000},
+		PreTokenBalances:  []tokenBalance{balance("Mint111", "Trader111", "0")},
+		PostTokenBalances: []tokenBalance{balance("Mint111", "Trader111", "125000.5"), balance("Mint111", "Pool111", "1")},
+	}
+
+	token := models.TokenFound{Chain: models.ChainSolana, TokenAddress: "Mint111"}
+	event, ok := decodeSolanaSwap(token, "sig", tx)
+	if !ok {
+		t.Fatal("expected a swap to be decoded")
+	}
+	if event.Side != "buy" || event.Trader != "Trader111" || event.AmountToken != 125000.5 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if math.Abs(event.AmountNative-1.0) > 1e-9 {
+		t.Errorf("expected 1 SOL spent net of fees, got %f", event.AmountNative)
+	}
+	if !event.Timestamp.Equal(time.Unix(blockTime, 0)) {
+		t.Errorf("unexpected timestamp %v", event.Timestamp)
+	}
+}
+
+func TestSolanaSwapsStartFromPoolCreation(t *testing.T) {
+	// The pool has 2500 signatures after the creation transaction, more than one page
+	history := []string{"create"}
+	for i := 1; i <= 2500; i++ {
+		history = append(history, fmt.Sprintf("sig%d", i))
+	}
+	var calls []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := "null"
+		if req.Method == "getSignaturesForAddress" {
+			var opts map[string]interface{}
+			json.Unmarshal(req.Params[1], &opts)
+			calls = append(calls, opts)
+
+			start := len(history) - 1
+			if before, ok := opts["before"].(string); ok {
+				start = sigIndex(before) - 1
+			}
+			var page []signatureInfo
+			for i := start; i >= 0 && len(page) < int(opts["limit"].(float64)); i-- {
+				if history[i] == opts["until"] {
+					break
+				}
+				page = append(page, signatureInfo{Signature: history[i]})
+			}
+			raw, _ := json.Marshal(page)
+			result = string(raw)
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+	}))
+	defer server.Close()
+
+	source := NewSolanaSwapSource(server.URL)
+	token := models.TokenFound{
+		Chain:            models.ChainSolana,
+		TokenAddress:     "Mint111",
+		TxHash:           "create",
+		InitialLiquidity: models.InitialLiquidity{Pair: "Pool111"},
+	}
+	_, next, err := source.Swaps(context.Background(), token, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || calls[0]["until"] != "create" || calls[2]["before"] != "sig501" {
+		t.Errorf("expected three pages back to the creation signature, got %v", calls)
+	}
+	// The oldest batch is processed first so no swap after creation is skipped
+	if next != fmt.Sprintf("sig%d", maxSignaturesPerPoll) {
+		t.Errorf("expected the cursor to stop after the oldest batch, got %s", next)
+	}
+}
+
+func sigIndex(sig string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(sig, "sig"))
+	return n
+}
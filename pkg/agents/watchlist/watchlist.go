@@ -0,0 +1,187 @@
+package watchlist
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// WatchlistAgent keeps tokens whose decision was "monitor" and hands them
+// back for re-evaluation until they are promoted or their window runs out
+type WatchlistAgent struct {
+	config  *config.Config
+	entries map[models.CandidateKey]*models.WatchedToken
+	now     func() time.Time
+	mu      sync.RWMutex
+}
+
+// NewWatchlistAgent creates a new watchlist agent
+func NewWatchlistAgent(cfg *config.Config) *WatchlistAgent {
+	return &WatchlistAgent{
+		config:  cfg,
+		entries: make(map[models.CandidateKey]*models.WatchedToken),
+		now:     time.Now,
+	}
+}
+
+// Add starts watching a token, or records a new decision for one already
+// watched. The window runs from when the token was first added, so finding it
+// again does not extend it.
+func (w *WatchlistAgent) Add(
+	token models.PreFilteredToken,
+	safety models.SafetyReport,
+	offchain models.OffChainMetrics,
+	decision models.StrategyDecision,
+) models.WatchedToken {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	key := token.Token.Key()
+	entry, exists := w.entries[key]
+	if !exists {
+		entry = &models.WatchedToken{
+			Token:     token,
+			AddedAt:   now,
+			ExpiresAt: now.Add(w.config.DefaultTimeWindow),
+		}
+		w.entries[key] = entry
+		log.Printf("WatchlistAgent: Watching %s until %s - WinProb: %.2f\n",
+			key, entry.ExpiresAt.Format(time.RFC3339), decision.WinProbability)
+	}
+
+	entry.SafetyReport = safety
+	w.record(entry, offchain, decision, now)
+	return *entry
+}
+
+// Update records the result of re-evaluating a watched token
+func (w *WatchlistAgent) Update(
+	key models.CandidateKey,
+	offchain models.OffChainMetrics,
+	decision models.StrategyDecision,
+) (models.WatchedToken, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry, exists := w.entries[key]
+	if !exists {
+		return models.WatchedToken{}, false
+	}
+	entry.Evaluations++
+	w.record(entry, offchain, decision, w.now())
+	return *entry, true
+}
+
+// record stores the latest data and decision for an entry
+func (w *WatchlistAgent) record(
+	entry *models.WatchedToken,
+	offchain models.OffChainMetrics,
+	decision models.StrategyDecision,
+	now time.Time,
+) {
+	entry.OffChainMetrics = offchain
+	entry.StrategyDecision = decision
+	entry.LastEvaluatedAt = now
+	if decision.WinProbability > entry.PeakWinProbability {
+		entry.PeakWinProbability = decision.WinProbability
+	}
+}
+
+// Remove stops watching a token
+func (w *WatchlistAgent) Remove(key models.CandidateKey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.entries, key)
+}
+
+// Expire removes and returns tokens whose window has passed
+func (w *WatchlistAgent) Expire() []models.WatchedToken {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	expired := make([]models.WatchedToken, 0)
+	for key, entry := range w.entries {
+		if !now.Before(entry.ExpiresAt) {
+			expired = append(expired, *entry)
+			delete(w.entries, key)
+		}
+	}
+	return expired
+}
+
+// Due returns tokens not evaluated within the last interval. They are marked
+// as evaluated now so a slow re-evaluation is not started twice.
+func (w *WatchlistAgent) Due() []models.WatchedToken {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	due := make([]models.WatchedToken, 0)
+	for _, entry := range w.entries {
+		if now.Before(entry.ExpiresAt) && now.Sub(entry.LastEvaluatedAt) >= w.config.WatchlistInterval {
+			entry.LastEvaluatedAt = now
+			due = append(due, *entry)
+		}
+	}
+	return due
+}
+
+// GetAll returns all watched tokens, soonest to expire first
+func (w *WatchlistAgent) GetAll() []models.WatchedToken {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entries := make([]models.WatchedToken, 0, len(w.entries))
+	for _, entry := range w.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ExpiresAt.Before(entries[j].ExpiresAt)
+	})
+	return entries
+}
+
+// Count returns the number of watched tokens
+func (w *WatchlistAgent) Count() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.entries)
+}
+
+// Start expires old entries and passes due ones to evaluate on every tick of
+// the watchlist interval. Each evaluation runs in its own goroutine.
+func (w *WatchlistAgent) Start(evaluate func(models.WatchedToken)) chan struct{} {
+	stopChan := make(chan struct{})
+	if w.config.WatchlistInterval <= 0 {
+		log.Println("WatchlistAgent: Re-evaluation disabled")
+		return stopChan
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.config.WatchlistInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, entry := range w.Expire() {
+					log.Printf("WatchlistAgent: %s expired after %d re-evaluations - peak WinProb: %.2f\n",
+						entry.Token.Token.Key(), entry.Evaluations, entry.PeakWinProbability)
+				}
+				for _, entry := range w.Due() {
+					go evaluate(entry)
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	return stopChan
+}
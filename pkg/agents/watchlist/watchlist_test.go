@@ -0,0 +1,89 @@
+package watchlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func watched(address string) models.PreFilteredToken {
+	return models.PreFilteredToken{Token: models.TokenFound{TokenAddress: address, Chain: models.ChainSolana}}
+}
+
+func TestWatchlistLifecycle(t *testing.T) {
+	cfg := &config.Config{DefaultTimeWindow: 15 * time.Minute, WatchlistInterval: time.Minute}
+	w := NewWatchlistAgent(cfg)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	entry := w.Add(watched("tokenA"), models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{WinProbability: 0.65})
+	if !entry.ExpiresAt.Equal(now.Add(15 * time.Minute)) {
+		t.Fatalf("unexpected expiry %s", entry.ExpiresAt)
+	}
+	if due := w.Due(); len(due) != 0 {
+		t.Fatalf("freshly evaluated token should not be due, got %d", len(due))
+	}
+
+	now = now.Add(time.Minute)
+	due := w.Due()
+	if len(due) != 1 || due[0].Token.Token.TokenAddress != "tokenA" {
+		t.Fatalf("expected tokenA to be due, got %+v", due)
+	}
+	// Claimed by the first call, so not handed out twice
+	if again := w.Due(); len(again) != 0 {
+		t.Errorf("expected no due tokens while tokenA is being evaluated, got %d", len(again))
+	}
+
+	updated, ok := w.Update(watched("tokenA").Token.Key(), models.OffChainMetrics{Volume24hDEX: 5000}, models.StrategyDecision{WinProbability: 0.72})
+	if !ok || updated.Evaluations != 1 || updated.PeakWinProbability != 0.72 || updated.OffChainMetrics.Volume24hDEX != 5000 {
+		t.Errorf("unexpected update %+v", updated)
+	}
+	updated, _ = w.Update(watched("tokenA").Token.Key(), models.OffChainMetrics{}, models.StrategyDecision{WinProbability: 0.55})
+	if updated.PeakWinProbability != 0.72 || updated.StrategyDecision.WinProbability != 0.55 {
+		t.Errorf("peak should survive a lower re-evaluation, got %+v", updated)
+	}
+
+	// Finding the token again does not extend its window
+	now = now.Add(5 * time.Minute)
+	entry = w.Add(watched("tokenA"), models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{WinProbability: 0.61})
+	if !entry.ExpiresAt.Equal(updated.ExpiresAt) {
+		t.Errorf("window extended from %s to %s", updated.ExpiresAt, entry.ExpiresAt)
+	}
+
+	w.Add(watched("tokenB"), models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{WinProbability: 0.6})
+	if all := w.GetAll(); len(all) != 2 || all[0].Token.Token.TokenAddress != "tokenA" {
+		t.Errorf("expected tokenA first as it expires first, got %+v", all)
+	}
+
+	now = now.Add(9 * time.Minute)
+	expired := w.Expire()
+	if len(expired) != 1 || expired[0].Token.Token.TokenAddress != "tokenA" {
+		t.Fatalf("expected tokenA to expire, got %+v", expired)
+	}
+	if w.Count() != 1 {
+		t.Errorf("expected tokenB to remain, got %d", w.Count())
+	}
+
+	w.Remove(watched("tokenB").Token.Key())
+	if _, ok := w.Update(watched("tokenB").Token.Key(), models.OffChainMetrics{}, models.StrategyDecision{}); ok {
+		t.Error("removed token should not be updated")
+	}
+}
+
+func TestWatchlistKeepsChainsApart(t *testing.T) {
+	w := NewWatchlistAgent(&config.Config{DefaultTimeWindow: 15 * time.Minute})
+	onBase := models.PreFilteredToken{Token: models.TokenFound{TokenAddress: "tokenA", Chain: models.ChainBase}}
+
+	w.Add(watched("tokenA"), models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{WinProbability: 0.6})
+	w.Add(onBase, models.SafetyReport{}, models.OffChainMetrics{}, models.StrategyDecision{WinProbability: 0.7})
+	if w.Count() != 2 {
+		t.Fatalf("expected the address to be watched on both chains, got %d", w.Count())
+	}
+
+	w.Remove(onBase.Token.Key())
+	if all := w.GetAll(); len(all) != 1 || all[0].Token.Token.Chain != models.ChainSolana {
+		t.Errorf("removing the Base token touched the Solana one: %+v", all)
+	}
+}
@@ -0,0 +1,526 @@
+// Package backtest replays recorded token histories through the live
+// prefilter, strategy, risk and (simulated) execution agents on a virtual
+// clock, and reports the resulting trades and equity curve.
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/agents/execution"
+	"github.com/mumugogoing/meme_bot/pkg/agents/prefilter"
+	"github.com/mumugogoing/meme_bot/pkg/agents/risk"
+	"github.com/mumugogoing/meme_bot/pkg/agents/safety"
+	"github.com/mumugogoing/meme_bot/pkg/agents/strategy"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/impact"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// Clock is the virtual time of a replay. It only moves forward.
+type Clock struct {
+	now time.Time
+}
+
+// Now returns the current virtual time
+func (c *Clock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock to t unless t is in the past
+func (c *Clock) Advance(t time.Time) {
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+// Options control a backtest run
+type Options struct {
+	Seed int64
+	// SlippageNoise is the standard deviation of extra slippage on each fill,
+	// on top of the decision's estimated costs. Draws come from Seed.
+	SlippageNoise float64
+	// Weights overrides the strategy weights; nil uses STRATEGY_WEIGHTS_FILE
+	// or the built-in weights
+	Weights *strategy.Weights
+}
+
+// Trade is one simulated position
+type Trade struct {
+	TokenAddress  string                  `json:"token_address"`
+	Chain         models.Chain            `json:"chain"`
+	Strategy      string                  `json:"strategy"`
+	Decision      models.StrategyDecision `json:"decision"`
+	OpenedAt      time.Time               `json:"opened_at"`
+	ClosedAt      time.Time               `json:"closed_at"`
+	EntryPrice    float64                 `json:"entry_price"`
+	ExitPrice     float64                 `json:"exit_price"`
+	SizeUSD       float64                 `json:"size_usd"`
+	ExtraSlippage float64                 `json:"extra_slippage,omitempty"` // both legs, from SlippageNoise
+	ExitReason    string                  `json:"exit_reason"`              // "take_profit", "stop_loss", "time_horizon", "end_of_data"
+	ROI           float64                 `json:"roi"`                      // net of costs and slippage
+	PnLUSD        float64                 `json:"pnl_usd"`
+}
+
+// EquityPoint is the account equity after a trade closes
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Attribution summarizes the trades sharing a reason or strategy
+type Attribution struct {
+	Trades  int     `json:"trades"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"`
+	PnLUSD  float64 `json:"pnl_usd"`
+}
+
+// Result is the outcome of a backtest
+type Result struct {
+	Seed           int64                  `json:"seed"`
+	Tokens         int                    `json:"tokens"`
+	Start          time.Time              `json:"start"`
+	End            time.Time              `json:"end"`
+	Trades         []Trade                `json:"trades"`
+	Equity         []EquityPoint          `json:"equity"`
+	StartingEquity float64                `json:"starting_equity"`
+	FinalEquity    float64                `json:"final_equity"`
+	PnLUSD         float64                `json:"pnl_usd"`
+	Wins           int                    `json:"wins"`
+	WinRate        float64                `json:"win_rate"`
+	MaxDrawdown    float64                `json:"max_drawdown"` // largest peak-to-trough fall, as a share of the peak
+	MaxDrawdownUSD float64                `json:"max_drawdown_usd"`
+	ByExitReason   map[string]Attribution `json:"by_exit_reason"`
+	ByStrategy     map[string]Attribution `json:"by_strategy"`
+	Skipped        map[string]int         `json:"skipped"` // tokens never traded, by the reason they stopped
+}
+
+// eventKind orders events that share a timestamp: prices first, so decisions
+// and exits see the latest price, then snapshots, then discoveries
+type eventKind int
+
+const (
+	priceEvent eventKind = iota
+	offchainEvent
+	tokenEvent
+)
+
+// event is one recorded observation in replay order
+type event struct {
+	at    time.Time
+	kind  eventKind
+	token int // index into the histories
+	index int // index into the token's prices or snapshots
+}
+
+// position is an open simulated trade
+type position struct {
+	trade    Trade
+	deadline time.Time
+	slippage float64
+}
+
+// tokenState is what the replay knows about a token so far
+type tokenState struct {
+	history      *TokenHistory
+	prefiltered  models.PreFilteredToken
+	snapshot     *models.OffChainMetrics
+	price        float64
+	seen         bool
+	done         bool
+	monitorUntil time.Time // non-zero while the token is being watched after a "monitor" decision
+	position     *position
+}
+
+// engine runs one backtest
+type engine struct {
+	config    *config.Config
+	options   Options
+	clock     *Clock
+	rng       *rand.Rand
+	prefilter *prefilter.PreFilterAgent
+	safety    *safety.OnChainSafetyAgent
+	strategy  *strategy.StrategyEvaluatorAgent
+	risk      *risk.RiskManagerAgent
+	execution *execution.ExecutionAgent
+	tokens    []*tokenState
+	equity    float64
+	result    *Result
+}
+
+// Run replays the histories and returns the simulated trades. Every list or
+// buy decision is executed, subject to the risk manager. Runs are
+// deterministic for the same histories, configuration and seed.
+func Run(cfg *config.Config, histories []TokenHistory, options Options) (*Result, error) {
+	if cfg.AccountBalance <= 0 {
+		return nil, fmt.Errorf("account balance must be positive, got %.2f", cfg.AccountBalance)
+	}
+
+	// Execution is always simulated, and shadow strategies would track wall-clock prices
+	c := *cfg
+	c.DryRun = true
+	c.AutoExecute = true
+	c.ShadowStrategies = nil
+
+	var evaluator *strategy.StrategyEvaluatorAgent
+	if options.Weights != nil {
+		evaluator = strategy.NewStrategyEvaluatorAgentWithWeights(&c, strategy.NewStaticWeightsStore(options.Weights))
+	} else {
+		evaluator = strategy.NewStrategyEvaluatorAgent(&c)
+	}
+
+	e := &engine{
+		config:    &c,
+		options:   options,
+		clock:     &Clock{},
+		rng:       rand.New(rand.NewSource(options.Seed)),
+		prefilter: prefilter.NewPreFilterAgent(&c),
+		safety:    safety.NewOnChainSafetyAgent(&c),
+		strategy:  evaluator,
+		risk:      risk.NewRiskManagerAgent(&c),
+		execution: execution.NewExecutionAgent(&c),
+		equity:    c.AccountBalance,
+		result: &Result{
+			Seed:           options.Seed,
+			Tokens:         len(histories),
+			Trades:         []Trade{},
+			StartingEquity: c.AccountBalance,
+			ByExitReason:   make(map[string]Attribution),
+			ByStrategy:     make(map[string]Attribution),
+			Skipped:        make(map[string]int),
+		},
+	}
+	e.strategy.SetExposureProvider(e.risk)
+
+	events := e.schedule(histories)
+	if len(events) == 0 {
+		e.result.FinalEquity = e.equity
+		return e.result, nil
+	}
+	e.clock.Advance(events[0].at)
+	e.risk.SetClock(e.clock.Now)
+	e.result.Start = events[0].at
+	e.result.Equity = append(e.result.Equity, EquityPoint{Time: events[0].at, Equity: e.equity})
+
+	for _, ev := range events {
+		e.clock.Advance(ev.at)
+		e.expire(ev.at)
+		e.handle(ev)
+	}
+
+	end := events[len(events)-1].at
+	e.finish(end)
+	e.summarize(end)
+	return e.result, nil
+}
+
+// schedule copies the histories into replay state and orders their events
+func (e *engine) schedule(histories []TokenHistory) []event {
+	var events []event
+	for i := range histories {
+		history := histories[i]
+		history.OffChain = append([]models.OffChainMetrics(nil), history.OffChain...)
+		history.Prices = append([]PricePoint(nil), history.Prices...)
+		if err := history.normalize(); err != nil {
+			e.result.Skipped["invalid_history"]++
+			e.tokens = append(e.tokens, &tokenState{history: &history, done: true})
+			continue
+		}
+		e.tokens = append(e.tokens, &tokenState{history: &history})
+
+		events = append(events, event{at: history.SeenAt, kind: tokenEvent, token: i})
+		for j, snapshot := range history.OffChain {
+			events = append(events, event{at: snapshot.EvaluatedAt, kind: offchainEvent, token: i, index: j})
+		}
+		for j, point := range history.Prices {
+			events = append(events, event{at: point.Time, kind: priceEvent, token: i, index: j})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.at.Equal(b.at) {
+			return a.at.Before(b.at)
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.token != b.token {
+			addressA := e.tokens[a.token].history.Token.TokenAddress
+			addressB := e.tokens[b.token].history.Token.TokenAddress
+			if addressA != addressB {
+				return addressA < addressB
+			}
+			return a.token < b.token
+		}
+		return a.index < b.index
+	})
+	return events
+}
+
+// handle applies one event
+func (e *engine) handle(ev event) {
+	st := e.tokens[ev.token]
+	switch ev.kind {
+	case priceEvent:
+		point := st.history.Prices[ev.index]
+		if point.PriceUSD <= 0 {
+			return
+		}
+		st.price = point.PriceUSD
+		if st.position != nil {
+			e.mark(st, ev.at)
+		}
+
+	case offchainEvent:
+		snapshot := st.history.OffChain[ev.index]
+		st.snapshot = &snapshot
+		// Watched tokens are re-evaluated whenever fresh data arrives
+		if st.seen && !st.done && st.position == nil && !st.monitorUntil.IsZero() {
+			e.evaluate(st)
+		}
+
+	case tokenEvent:
+		if st.done {
+			return
+		}
+		st.seen = true
+		st.prefiltered = e.prefilter.Filter(st.history.Token)
+		if st.prefiltered.Dropped {
+			reason := "dropped"
+			if len(st.prefiltered.Reasons) > 0 {
+				reason = st.prefiltered.Reasons[0]
+			}
+			e.skip(st, "prefilter:"+reason)
+			return
+		}
+		if !e.safety.CanTrade(&st.history.Safety) {
+			e.skip(st, "safety")
+			return
+		}
+		e.evaluate(st)
+	}
+}
+
+// evaluate runs the strategy on the token's latest snapshot and acts on the decision
+func (e *engine) evaluate(st *tokenState) {
+	now := e.clock.Now()
+	offchain := models.OffChainMetrics{TokenAddress: st.history.Token.TokenAddress, EvaluatedAt: now}
+	if st.snapshot != nil {
+		offchain = *st.snapshot
+	}
+	price := st.price
+	if price == 0 {
+		price = offchain.PriceOnDEX
+	}
+	if offchain.PriceOnDEX == 0 {
+		offchain.PriceOnDEX = price
+	}
+
+	decision, err := e.strategy.Evaluate(&st.history.Safety, &offchain, st.prefiltered)
+	if err != nil {
+		e.skip(st, "strategy_error")
+		return
+	}
+	decision.EvaluatedAt = now
+
+	switch decision.Action {
+	case "buy", "list":
+		e.open(st, decision, offchain, price)
+	case "monitor":
+		if st.monitorUntil.IsZero() {
+			st.monitorUntil = now.Add(e.config.DefaultTimeWindow)
+		}
+	default:
+		// Like the watchlist, a watched token keeps its window after a worse decision
+		if st.monitorUntil.IsZero() {
+			e.skip(st, "action:"+decision.Action)
+		}
+	}
+}
+
+// open executes a decision through the risk manager and the simulated execution agent
+func (e *engine) open(st *tokenState, decision *models.StrategyDecision, offchain models.OffChainMetrics, price float64) {
+	if price <= 0 {
+		e.skip(st, "no_price")
+		return
+	}
+
+	e.risk.CheckDailyReset()
+	if ok, reason := e.risk.CanExecute(decision); !ok {
+		e.skip(st, "risk:"+reason)
+		return
+	}
+
+	now := e.clock.Now()
+	candidate := &models.CandidateToken{
+		Token:            st.history.Token,
+		SafetyReport:     st.history.Safety,
+		OffChainMetrics:  offchain,
+		StrategyDecision: *decision,
+		ListedAt:         now,
+		Status:           models.CandidateApproved,
+	}
+	result, err := e.execution.Execute(context.Background(), candidate)
+	if err != nil || result.Status != "confirmed" {
+		e.skip(st, "execution_failed")
+		return
+	}
+	result.Timestamp = now
+	e.risk.RecordExecution(result)
+
+	horizon := time.Duration(decision.TimeHorizonMinutes) * time.Minute
+	if horizon <= 0 {
+		horizon = e.config.DefaultTimeWindow
+	}
+	st.monitorUntil = time.Time{}
+	st.position = &position{
+		trade: Trade{
+			TokenAddress: st.history.Token.TokenAddress,
+			Chain:        st.history.Token.Chain,
+			Strategy:     decision.Strategy,
+			Decision:     *decision,
+			OpenedAt:     now,
+			EntryPrice:   price,
+			SizeUSD:      result.AmountUSD,
+		},
+		deadline: now.Add(horizon),
+		slippage: e.slippage(),
+	}
+}
+
+// slippage draws extra slippage for one fill
+func (e *engine) slippage() float64 {
+	if e.options.SlippageNoise <= 0 {
+		return 0
+	}
+	return math.Min(math.Abs(e.rng.NormFloat64())*e.options.SlippageNoise, 1)
+}
+
+// mark closes an open position that has reached its take profit or stop loss
+func (e *engine) mark(st *tokenState, at time.Time) {
+	decision := st.position.trade.Decision
+	gross := st.price/st.position.trade.EntryPrice - 1
+
+	// Exits are assumed to fill at the level that triggered them
+	switch {
+	case decision.TakeProfitPct > 0 && gross >= decision.TakeProfitPct:
+		e.close(st, decision.TakeProfitPct, "take_profit", at)
+	case decision.StopLossPct > 0 && gross <= -decision.StopLossPct:
+		e.close(st, -decision.StopLossPct, "stop_loss", at)
+	}
+}
+
+// expire closes positions past their time horizon and ends watches past their window
+func (e *engine) expire(now time.Time) {
+	var due []*tokenState
+	for _, st := range e.tokens {
+		if st.position != nil && !now.Before(st.position.deadline) {
+			due = append(due, st)
+		}
+		if st.position == nil && !st.done && !st.monitorUntil.IsZero() && !now.Before(st.monitorUntil) {
+			e.skip(st, "monitor_expired")
+		}
+	}
+	// Close in deadline order so the equity curve is in time order
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].position.deadline.Before(due[j].position.deadline)
+	})
+	for _, st := range due {
+		e.close(st, st.price/st.position.trade.EntryPrice-1, "time_horizon", st.position.deadline)
+	}
+}
+
+// finish closes everything still open when the data runs out
+func (e *engine) finish(end time.Time) {
+	for _, st := range e.tokens {
+		switch {
+		case st.position != nil:
+			e.close(st, st.price/st.position.trade.EntryPrice-1, "end_of_data", end)
+		case !st.done && !st.monitorUntil.IsZero():
+			e.skip(st, "monitor_expired")
+		}
+	}
+}
+
+// close settles a position at a gross return
+func (e *engine) close(st *tokenState, gross float64, reason string, at time.Time) {
+	p := st.position
+	trade := p.trade
+
+	roi := gross
+	if trade.Decision.Costs != nil {
+		roi = impact.NetROI(gross, *trade.Decision.Costs)
+	}
+	exitSlippage := e.slippage()
+	roi = (1+roi)*(1-p.slippage)*(1-exitSlippage) - 1
+
+	trade.ClosedAt = at
+	trade.ExitPrice = trade.EntryPrice * (1 + gross)
+	trade.ExitReason = reason
+	trade.ExtraSlippage = 1 - (1-p.slippage)*(1-exitSlippage)
+	trade.ROI = roi
+	trade.PnLUSD = trade.SizeUSD * roi
+
+	e.risk.RecordProfit(models.CandidateKey{Chain: trade.Chain, TokenAddress: trade.TokenAddress}, trade.PnLUSD)
+	e.risk.ReleaseExposure(trade.SizeUSD)
+	e.equity += trade.PnLUSD
+	e.result.Trades = append(e.result.Trades, trade)
+	e.result.Equity = append(e.result.Equity, EquityPoint{Time: at, Equity: e.equity})
+
+	st.position = nil
+	st.done = true
+}
+
+// skip records why a token was not traded
+func (e *engine) skip(st *tokenState, reason string) {
+	st.done = true
+	st.monitorUntil = time.Time{}
+	e.result.Skipped[reason]++
+}
+
+// summarize computes the headline statistics
+func (e *engine) summarize(end time.Time) {
+	r := e.result
+	r.End = end
+	r.FinalEquity = e.equity
+	r.PnLUSD = e.equity - r.StartingEquity
+
+	for _, trade := range r.Trades {
+		win := trade.PnLUSD > 0
+		if win {
+			r.Wins++
+		}
+		r.ByExitReason[trade.ExitReason] = r.ByExitReason[trade.ExitReason].add(trade.PnLUSD, win)
+		r.ByStrategy[trade.Strategy] = r.ByStrategy[trade.Strategy].add(trade.PnLUSD, win)
+	}
+	if len(r.Trades) > 0 {
+		r.WinRate = float64(r.Wins) / float64(len(r.Trades))
+	}
+
+	peak := r.StartingEquity
+	for _, point := range r.Equity {
+		peak = math.Max(peak, point.Equity)
+		drawdown := peak - point.Equity
+		r.MaxDrawdownUSD = math.Max(r.MaxDrawdownUSD, drawdown)
+		if peak > 0 {
+			r.MaxDrawdown = math.Max(r.MaxDrawdown, drawdown/peak)
+		}
+	}
+}
+
+// add counts one trade
+func (a Attribution) add(pnl float64, win bool) Attribution {
+	a.Trades++
+	a.PnLUSD += pnl
+	if win {
+		a.Wins++
+	}
+	a.WinRate = float64(a.Wins) / float64(a.Trades)
+	return a
+}
@@ -0,0 +1,170 @@
+package backtest
+
+import (
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		WinProbabilityThreshold: 0.8,
+		MinVolumeDEX:            10000,
+		MinLiquidity:            5000,
+		MaxHoneypotScore:        0.2,
+		MaxSlippage:             0.05,
+		DefaultStrategy:         "heuristic",
+		AccountBalance:          10000,
+		SinglePositionPct:       0.05,
+		TotalExposurePct:        0.5,
+		DailyLossLimit:          1000,
+		KellyFraction:           0.25,
+		MinPositionUSD:          10,
+		DefaultTimeWindow:       15 * time.Minute,
+		BlacklistedTokens:       []string{"blacklisted"},
+	}
+}
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// history builds a token that the heuristic strategy lists, followed by the given prices
+func history(address string, prices ...float64) TokenHistory {
+	h := TokenHistory{
+		Token: models.TokenFound{
+			Chain:            models.ChainBase,
+			TokenAddress:     address,
+			InitialLiquidity: models.InitialLiquidity{ReserveNative: 50000, ReserveToken: 1e9},
+		},
+		SeenAt: start,
+		Safety: models.SafetyReport{
+			CanBuy: true, CanSell: true, HoneypotScore: 0.01, LiquidityLocked: true,
+			OwnerControls: models.OwnerControls{Renounced: true},
+		},
+		OffChain: []models.OffChainMetrics{{
+			TokenAddress: address, Volume24hDEX: 250000, Volume24hCEX: 100000, LiquidityUSD: 500000,
+			Velocity: "rising", SocialMentions: map[string]int{"twitter": 500}, EvaluatedAt: start,
+		}},
+	}
+	for i, price := range prices {
+		h.Prices = append(h.Prices, PricePoint{Time: start.Add(time.Duration(i) * 5 * time.Minute), PriceUSD: price})
+	}
+	return h
+}
+
+func dataset() []TokenHistory {
+	unsafe := history("unsafe", 1, 2)
+	unsafe.Safety.CanSell = false
+	return []TokenHistory{
+		history("winner", 1, 1.1, 3),
+		history("loser", 1, 0.9, 0.2),
+		history("flat", 1, 0.98, 0.99, 0.98, 0.97, 0.98, 0.99, 0.98, 0.97, 0.98, 0.99, 0.98, 0.97, 0.98, 0.99, 0.98, 0.97, 0.98, 0.99),
+		history("blacklisted", 1, 2),
+		unsafe,
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	result, err := Run(testConfig(), dataset(), Options{Seed: 1})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(result.Trades) != 3 {
+		t.Fatalf("expected 3 trades, got %+v (skipped %v)", result.Trades, result.Skipped)
+	}
+	reasons := make(map[string]Trade)
+	for _, trade := range result.Trades {
+		reasons[trade.TokenAddress] = trade
+	}
+	if reasons["winner"].ExitReason != "take_profit" || reasons["winner"].PnLUSD <= 0 {
+		t.Errorf("unexpected winner %+v", reasons["winner"])
+	}
+	if reasons["loser"].ExitReason != "stop_loss" || reasons["loser"].PnLUSD >= 0 {
+		t.Errorf("unexpected loser %+v", reasons["loser"])
+	}
+	if reasons["flat"].ExitReason != "time_horizon" {
+		t.Errorf("unexpected flat %+v", reasons["flat"])
+	}
+	if result.Skipped["prefilter:token_blacklisted"] != 1 || result.Skipped["safety"] != 1 {
+		t.Errorf("unexpected skips %v", result.Skipped)
+	}
+
+	// Exits are simulated at their trigger level, net of the decision's costs
+	winner := reasons["winner"]
+	if want := winner.Decision.TakeProfitPct; math.Abs(winner.ExitPrice/winner.EntryPrice-1-want) > 1e-9 {
+		t.Errorf("take profit filled at %f, want %f", winner.ExitPrice/winner.EntryPrice-1, want)
+	}
+
+	pnl := 0.0
+	for _, trade := range result.Trades {
+		pnl += trade.PnLUSD
+	}
+	if math.Abs(result.PnLUSD-pnl) > 1e-9 || math.Abs(result.FinalEquity-result.StartingEquity-pnl) > 1e-9 {
+		t.Errorf("PnL %f does not match trades %f", result.PnLUSD, pnl)
+	}
+	if len(result.Equity) != 4 || result.MaxDrawdownUSD <= 0 {
+		t.Errorf("unexpected equity curve %+v, drawdown %f", result.Equity, result.MaxDrawdownUSD)
+	}
+	if result.WinRate != 1.0/3 || result.ByExitReason["stop_loss"].Trades != 1 || result.ByStrategy["heuristic"].Trades != 3 {
+		t.Errorf("unexpected attribution: win rate %f, by reason %v, by strategy %v",
+			result.WinRate, result.ByExitReason, result.ByStrategy)
+	}
+}
+
+func TestBacktestIsDeterministic(t *testing.T) {
+	cfg := testConfig()
+	first, err := Run(cfg, dataset(), Options{Seed: 7, SlippageNoise: 0.01})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	second, _ := Run(cfg, dataset(), Options{Seed: 7, SlippageNoise: 0.01})
+	if !reflect.DeepEqual(first, second) {
+		t.Error("same dataset and seed produced different results")
+	}
+
+	other, _ := Run(cfg, dataset(), Options{Seed: 8, SlippageNoise: 0.01})
+	if other.PnLUSD == first.PnLUSD {
+		t.Error("expected a different seed to draw different slippage")
+	}
+}
+
+func TestReadDataset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "histories.jsonl")
+	data := `{"token":{"chain":"solana","token_address":"Mint1","first_seen_ts":1709294400},"prices":[{"time":"2024-03-01T12:10:00Z","price_usd":2},{"time":"2024-03-01T12:05:00Z","price_usd":1}]}
+
+{"token":{"chain":"base","token_address":"0xabc"},"seen_at":"2024-03-01T12:00:00Z"}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	histories, err := ReadDataset(path)
+	if err != nil {
+		t.Fatalf("ReadDataset: %v", err)
+	}
+	if len(histories) != 2 || !histories[0].SeenAt.Equal(start) {
+		t.Fatalf("unexpected histories %+v", histories)
+	}
+	if histories[0].Prices[0].PriceUSD != 1 {
+		t.Errorf("prices not sorted: %+v", histories[0].Prices)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"token":{"token_address":"x"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadDataset(path); err == nil {
+		t.Error("expected an error for a token without a discovery time")
+	}
+}
@@ -0,0 +1,81 @@
+package backtest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// PricePoint is one observation of a token's pool price
+type PricePoint struct {
+	Time     time.Time `json:"time"`
+	PriceUSD float64   `json:"price_usd"`
+}
+
+// TokenHistory is everything recorded about one token: the discovery event,
+// the safety report taken at the time, off-chain snapshots (each effective
+// from its EvaluatedAt) and the pool price series
+type TokenHistory struct {
+	Token    models.TokenFound        `json:"token"`
+	SeenAt   time.Time                `json:"seen_at"` // defaults to Token.FirstSeenTS (unix seconds)
+	Safety   models.SafetyReport      `json:"safety"`
+	OffChain []models.OffChainMetrics `json:"offchain"`
+	Prices   []PricePoint             `json:"prices"`
+}
+
+// ReadDataset reads token histories, one JSON object per line
+func ReadDataset(path string) ([]TokenHistory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var histories []TokenHistory
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var history TokenHistory
+		if err := json.Unmarshal(scanner.Bytes(), &history); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if err := history.normalize(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		histories = append(histories, history)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// normalize fills the discovery time and puts snapshots and prices in time order
+func (h *TokenHistory) normalize() error {
+	if h.Token.TokenAddress == "" {
+		return fmt.Errorf("token has no address")
+	}
+	if h.SeenAt.IsZero() {
+		if h.Token.FirstSeenTS == 0 {
+			return fmt.Errorf("token %s has no seen_at or first_seen_ts", h.Token.TokenAddress)
+		}
+		h.SeenAt = time.Unix(h.Token.FirstSeenTS, 0).UTC()
+	}
+	sort.SliceStable(h.OffChain, func(i, j int) bool {
+		return h.OffChain[i].EvaluatedAt.Before(h.OffChain[j].EvaluatedAt)
+	})
+	sort.SliceStable(h.Prices, func(i, j int) bool {
+		return h.Prices[i].Time.Before(h.Prices[j].Time)
+	})
+	return nil
+}
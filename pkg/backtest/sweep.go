@@ -0,0 +1,544 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/agents/strategy"
+	"github.com/mumugogoing/meme_bot/pkg/config"
+)
+
+// sweepParam sets one tunable parameter on a configuration and its weights
+type sweepParam struct {
+	set func(cfg *config.Config, w *strategy.Weights, v float64)
+	// strategies that read the parameter; nil for parameters every strategy uses
+	strategies []string
+}
+
+// sweepParams are the parameters a sweep can vary, keyed by name
+var sweepParams = buildSweepParams()
+
+func buildSweepParams() map[string]sweepParam {
+	params := map[string]sweepParam{
+		"win_probability_threshold": {set: func(cfg *config.Config, w *strategy.Weights, v float64) { cfg.WinProbabilityThreshold = v }},
+		"min_liquidity":             {set: func(cfg *config.Config, w *strategy.Weights, v float64) { cfg.MinLiquidity = v }},
+		"max_honeypot_score":        {set: func(cfg *config.Config, w *strategy.Weights, v float64) { cfg.MaxHoneypotScore = v }},
+	}
+
+	// The top-level risk table is read by the heuristic and model strategies;
+	// the specialised strategies have their own under strategies.<name>
+	addRiskParams(params, "", []string{"heuristic", "model"},
+		func(w *strategy.Weights) *strategy.RiskTable { return &w.RiskTable })
+	addRiskParams(params, "strategies.pumpfun_sniper.", []string{"pumpfun_sniper"},
+		func(w *strategy.Weights) *strategy.RiskTable { return &w.Strategies.PumpFun.RiskTable })
+	addRiskParams(params, "strategies.momentum.", []string{"momentum"},
+		func(w *strategy.Weights) *strategy.RiskTable { return &w.Strategies.Momentum.RiskTable })
+	params["strategies.pumpfun_sniper.size_scale"] = sweepParam{
+		set:        func(cfg *config.Config, w *strategy.Weights, v float64) { w.Strategies.PumpFun.SizeScale = v },
+		strategies: []string{"pumpfun_sniper"},
+	}
+	params["strategies.momentum.size_scale"] = sweepParam{
+		set:        func(cfg *config.Config, w *strategy.Weights, v float64) { w.Strategies.Momentum.SizeScale = v },
+		strategies: []string{"momentum"},
+	}
+	return params
+}
+
+// addRiskParams registers the stop loss and take profit parameters of one risk table
+func addRiskParams(params map[string]sweepParam, prefix string, strategies []string, table func(w *strategy.Weights) *strategy.RiskTable) {
+	for _, level := range []string{"high", "medium", "low"} {
+		level := level
+		params[prefix+"stop_loss."+level] = sweepParam{
+			set:        func(cfg *config.Config, w *strategy.Weights, v float64) { table(w).StopLoss[level] = v },
+			strategies: strategies,
+		}
+	}
+	params[prefix+"take_profit.roi_multiple"] = sweepParam{
+		set:        func(cfg *config.Config, w *strategy.Weights, v float64) { table(w).TakeProfit.ROIMultiple = v },
+		strategies: strategies,
+	}
+	params[prefix+"take_profit.min"] = sweepParam{
+		set:        func(cfg *config.Config, w *strategy.Weights, v float64) { table(w).TakeProfit.Min = v },
+		strategies: strategies,
+	}
+	params[prefix+"take_profit.max"] = sweepParam{
+		set:        func(cfg *config.Config, w *strategy.Weights, v float64) { table(w).TakeProfit.Max = v },
+		strategies: strategies,
+	}
+}
+
+// SweepParamNames lists the parameters a sweep can vary
+func SweepParamNames() []string {
+	names := make([]string, 0, len(sweepParams))
+	for name := range sweepParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Objectives a sweep can rank configurations by
+const (
+	ObjectivePnL             = "pnl"
+	ObjectiveWinRate         = "win_rate"
+	ObjectivePnLOverDrawdown = "pnl_over_drawdown"
+)
+
+// SweepParam is the values one parameter takes
+type SweepParam struct {
+	Values []float64 `json:"values,omitempty"` // grid points, or the choices for random search
+	Min    float64   `json:"min,omitempty"`    // uniform range for random search when Values is empty
+	Max    float64   `json:"max,omitempty"`
+}
+
+// SweepSpec describes a parameter sweep
+type SweepSpec struct {
+	Params        map[string]SweepParam `json:"params"`
+	Samples       int                   `json:"samples,omitempty"`        // random search with this many configurations; 0 expands the full grid
+	Folds         int                   `json:"folds,omitempty"`          // walk-forward folds; 0 backtests every configuration on the whole dataset
+	Objective     string                `json:"objective,omitempty"`      // "pnl" (default), "win_rate" or "pnl_over_drawdown"
+	Seed          int64                 `json:"seed,omitempty"`           // drives random search and simulated slippage
+	SlippageNoise float64               `json:"slippage_noise,omitempty"` // as Options.SlippageNoise
+	Workers       int                   `json:"workers,omitempty"`        // parallel backtests; 0 uses every CPU
+}
+
+// Validate rejects unknown parameters, empty ranges and unknown objectives
+func (s *SweepSpec) Validate() error {
+	if len(s.Params) == 0 {
+		return fmt.Errorf("sweep has no parameters")
+	}
+	for name, param := range s.Params {
+		if _, exists := sweepParams[name]; !exists {
+			return fmt.Errorf("unknown parameter %q (known: %v)", name, SweepParamNames())
+		}
+		if len(param.Values) > 0 {
+			continue
+		}
+		if s.Samples == 0 {
+			return fmt.Errorf("parameter %q needs values for a grid search", name)
+		}
+		if param.Max < param.Min {
+			return fmt.Errorf("parameter %q has max %v below min %v", name, param.Max, param.Min)
+		}
+	}
+	if s.Samples < 0 || s.Folds < 0 || s.Workers < 0 {
+		return fmt.Errorf("samples, folds and workers must not be negative")
+	}
+	switch s.Objective {
+	case "", ObjectivePnL, ObjectiveWinRate, ObjectivePnLOverDrawdown:
+	default:
+		return fmt.Errorf("unknown objective %q", s.Objective)
+	}
+	return nil
+}
+
+// checkRouted rejects parameters that only strategies no route sends tokens
+// to would read, since varying them cannot change any backtest
+func (s *SweepSpec) checkRouted(cfg *config.Config) error {
+	routed := strategy.RoutedStrategies(cfg)
+	for name := range s.Params {
+		param := sweepParams[name]
+		if param.strategies == nil {
+			continue
+		}
+		used := false
+		for _, strategy := range param.strategies {
+			used = used || routed[strategy]
+		}
+		if !used {
+			return fmt.Errorf("parameter %q only affects %s, which no route or default sends tokens to",
+				name, strings.Join(param.strategies, " and "))
+		}
+	}
+	return nil
+}
+
+// Window is the first and last discovery time of a set of tokens
+type Window struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Tokens int       `json:"tokens"`
+}
+
+// SweepMetrics aggregates the backtests of one configuration over a set of windows
+type SweepMetrics struct {
+	Runs           int     `json:"runs"`
+	Trades         int     `json:"trades"`
+	Wins           int     `json:"wins"`
+	WinRate        float64 `json:"win_rate"`
+	PnLUSD         float64 `json:"pnl_usd"`
+	MaxDrawdownUSD float64 `json:"max_drawdown_usd"` // worst of the runs
+	Objective      float64 `json:"objective"`
+}
+
+// add folds one backtest into the metrics
+func (m *SweepMetrics) add(r *Result) {
+	m.Runs++
+	m.Trades += len(r.Trades)
+	m.Wins += r.Wins
+	m.PnLUSD += r.PnLUSD
+	m.MaxDrawdownUSD = math.Max(m.MaxDrawdownUSD, r.MaxDrawdownUSD)
+	if m.Trades > 0 {
+		m.WinRate = float64(m.Wins) / float64(m.Trades)
+	}
+}
+
+// score computes the objective from the aggregated metrics
+func (m *SweepMetrics) score(objective string) {
+	switch objective {
+	case ObjectiveWinRate:
+		m.Objective = m.WinRate
+	case ObjectivePnLOverDrawdown:
+		m.Objective = m.PnLUSD / math.Max(m.MaxDrawdownUSD, 1)
+	default:
+		m.Objective = m.PnLUSD
+	}
+}
+
+// SweepConfig is one configuration and how it performed. With walk-forward
+// folds Train covers the training windows and Test the windows after them;
+// without, Train covers the whole dataset and Test is empty.
+type SweepConfig struct {
+	Rank   int                `json:"rank"`
+	Params map[string]float64 `json:"params"`
+	Train  SweepMetrics       `json:"train"`
+	Test   SweepMetrics       `json:"test"`
+	Score  float64            `json:"score"` // the test objective, or the train objective without folds
+	Error  string             `json:"error,omitempty"`
+}
+
+// SweepFold is one walk-forward split and the configuration that did best on its training window
+type SweepFold struct {
+	Fold           int                `json:"fold"`
+	Train          Window             `json:"train"`
+	Test           Window             `json:"test"`
+	BestParams     map[string]float64 `json:"best_params"`
+	TrainObjective float64            `json:"train_objective"`
+	TestObjective  float64            `json:"test_objective"`
+	TestPnLUSD     float64            `json:"test_pnl_usd"`
+}
+
+// SweepResult is every configuration ranked by score, best first
+type SweepResult struct {
+	Spec    SweepSpec     `json:"spec"`
+	Folds   []SweepFold   `json:"folds,omitempty"`
+	Configs []SweepConfig `json:"configs"`
+	// WalkForwardPnLUSD is the out-of-sample PnL of trading each test window
+	// with the configuration chosen on its training window
+	WalkForwardPnLUSD float64 `json:"walk_forward_pnl_usd,omitempty"`
+}
+
+// sweepJob is one backtest of one configuration on one window
+type sweepJob struct {
+	config int
+	fold   int
+	test   bool
+}
+
+// Sweep backtests every configuration in the spec, in parallel, and ranks
+// them. Parameters not in the spec keep their values from cfg and weights;
+// nil weights are loaded from STRATEGY_WEIGHTS_FILE or the built-in defaults.
+func Sweep(cfg *config.Config, weights *strategy.Weights, histories []TokenHistory, spec SweepSpec) (*SweepResult, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	if err := spec.checkRouted(cfg); err != nil {
+		return nil, err
+	}
+	if spec.Objective == "" {
+		spec.Objective = ObjectivePnL
+	}
+	if weights == nil {
+		weights = strategy.DefaultWeights()
+		if cfg.StrategyWeightsFile != "" {
+			loaded, err := strategy.LoadWeights(cfg.StrategyWeightsFile)
+			if err != nil {
+				return nil, err
+			}
+			weights = loaded
+		}
+	}
+
+	windows, err := walkForward(histories, spec.Folds)
+	if err != nil {
+		return nil, err
+	}
+
+	params := spec.configurations()
+	configs := make([]config.Config, len(params))
+	configWeights := make([]*strategy.Weights, len(params))
+	result := &SweepResult{Spec: spec, Configs: make([]SweepConfig, len(params))}
+	for i, values := range params {
+		configs[i] = *cfg
+		configWeights[i] = weights.Clone()
+		configWeights[i].Version = fmt.Sprintf("sweep-%d", i+1)
+		for name, v := range values {
+			sweepParams[name].set(&configs[i], configWeights[i], v)
+		}
+		result.Configs[i].Params = values
+		if err := configWeights[i].Validate(); err != nil {
+			result.Configs[i].Error = err.Error()
+		}
+	}
+
+	var jobs []sweepJob
+	for i := range params {
+		if result.Configs[i].Error != "" {
+			continue
+		}
+		for fold := range windows {
+			jobs = append(jobs, sweepJob{config: i, fold: fold})
+			if spec.Folds > 0 {
+				jobs = append(jobs, sweepJob{config: i, fold: fold, test: true})
+			}
+		}
+	}
+
+	workers := spec.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]*Result, len(jobs))
+	errs := make([]error, len(jobs))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				job := jobs[j]
+				data := windows[job.fold].train
+				if job.test {
+					data = windows[job.fold].test
+				}
+				options := Options{Seed: spec.Seed, SlippageNoise: spec.SlippageNoise, Weights: configWeights[job.config]}
+				results[j], errs[j] = Run(&configs[job.config], data, options)
+			}
+		}()
+	}
+	for j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	// Per-fold metrics for choosing each fold's best configuration
+	foldTrain := make([][]SweepMetrics, len(windows))
+	foldTest := make([][]SweepMetrics, len(windows))
+	for fold := range windows {
+		foldTrain[fold] = make([]SweepMetrics, len(params))
+		foldTest[fold] = make([]SweepMetrics, len(params))
+	}
+	for j, job := range jobs {
+		c := &result.Configs[job.config]
+		if errs[j] != nil {
+			c.Error = errs[j].Error()
+			continue
+		}
+		if job.test {
+			c.Test.add(results[j])
+			foldTest[job.fold][job.config].add(results[j])
+		} else {
+			c.Train.add(results[j])
+			foldTrain[job.fold][job.config].add(results[j])
+		}
+	}
+
+	for i := range result.Configs {
+		c := &result.Configs[i]
+		c.Train.score(spec.Objective)
+		c.Test.score(spec.Objective)
+		c.Score = c.Train.Objective
+		if spec.Folds > 0 {
+			c.Score = c.Test.Objective
+		}
+	}
+
+	if spec.Folds > 0 {
+		for fold, window := range windows {
+			best := -1
+			for i := range params {
+				if result.Configs[i].Error != "" {
+					continue
+				}
+				foldTrain[fold][i].score(spec.Objective)
+				if best < 0 || foldTrain[fold][i].Objective > foldTrain[fold][best].Objective {
+					best = i
+				}
+			}
+			summary := SweepFold{Fold: fold + 1, Train: window.trainWindow, Test: window.testWindow}
+			if best >= 0 {
+				test := foldTest[fold][best]
+				test.score(spec.Objective)
+				summary.BestParams = params[best]
+				summary.TrainObjective = foldTrain[fold][best].Objective
+				summary.TestObjective = test.Objective
+				summary.TestPnLUSD = test.PnLUSD
+				result.WalkForwardPnLUSD += test.PnLUSD
+			}
+			result.Folds = append(result.Folds, summary)
+		}
+	}
+
+	// Failed configurations rank last; ties keep the order they were generated in
+	sort.SliceStable(result.Configs, func(i, j int) bool {
+		a, b := result.Configs[i], result.Configs[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		return a.Score > b.Score
+	})
+	for i := range result.Configs {
+		result.Configs[i].Rank = i + 1
+	}
+	return result, nil
+}
+
+// configurations expands the grid, or draws the random samples
+func (s *SweepSpec) configurations() []map[string]float64 {
+	names := make([]string, 0, len(s.Params))
+	for name := range s.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if s.Samples > 0 {
+		rng := rand.New(rand.NewSource(s.Seed))
+		configs := make([]map[string]float64, s.Samples)
+		for i := range configs {
+			configs[i] = make(map[string]float64, len(names))
+			for _, name := range names {
+				param := s.Params[name]
+				if len(param.Values) > 0 {
+					configs[i][name] = param.Values[rng.Intn(len(param.Values))]
+				} else {
+					configs[i][name] = param.Min + rng.Float64()*(param.Max-param.Min)
+				}
+			}
+		}
+		return configs
+	}
+
+	configs := []map[string]float64{{}}
+	for _, name := range names {
+		var expanded []map[string]float64
+		for _, base := range configs {
+			for _, v := range s.Params[name].Values {
+				next := make(map[string]float64, len(base)+1)
+				for k, bv := range base {
+					next[k] = bv
+				}
+				next[name] = v
+				expanded = append(expanded, next)
+			}
+		}
+		configs = expanded
+	}
+	return configs
+}
+
+// foldData is the histories of one walk-forward split
+type foldData struct {
+	train, test             []TokenHistory
+	trainWindow, testWindow Window
+}
+
+// walkForward splits the histories by discovery time into folds+1 equal
+// blocks. Fold i trains on blocks 1..i and tests on block i+1, so every test
+// window lies after the data its configuration was chosen on. Without folds
+// the whole dataset is one training window.
+func walkForward(histories []TokenHistory, folds int) ([]foldData, error) {
+	if folds == 0 {
+		return []foldData{{train: histories, trainWindow: window(histories)}}, nil
+	}
+
+	sorted := make([]TokenHistory, len(histories))
+	copy(sorted, histories)
+	for i := range sorted {
+		if err := sorted[i].normalize(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SeenAt.Before(sorted[j].SeenAt) })
+
+	blocks := folds + 1
+	if len(sorted) < blocks {
+		return nil, fmt.Errorf("%d folds need at least %d tokens, got %d", folds, blocks, len(sorted))
+	}
+	bounds := make([]int, blocks+1)
+	for b := range bounds {
+		bounds[b] = b * len(sorted) / blocks
+	}
+
+	splits := make([]foldData, folds)
+	for i := range splits {
+		train := sorted[:bounds[i+1]]
+		test := sorted[bounds[i+1]:bounds[i+2]]
+		splits[i] = foldData{train: train, test: test, trainWindow: window(train), testWindow: window(test)}
+	}
+	return splits, nil
+}
+
+// window spans the discovery times of the histories
+func window(histories []TokenHistory) Window {
+	w := Window{Tokens: len(histories)}
+	for _, h := range histories {
+		seen := h.SeenAt
+		if seen.IsZero() && h.Token.FirstSeenTS != 0 {
+			seen = time.Unix(h.Token.FirstSeenTS, 0).UTC()
+		}
+		if w.Start.IsZero() || seen.Before(w.Start) {
+			w.Start = seen
+		}
+		if seen.After(w.End) {
+			w.End = seen
+		}
+	}
+	return w
+}
+
+// WriteSweepCSV writes one row per configuration, in rank order
+func WriteSweepCSV(out io.Writer, r *SweepResult) error {
+	names := make([]string, 0, len(r.Spec.Params))
+	for name := range r.Spec.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := csv.NewWriter(out)
+	header := append([]string{"rank"}, names...)
+	for _, set := range []string{"train", "test"} {
+		header = append(header, set+"_trades", set+"_win_rate", set+"_pnl_usd", set+"_max_drawdown_usd", set+"_objective")
+	}
+	header = append(header, "score", "error")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for _, c := range r.Configs {
+		row := []string{strconv.Itoa(c.Rank)}
+		for _, name := range names {
+			row = append(row, format(c.Params[name]))
+		}
+		for _, m := range []SweepMetrics{c.Train, c.Test} {
+			row = append(row, strconv.Itoa(m.Trades), format(m.WinRate), format(m.PnLUSD), format(m.MaxDrawdownUSD), format(m.Objective))
+		}
+		row = append(row, format(c.Score), c.Error)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,173 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/agents/strategy"
+)
+
+// staggered returns n alternating winners and losers, discovered an hour apart
+func staggered(n int) []TokenHistory {
+	var histories []TokenHistory
+	for i := 0; i < n; i++ {
+		h := history(string(rune('a'+i)), 1, 1.1, 3)
+		if i%2 == 1 {
+			h = history(string(rune('a'+i)), 1, 0.9, 0.2)
+		}
+		shift := time.Duration(i) * time.Hour
+		h.SeenAt = h.SeenAt.Add(shift)
+		h.OffChain[0].EvaluatedAt = h.OffChain[0].EvaluatedAt.Add(shift)
+		for j := range h.Prices {
+			h.Prices[j].Time = h.Prices[j].Time.Add(shift)
+		}
+		histories = append(histories, h)
+	}
+	return histories
+}
+
+func TestSweepGrid(t *testing.T) {
+	spec := SweepSpec{
+		Params: map[string]SweepParam{
+			"win_probability_threshold": {Values: []float64{0.8, 0.999}},
+			"stop_loss.medium":          {Values: []float64{0.1, 0.5}},
+			"take_profit.min":           {Values: []float64{0.2, 2}}, // 2 is above take_profit.max
+		},
+		Seed: 1,
+	}
+	result, err := Sweep(testConfig(), nil, dataset(), spec)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if len(result.Configs) != 8 {
+		t.Fatalf("expected 8 configurations, got %d", len(result.Configs))
+	}
+	for i, c := range result.Configs {
+		if c.Rank != i+1 {
+			t.Errorf("config %d has rank %d", i, c.Rank)
+		}
+		if i < 4 && c.Error != "" {
+			t.Errorf("valid configuration ranked below an invalid one: %+v", c)
+		}
+		if i >= 4 && (c.Error == "" || c.Params["take_profit.min"] != 2) {
+			t.Errorf("expected invalid configurations last, got %+v", c)
+		}
+		if i > 0 && i < 4 && c.Score > result.Configs[i-1].Score {
+			t.Errorf("configurations out of order: %v before %v", result.Configs[i-1].Score, c.Score)
+		}
+	}
+
+	// The best configuration scores what a single backtest with its parameters does
+	best := result.Configs[0]
+	cfg := testConfig()
+	cfg.WinProbabilityThreshold = best.Params["win_probability_threshold"]
+	weights := strategy.DefaultWeights()
+	weights.StopLoss["medium"] = best.Params["stop_loss.medium"]
+	weights.TakeProfit.Min = best.Params["take_profit.min"]
+	single, err := Run(cfg, dataset(), Options{Seed: 1, Weights: weights})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if best.Score != single.PnLUSD || best.Train.Trades != len(single.Trades) {
+		t.Errorf("sweep scored %f over %d trades, backtest made %f over %d", best.Score, best.Train.Trades, single.PnLUSD, len(single.Trades))
+	}
+
+	// Parallelism does not change the outcome
+	spec.Workers = 1
+	serial, _ := Sweep(testConfig(), nil, dataset(), spec)
+	spec.Workers = 4
+	parallel, _ := Sweep(testConfig(), nil, dataset(), spec)
+	if !reflect.DeepEqual(serial.Configs, parallel.Configs) {
+		t.Error("serial and parallel sweeps ranked differently")
+	}
+
+	var out bytes.Buffer
+	if err := WriteSweepCSV(&out, result); err != nil {
+		t.Fatalf("WriteSweepCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(rows) != 9 || rows[0][1] != "stop_loss.medium" || rows[1][0] != "1" {
+		t.Errorf("unexpected CSV %v", rows)
+	}
+}
+
+func TestSweepWalkForward(t *testing.T) {
+	spec := SweepSpec{
+		Params:  map[string]SweepParam{"win_probability_threshold": {Min: 0.5, Max: 0.99}},
+		Samples: 5,
+		Folds:   3,
+		Seed:    3,
+	}
+	result, err := Sweep(testConfig(), nil, staggered(8), spec)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if len(result.Configs) != 5 || len(result.Folds) != 3 {
+		t.Fatalf("expected 5 configurations over 3 folds, got %d and %d", len(result.Configs), len(result.Folds))
+	}
+	for _, c := range result.Configs {
+		v := c.Params["win_probability_threshold"]
+		if v < 0.5 || v > 0.99 {
+			t.Errorf("sample %v outside its range", v)
+		}
+		if c.Train.Runs != 3 || c.Test.Runs != 3 || c.Score != c.Test.Objective {
+			t.Errorf("expected one train and one test run per fold, scored on test: %+v", c)
+		}
+	}
+
+	walkForward := 0.0
+	for i, fold := range result.Folds {
+		if !fold.Test.Start.After(fold.Train.End) {
+			t.Errorf("fold %d tests on %s, before its training window ends at %s", fold.Fold, fold.Test.Start, fold.Train.End)
+		}
+		if i > 0 && fold.Train.Tokens <= result.Folds[i-1].Train.Tokens {
+			t.Errorf("fold %d training window did not grow", fold.Fold)
+		}
+		walkForward += fold.TestPnLUSD
+	}
+	if result.WalkForwardPnLUSD != walkForward {
+		t.Errorf("walk-forward PnL %f does not match the folds' %f", result.WalkForwardPnLUSD, walkForward)
+	}
+
+	again, _ := Sweep(testConfig(), nil, staggered(8), spec)
+	if !reflect.DeepEqual(result, again) {
+		t.Error("same spec and seed produced a different sweep")
+	}
+
+	spec.Folds = 8
+	if _, err := Sweep(testConfig(), nil, staggered(8), spec); err == nil {
+		t.Error("expected an error for more folds than tokens")
+	}
+	if _, err := Sweep(testConfig(), nil, staggered(8), SweepSpec{Params: map[string]SweepParam{"unknown": {Values: []float64{1}}}}); err == nil {
+		t.Error("expected an error for an unknown parameter")
+	}
+}
+
+func TestSweepRejectsParamsOfUnroutedStrategies(t *testing.T) {
+	cfg := testConfig()
+	cfg.DefaultStrategy = "momentum"
+	cfg.StrategyRoutes = []string{"solana=pumpfun_sniper"}
+
+	spec := SweepSpec{Params: map[string]SweepParam{"stop_loss.medium": {Values: []float64{0.1, 0.2}}}}
+	if _, err := Sweep(cfg, nil, dataset(), spec); err == nil || !strings.Contains(err.Error(), "heuristic and model") {
+		t.Errorf("expected the heuristic risk table to be rejected, got %v", err)
+	}
+
+	spec.Params = map[string]SweepParam{"strategies.momentum.stop_loss.medium": {Values: []float64{0.1, 0.2}}}
+	result, err := Sweep(cfg, nil, dataset(), spec)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	for _, c := range result.Configs {
+		if c.Error != "" {
+			t.Errorf("unexpected configuration error %s", c.Error)
+		}
+	}
+}
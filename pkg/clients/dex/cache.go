@@ -0,0 +1,121 @@
+package dex
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// loadTimeout bounds a shared upstream request, which outlives the caller
+// that started it
+const loadTimeout = 30 * time.Second
+
+// cacheEntry is a cached response with its expiry
+type cacheEntry struct {
+	value     *TokenData
+	expiresAt time.Time
+}
+
+// inflightCall is a lookup shared by concurrent callers of the same key
+type inflightCall struct {
+	done  chan struct{}
+	value *TokenData
+	err   error
+}
+
+// responseCache is a small TTL cache for token lookups. Concurrent misses
+// for the same key share one upstream request.
+type responseCache struct {
+	ttl      time.Duration
+	entries  map[string]cacheEntry
+	inflight map[string]*inflightCall
+	mu       sync.Mutex
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:      ttl,
+		entries:  make(map[string]cacheEntry),
+		inflight: make(map[string]*inflightCall),
+	}
+}
+
+// fetch returns the cached value for key or calls load once for all
+// concurrent callers. The load runs detached from any one caller, so a
+// cancelled caller gets its own ctx error while the others still get the
+// result.
+func (c *responseCache) fetch(ctx context.Context, key string, load func(context.Context) (*TokenData, error)) (*TokenData, error) {
+	if value, ok := c.get(key); ok {
+		return value, nil
+	}
+
+	c.mu.Lock()
+	call, exists := c.inflight[key]
+	if !exists {
+		call = &inflightCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		go c.load(ctx, key, call, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load runs a shared lookup on a context that keeps ctx's values but not its
+// cancellation
+func (c *responseCache) load(ctx context.Context, key string, call *inflightCall, load func(context.Context) (*TokenData, error)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+	defer cancel()
+
+	call.value, call.err = load(ctx)
+	if call.err == nil {
+		c.put(key, call.value)
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+}
+
+func (c *responseCache) get(key string) (*TokenData, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *responseCache) put(key string, value *TokenData) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Drop expired entries opportunistically so the cache does not grow unbounded
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,170 @@
+// Package dex provides clients for public DEX market data APIs
+// (DexScreener and GeckoTerminal).
+package dex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// PairData describes a single liquidity pool for a token
+type PairData struct {
+	DEX            string   `json:"dex"`
+	PairAddress    string   `json:"pair_address"`
+	QuoteSymbol    string   `json:"quote_symbol"`
+	PriceUSD       float64  `json:"price_usd"`
+	PriceNative    float64  `json:"price_native"`
+	Volume24h      float64  `json:"volume_24h"`
+	Buys24h        int      `json:"buys_24h"`
+	Sells24h       int      `json:"sells_24h"`
+	LiquidityUSD   float64  `json:"liquidity_usd"`
+	LiquidityBase  float64  `json:"liquidity_base"`   // token reserve
+	LiquidityQuote float64  `json:"liquidity_quote"`  // quote reserve
+	Labels         []string `json:"labels,omitempty"` // pool version, e.g. "v2", "v3", "CLMM", "wp"
+}
+
+// TokenData aggregates market data for a token across its pools on one chain
+type TokenData struct {
+	Chain        models.Chain `json:"chain"`
+	TokenAddress string       `json:"token_address"`
+	PriceUSD     float64      `json:"price_usd"`  // from the deepest pool
+	Volume24h    float64      `json:"volume_24h"` // summed across pools
+	Trades24h    int          `json:"trades_24h"`
+	LiquidityUSD float64      `json:"liquidity_usd"` // summed across pools
+	MarketCap    float64      `json:"market_cap"`
+	FDV          float64      `json:"fdv"`
+	Pairs        []PairData   `json:"pairs,omitempty"`
+	Socials      []Social     `json:"socials,omitempty"`
+	FetchedAt    time.Time    `json:"fetched_at"`
+}
+
+// Social is a project link listed for a token
+type Social struct {
+	Type string `json:"type"` // "website", "twitter", "telegram", "discord", ...
+	URL  string `json:"url"`
+}
+
+// addSocial records a project link once, ignoring duplicates across pools
+func (t *TokenData) addSocial(kind, url string) {
+	if url == "" {
+		return
+	}
+	for _, s := range t.Socials {
+		if s.URL == url {
+			return
+		}
+	}
+	t.Socials = append(t.Socials, Social{Type: kind, URL: url})
+}
+
+// TopPair returns the pool with the deepest liquidity, if any
+func (t *TokenData) TopPair() *PairData {
+	var top *PairData
+	for i := range t.Pairs {
+		if top == nil || t.Pairs[i].LiquidityUSD > top.LiquidityUSD {
+			top = &t.Pairs[i]
+		}
+	}
+	return top
+}
+
+// Pool returns the pool's reserves for price impact estimates, or nil when the
+// API reported no reserves. Concentrated pools carry no liquidity ranges here,
+// so impact is estimated as if the reported reserves were constant-product,
+// which overstates impact near the current price.
+func (p *PairData) Pool() *models.PoolState {
+	if p.LiquidityBase <= 0 || p.LiquidityQuote <= 0 || p.PriceNative <= 0 || p.PriceUSD <= 0 {
+		return nil
+	}
+
+	kind, feeBps := poolKind(p.DEX, p.Labels)
+	version := ""
+	if len(p.Labels) > 0 {
+		version = strings.ToLower(p.Labels[0])
+	}
+	return &models.PoolState{
+		DEX:           p.DEX,
+		Address:       p.PairAddress,
+		Kind:          kind,
+		Version:       version,
+		QuoteSymbol:   p.QuoteSymbol,
+		QuotePriceUSD: p.PriceUSD / p.PriceNative,
+		ReserveToken:  p.LiquidityBase,
+		ReserveQuote:  p.LiquidityQuote,
+		FeeBps:        feeBps,
+		Price:         p.PriceNative,
+	}
+}
+
+// poolKind infers the pool type and swap fee from the DEX and its version labels.
+// Fee tiers of concentrated pools are not reported, so the common tier is assumed.
+func poolKind(dexID string, labels []string) (string, float64) {
+	label := ""
+	if len(labels) > 0 {
+		label = strings.ToLower(labels[0])
+	}
+
+	switch strings.ToLower(dexID) {
+	case "uniswap", "pancakeswap", "sushiswap":
+		if label == "v3" || label == "v4" {
+			return models.PoolConcentrated, 30
+		}
+		return models.PoolConstantProduct, 30
+	case "raydium":
+		if label == "clmm" {
+			return models.PoolConcentrated, 25
+		}
+		return models.PoolConstantProduct, 25
+	case "orca":
+		return models.PoolConcentrated, 30
+	case "meteora":
+		if label == "dlmm" {
+			return models.PoolConcentrated, 30
+		}
+		return models.PoolConstantProduct, 30
+	case "pumpfun":
+		return models.PoolConstantProduct, 100
+	}
+	return models.PoolConstantProduct, 30
+}
+
+// ErrNotFound is returned when the API has no data for the token
+var ErrNotFound = fmt.Errorf("token not found")
+
+// getJSON performs a rate-limited GET and decodes the JSON body into out
+func getJSON(ctx context.Context, client *http.Client, limiter *RateLimiter, url string, out interface{}) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited by %s", req.URL.Host)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,241 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// replayServer serves recorded JSON fixtures by request path and counts hits
+func replayServer(t *testing.T, fixtures map[string]string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		fixture, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("read fixture %s: %v", fixture, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &hits
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestDexScreenerSolana(t *testing.T) {
+	const token = "7GCihgDB8fe6KNjn2MYtkzZcRjQy3t9GHdC8uHYmW2hr"
+	server, _ := replayServer(t, map[string]string{
+		"/tokens/v1/solana/" + token: "dexscreener_solana.json",
+	})
+
+	client := NewDexScreenerClient(server.URL, time.Minute)
+	data, err := client.GetToken(context.Background(), models.ChainSolana, token)
+	if err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+
+	// The SOL/POPCAT pool quotes SOL, not our token, and must be ignored
+	if len(data.Pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %d", len(data.Pairs))
+	}
+	if !approx(data.Volume24h, 1825432.17+210441.9) {
+		t.Errorf("unexpected volume %f", data.Volume24h)
+	}
+	if !approx(data.LiquidityUSD, 2401877.31+301220.44) {
+		t.Errorf("unexpected liquidity %f", data.LiquidityUSD)
+	}
+	if !approx(data.PriceUSD, 0.6421) {
+		t.Errorf("expected price from deepest pool, got %f", data.PriceUSD)
+	}
+	if data.Trades24h != 3120+2980+510+488 {
+		t.Errorf("unexpected trade count %d", data.Trades24h)
+	}
+	if !approx(data.MarketCap, 629301223) {
+		t.Errorf("unexpected market cap %f", data.MarketCap)
+	}
+
+	top := data.TopPair()
+	if top.DEX != "raydium" || !approx(top.LiquidityBase, 1870211.4) || !approx(top.LiquidityQuote, 8432.9) {
+		t.Errorf("unexpected top pair %+v", top)
+	}
+
+	pool := top.Pool()
+	if pool == nil || pool.Kind != models.PoolConstantProduct || pool.FeeBps != 25 {
+		t.Fatalf("unexpected pool %+v", pool)
+	}
+	if !approx(pool.QuotePriceUSD, 0.6421/0.004512) || !approx(pool.ReserveQuote, 8432.9) {
+		t.Errorf("unexpected pool reserves %+v", pool)
+	}
+	if orca := data.Pairs[1].Pool(); orca == nil || orca.Kind != models.PoolConcentrated {
+		t.Errorf("expected whirlpool to be concentrated, got %+v", orca)
+	}
+}
+
+func TestDexScreenerBaseMatchesAddressCaseInsensitively(t *testing.T) {
+	const token = "0x532f27101965dd16442E59d40670FaF5eBB142E4"
+	server, _ := replayServer(t, map[string]string{
+		"/tokens/v1/base/" + token: "dexscreener_base.json",
+	})
+
+	client := NewDexScreenerClient(server.URL, time.Minute)
+	data, err := client.GetToken(context.Background(), models.ChainBase, token)
+	if err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+
+	if len(data.Pairs) != 2 {
+		t.Fatalf("expected both pools to match, got %d", len(data.Pairs))
+	}
+	if !approx(data.Volume24h, 3210993.4+402117.6) {
+		t.Errorf("unexpected volume %f", data.Volume24h)
+	}
+	if len(data.Socials) != 3 || data.Socials[2] != (Social{Type: "telegram", URL: "https://t.me/BasedBrett"}) {
+		t.Errorf("unexpected socials %+v", data.Socials)
+	}
+}
+
+func TestDexScreenerCachesResponses(t *testing.T) {
+	const token = "7GCihgDB8fe6KNjn2MYtkzZcRjQy3t9GHdC8uHYmW2hr"
+	server, hits := replayServer(t, map[string]string{
+		"/tokens/v1/solana/" + token: "dexscreener_solana.json",
+	})
+
+	client := NewDexScreenerClient(server.URL, time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetToken(context.Background(), models.ChainSolana, token); err != nil {
+			t.Fatalf("GetToken: %v", err)
+		}
+	}
+
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("expected 1 upstream request, got %d", n)
+	}
+}
+
+func TestCacheLoadSurvivesCancelledCaller(t *testing.T) {
+	cache := newResponseCache(time.Minute)
+	release := make(chan struct{})
+	load := func(ctx context.Context) (*TokenData, error) {
+		select {
+		case <-release:
+			return &TokenData{PriceUSD: 1.5}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cache.fetch(first, "k", load)
+		firstErr <- err
+	}()
+	waiter := make(chan *TokenData, 1)
+	go func() {
+		// Give the first caller time to start the shared load
+		time.Sleep(20 * time.Millisecond)
+		value, err := cache.fetch(context.Background(), "k", load)
+		if err != nil {
+			t.Errorf("waiter: %v", err)
+		}
+		waiter <- value
+	}()
+
+	time.Sleep(40 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled caller to get its own error, got %v", err)
+	}
+	close(release)
+	if value := <-waiter; value == nil || value.PriceUSD != 1.5 {
+		t.Errorf("expected the waiter to get the shared result, got %+v", value)
+	}
+}
+
+func TestDexScreenerNotFound(t *testing.T) {
+	server, _ := replayServer(t, map[string]string{})
+
+	client := NewDexScreenerClient(server.URL, time.Minute)
+	_, err := client.GetToken(context.Background(), models.ChainBase, "0xdead")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGeckoTerminalBase(t *testing.T) {
+	const token = "0x532f27101965dd16442e59d40670faf5ebb142e4"
+	server, _ := replayServer(t, map[string]string{
+		"/networks/base/tokens/" + token: "geckoterminal_base.json",
+	})
+
+	client := NewGeckoTerminalClient(server.URL, time.Minute)
+	data, err := client.GetToken(context.Background(), models.ChainBase, token)
+	if err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+
+	if !approx(data.PriceUSD, 0.101174120394) {
+		t.Errorf("unexpected price %f", data.PriceUSD)
+	}
+	if !approx(data.Volume24h, 3613111.2031) {
+		t.Errorf("unexpected volume %f", data.Volume24h)
+	}
+	if !approx(data.LiquidityUSD, 10342349.1124) {
+		t.Errorf("unexpected liquidity %f", data.LiquidityUSD)
+	}
+	// market_cap_usd is null in the recording, so FDV is used instead
+	if !approx(data.MarketCap, 1011741193.21) {
+		t.Errorf("expected FDV fallback for market cap, got %f", data.MarketCap)
+	}
+}
+
+func TestRateLimiterWaits(t *testing.T) {
+	limiter := NewRateLimiter(2, 200*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+
+	// Two requests fit in the burst; the third waits for a refill (~100ms)
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected third request to be delayed, took %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if err := limiter.Wait(cancelled); err == nil {
+		t.Error("expected cancelled context to abort the wait")
+	}
+}
@@ -0,0 +1,168 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// DexScreenerBaseURL is the public DexScreener API
+const DexScreenerBaseURL = "https://api.dexscreener.com"
+
+// DexScreener allows 300 requests per minute on the pair/token endpoints
+const dexScreenerRequestsPerMinute = 300
+
+// DexScreenerClient queries the DexScreener token pairs endpoint
+type DexScreenerClient struct {
+	baseURL string
+	http    *http.Client
+	limiter *RateLimiter
+	cache   *responseCache
+}
+
+// NewDexScreenerClient creates a DexScreener client. An empty baseURL uses the public API.
+func NewDexScreenerClient(baseURL string, cacheTTL time.Duration) *DexScreenerClient {
+	if baseURL == "" {
+		baseURL = DexScreenerBaseURL
+	}
+	return &DexScreenerClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+		limiter: NewRateLimiter(dexScreenerRequestsPerMinute, time.Minute),
+		cache:   newResponseCache(cacheTTL),
+	}
+}
+
+// dexScreenerPair mirrors a pair object of the DexScreener API
+type dexScreenerPair struct {
+	ChainID     string   `json:"chainId"`
+	DexID       string   `json:"dexId"`
+	PairAddress string   `json:"pairAddress"`
+	Labels      []string `json:"labels"`
+	BaseToken   struct {
+		Address string `json:"address"`
+		Symbol  string `json:"symbol"`
+	} `json:"baseToken"`
+	QuoteToken struct {
+		Address string `json:"address"`
+		Symbol  string `json:"symbol"`
+	} `json:"quoteToken"`
+	PriceNative string `json:"priceNative"`
+	PriceUSD    string `json:"priceUsd"`
+	Txns        map[string]struct {
+		Buys  int `json:"buys"`
+		Sells int `json:"sells"`
+	} `json:"txns"`
+	Volume    map[string]float64 `json:"volume"`
+	Liquidity *struct {
+		USD   float64 `json:"usd"`
+		Base  float64 `json:"base"`
+		Quote float64 `json:"quote"`
+	} `json:"liquidity"`
+	FDV       float64 `json:"fdv"`
+	MarketCap float64 `json:"marketCap"`
+	Info      *struct {
+		Websites []struct {
+			URL string `json:"url"`
+		} `json:"websites"`
+		Socials []struct {
+			Type string `json:"type"`
+			URL  string `json:"url"`
+		} `json:"socials"`
+	} `json:"info"`
+}
+
+// GetToken returns aggregated market data for a token on the given chain
+func (c *DexScreenerClient) GetToken(ctx context.Context, chain models.Chain, tokenAddress string) (*TokenData, error) {
+	return c.cache.fetch(ctx, string(chain)+":"+tokenAddress, func(ctx context.Context) (*TokenData, error) {
+		return c.fetchToken(ctx, chain, tokenAddress)
+	})
+}
+
+// fetchToken queries the API for a token, bypassing the cache
+func (c *DexScreenerClient) fetchToken(ctx context.Context, chain models.Chain, tokenAddress string) (*TokenData, error) {
+	endpoint := fmt.Sprintf("%s/tokens/v1/%s/%s", c.baseURL, url.PathEscape(string(chain)), url.PathEscape(tokenAddress))
+
+	var pairs []dexScreenerPair
+	if err := getJSON(ctx, c.http, c.limiter, endpoint, &pairs); err != nil {
+		return nil, fmt.Errorf("dexscreener: %w", err)
+	}
+
+	data := &TokenData{
+		Chain:        chain,
+		TokenAddress: tokenAddress,
+		FetchedAt:    time.Now(),
+	}
+
+	for _, p := range pairs {
+		// Only pools where our token is the base asset quote its price directly
+		if p.ChainID != string(chain) || !strings.EqualFold(p.BaseToken.Address, tokenAddress) {
+			continue
+		}
+
+		pair := PairData{
+			DEX:         p.DexID,
+			PairAddress: p.PairAddress,
+			QuoteSymbol: p.QuoteToken.Symbol,
+			PriceUSD:    parseFloat(p.PriceUSD),
+			PriceNative: parseFloat(p.PriceNative),
+			Volume24h:   p.Volume["h24"],
+			Buys24h:     p.Txns["h24"].Buys,
+			Sells24h:    p.Txns["h24"].Sells,
+			Labels:      p.Labels,
+		}
+		if p.Liquidity != nil {
+			pair.LiquidityUSD = p.Liquidity.USD
+			pair.LiquidityBase = p.Liquidity.Base
+			pair.LiquidityQuote = p.Liquidity.Quote
+		}
+
+		data.Pairs = append(data.Pairs, pair)
+		data.Volume24h += pair.Volume24h
+		data.Trades24h += pair.Buys24h + pair.Sells24h
+		data.LiquidityUSD += pair.LiquidityUSD
+		if p.MarketCap > data.MarketCap {
+			data.MarketCap = p.MarketCap
+		}
+		if p.FDV > data.FDV {
+			data.FDV = p.FDV
+		}
+		if p.Info != nil {
+			for _, w := range p.Info.Websites {
+				data.addSocial("website", w.URL)
+			}
+			for _, s := range p.Info.Socials {
+				data.addSocial(s.Type, s.URL)
+			}
+		}
+	}
+
+	if len(data.Pairs) == 0 {
+		return nil, fmt.Errorf("dexscreener: %w", ErrNotFound)
+	}
+
+	data.PriceUSD = data.TopPair().PriceUSD
+	if data.MarketCap == 0 {
+		data.MarketCap = data.FDV
+	}
+
+	return data, nil
+}
+
+// parseFloat parses the string-encoded numbers used by DEX APIs, returning 0 when absent
+func parseFloat(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,94 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// GeckoTerminalBaseURL is the public GeckoTerminal API
+const GeckoTerminalBaseURL = "https://api.geckoterminal.com/api/v2"
+
+// GeckoTerminal's free tier allows 30 requests per minute
+const geckoTerminalRequestsPerMinute = 30
+
+// GeckoTerminalClient queries the GeckoTerminal token endpoint
+type GeckoTerminalClient struct {
+	baseURL string
+	http    *http.Client
+	limiter *RateLimiter
+	cache   *responseCache
+}
+
+// NewGeckoTerminalClient creates a GeckoTerminal client. An empty baseURL uses the public API.
+func NewGeckoTerminalClient(baseURL string, cacheTTL time.Duration) *GeckoTerminalClient {
+	if baseURL == "" {
+		baseURL = GeckoTerminalBaseURL
+	}
+	return &GeckoTerminalClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+		limiter: NewRateLimiter(geckoTerminalRequestsPerMinute, time.Minute),
+		cache:   newResponseCache(cacheTTL),
+	}
+}
+
+// geckoTerminalToken mirrors the token resource of the GeckoTerminal API
+type geckoTerminalToken struct {
+	Data struct {
+		Attributes struct {
+			Address           string            `json:"address"`
+			Symbol            string            `json:"symbol"`
+			PriceUSD          string            `json:"price_usd"`
+			FDVUSD            string            `json:"fdv_usd"`
+			MarketCapUSD      *string           `json:"market_cap_usd"`
+			TotalReserveInUSD string            `json:"total_reserve_in_usd"`
+			VolumeUSD         map[string]string `json:"volume_usd"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// GetToken returns aggregated market data for a token on the given chain
+func (c *GeckoTerminalClient) GetToken(ctx context.Context, chain models.Chain, tokenAddress string) (*TokenData, error) {
+	return c.cache.fetch(ctx, string(chain)+":"+tokenAddress, func(ctx context.Context) (*TokenData, error) {
+		return c.fetchToken(ctx, chain, tokenAddress)
+	})
+}
+
+// fetchToken queries the API for a token, bypassing the cache
+func (c *GeckoTerminalClient) fetchToken(ctx context.Context, chain models.Chain, tokenAddress string) (*TokenData, error) {
+	endpoint := fmt.Sprintf("%s/networks/%s/tokens/%s", c.baseURL, url.PathEscape(string(chain)), url.PathEscape(tokenAddress))
+
+	var token geckoTerminalToken
+	if err := getJSON(ctx, c.http, c.limiter, endpoint, &token); err != nil {
+		return nil, fmt.Errorf("geckoterminal: %w", err)
+	}
+
+	attrs := token.Data.Attributes
+	if attrs.Address == "" {
+		return nil, fmt.Errorf("geckoterminal: %w", ErrNotFound)
+	}
+
+	data := &TokenData{
+		Chain:        chain,
+		TokenAddress: tokenAddress,
+		PriceUSD:     parseFloat(attrs.PriceUSD),
+		Volume24h:    parseFloat(attrs.VolumeUSD["h24"]),
+		LiquidityUSD: parseFloat(attrs.TotalReserveInUSD),
+		FDV:          parseFloat(attrs.FDVUSD),
+		FetchedAt:    time.Now(),
+	}
+	if attrs.MarketCapUSD != nil {
+		data.MarketCap = parseFloat(*attrs.MarketCapUSD)
+	}
+	if data.MarketCap == 0 {
+		data.MarketCap = data.FDV
+	}
+
+	return data, nil
+}
@@ -0,0 +1,67 @@
+package dex
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting requests per interval
+type RateLimiter struct {
+	capacity float64
+	tokens   float64
+	refill   float64 // tokens per second
+	last     time.Time
+	mu       sync.Mutex
+}
+
+// NewRateLimiter allows up to requests calls per interval, with bursts of the same size
+func NewRateLimiter(requests int, interval time.Duration) *RateLimiter {
+	if requests <= 0 {
+		requests = 1
+	}
+	return &RateLimiter{
+		capacity: float64(requests),
+		tokens:   float64(requests),
+		refill:   float64(requests) / interval.Seconds(),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := r.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise returns how long to wait for one
+func (r *RateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.refill
+	if r.tokens > r.capacity {
+		r.tokens = r.capacity
+	}
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - r.tokens) / r.refill * float64(time.Second))
+}
@@ -0,0 +1,314 @@
+// Package okx is a minimal client for the OKX v5 REST API
+package okx
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BaseURL is the public OKX REST endpoint
+const BaseURL = "https://www.okx.com"
+
+// instrumentsTTL controls how often the spot instrument list is refreshed
+const instrumentsTTL = 10 * time.Minute
+
+// Credentials are the API key triple used to sign private requests
+type Credentials struct {
+	APIKey     string
+	Secret     string
+	Passphrase string
+}
+
+// Valid reports whether all three credential parts are set
+func (c Credentials) Valid() bool {
+	return c.APIKey != "" && c.Secret != "" && c.Passphrase != ""
+}
+
+// Client talks to the OKX REST API
+type Client struct {
+	baseURL     string
+	credentials Credentials
+	http        *http.Client
+	now         func() time.Time
+
+	instruments   map[string]Instrument // keyed by upper-case base currency
+	instrumentsAt time.Time
+	currencies    map[string][]Currency // keyed by upper-case currency
+	currenciesAt  time.Time
+	mu            sync.Mutex
+}
+
+// NewClient creates an OKX client. An empty baseURL uses the public API.
+// Public market endpoints work without credentials.
+func NewClient(baseURL string, credentials Credentials) *Client {
+	if baseURL == "" {
+		baseURL = BaseURL
+	}
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		credentials: credentials,
+		http:        &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+	}
+}
+
+// Instrument is a spot trading pair
+type Instrument struct {
+	InstID   string `json:"instId"`
+	BaseCcy  string `json:"baseCcy"`
+	QuoteCcy string `json:"quoteCcy"`
+	State    string `json:"state"`
+}
+
+// Currency is one chain a currency can be deposited and withdrawn on
+type Currency struct {
+	Ccy    string `json:"ccy"`
+	Chain  string `json:"chain"`  // "<ccy>-<network>", e.g. "PEPE-ERC20"
+	CtAddr string `json:"ctAddr"` // token contract address on that network
+}
+
+// Network returns the network part of Chain, e.g. "ERC20" or "Solana"
+func (c Currency) Network() string {
+	return strings.TrimPrefix(c.Chain, c.Ccy+"-")
+}
+
+// Ticker is the 24h market summary for an instrument
+type Ticker struct {
+	InstID         string
+	Last           float64
+	Volume24hBase  float64 // in base currency
+	Volume24hQuote float64 // in quote currency
+	Timestamp      time.Time
+}
+
+// APIError is returned when OKX answers with a non-zero code
+type APIError struct {
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("okx: code %s: %s", e.Code, e.Message)
+}
+
+// envelope is the common OKX response wrapper
+type envelope struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Sign computes the OK-ACCESS-SIGN header: base64(HMAC-SHA256(secret, timestamp+method+requestPath+body))
+func Sign(secret, timestamp, method, requestPath, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + strings.ToUpper(method) + requestPath + body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// do performs a request and decodes the data array into out. Requests are
+// signed whenever credentials are configured; private endpoints require it.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, private bool, out interface{}) error {
+	requestPath := path
+	if len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+requestPath, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.credentials.Valid() {
+		timestamp := c.now().UTC().Format("2006-01-02T15:04:05.000Z")
+		req.Header.Set("OK-ACCESS-KEY", c.credentials.APIKey)
+		req.Header.Set("OK-ACCESS-SIGN", Sign(c.credentials.Secret, timestamp, method, requestPath, string(payload)))
+		req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		req.Header.Set("OK-ACCESS-PASSPHRASE", c.credentials.Passphrase)
+	} else if private {
+		return fmt.Errorf("okx: %s requires API credentials", path)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("okx: unexpected response (status %d): %w", resp.StatusCode, err)
+	}
+	if env.Code != "0" {
+		return &APIError{Code: env.Code, Message: env.Msg}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("okx: unexpected status %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal(env.Data, out)
+}
+
+// Instruments returns live spot instruments, cached for a few minutes
+func (c *Client) Instruments(ctx context.Context) (map[string]Instrument, error) {
+	c.mu.Lock()
+	if c.instruments != nil && c.now().Sub(c.instrumentsAt) < instrumentsTTL {
+		instruments := c.instruments
+		c.mu.Unlock()
+		return instruments, nil
+	}
+	c.mu.Unlock()
+
+	var list []Instrument
+	query := url.Values{"instType": {"SPOT"}}
+	if err := c.do(ctx, http.MethodGet, "/api/v5/public/instruments", query, nil, false, &list); err != nil {
+		return nil, err
+	}
+
+	// Only USD stablecoin quotes price in USD; prefer USDT, then USDC
+	instruments := make(map[string]Instrument)
+	for _, inst := range list {
+		if inst.State != "live" || quoteRank(inst.QuoteCcy) >= 2 {
+			continue
+		}
+		base := strings.ToUpper(inst.BaseCcy)
+		existing, exists := instruments[base]
+		if !exists || quoteRank(inst.QuoteCcy) < quoteRank(existing.QuoteCcy) {
+			instruments[base] = inst
+		}
+	}
+
+	c.mu.Lock()
+	c.instruments = instruments
+	c.instrumentsAt = c.now()
+	c.mu.Unlock()
+
+	return instruments, nil
+}
+
+// quoteRank orders quote currencies by preference for USD pricing
+func quoteRank(quote string) int {
+	switch strings.ToUpper(quote) {
+	case "USDT":
+		return 0
+	case "USDC":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Currencies returns every currency's deposit networks and contract
+// addresses, cached like the instrument list. It is a signed request.
+func (c *Client) Currencies(ctx context.Context) (map[string][]Currency, error) {
+	c.mu.Lock()
+	if c.currencies != nil && c.now().Sub(c.currenciesAt) < instrumentsTTL {
+		currencies := c.currencies
+		c.mu.Unlock()
+		return currencies, nil
+	}
+	c.mu.Unlock()
+
+	var list []Currency
+	if err := c.do(ctx, http.MethodGet, "/api/v5/asset/currencies", nil, nil, true, &list); err != nil {
+		return nil, err
+	}
+
+	currencies := make(map[string][]Currency)
+	for _, ccy := range list {
+		key := strings.ToUpper(ccy.Ccy)
+		currencies[key] = append(currencies[key], ccy)
+	}
+
+	c.mu.Lock()
+	c.currencies = currencies
+	c.currenciesAt = c.now()
+	c.mu.Unlock()
+
+	return currencies, nil
+}
+
+// Listing returns the preferred USDT or USDC spot instrument for a symbol, if OKX lists it
+func (c *Client) Listing(ctx context.Context, symbol string) (*Instrument, bool, error) {
+	instruments, err := c.Instruments(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	inst, exists := instruments[strings.ToUpper(symbol)]
+	if !exists {
+		return nil, false, nil
+	}
+	return &inst, true, nil
+}
+
+// Ticker returns the 24h ticker for an instrument
+func (c *Client) Ticker(ctx context.Context, instID string) (*Ticker, error) {
+	var tickers []struct {
+		InstID    string `json:"instId"`
+		Last      string `json:"last"`
+		Vol24h    string `json:"vol24h"`
+		VolCcy24h string `json:"volCcy24h"`
+		TS        string `json:"ts"`
+	}
+	query := url.Values{"instId": {instID}}
+	if err := c.do(ctx, http.MethodGet, "/api/v5/market/ticker", query, nil, false, &tickers); err != nil {
+		return nil, err
+	}
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("okx: no ticker for %s", instID)
+	}
+
+	t := tickers[0]
+	ticker := &Ticker{InstID: t.InstID}
+	ticker.Last, _ = strconv.ParseFloat(t.Last, 64)
+	ticker.Volume24hBase, _ = strconv.ParseFloat(t.Vol24h, 64)
+	ticker.Volume24hQuote, _ = strconv.ParseFloat(t.VolCcy24h, 64)
+	if ms, err := strconv.ParseInt(t.TS, 10, 64); err == nil {
+		ticker.Timestamp = time.UnixMilli(ms)
+	}
+	return ticker, nil
+}
+
+// TotalEquityUSD returns the account's total equity; it is a signed request
+// and doubles as a credentials check
+func (c *Client) TotalEquityUSD(ctx context.Context) (float64, error) {
+	var balances []struct {
+		TotalEq string `json:"totalEq"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v5/account/balance", nil, nil, true, &balances); err != nil {
+		return 0, err
+	}
+	if len(balances) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseFloat(balances[0].TotalEq, 64)
+}
@@ -0,0 +1,190 @@
+package okx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret    = "22582BD0CFF14C41EDBF1AB98506286D"
+	testTimestamp = "2020-12-08T09:08:57.715Z"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		method, path, body, want string
+	}{
+		{"GET", "/api/v5/account/balance?ccy=BTC", "", "HiZhvSfMtWJA3uUIVXV3a/bSXNPCWvYFXoGCVS8V4zY="},
+		{"post", "/api/v5/trade/order", `{"instId":"BTC-USDT"}`, "YQ/tkEzvXm0I2aOIDXzW4cvOJ+Hn6Vy0Xqh6kJ1Nu7g="},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(testSecret, testTimestamp, tt.method, tt.path, tt.body); got != tt.want {
+			t.Errorf("Sign(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// stubServer emulates the OKX endpoints used by the client
+func stubServer(t *testing.T, instrumentHits *int32) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v5/public/instruments", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(instrumentHits, 1)
+		if r.URL.Query().Get("instType") != "SPOT" {
+			t.Errorf("unexpected instType %q", r.URL.Query().Get("instType"))
+		}
+		w.Write([]byte(`{"code":"0","msg":"","data":[
+			{"instId":"PEPE-USDC","baseCcy":"PEPE","quoteCcy":"USDC","state":"live"},
+			{"instId":"PEPE-USDT","baseCcy":"PEPE","quoteCcy":"USDT","state":"live"},
+			{"instId":"PEPE-EUR","baseCcy":"PEPE","quoteCcy":"EUR","state":"live"},
+			{"instId":"FLOKI-EUR","baseCcy":"FLOKI","quoteCcy":"EUR","state":"live"},
+			{"instId":"DEAD-USDT","baseCcy":"DEAD","quoteCcy":"USDT","state":"suspend"}
+		]}`))
+	})
+	mux.HandleFunc("/api/v5/market/ticker", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("instId") != "PEPE-USDT" {
+			w.Write([]byte(`{"code":"51001","msg":"Instrument ID does not exist","data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"code":"0","msg":"","data":[{"instType":"SPOT","instId":"PEPE-USDT",
+			"last":"0.00001182","vol24h":"8876543210987","volCcy24h":"104921334.12","ts":"1700000000000"}]}`))
+	})
+	mux.HandleFunc("/api/v5/asset/currencies", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("OK-ACCESS-KEY") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"50111","msg":"Invalid OK-ACCESS-KEY","data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"code":"0","msg":"","data":[
+			{"ccy":"PEPE","chain":"PEPE-ERC20","ctAddr":"0x6982508145454ce325ddbe47a25d4ec3d2311933"},
+			{"ccy":"WIF","chain":"WIF-Solana","ctAddr":"EKpQGSJtjMFqKZ9KQanSqYXRcF8fBopzLHYxdM65zcjm"}
+		]}`))
+	})
+	mux.HandleFunc("/api/v5/account/balance", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("OK-ACCESS-KEY") != "key" || r.Header.Get("OK-ACCESS-PASSPHRASE") != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"50111","msg":"Invalid OK-ACCESS-KEY","data":[]}`))
+			return
+		}
+
+		timestamp := r.Header.Get("OK-ACCESS-TIMESTAMP")
+		want := Sign(testSecret, timestamp, r.Method, r.URL.RequestURI(), "")
+		if r.Header.Get("OK-ACCESS-SIGN") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"50113","msg":"Invalid Sign","data":[]}`))
+			return
+		}
+
+		w.Write([]byte(`{"code":"0","msg":"","data":[{"totalEq":"10234.56"}]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListingPrefersUSDTAndCaches(t *testing.T) {
+	var hits int32
+	server := stubServer(t, &hits)
+	client := NewClient(server.URL, Credentials{})
+
+	inst, listed, err := client.Listing(context.Background(), "pepe")
+	if err != nil {
+		t.Fatalf("Listing: %v", err)
+	}
+	if !listed || inst.InstID != "PEPE-USDT" {
+		t.Errorf("expected PEPE-USDT listing, got %+v (listed=%v)", inst, listed)
+	}
+
+	_, listed, err = client.Listing(context.Background(), "DEAD")
+	if err != nil {
+		t.Fatalf("Listing: %v", err)
+	}
+	if listed {
+		t.Error("suspended instruments should not count as listed")
+	}
+
+	_, listed, err = client.Listing(context.Background(), "FLOKI")
+	if err != nil || listed {
+		t.Errorf("non-USD quotes should not count as listed (listed=%v, err=%v)", listed, err)
+	}
+
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected instruments to be fetched once, got %d", n)
+	}
+}
+
+func TestCurrenciesRequireCredentials(t *testing.T) {
+	var hits int32
+	server := stubServer(t, &hits)
+
+	if _, err := NewClient(server.URL, Credentials{}).Currencies(context.Background()); err == nil {
+		t.Error("expected currencies to require credentials")
+	}
+
+	client := NewClient(server.URL, Credentials{APIKey: "key", Secret: testSecret, Passphrase: "pass"})
+	currencies, err := client.Currencies(context.Background())
+	if err != nil {
+		t.Fatalf("Currencies: %v", err)
+	}
+	wif := currencies["WIF"]
+	if len(wif) != 1 || wif[0].Network() != "Solana" || wif[0].CtAddr != "EKpQGSJtjMFqKZ9KQanSqYXRcF8fBopzLHYxdM65zcjm" {
+		t.Errorf("unexpected WIF networks %+v", wif)
+	}
+}
+
+func TestTicker(t *testing.T) {
+	var hits int32
+	server := stubServer(t, &hits)
+	client := NewClient(server.URL, Credentials{})
+
+	ticker, err := client.Ticker(context.Background(), "PEPE-USDT")
+	if err != nil {
+		t.Fatalf("Ticker: %v", err)
+	}
+	if ticker.Last != 0.00001182 || ticker.Volume24hQuote != 104921334.12 {
+		t.Errorf("unexpected ticker %+v", ticker)
+	}
+	if !ticker.Timestamp.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("unexpected timestamp %v", ticker.Timestamp)
+	}
+
+	_, err = client.Ticker(context.Background(), "NOPE-USDT")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "51001" {
+		t.Errorf("expected APIError 51001, got %v", err)
+	}
+}
+
+func TestSignedRequest(t *testing.T) {
+	var hits int32
+	server := stubServer(t, &hits)
+
+	client := NewClient(server.URL, Credentials{APIKey: "key", Secret: testSecret, Passphrase: "pass"})
+	equity, err := client.TotalEquityUSD(context.Background())
+	if err != nil {
+		t.Fatalf("TotalEquityUSD: %v", err)
+	}
+	if equity != 10234.56 {
+		t.Errorf("unexpected equity %f", equity)
+	}
+
+	wrongSecret := NewClient(server.URL, Credentials{APIKey: "key", Secret: "other", Passphrase: "pass"})
+	_, err = wrongSecret.TotalEquityUSD(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "50113" {
+		t.Errorf("expected invalid sign error, got %v", err)
+	}
+
+	anonymous := NewClient(server.URL, Credentials{})
+	if _, err := anonymous.TotalEquityUSD(context.Background()); err == nil {
+		t.Error("expected private endpoint to require credentials")
+	}
+}
@@ -0,0 +1,176 @@
+// Package telegram is a minimal client for the Telegram Bot API
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BaseURL is the public Bot API endpoint
+const BaseURL = "https://api.telegram.org"
+
+// Client calls Bot API methods with a bot token
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a Bot API client. An empty baseURL uses the public API.
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = BaseURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		// Long polling holds getUpdates open for its timeout, so leave headroom
+		http: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Chat is a group, supergroup or channel
+type Chat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"` // "group", "supergroup", "channel"
+	Title    string `json:"title"`
+	Username string `json:"username"`
+}
+
+// Message is a message or channel post
+type Message struct {
+	MessageID int64 `json:"message_id"`
+	Date      int64 `json:"date"` // unix seconds
+	Chat      Chat  `json:"chat"`
+}
+
+// Time returns when the message was sent
+func (m *Message) Time() time.Time {
+	return time.Unix(m.Date, 0)
+}
+
+// Update is an incoming update from getUpdates
+type Update struct {
+	UpdateID    int64    `json:"update_id"`
+	Message     *Message `json:"message,omitempty"`
+	ChannelPost *Message `json:"channel_post,omitempty"`
+}
+
+// Post returns the group message or channel post carried by the update, if any
+func (u *Update) Post() *Message {
+	if u.Message != nil {
+		return u.Message
+	}
+	return u.ChannelPost
+}
+
+// APIError is returned when the Bot API answers with ok=false
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration // set on 429 responses
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram: %d: %s", e.Code, e.Description)
+}
+
+// NotFound reports whether the chat does not exist or is not visible to the bot
+func (e *APIError) NotFound() bool {
+	return e.Code == http.StatusBadRequest && strings.Contains(strings.ToLower(e.Description), "chat not found")
+}
+
+// envelope is the common Bot API response wrapper
+type envelope struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// call invokes a Bot API method with form parameters and decodes the result into out
+func (c *Client) call(ctx context.Context, method string, params url.Values, out interface{}) error {
+	if c.token == "" {
+		return fmt.Errorf("telegram: bot token not configured")
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// The request URL embeds the bot token; keep it out of logs
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram: %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("telegram: unexpected response (status %d): %w", resp.StatusCode, err)
+	}
+	if !env.OK {
+		apiErr := &APIError{Code: env.ErrorCode, Description: env.Description}
+		if env.Parameters != nil {
+			apiErr.RetryAfter = time.Duration(env.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
+	}
+
+	return json.Unmarshal(env.Result, out)
+}
+
+// GetChat returns public information about a chat. chatID is a numeric ID or "@username".
+func (c *Client) GetChat(ctx context.Context, chatID string) (*Chat, error) {
+	var chat Chat
+	if err := c.call(ctx, "getChat", url.Values{"chat_id": {chatID}}, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// GetChatMemberCount returns the number of members (or subscribers) of a chat
+func (c *Client) GetChatMemberCount(ctx context.Context, chatID string) (int, error) {
+	var count int
+	if err := c.call(ctx, "getChatMemberCount", url.Values{"chat_id": {chatID}}, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetUpdates long-polls for updates starting at offset. Only messages from
+// chats the bot is a member of are delivered.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	params := url.Values{
+		"offset":          {strconv.FormatInt(offset, 10)},
+		"timeout":         {strconv.Itoa(int(timeout / time.Second))},
+		"allowed_updates": {`["message","channel_post"]`},
+	}
+
+	var updates []Update
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
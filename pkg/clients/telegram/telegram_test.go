@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:TEST"
+
+// stubBotAPI serves canned Bot API responses keyed by method name
+func stubBotAPI(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/bot" + testToken + "/"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+
+		method := strings.TrimPrefix(r.URL.Path, prefix)
+		body, ok := responses[method+"?"+r.PostForm.Get("chat_id")]
+		if !ok {
+			body, ok = responses[method]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGetChatAndMemberCount(t *testing.T) {
+	server := stubBotAPI(t, map[string]string{
+		"getChat?@pepe_portal":            `{"ok":true,"result":{"id":-1001234567890,"type":"supergroup","title":"PEPE Portal","username":"pepe_portal"}}`,
+		"getChatMemberCount?@pepe_portal": `{"ok":true,"result":4821}`,
+		"getChat?@missing_chat":           `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`,
+	})
+	client := NewClient(server.URL, testToken)
+	ctx := context.Background()
+
+	chat, err := client.GetChat(ctx, "@pepe_portal")
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if chat.ID != -1001234567890 || chat.Username != "pepe_portal" || chat.Type != "supergroup" {
+		t.Errorf("unexpected chat %+v", chat)
+	}
+
+	count, err := client.GetChatMemberCount(ctx, "@pepe_portal")
+	if err != nil {
+		t.Fatalf("GetChatMemberCount: %v", err)
+	}
+	if count != 4821 {
+		t.Errorf("expected 4821 members, got %d", count)
+	}
+
+	_, err = client.GetChat(ctx, "@missing_chat")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.NotFound() {
+		t.Errorf("expected chat not found error, got %v", err)
+	}
+}
+
+func TestGetUpdates(t *testing.T) {
+	server := stubBotAPI(t, map[string]string{
+		"getUpdates": `{"ok":true,"result":[
+			{"update_id":10,"message":{"message_id":1,"date":1714564800,"chat":{"id":-100,"type":"supergroup","username":"pepe_portal"}}},
+			{"update_id":11,"channel_post":{"message_id":7,"date":1714564860,"chat":{"id":-200,"type":"channel","username":"pepe_news"}}},
+			{"update_id":12}
+		]}`,
+	})
+	client := NewClient(server.URL, testToken)
+
+	updates, err := client.GetUpdates(context.Background(), 0, time.Second)
+	if err != nil {
+		t.Fatalf("GetUpdates: %v", err)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("expected 3 updates, got %d", len(updates))
+	}
+	if post := updates[0].Post(); post == nil || post.Chat.Username != "pepe_portal" {
+		t.Errorf("expected group message, got %+v", post)
+	}
+	if post := updates[1].Post(); post == nil || post.Chat.Username != "pepe_news" || !post.Time().Equal(time.Unix(1714564860, 0)) {
+		t.Errorf("expected channel post, got %+v", post)
+	}
+	if updates[2].Post() != nil {
+		t.Error("expected update without a post")
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	server := stubBotAPI(t, map[string]string{
+		"getChatMemberCount": `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`,
+	})
+	client := NewClient(server.URL, testToken)
+
+	_, err := client.GetChatMemberCount(context.Background(), "@pepe_portal")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 429 || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("expected rate limit error with retry_after, got %v", err)
+	}
+}
+
+func TestMissingToken(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "")
+	if _, err := client.GetChat(context.Background(), "@pepe_portal"); err == nil {
+		t.Error("expected an error without a bot token")
+	}
+}
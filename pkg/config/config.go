@@ -15,11 +15,23 @@ type Config struct {
 	DryRun              bool
 	AutoExecute         bool
 	
+	// API settings
+	APIToken            string   // bearer token the API requires; empty leaves it open
+	APIAllowedOrigins   []string // origins allowed to call the API cross-origin
+	
 	// Chain settings
 	SolanaRPCURL        string
 	SolanaWSURL         string
+	RaydiumAMMProgram   string
+	SolanaComputeUnitLimit uint32
+	SolanaComputeUnitPrice uint64 // priority fee, micro-lamports per compute unit
 	BaseRPCURL          string
 	BaseWSURL           string
+	BaseChainID         int64
+	BaseRouterV2        string // Uniswap V2 router
+	BaseRouterV3        string // Uniswap V3 SwapRouter02
+	BaseQuoterV3        string // Uniswap V3 QuoterV2
+	BaseWETH            string
 	ScanIntervalSolana  time.Duration
 	ScanIntervalBase    time.Duration
 	
@@ -30,22 +42,43 @@ type Config struct {
 	MaxHoneypotScore        float64
 	MaxSlippage             float64
 	
+	// Strategy routing
+	DefaultStrategy     string
+	StrategyRoutes      []string // "chain[:venue]=strategy"
+	StrategyWeightsFile   string
+	StrategyWeightsReload time.Duration
+	StrategyModelFile     string // trained win probability model for the "model" strategy
+	CalibrationFile       string // win probability calibration written by `trading calibrate`
+	CalibrationDataFile   string // labeled candidates (JSONL) for the calibration report
+	ShadowStrategies      []string // evaluated on every token alongside the live strategy, never executed
+	ShadowMarkInterval    time.Duration
+	
 	// Risk management
 	SinglePositionPct   float64
 	TotalExposurePct    float64
 	DailyLossLimit      float64
 	AccountBalance      float64
+	KellyFraction       float64 // share of the full Kelly stake to bet
+	MinPositionUSD      float64 // smaller positions are not taken
 	
 	// Execution settings
 	MaxSimulateRetries  int
 	SimulateTimeout     time.Duration
 	ConfirmationsWait   int
+	ConfirmTimeout      time.Duration // how long to wait for a sent transaction's confirmations
+	ApprovalTTL         time.Duration // how long after listing a candidate can still be approved
+	ExecutionQueueSize     int
+	ExecutionQueueHalfLife time.Duration // queued candidates' expected value halves this often
+	ExecutionQueueDeadline time.Duration // queued candidates not executed by then are dropped
+	CandidateRetention   []string // "status=minutes" before finished candidates are archived
+	CandidateArchiveFile string
 	
 	// Time windows
 	ObservationWindow5m  time.Duration
 	ObservationWindow15m time.Duration
 	ObservationWindow1h  time.Duration
 	DefaultTimeWindow    time.Duration
+	WatchlistInterval    time.Duration // how often monitored tokens are re-evaluated
 	
 	// API settings
 	CoinGeckoAPIKey     string
@@ -53,14 +86,31 @@ type Config struct {
 	OKXAPISecret        string
 	OKXAPIPassphrase    string
 	TwitterAPIKey       string
+	TelegramBotToken    string
+	
+	// Off-chain data providers
+	OffChainProviders   []string
+	ProviderTimeout     time.Duration
+	MarketDataCacheTTL  time.Duration
+	
+	// Trade flow tracking
+	TradeFlowEnabled    bool
+	TradeFlowPollInterval time.Duration
+	SniperBlocks        int
 	
 	// Wallet settings
 	UseOKXWallet        bool
 	PrivateKey          string // Use with caution - prefer KMS
+	SolanaPrivateKey    string // base58 keypair or solana-keygen JSON array
 	
 	// Database settings
 	DatabaseURL         string
 	
+	// Event journal
+	JournalDir          string // empty disables the journal
+	JournalMaxSizeMB    int    // the journal file is rotated at this size
+	JournalCompress     bool   // gzip rotated journal files
+	
 	// Telemetry
 	PrometheusPort      int
 	LogLevel            string
@@ -81,11 +131,23 @@ func LoadConfig() *Config {
 		DryRun:              getEnvBool("DRY_RUN", true),
 		AutoExecute:         getEnvBool("AUTO_EXECUTE", false),
 		
+		// API
+		APIToken:            getEnv("API_TOKEN", ""),
+		APIAllowedOrigins:   getEnvList("API_ALLOWED_ORIGINS"),
+		
 		// Chain settings
 		SolanaRPCURL:        getEnv("SOLANA_RPC_URL", "https://api.mainnet-beta.solana.com"),
 		SolanaWSURL:         getEnv("SOLANA_WS_URL", "wss://api.mainnet-beta.solana.com"),
+		RaydiumAMMProgram:   getEnv("RAYDIUM_AMM_PROGRAM", "675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8"),
+		SolanaComputeUnitLimit: uint32(getEnvInt("SOLANA_COMPUTE_UNIT_LIMIT", 200000)),
+		SolanaComputeUnitPrice: uint64(getEnvInt("SOLANA_COMPUTE_UNIT_PRICE", 50000)),
 		BaseRPCURL:          getEnv("BASE_RPC_URL", "https://mainnet.base.org"),
 		BaseWSURL:           getEnv("BASE_WS_URL", "wss://mainnet.base.org"),
+		BaseChainID:         int64(getEnvInt("BASE_CHAIN_ID", 8453)),
+		BaseRouterV2:        getEnv("BASE_ROUTER_V2", "0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24"),
+		BaseRouterV3:        getEnv("BASE_ROUTER_V3", "0x2626664c2603336E57B271c5C0b26F421741e481"),
+		BaseQuoterV3:        getEnv("BASE_QUOTER_V3", "0x3d4e44Eb1374240CE5F1B871ab261CD16335B76a"),
+		BaseWETH:            getEnv("BASE_WETH", "0x4200000000000000000000000000000000000006"),
 		ScanIntervalSolana:  time.Duration(getEnvInt("SCAN_INTERVAL_SOLANA_SEC", 2)) * time.Second,
 		ScanIntervalBase:    time.Duration(getEnvInt("SCAN_INTERVAL_BASE_SEC", 2)) * time.Second,
 		
@@ -96,22 +158,43 @@ func LoadConfig() *Config {
 		MaxHoneypotScore:        getEnvFloat("MAX_HONEYPOT_SCORE", 0.2),
 		MaxSlippage:             getEnvFloat("MAX_SLIPPAGE", 0.05),
 		
+		// Strategy routing
+		DefaultStrategy:     getEnv("STRATEGY_DEFAULT", "heuristic"),
+		StrategyRoutes:      getEnvList("STRATEGY_ROUTES"),
+		StrategyWeightsFile:   getEnv("STRATEGY_WEIGHTS_FILE", ""),
+		StrategyWeightsReload: time.Duration(getEnvInt("STRATEGY_WEIGHTS_RELOAD_SEC", 10)) * time.Second,
+		StrategyModelFile:     getEnv("STRATEGY_MODEL_FILE", ""),
+		CalibrationFile:       getEnv("CALIBRATION_FILE", ""),
+		CalibrationDataFile:   getEnv("CALIBRATION_DATA_FILE", ""),
+		ShadowStrategies:      getEnvList("STRATEGY_SHADOW"),
+		ShadowMarkInterval:    time.Duration(getEnvInt("SHADOW_MARK_INTERVAL_SEC", 60)) * time.Second,
+		
 		// Risk management
 		SinglePositionPct:   getEnvFloat("SINGLE_POSITION_PCT", 0.01),
 		TotalExposurePct:    getEnvFloat("TOTAL_EXPOSURE_PCT", 0.05),
 		DailyLossLimit:      getEnvFloat("DAILY_LOSS_LIMIT", 500.0),
 		AccountBalance:      getEnvFloat("ACCOUNT_BALANCE", 10000.0),
+		KellyFraction:       getEnvFloat("KELLY_FRACTION", 0.25),
+		MinPositionUSD:      getEnvFloat("MIN_POSITION_USD", 10.0),
 		
 		// Execution
 		MaxSimulateRetries:  getEnvInt("MAX_SIMULATE_RETRIES", 3),
 		SimulateTimeout:     time.Duration(getEnvInt("SIMULATE_TIMEOUT_SEC", 30)) * time.Second,
 		ConfirmationsWait:   getEnvInt("CONFIRMATIONS_WAIT", 2),
+		ConfirmTimeout:      time.Duration(getEnvInt("EXECUTION_CONFIRM_TIMEOUT_SEC", 120)) * time.Second,
+		ApprovalTTL:         time.Duration(getEnvInt("CANDIDATE_APPROVAL_TTL_SEC", 300)) * time.Second,
+		ExecutionQueueSize:     getEnvInt("EXECUTION_QUEUE_SIZE", 100),
+		ExecutionQueueHalfLife: time.Duration(getEnvInt("EXECUTION_QUEUE_HALF_LIFE_SEC", 30)) * time.Second,
+		ExecutionQueueDeadline: time.Duration(getEnvInt("EXECUTION_QUEUE_DEADLINE_SEC", 60)) * time.Second,
+		CandidateRetention:   getEnvList("CANDIDATE_RETENTION"),
+		CandidateArchiveFile: getEnv("CANDIDATE_ARCHIVE_FILE", "candidates_archive.jsonl"),
 		
 		// Time windows
 		ObservationWindow5m:  5 * time.Minute,
 		ObservationWindow15m: 15 * time.Minute,
 		ObservationWindow1h:  60 * time.Minute,
 		DefaultTimeWindow:    time.Duration(getEnvInt("DEFAULT_TIME_WINDOW_MIN", 15)) * time.Minute,
+		WatchlistInterval:    time.Duration(getEnvInt("WATCHLIST_INTERVAL_SEC", 60)) * time.Second,
 		
 		// API settings
 		CoinGeckoAPIKey:     getEnv("COINGECKO_API_KEY", ""),
@@ -119,14 +202,31 @@ func LoadConfig() *Config {
 		OKXAPISecret:        getEnv("OKX_API_SECRET", ""),
 		OKXAPIPassphrase:    getEnv("OKX_API_PASSPHRASE", ""),
 		TwitterAPIKey:       getEnv("TWITTER_API_KEY", ""),
+		TelegramBotToken:    getEnv("TELEGRAM_BOT_TOKEN", ""),
+		
+		// Off-chain data providers
+		OffChainProviders:   getEnvList("OFFCHAIN_PROVIDERS"),
+		ProviderTimeout:     time.Duration(getEnvInt("PROVIDER_TIMEOUT_SEC", 5)) * time.Second,
+		MarketDataCacheTTL:  time.Duration(getEnvInt("MARKET_DATA_CACHE_TTL_SEC", 30)) * time.Second,
+		
+		// Trade flow tracking
+		TradeFlowEnabled:    getEnvBool("TRADEFLOW_ENABLED", false),
+		TradeFlowPollInterval: time.Duration(getEnvInt("TRADEFLOW_POLL_SEC", 10)) * time.Second,
+		SniperBlocks:        getEnvInt("SNIPER_BLOCKS", 3),
 		
 		// Wallet
 		UseOKXWallet:        getEnvBool("USE_OKX_WALLET", true),
 		PrivateKey:          getEnv("PRIVATE_KEY", ""),
+		SolanaPrivateKey:    getEnv("SOLANA_PRIVATE_KEY", ""),
 		
 		// Database
 		DatabaseURL:         getEnv("DATABASE_URL", "sqlite://./meme_bot.db"),
 		
+		// Event journal
+		JournalDir:          getEnv("JOURNAL_DIR", "./journal"),
+		JournalMaxSizeMB:    getEnvInt("JOURNAL_MAX_SIZE_MB", 100),
+		JournalCompress:     getEnvBool("JOURNAL_COMPRESS", true),
+		
 		// Telemetry
 		PrometheusPort:      getEnvInt("PROMETHEUS_PORT", 9090),
 		LogLevel:            getEnv("LOG_LEVEL", "info"),
@@ -0,0 +1,132 @@
+package evm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 hashes data the way Ethereum does
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Selector returns the 4-byte function selector of a signature such as
+// "transfer(address,uint256)"
+func Selector(signature string) []byte {
+	return Keccak256([]byte(signature))[:4]
+}
+
+// Address is a 20-byte account address
+type Address [20]byte
+
+// ParseAddress parses a 0x-prefixed hex address
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+	if err != nil || len(b) != len(a) {
+		return a, fmt.Errorf("invalid address %q", s)
+	}
+	copy(a[:], b)
+	return a, nil
+}
+
+// Hex returns the address as lowercase 0x-prefixed hex
+func (a Address) Hex() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// Word is one 32-byte ABI slot
+type Word [32]byte
+
+// Uint encodes an unsigned integer as an ABI word
+func Uint(v *big.Int) Word {
+	var w Word
+	v.FillBytes(w[:])
+	return w
+}
+
+// Uint64 encodes a small unsigned integer as an ABI word
+func Uint64(v uint64) Word {
+	return Uint(new(big.Int).SetUint64(v))
+}
+
+// AddressWord encodes an address as an ABI word
+func AddressWord(a Address) Word {
+	var w Word
+	copy(w[12:], a[:])
+	return w
+}
+
+// Call encodes a call to a function whose arguments are all static words;
+// tuples of static fields are encoded inline the same way
+func Call(selector []byte, args ...Word) []byte {
+	data := append([]byte(nil), selector...)
+	for _, arg := range args {
+		data = append(data, arg[:]...)
+	}
+	return data
+}
+
+// CallWithAddresses encodes a call whose argument at index dynamicIndex is an
+// address[] and whose other arguments are static words
+func CallWithAddresses(selector []byte, static []Word, dynamicIndex int, addresses []Address) []byte {
+	head := make([]Word, 0, len(static)+1)
+	head = append(head, static[:dynamicIndex]...)
+	head = append(head, Uint64(uint64(32*(len(static)+1))))
+	head = append(head, static[dynamicIndex:]...)
+
+	tail := []Word{Uint64(uint64(len(addresses)))}
+	for _, a := range addresses {
+		tail = append(tail, AddressWord(a))
+	}
+	return Call(selector, append(head, tail...)...)
+}
+
+// CallWithBytesList encodes a call whose argument at index dynamicIndex is a
+// bytes[] and whose other arguments are static words
+func CallWithBytesList(selector []byte, static []Word, dynamicIndex int, items [][]byte) []byte {
+	head := make([]Word, 0, len(static)+1)
+	head = append(head, static[:dynamicIndex]...)
+	head = append(head, Uint64(uint64(32*(len(static)+1))))
+	head = append(head, static[dynamicIndex:]...)
+
+	// The length, then each item's offset from the first offset, then the items
+	tail := []Word{Uint64(uint64(len(items)))}
+	var body []Word
+	offset := 32 * len(items)
+	for _, item := range items {
+		tail = append(tail, Uint64(uint64(offset)))
+		words := bytesWords(item)
+		body = append(body, words...)
+		offset += 32 * len(words)
+	}
+	return Call(selector, append(append(head, tail...), body...)...)
+}
+
+// bytesWords encodes dynamic bytes as their length and the bytes padded to whole words
+func bytesWords(b []byte) []Word {
+	words := []Word{Uint64(uint64(len(b)))}
+	for i := 0; i < len(b); i += 32 {
+		var w Word
+		copy(w[:], b[i:])
+		words = append(words, w)
+	}
+	return words
+}
+
+// WordAt returns the i-th 32-byte word of ABI-encoded return data as an integer
+func WordAt(data []byte, i int) (*big.Int, error) {
+	start := i * 32
+	if len(data) < start+32 {
+		return nil, fmt.Errorf("return data has %d bytes, need word %d", len(data), i)
+	}
+	return new(big.Int).SetBytes(data[start : start+32]), nil
+}
@@ -0,0 +1,188 @@
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/mumugogoing/meme_bot/pkg/rpc"
+)
+
+// Client wraps the eth_* JSON-RPC methods needed to send transactions
+type Client struct {
+	rpc *rpc.Client
+}
+
+// NewClient creates a client for the given RPC endpoint
+func NewClient(rpcURL string) *Client {
+	return &Client{rpc: rpc.NewClient(rpcURL)}
+}
+
+// Receipt is the part of a transaction receipt the bot uses
+type Receipt struct {
+	Status      uint64
+	BlockNumber uint64
+	GasUsed     uint64
+	Logs        []Log
+}
+
+// Log is an event emitted by a transaction
+type Log struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// ChainID returns the chain ID of the node
+func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
+	return c.bigCall(ctx, "eth_chainId")
+}
+
+// PendingNonce returns the next nonce of account, counting pending transactions
+func (c *Client) PendingNonce(ctx context.Context, account Address) (uint64, error) {
+	n, err := c.bigCall(ctx, "eth_getTransactionCount", account.Hex(), "pending")
+	if err != nil {
+		return 0, err
+	}
+	return n.Uint64(), nil
+}
+
+// SuggestGasTipCap returns the node's suggested priority fee per gas
+func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return c.bigCall(ctx, "eth_maxPriorityFeePerGas")
+}
+
+// BaseFee returns the base fee per gas of the latest block
+func (c *Client) BaseFee(ctx context.Context) (*big.Int, error) {
+	var block struct {
+		BaseFeePerGas string `json:"baseFeePerGas"`
+	}
+	if err := c.rpc.Call(ctx, "eth_getBlockByNumber", []interface{}{"latest", false}, &block); err != nil {
+		return nil, err
+	}
+	if block.BaseFeePerGas == "" {
+		return nil, fmt.Errorf("latest block has no base fee")
+	}
+	return parseBig(block.BaseFeePerGas)
+}
+
+// BlockNumber returns the latest block number
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	n, err := c.bigCall(ctx, "eth_blockNumber")
+	if err != nil {
+		return 0, err
+	}
+	return n.Uint64(), nil
+}
+
+// EstimateGas estimates the gas a call from `from` would use
+func (c *Client) EstimateGas(ctx context.Context, from, to Address, value *big.Int, data []byte) (uint64, error) {
+	n, err := c.bigCall(ctx, "eth_estimateGas", callArgs(from, to, value, data))
+	if err != nil {
+		return 0, err
+	}
+	return n.Uint64(), nil
+}
+
+// Call executes a read-only call against the latest block
+func (c *Client) Call(ctx context.Context, from, to Address, value *big.Int, data []byte) ([]byte, error) {
+	var result string
+	if err := c.rpc.Call(ctx, "eth_call", []interface{}{callArgs(from, to, value, data), "latest"}, &result); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+// SendRawTransaction broadcasts a signed transaction and returns its hash
+func (c *Client) SendRawTransaction(ctx context.Context, raw []byte) (string, error) {
+	var hash string
+	if err := c.rpc.Call(ctx, "eth_sendRawTransaction", []interface{}{"0x" + hex.EncodeToString(raw)}, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// TransactionKnown reports whether the node knows a transaction, mined or
+// waiting in its mempool; a sent transaction it no longer knows was dropped
+func (c *Client) TransactionKnown(ctx context.Context, hash string) (bool, error) {
+	var raw json.RawMessage
+	if err := c.rpc.Call(ctx, "eth_getTransactionByHash", []interface{}{hash}, &raw); err != nil {
+		return false, err
+	}
+	return len(raw) > 0 && string(raw) != "null", nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction, or nil
+// while it is still pending
+func (c *Client) TransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	var raw *struct {
+		Status      string `json:"status"`
+		BlockNumber string `json:"blockNumber"`
+		GasUsed     string `json:"gasUsed"`
+		Logs        []Log  `json:"logs"`
+	}
+	if err := c.rpc.Call(ctx, "eth_getTransactionReceipt", []interface{}{hash}, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil || raw.BlockNumber == "" {
+		return nil, nil
+	}
+	receipt := &Receipt{Logs: raw.Logs}
+	for _, field := range []struct {
+		value string
+		dst   *uint64
+	}{
+		{raw.Status, &receipt.Status},
+		{raw.BlockNumber, &receipt.BlockNumber},
+		{raw.GasUsed, &receipt.GasUsed},
+	} {
+		n, err := parseBig(field.value)
+		if err != nil {
+			return nil, err
+		}
+		*field.dst = n.Uint64()
+	}
+	return receipt, nil
+}
+
+// bigCall invokes a method that returns a hex quantity
+func (c *Client) bigCall(ctx context.Context, method string, params ...interface{}) (*big.Int, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	var result string
+	if err := c.rpc.Call(ctx, method, params, &result); err != nil {
+		return nil, err
+	}
+	return parseBig(result)
+}
+
+// callArgs is the transaction object of eth_call and eth_estimateGas
+func callArgs(from, to Address, value *big.Int, data []byte) map[string]string {
+	args := map[string]string{
+		"from": from.Hex(),
+		"to":   to.Hex(),
+		"data": "0x" + hex.EncodeToString(data),
+	}
+	if value != nil && value.Sign() > 0 {
+		args["value"] = FormatBig(value)
+	}
+	return args
+}
+
+// parseBig parses a hex quantity such as "0x1a"
+func parseBig(value string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity %q", value)
+	}
+	return n, nil
+}
+
+// FormatBig renders n as a hex quantity
+func FormatBig(n *big.Int) string {
+	return "0x" + n.Text(16)
+}
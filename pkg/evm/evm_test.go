@@ -0,0 +1,154 @@
+package evm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const testKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func TestPrivateKeyAddress(t *testing.T) {
+	key, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := key.Address().Hex(), strings.ToLower("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"); got != want {
+		t.Errorf("address %s, want %s", got, want)
+	}
+	if _, err := ParsePrivateKey("0x1234"); err == nil {
+		t.Error("expected a short key to be rejected")
+	}
+}
+
+func TestSelectorAndCallEncoding(t *testing.T) {
+	if got := hex.EncodeToString(Selector("transfer(address,uint256)")); got != "a9059cbb" {
+		t.Errorf("selector %s, want a9059cbb", got)
+	}
+
+	a, _ := ParseAddress("0x4200000000000000000000000000000000000006")
+	b, _ := ParseAddress("0x00000000000000000000000000000000000000bb")
+	data := CallWithAddresses(Selector("getAmountsOut(uint256,address[])"), []Word{Uint64(1000)}, 1, []Address{a, b})
+	want := "d06ca61f" +
+		"00000000000000000000000000000000000000000000000000000000000003e8" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000004200000000000000000000000000000000000006" +
+		"00000000000000000000000000000000000000000000000000000000000000bb"
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("getAmountsOut calldata\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestBytesListEncoding(t *testing.T) {
+	// SwapRouter02's multicall(uint256 deadline, bytes[] data)
+	selector := Selector("multicall(uint256,bytes[])")
+	if got := hex.EncodeToString(selector); got != "5ae401dc" {
+		t.Errorf("selector %s, want 5ae401dc", got)
+	}
+
+	data := CallWithBytesList(selector, []Word{Uint64(100)}, 1, [][]byte{{0xaa, 0xbb}, []byte(strings.Repeat("\xcc", 33))})
+	want := "5ae401dc" +
+		"0000000000000000000000000000000000000000000000000000000000000064" + // deadline
+		"0000000000000000000000000000000000000000000000000000000000000040" + // offset of the list
+		"0000000000000000000000000000000000000000000000000000000000000002" + // two items
+		"0000000000000000000000000000000000000000000000000000000000000040" + // offsets from here
+		"0000000000000000000000000000000000000000000000000000000000000080" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"aabb000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000021" +
+		strings.Repeat("cc", 32) +
+		"cc00000000000000000000000000000000000000000000000000000000000000"
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("multicall calldata\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestRLPEncoding(t *testing.T) {
+	cases := []struct {
+		got  []byte
+		want string
+	}{
+		{rlpUint(0), "80"},
+		{rlpUint(15), "0f"},
+		{rlpUint(1024), "820400"},
+		{rlpBytes([]byte("dog")), "83646f67"},
+		{rlpList(rlpBytes([]byte("cat")), rlpBytes([]byte("dog"))), "c88363617483646f67"},
+		{rlpBytes([]byte(strings.Repeat("a", 56))), "b838" + strings.Repeat("61", 56)},
+	}
+	for i, c := range cases {
+		if got := hex.EncodeToString(c.got); got != c.want {
+			t.Errorf("case %d: got %s, want %s", i, got, c.want)
+		}
+	}
+}
+
+func TestSignedTransactionRecoversSender(t *testing.T) {
+	key, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, _ := ParseAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24")
+	tx := &DynamicFeeTx{
+		ChainID:   big.NewInt(8453),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000),
+		GasFeeCap: big.NewInt(50_000_000),
+		Gas:       210_000,
+		To:        to,
+		Value:     big.NewInt(1e15),
+		Data:      Call(Selector("transfer(address,uint256)"), AddressWord(to), Uint64(1)),
+	}
+	raw, hash := tx.Sign(key)
+	if raw[0] != 0x02 || len(hash) != 66 {
+		t.Fatalf("unexpected raw transaction %x / hash %s", raw[:1], hash)
+	}
+
+	sender, gotTo, data, err := RecoverSender(raw, big.NewInt(8453))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != key.Address() || gotTo != to || hex.EncodeToString(data) != hex.EncodeToString(tx.Data) {
+		t.Errorf("recovered %s -> %s, want %s -> %s", sender.Hex(), gotTo.Hex(), key.Address().Hex(), to.Hex())
+	}
+	if _, _, _, err := RecoverSender(raw, big.NewInt(1)); err == nil {
+		t.Error("expected a chain id mismatch")
+	}
+}
+
+// The expected bytes come from an independent implementation that reproduces
+// the EIP-155 example transaction; signatures are deterministic (RFC 6979)
+func TestSignedTransactionVector(t *testing.T) {
+	key, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, _ := ParseAddress("0x4752ba5DBc23f44D87826276BF6Fd6b1C372aD24")
+	tx := &DynamicFeeTx{
+		ChainID:   big.NewInt(8453),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000),
+		GasFeeCap: big.NewInt(50_000_000),
+		Gas:       210_000,
+		To:        to,
+		Value:     big.NewInt(1e15),
+		Data:      Call(Selector("transfer(address,uint256)"), AddressWord(to), Uint64(1)),
+	}
+	if got, want := hex.EncodeToString(tx.SigningHash()), "66b82abc498f34c6a675e33cec376b41cddd8410dff6636a1b9d956a819a693e"; got != want {
+		t.Errorf("signing hash\n got %s\nwant %s", got, want)
+	}
+
+	raw, hash := tx.Sign(key)
+	want := "02f8b882210507830f42408402faf08083033450944752ba5dbc23f44d87826276bf6fd6b1c372ad24" +
+		"87038d7ea4c68000b844a9059cbb0000000000000000000000004752ba5dbc23f44d87826276bf6fd6b1c372ad24" +
+		"0000000000000000000000000000000000000000000000000000000000000001c080" +
+		"a05b2466735e807f1683d9828f3193556280286504381dd252aa8a49d46fe461d6" +
+		"a039f869b4ae5fdc8c843c43ff8ca9fdb6a7d3a1972f64e25af7ae1ea4f77c6636"
+	if got := hex.EncodeToString(raw); got != want {
+		t.Errorf("raw transaction\n got %s\nwant %s", got, want)
+	}
+	if want := "0xcc65bd8bc36a785a98dba67fe567f80352237452c3ac4939ee3ea1b7e5cbbc55"; hash != want {
+		t.Errorf("hash %s, want %s", hash, want)
+	}
+}
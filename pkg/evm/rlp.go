@@ -0,0 +1,42 @@
+package evm
+
+import "math/big"
+
+// rlpBytes encodes a byte string
+func rlpBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+// rlpUint encodes an unsigned integer; zero is the empty string
+func rlpUint(v uint64) []byte {
+	return rlpBigInt(new(big.Int).SetUint64(v))
+}
+
+// rlpBigInt encodes a non-negative big integer without leading zeros
+func rlpBigInt(v *big.Int) []byte {
+	if v == nil {
+		return rlpBytes(nil)
+	}
+	return rlpBytes(v.Bytes())
+}
+
+// rlpList encodes already-encoded items as a list
+func rlpList(items ...[]byte) []byte {
+	var payload []byte
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(rlpHeader(0xc0, len(payload)), payload...)
+}
+
+// rlpHeader returns the prefix for a string (0x80) or list (0xc0) of n bytes
+func rlpHeader(offset byte, n int) []byte {
+	if n < 56 {
+		return []byte{offset + byte(n)}
+	}
+	length := new(big.Int).SetInt64(int64(n)).Bytes()
+	return append([]byte{offset + 55 + byte(len(length))}, length...)
+}
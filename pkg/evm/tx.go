@@ -0,0 +1,193 @@
+package evm
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// dynamicFeeTxType is the EIP-2718 type of an EIP-1559 transaction
+const dynamicFeeTxType = 0x02
+
+// DynamicFeeTx is an EIP-1559 transaction without an access list
+type DynamicFeeTx struct {
+	ChainID   *big.Int
+	Nonce     uint64
+	GasTipCap *big.Int // max priority fee per gas
+	GasFeeCap *big.Int // max fee per gas
+	Gas       uint64
+	To        Address
+	Value     *big.Int
+	Data      []byte
+}
+
+// fields returns the RLP-encoded fields shared by the signing payload and the
+// signed transaction
+func (tx *DynamicFeeTx) fields() [][]byte {
+	return [][]byte{
+		rlpBigInt(tx.ChainID),
+		rlpUint(tx.Nonce),
+		rlpBigInt(tx.GasTipCap),
+		rlpBigInt(tx.GasFeeCap),
+		rlpUint(tx.Gas),
+		rlpBytes(tx.To[:]),
+		rlpBigInt(tx.Value),
+		rlpBytes(tx.Data),
+		rlpList(), // access list
+	}
+}
+
+// SigningHash returns the hash the sender signs
+func (tx *DynamicFeeTx) SigningHash() []byte {
+	return Keccak256([]byte{dynamicFeeTxType}, rlpList(tx.fields()...))
+}
+
+// Sign signs the transaction and returns its raw encoding, ready for
+// eth_sendRawTransaction, and its hash
+func (tx *DynamicFeeTx) Sign(key *PrivateKey) (raw []byte, hash string) {
+	// SignCompact puts the recovery code 27+v in front of r and s
+	sig := ecdsa.SignCompact(key.key, tx.SigningHash(), false)
+	v := uint64(sig[0] - 27)
+
+	fields := append(tx.fields(), rlpUint(v), rlpBigInt(new(big.Int).SetBytes(sig[1:33])), rlpBigInt(new(big.Int).SetBytes(sig[33:65])))
+	raw = append([]byte{dynamicFeeTxType}, rlpList(fields...)...)
+	return raw, "0x" + hex.EncodeToString(Keccak256(raw))
+}
+
+// PrivateKey signs transactions for one account
+type PrivateKey struct {
+	key     *secp256k1.PrivateKey
+	address Address
+}
+
+// ParsePrivateKey parses a hex-encoded secp256k1 private key
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil || len(b) != 32 {
+		return nil, errors.New("private key must be 32 hex-encoded bytes")
+	}
+	key := secp256k1.PrivKeyFromBytes(b)
+	if key.Key.IsZero() {
+		return nil, errors.New("private key is zero")
+	}
+	return &PrivateKey{key: key, address: pubKeyAddress(key.PubKey())}, nil
+}
+
+// Address returns the account the key signs for
+func (k *PrivateKey) Address() Address {
+	return k.address
+}
+
+// pubKeyAddress is the last 20 bytes of the hash of the uncompressed key
+func pubKeyAddress(pub *secp256k1.PublicKey) Address {
+	var a Address
+	copy(a[:], Keccak256(pub.SerializeUncompressed()[1:])[12:])
+	return a
+}
+
+// RecoverSender returns the account that signed a raw EIP-1559 transaction,
+// and the transaction's to address and calldata
+func RecoverSender(raw []byte, chainID *big.Int) (sender Address, to Address, data []byte, err error) {
+	if len(raw) == 0 || raw[0] != dynamicFeeTxType {
+		return sender, to, nil, errors.New("not an EIP-1559 transaction")
+	}
+	items, err := rlpDecodeList(raw[1:])
+	if err != nil {
+		return sender, to, nil, err
+	}
+	if len(items) != 12 {
+		return sender, to, nil, fmt.Errorf("transaction has %d fields", len(items))
+	}
+	if new(big.Int).SetBytes(items[0]).Cmp(chainID) != 0 {
+		return sender, to, nil, fmt.Errorf("chain id %s, want %s", new(big.Int).SetBytes(items[0]), chainID)
+	}
+	copy(to[:], items[5])
+
+	tx := &DynamicFeeTx{
+		ChainID:   new(big.Int).SetBytes(items[0]),
+		Nonce:     new(big.Int).SetBytes(items[1]).Uint64(),
+		GasTipCap: new(big.Int).SetBytes(items[2]),
+		GasFeeCap: new(big.Int).SetBytes(items[3]),
+		Gas:       new(big.Int).SetBytes(items[4]).Uint64(),
+		To:        to,
+		Value:     new(big.Int).SetBytes(items[6]),
+		Data:      items[7],
+	}
+	sig := make([]byte, 65)
+	sig[0] = 27 + byte(new(big.Int).SetBytes(items[9]).Uint64())
+	new(big.Int).SetBytes(items[10]).FillBytes(sig[1:33])
+	new(big.Int).SetBytes(items[11]).FillBytes(sig[33:65])
+	pub, _, err := ecdsa.RecoverCompact(sig, tx.SigningHash())
+	if err != nil {
+		return sender, to, nil, err
+	}
+	return pubKeyAddress(pub), to, tx.Data, nil
+}
+
+// rlpDecodeList decodes a flat RLP list of byte strings; the access list is
+// returned as its raw encoding
+func rlpDecodeList(b []byte) ([][]byte, error) {
+	payload, rest, err := rlpSplit(b, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after list")
+	}
+	var items [][]byte
+	for len(payload) > 0 {
+		var item []byte
+		item, payload, err = rlpSplit(payload, false)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// rlpSplit returns the content of the first item in b and what follows it
+func rlpSplit(b []byte, wantList bool) (content, rest []byte, err error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("unexpected end of input")
+	}
+	prefix := b[0]
+	isList := prefix >= 0xc0
+	if wantList && !isList {
+		return nil, nil, errors.New("expected a list")
+	}
+	var offset, size int
+	switch {
+	case prefix < 0x80:
+		return b[:1], b[1:], nil
+	case prefix < 0xb8:
+		offset, size = 1, int(prefix-0x80)
+	case prefix < 0xc0:
+		n := int(prefix - 0xb7)
+		if len(b) < 1+n {
+			return nil, nil, errors.New("unexpected end of input")
+		}
+		offset, size = 1+n, int(new(big.Int).SetBytes(b[1:1+n]).Int64())
+	case prefix < 0xf8:
+		offset, size = 1, int(prefix-0xc0)
+	default:
+		n := int(prefix - 0xf7)
+		if len(b) < 1+n {
+			return nil, nil, errors.New("unexpected end of input")
+		}
+		offset, size = 1+n, int(new(big.Int).SetBytes(b[1:1+n]).Int64())
+	}
+	if len(b) < offset+size {
+		return nil, nil, errors.New("unexpected end of input")
+	}
+	if isList && !wantList {
+		// Nested lists are kept encoded
+		return b[:offset+size], b[offset+size:], nil
+	}
+	return b[offset : offset+size], b[offset+size:], nil
+}
@@ -0,0 +1,201 @@
+// Package impact estimates the price impact of swapping against a token's
+// pool. Constant-product pools use their reserves; concentrated-liquidity pools
+// are walked range by range when their liquidity distribution is known.
+package impact
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// ErrInsufficientLiquidity is returned when a swap would exhaust the pool
+var ErrInsufficientLiquidity = errors.New("insufficient pool liquidity")
+
+// Estimate returns the costs of buying sizeUSD of the token and immediately
+// selling it back. tax is the token's transfer tax, charged on both legs.
+func Estimate(pool models.PoolState, sizeUSD, tax float64) (models.TradeCosts, error) {
+	costs := models.TradeCosts{SizeUSD: sizeUSD, Tax: tax}
+	if err := validate(pool); err != nil {
+		return costs, err
+	}
+	if sizeUSD <= 0 {
+		return costs, nil
+	}
+
+	quoteIn := sizeUSD / pool.QuotePriceUSD
+	spot := spotPrice(pool)
+	tokensOut, _, err := buy(pool, quoteIn)
+	if err != nil {
+		return costs, err
+	}
+	costs.EntryImpact = 1 - tokensOut*spot/quoteIn
+
+	// The exit is quoted against the pool as it is now rather than after our
+	// own buy: by the time we sell, the price our buy pushed up will have been
+	// traded away, and the expected ROI is measured from the market price.
+	// The tax is taken when the tokens are received and again when they are sent back.
+	sold := tokensOut * (1 - tax) * (1 - tax)
+	quoteBack, _, err := sell(pool, sold)
+	if err != nil {
+		return costs, err
+	}
+	if sold > 0 {
+		costs.ExitImpact = 1 - quoteBack/(sold*spot)
+	}
+	costs.RoundTrip = 1 - quoteBack/quoteIn
+	return costs, nil
+}
+
+// NetROI applies round-trip costs to a gross expected return
+func NetROI(gross float64, costs models.TradeCosts) float64 {
+	return (1+gross)*(1-costs.RoundTrip) - 1
+}
+
+// MaxBuy returns the largest buy in USD whose entry impact stays within
+// target, or 0 if even the smallest buy exceeds it (e.g. the LP fee alone)
+func MaxBuy(pool models.PoolState, target float64) (float64, error) {
+	if err := validate(pool); err != nil {
+		return 0, err
+	}
+
+	within := func(sizeUSD float64) bool {
+		costs, err := Estimate(pool, sizeUSD, 0)
+		return err == nil && costs.EntryImpact <= target
+	}
+
+	lo, hi := 0.0, math.Max(depthUSD(pool), 1)
+	if !within(lo + 1e-9*hi) {
+		return 0, nil
+	}
+	for within(hi) {
+		lo, hi = hi, hi*2
+		if hi > 1e15 {
+			return lo, nil
+		}
+	}
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if within(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// validate checks the pool has what its kind needs to quote a swap
+func validate(pool models.PoolState) error {
+	if pool.QuotePriceUSD <= 0 {
+		return fmt.Errorf("pool %s has no quote price", pool.Address)
+	}
+	if pool.Kind == models.PoolConcentrated && len(pool.Ranges) > 0 {
+		if pool.Price <= 0 {
+			return fmt.Errorf("pool %s has no current price", pool.Address)
+		}
+		for i, r := range pool.Ranges {
+			if r.Lower <= 0 || r.Upper <= r.Lower || (i > 0 && r.Lower < pool.Ranges[i-1].Upper) {
+				return fmt.Errorf("pool %s has invalid liquidity range %d", pool.Address, i)
+			}
+		}
+		return nil
+	}
+	if pool.ReserveToken <= 0 || pool.ReserveQuote <= 0 {
+		return fmt.Errorf("pool %s has no reserves", pool.Address)
+	}
+	return nil
+}
+
+// concentrated reports whether the pool is walked by liquidity range
+func concentrated(pool models.PoolState) bool {
+	return pool.Kind == models.PoolConcentrated && len(pool.Ranges) > 0
+}
+
+// spotPrice returns the marginal price in quote per token
+func spotPrice(pool models.PoolState) float64 {
+	if concentrated(pool) {
+		return pool.Price
+	}
+	return pool.ReserveQuote / pool.ReserveToken
+}
+
+// depthUSD is a rough size scale for the pool, used to seed searches
+func depthUSD(pool models.PoolState) float64 {
+	if concentrated(pool) {
+		return pool.Ranges[len(pool.Ranges)-1].Liquidity * math.Sqrt(pool.Price) * pool.QuotePriceUSD
+	}
+	return pool.ReserveQuote * pool.QuotePriceUSD
+}
+
+// buy swaps quoteIn for tokens and returns the tokens received and the pool afterwards
+func buy(pool models.PoolState, quoteIn float64) (float64, models.PoolState, error) {
+	in := quoteIn * (1 - pool.FeeBps/10000)
+	if !concentrated(pool) {
+		out := pool.ReserveToken * in / (pool.ReserveQuote + in)
+		pool.ReserveQuote += quoteIn
+		pool.ReserveToken -= out
+		return out, pool, nil
+	}
+
+	// Price rises as quote is added: L * (sqrtP' - sqrtP) quote buys L * (1/sqrtP - 1/sqrtP') tokens
+	sqrtP := math.Sqrt(pool.Price)
+	out := 0.0
+	for _, r := range pool.Ranges {
+		if r.Upper <= sqrtP*sqrtP || r.Liquidity <= 0 {
+			continue
+		}
+		// Price jumps across gaps without liquidity
+		sqrtP = math.Max(sqrtP, math.Sqrt(r.Lower))
+		sqrtUpper := math.Sqrt(r.Upper)
+
+		capacity := r.Liquidity * (sqrtUpper - sqrtP)
+		if in <= capacity {
+			next := sqrtP + in/r.Liquidity
+			out += r.Liquidity * (1/sqrtP - 1/next)
+			pool.Price = next * next
+			return out, pool, nil
+		}
+		out += r.Liquidity * (1/sqrtP - 1/sqrtUpper)
+		in -= capacity
+		sqrtP = sqrtUpper
+	}
+	return out, pool, ErrInsufficientLiquidity
+}
+
+// sell swaps tokensIn for quote and returns the quote received and the pool afterwards
+func sell(pool models.PoolState, tokensIn float64) (float64, models.PoolState, error) {
+	in := tokensIn * (1 - pool.FeeBps/10000)
+	if !concentrated(pool) {
+		out := pool.ReserveQuote * in / (pool.ReserveToken + in)
+		pool.ReserveToken += tokensIn
+		pool.ReserveQuote -= out
+		return out, pool, nil
+	}
+
+	// Price falls as tokens are added: L * (1/sqrtP' - 1/sqrtP) tokens return L * (sqrtP - sqrtP') quote
+	sqrtP := math.Sqrt(pool.Price)
+	out := 0.0
+	for i := len(pool.Ranges) - 1; i >= 0; i-- {
+		r := pool.Ranges[i]
+		if r.Lower >= sqrtP*sqrtP || r.Liquidity <= 0 {
+			continue
+		}
+		sqrtP = math.Min(sqrtP, math.Sqrt(r.Upper))
+		sqrtLower := math.Sqrt(r.Lower)
+
+		capacity := r.Liquidity * (1/sqrtLower - 1/sqrtP)
+		if in <= capacity {
+			next := 1 / (1/sqrtP + in/r.Liquidity)
+			out += r.Liquidity * (sqrtP - next)
+			pool.Price = next * next
+			return out, pool, nil
+		}
+		out += r.Liquidity * (sqrtP - sqrtLower)
+		in -= capacity
+		sqrtP = sqrtLower
+	}
+	return out, pool, ErrInsufficientLiquidity
+}
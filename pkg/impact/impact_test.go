@@ -0,0 +1,131 @@
+package impact
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func constantProduct() models.PoolState {
+	return models.PoolState{
+		Kind:          models.PoolConstantProduct,
+		QuotePriceUSD: 100,
+		ReserveToken:  1_000_000,
+		ReserveQuote:  1_000, // $100k of quote, token at 0.1 USD
+		FeeBps:        30,
+	}
+}
+
+func approx(a, b, tol float64) bool {
+	return math.Abs(a-b) < tol
+}
+
+func TestEstimateConstantProduct(t *testing.T) {
+	pool := constantProduct()
+	costs, err := Estimate(pool, 10_000, 0)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+
+	// Entry impact of x*y=k with fee f on quote in q is 1 - (1-f)R/(R+(1-f)q)
+	in := 100 * (1 - 0.003)
+	want := 1 - 1_000/(1_000+in)*(1-0.003)
+	if !approx(costs.EntryImpact, want, 1e-9) {
+		t.Errorf("entry impact %f, want %f", costs.EntryImpact, want)
+	}
+	if costs.ExitImpact <= 0 || costs.RoundTrip <= costs.EntryImpact {
+		t.Errorf("expected exit impact on top of entry, got %+v", costs)
+	}
+
+	if want := 1 - (1-costs.EntryImpact)*(1-costs.ExitImpact); !approx(costs.RoundTrip, want, 1e-9) {
+		t.Errorf("round trip %f, want %f from entry and exit", costs.RoundTrip, want)
+	}
+}
+
+func TestEstimateTax(t *testing.T) {
+	pool := constantProduct()
+	pool.FeeBps = 0
+	costs, err := Estimate(pool, 1, 0.05)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	// A negligible size pays only the tax, once on each leg
+	if !approx(costs.RoundTrip, 1-0.95*0.95, 1e-4) {
+		t.Errorf("round trip %f, want %f", costs.RoundTrip, 1-0.95*0.95)
+	}
+	if got := NetROI(0.5, costs); !approx(got, 1.5*0.95*0.95-1, 1e-4) {
+		t.Errorf("net ROI %f", got)
+	}
+}
+
+func TestEstimateConcentratedMatchesConstantProductInFullRange(t *testing.T) {
+	cp := constantProduct()
+	// A single range covering every price is a constant-product pool with L = sqrt(x*y)
+	cl := cp
+	cl.Kind = models.PoolConcentrated
+	cl.Price = cp.ReserveQuote / cp.ReserveToken
+	cl.Ranges = []models.LiquidityRange{{Lower: 1e-12, Upper: 1e12, Liquidity: math.Sqrt(cp.ReserveToken * cp.ReserveQuote)}}
+
+	want, _ := Estimate(cp, 25_000, 0.01)
+	got, err := Estimate(cl, 25_000, 0.01)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if !approx(got.EntryImpact, want.EntryImpact, 1e-6) || !approx(got.RoundTrip, want.RoundTrip, 1e-6) {
+		t.Errorf("concentrated %+v, constant-product %+v", got, want)
+	}
+}
+
+func TestEstimateConcentratedCrossesRanges(t *testing.T) {
+	pool := models.PoolState{
+		Kind:          models.PoolConcentrated,
+		QuotePriceUSD: 1,
+		Price:         1,
+		Ranges: []models.LiquidityRange{
+			{Lower: 0.5, Upper: 1.1, Liquidity: 10_000},
+			{Lower: 1.1, Upper: 2, Liquidity: 1_000},
+		},
+	}
+
+	// The first range holds L*(sqrt(1.1)-1) of quote above the current price;
+	// buying past it moves into the thinner range and costs more per unit
+	capacity := 10_000 * (math.Sqrt(1.1) - 1)
+	inside, err := Estimate(pool, capacity*0.9, 0)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	across, err := Estimate(pool, capacity*1.5, 0)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if across.EntryImpact <= inside.EntryImpact*1.5 {
+		t.Errorf("expected impact to jump past the range boundary: inside %f, across %f",
+			inside.EntryImpact, across.EntryImpact)
+	}
+
+	if _, err := Estimate(pool, 1e6, 0); err != ErrInsufficientLiquidity {
+		t.Errorf("expected insufficient liquidity, got %v", err)
+	}
+}
+
+func TestMaxBuy(t *testing.T) {
+	pool := constantProduct()
+	max, err := MaxBuy(pool, 0.02)
+	if err != nil {
+		t.Fatalf("MaxBuy: %v", err)
+	}
+	costs, _ := Estimate(pool, max, 0)
+	if !approx(costs.EntryImpact, 0.02, 1e-6) {
+		t.Errorf("entry impact at max buy %f, want 0.02", costs.EntryImpact)
+	}
+
+	// The LP fee alone exceeds a target below it
+	if max, _ := MaxBuy(pool, 0.001); max != 0 {
+		t.Errorf("expected no size within a target below the fee, got %f", max)
+	}
+
+	if _, err := MaxBuy(models.PoolState{QuotePriceUSD: 1}, 0.02); err == nil {
+		t.Error("expected an error for a pool without reserves")
+	}
+}
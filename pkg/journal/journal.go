@@ -0,0 +1,231 @@
+package journal
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// Pipeline stages a record can come from
+const (
+	StageTokenFound       = "token_found"
+	StagePreFiltered      = "prefiltered"
+	StageSafetyReport     = "safety_report"
+	StageOffChainMetrics  = "offchain_metrics"
+	StageStrategyDecision = "strategy_decision"
+	StageRiskVerdict      = "risk_verdict"
+	StageExecutionResult  = "execution_result"
+)
+
+// activeFile is the file being appended to; rotated files are named
+// journal-<UTC time>.jsonl, plus .gz when compressed
+const (
+	activeFile    = "journal.jsonl"
+	rotatedPrefix = "journal-"
+	rotatedLayout = "20060102T150405.000000000"
+)
+
+// Record is one event in the journal
+type Record struct {
+	Time          time.Time       `json:"time"`
+	CorrelationID string          `json:"correlation_id"`
+	Chain         models.Chain    `json:"chain"`
+	TokenAddress  string          `json:"token_address"`
+	Stage         string          `json:"stage"`
+	Event         json.RawMessage `json:"event"`
+}
+
+// NewCorrelationID returns a random ID for a newly found token
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Journal appends records to a JSONL file in a directory, one per line. The
+// file is rotated once it reaches maxBytes and, if compress is set, rotated
+// files are gzipped in the background. Records are never rewritten.
+type Journal struct {
+	dir      string
+	maxBytes int64
+	compress bool
+	file     *os.File
+	size     int64
+	now      func() time.Time
+	pending  sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// Open opens the journal in dir, creating it if needed and appending to the
+// active file; a maxBytes of 0 never rotates
+func Open(dir string, maxBytes int64, compress bool) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+	j := &Journal{dir: dir, maxBytes: maxBytes, compress: compress, now: time.Now}
+	if err := j.openActive(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// openActive opens the active file for appending; the caller holds the lock
+func (j *Journal) openActive() error {
+	f, err := os.OpenFile(filepath.Join(j.dir, activeFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open journal: %w", err)
+	}
+	j.file = f
+	j.size = info.Size()
+	return j.terminate()
+}
+
+// terminate ends a torn last line left by a crash, so the next record starts
+// on a line of its own; the caller holds the lock
+func (j *Journal) terminate() error {
+	if j.size == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	r, err := os.Open(j.file.Name())
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	defer r.Close()
+	if _, err := r.ReadAt(last, j.size-1); err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	n, err := j.file.Write([]byte{'\n'})
+	j.size += int64(n)
+	return err
+}
+
+// Append writes event as a record of the token's stage
+func (j *Journal) Append(token models.TokenFound, stage string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", stage, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	line, err := json.Marshal(Record{
+		Time:          j.now(),
+		CorrelationID: token.CorrelationID,
+		Chain:         token.Chain,
+		TokenAddress:  token.TokenAddress,
+		Stage:         stage,
+		Event:         data,
+	})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if j.file == nil {
+		return fmt.Errorf("journal is closed")
+	}
+	if j.maxBytes > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxBytes {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	return err
+}
+
+// rotate renames the active file and starts a new one; the caller holds the lock
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("rotate journal: %w", err)
+	}
+	rotated := filepath.Join(j.dir, rotatedPrefix+j.now().UTC().Format(rotatedLayout)+".jsonl")
+	if err := os.Rename(filepath.Join(j.dir, activeFile), rotated); err != nil {
+		return fmt.Errorf("rotate journal: %w", err)
+	}
+	if err := j.openActive(); err != nil {
+		return err
+	}
+
+	if j.compress {
+		j.pending.Add(1)
+		go func() {
+			defer j.pending.Done()
+			if err := compressFile(rotated); err != nil {
+				log.Printf("Journal: Failed to compress %s: %v\n", rotated, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// compressFile gzips path to path.gz and removes path. The .gz appears
+// complete or not at all, so readers never see a truncated archive.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Close waits for compression to finish and closes the active file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.pending.Wait()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
@@ -0,0 +1,126 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func TestJournalRotatesAndReadsBack(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, 600, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	j.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	tokenA := models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xAbC", CorrelationID: NewCorrelationID()}
+	tokenB := models.TokenFound{Chain: models.ChainSolana, TokenAddress: "0xabc", CorrelationID: NewCorrelationID()}
+	if tokenA.CorrelationID == tokenB.CorrelationID {
+		t.Fatal("correlation IDs must differ")
+	}
+
+	for _, token := range []models.TokenFound{tokenA, tokenB} {
+		steps := []struct {
+			stage string
+			event interface{}
+		}{
+			{StageTokenFound, token},
+			{StagePreFiltered, models.PreFilteredToken{Token: token, Priority: "high"}},
+			{StageSafetyReport, models.SafetyReport{TokenAddress: token.TokenAddress, HoneypotScore: 0.05, CanBuy: true, CanSell: true}},
+			{StageStrategyDecision, models.StrategyDecision{Strategy: "heuristic", Action: "buy", WinProbability: 0.85, SuggestedAmountUSD: 50}},
+			{StageRiskVerdict, models.RiskVerdict{Allowed: false, Reason: "trading_halted", AmountUSD: 50}},
+		}
+		for _, step := range steps {
+			if err := j.Append(token, step.stage, step.event); err != nil {
+				t.Fatalf("append %s: %v", step.stage, err)
+			}
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 3 {
+		t.Fatalf("expected the journal to rotate, got %v", files)
+	}
+	for _, path := range files[:len(files)-1] {
+		if !strings.HasSuffix(path, ".jsonl.gz") {
+			t.Errorf("expected rotated files to be compressed, got %s", path)
+		}
+	}
+
+	story, err := Read(dir, Filter{Chain: models.ChainBase, TokenAddress: "0xabc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(story) != 5 {
+		t.Fatalf("expected the five records of the base token, got %d", len(story))
+	}
+	for i, stage := range []string{StageTokenFound, StagePreFiltered, StageSafetyReport, StageStrategyDecision, StageRiskVerdict} {
+		if story[i].Stage != stage || story[i].CorrelationID != tokenA.CorrelationID {
+			t.Errorf("record %d: got %s/%s, want %s/%s", i, story[i].Stage, story[i].CorrelationID, stage, tokenA.CorrelationID)
+		}
+		if i > 0 && !story[i].Time.After(story[i-1].Time) {
+			t.Errorf("record %d is out of order", i)
+		}
+	}
+	if got := story[4].Summary(); got != "blocked $50.00: trading_halted" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	byID, err := Read(dir, Filter{CorrelationID: tokenB.CorrelationID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byID) != 5 || byID[0].Chain != models.ChainSolana {
+		t.Errorf("expected the solana token's records, got %d", len(byID))
+	}
+}
+
+func TestReadSkipsTornLastLine(t *testing.T) {
+	dir := t.TempDir()
+	j, err := Open(dir, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := models.TokenFound{Chain: models.ChainBase, TokenAddress: "0xabc", CorrelationID: "c1"}
+	j.Append(token, StageTokenFound, token)
+	j.Close()
+
+	// A crash mid-write leaves a partial record at the end of the active file
+	f, err := os.OpenFile(filepath.Join(dir, activeFile), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2024-01-01T12:00:00Z","correlation_id":"c1","st`)
+	f.Close()
+
+	// Reopening appends after the torn line without losing what came before
+	j, err = Open(dir, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Append(token, StagePreFiltered, models.PreFilteredToken{Token: token, Dropped: true, Reasons: []string{"blacklisted"}})
+	j.Close()
+
+	records, err := Read(dir, Filter{CorrelationID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Stage != StageTokenFound || records[1].Stage != StagePreFiltered {
+		t.Errorf("expected the records around the torn line, got %+v", records)
+	}
+}
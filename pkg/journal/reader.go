@@ -0,0 +1,188 @@
+package journal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// maxLineBytes bounds one record; off-chain metrics with trade flow can be large
+const maxLineBytes = 16 << 20
+
+// Filter selects records; empty fields match everything
+type Filter struct {
+	CorrelationID string
+	Chain         models.Chain
+	TokenAddress  string
+}
+
+// matches reports whether a record passes the filter
+func (f Filter) matches(record *Record) bool {
+	if f.CorrelationID != "" && record.CorrelationID != f.CorrelationID {
+		return false
+	}
+	if f.Chain != "" && record.Chain != f.Chain {
+		return false
+	}
+	return f.TokenAddress == "" || strings.EqualFold(record.TokenAddress, f.TokenAddress)
+}
+
+// Files returns the journal's files oldest first: rotated files by rotation
+// time, then the active file. A rotated file caught mid-compression is read
+// from the plain copy.
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make(map[string]bool)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), rotatedPrefix) && strings.HasSuffix(entry.Name(), ".jsonl") {
+			plain[entry.Name()] = true
+		}
+	}
+
+	var rotated []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, rotatedPrefix) {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, ".jsonl"):
+			rotated = append(rotated, name)
+		case strings.HasSuffix(name, ".jsonl.gz") && !plain[strings.TrimSuffix(name, ".gz")]:
+			rotated = append(rotated, name)
+		}
+	}
+	sort.Strings(rotated)
+
+	files := make([]string, 0, len(rotated)+1)
+	for _, name := range rotated {
+		files = append(files, filepath.Join(dir, name))
+	}
+	if _, err := os.Stat(filepath.Join(dir, activeFile)); err == nil {
+		files = append(files, filepath.Join(dir, activeFile))
+	}
+	return files, nil
+}
+
+// Read returns every record in dir that matches filter, oldest first
+func Read(dir string, filter Filter) ([]Record, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, path := range files {
+		err := readFile(path, func(record *Record) {
+			if filter.matches(record) {
+				records = append(records, *record)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return records, nil
+}
+
+// readFile calls fn for each record in a plain or gzipped journal file
+func readFile(path string, fn func(*Record)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A crash can leave a line half written; the journal carries on
+			// after it on a new line, and so does the reader
+			log.Printf("Journal: Skipping unreadable line %d of %s: %v\n", line, filepath.Base(path), err)
+			continue
+		}
+		fn(&record)
+	}
+	return scanner.Err()
+}
+
+// Summary describes the record's event in one line
+func (r Record) Summary() string {
+	switch r.Stage {
+	case StageTokenFound:
+		var token models.TokenFound
+		if json.Unmarshal(r.Event, &token) == nil {
+			return fmt.Sprintf("found, creator %s, tx %s", token.CreatorAddress, token.TxHash)
+		}
+	case StagePreFiltered:
+		var prefiltered models.PreFilteredToken
+		if json.Unmarshal(r.Event, &prefiltered) == nil {
+			if prefiltered.Dropped {
+				return "dropped: " + strings.Join(prefiltered.Reasons, ", ")
+			}
+			return "passed, priority " + prefiltered.Priority
+		}
+	case StageSafetyReport:
+		var report models.SafetyReport
+		if json.Unmarshal(r.Event, &report) == nil {
+			return fmt.Sprintf("honeypot %.2f, can buy %v, can sell %v", report.HoneypotScore, report.CanBuy, report.CanSell)
+		}
+	case StageOffChainMetrics:
+		var metrics models.OffChainMetrics
+		if json.Unmarshal(r.Event, &metrics) == nil {
+			return fmt.Sprintf("liquidity $%.0f, DEX volume $%.0f, velocity %s", metrics.LiquidityUSD, metrics.Volume24hDEX, metrics.Velocity)
+		}
+	case StageStrategyDecision:
+		var decision models.StrategyDecision
+		if json.Unmarshal(r.Event, &decision) == nil {
+			return fmt.Sprintf("%s: %s at win probability %.2f, size $%.2f", decision.Strategy, decision.Action, decision.WinProbability, decision.SuggestedAmountUSD)
+		}
+	case StageRiskVerdict:
+		var verdict models.RiskVerdict
+		if json.Unmarshal(r.Event, &verdict) == nil {
+			if verdict.Allowed {
+				return fmt.Sprintf("allowed $%.2f", verdict.AmountUSD)
+			}
+			return fmt.Sprintf("blocked $%.2f: %s", verdict.AmountUSD, verdict.Reason)
+		}
+	case StageExecutionResult:
+		var result models.ExecutionResult
+		if json.Unmarshal(r.Event, &result) == nil {
+			if result.Error != "" {
+				return fmt.Sprintf("%s $%.2f: %s", result.Status, result.AmountUSD, result.Error)
+			}
+			return fmt.Sprintf("%s $%.2f, tx %s", result.Status, result.AmountUSD, result.TxHash)
+		}
+	}
+	return string(r.Event)
+}
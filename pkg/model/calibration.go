@@ -0,0 +1,366 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// Calibration methods
+const (
+	MethodPlatt    = "platt"
+	MethodIsotonic = "isotonic"
+)
+
+// DefaultCalibrator is the calibration key used for strategies without their own calibrator
+const DefaultCalibrator = "*"
+
+// Observation is a predicted win probability and whether the trade won
+type Observation struct {
+	Strategy string
+	Prob     float64
+	Label    float64
+}
+
+// ObservationsFromRecords joins each candidate's predicted win probability with its outcome.
+// The uncalibrated probability is used when the decision was calibrated.
+func ObservationsFromRecords(records []models.LabeledCandidate) []Observation {
+	observations := make([]Observation, 0, len(records))
+	for _, record := range records {
+		decision := record.Candidate.StrategyDecision
+		prob := decision.WinProbability
+		if decision.RawWinProbability > 0 {
+			prob = decision.RawWinProbability
+		}
+		observations = append(observations, Observation{
+			Strategy: decision.Strategy,
+			Prob:     prob,
+			Label:    flag(record.Outcome.ROI > 0),
+		})
+	}
+	return observations
+}
+
+// ReliabilityBin compares predicted and observed win rates within a probability range
+type ReliabilityBin struct {
+	Lower         float64 `json:"lower"`
+	Upper         float64 `json:"upper"`
+	Count         int     `json:"count"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	ObservedRate  float64 `json:"observed_rate"`
+}
+
+// ReliabilityReport summarizes how well predicted probabilities match outcomes
+type ReliabilityReport struct {
+	Examples int              `json:"examples"`
+	BaseRate float64          `json:"base_rate"`
+	Brier    float64          `json:"brier"`
+	ECE      float64          `json:"ece"` // count-weighted mean |predicted - observed| over bins
+	Bins     []ReliabilityBin `json:"bins"`
+}
+
+// Reliability bins the observations into equal-width probability ranges and
+// scores them. apply, if not nil, maps each probability before scoring.
+func Reliability(observations []Observation, bins int, apply func(Observation) float64) ReliabilityReport {
+	if bins <= 0 {
+		bins = 10
+	}
+	report := ReliabilityReport{Examples: len(observations), Bins: make([]ReliabilityBin, bins)}
+	for i := range report.Bins {
+		report.Bins[i].Lower = float64(i) / float64(bins)
+		report.Bins[i].Upper = float64(i+1) / float64(bins)
+	}
+	if len(observations) == 0 {
+		return report
+	}
+
+	for _, obs := range observations {
+		p := obs.Prob
+		if apply != nil {
+			p = apply(obs)
+		}
+		report.BaseRate += obs.Label
+		report.Brier += (p - obs.Label) * (p - obs.Label)
+
+		i := int(math.Min(p*float64(bins), float64(bins-1)))
+		if i < 0 {
+			i = 0
+		}
+		report.Bins[i].Count++
+		report.Bins[i].MeanPredicted += p
+		report.Bins[i].ObservedRate += obs.Label
+	}
+
+	n := float64(len(observations))
+	report.BaseRate /= n
+	report.Brier /= n
+	for i := range report.Bins {
+		bin := &report.Bins[i]
+		if bin.Count == 0 {
+			continue
+		}
+		bin.MeanPredicted /= float64(bin.Count)
+		bin.ObservedRate /= float64(bin.Count)
+		report.ECE += float64(bin.Count) / n * math.Abs(bin.MeanPredicted-bin.ObservedRate)
+	}
+	return report
+}
+
+// Calibrator maps raw win probabilities to calibrated ones
+type Calibrator struct {
+	Method   string `json:"method"`
+	Examples int    `json:"examples"`
+
+	// Platt scaling: sigmoid(A * logit(p) + B)
+	A float64 `json:"a,omitempty"`
+	B float64 `json:"b,omitempty"`
+
+	// Isotonic regression: step function over ascending thresholds
+	Thresholds []float64 `json:"thresholds,omitempty"`
+	Values     []float64 `json:"values,omitempty"`
+}
+
+// Apply returns the calibrated probability
+func (c *Calibrator) Apply(p float64) float64 {
+	switch c.Method {
+	case MethodPlatt:
+		return sigmoid(c.A*logit(p) + c.B)
+	case MethodIsotonic:
+		if len(c.Thresholds) == 0 {
+			return p
+		}
+		// Use the block whose lower bound is the largest threshold not above p
+		i := sort.SearchFloat64s(c.Thresholds, p)
+		if i == len(c.Thresholds) || c.Thresholds[i] > p {
+			i--
+		}
+		if i < 0 {
+			i = 0
+		}
+		return c.Values[i]
+	}
+	return p
+}
+
+// FitCalibrator fits a calibrator with the given method
+func FitCalibrator(observations []Observation, method string) (*Calibrator, error) {
+	if len(observations) == 0 {
+		return nil, fmt.Errorf("no observations")
+	}
+	switch method {
+	case MethodPlatt:
+		return fitPlatt(observations), nil
+	case MethodIsotonic:
+		return fitIsotonic(observations), nil
+	}
+	return nil, fmt.Errorf("unknown calibration method %q", method)
+}
+
+// fitPlatt fits a logistic regression on the log-odds of the raw probability
+func fitPlatt(observations []Observation) *Calibrator {
+	c := &Calibrator{Method: MethodPlatt, Examples: len(observations), A: 1}
+	n := float64(len(observations))
+
+	// Newton's method on the two parameters with a small ridge for stability
+	for iter := 0; iter < 100; iter++ {
+		var ga, gb, haa, hab, hbb float64
+		for _, obs := range observations {
+			x := logit(obs.Prob)
+			p := sigmoid(c.A*x + c.B)
+			r := p - obs.Label
+			w := math.Max(p*(1-p), 1e-9)
+			ga += r * x
+			gb += r
+			haa += w * x * x
+			hab += w * x
+			hbb += w
+		}
+		haa += 1e-6 * n
+		hbb += 1e-6 * n
+
+		det := haa*hbb - hab*hab
+		if det == 0 {
+			break
+		}
+		da := (hbb*ga - hab*gb) / det
+		db := (haa*gb - hab*ga) / det
+		c.A -= da
+		c.B -= db
+		if math.Abs(da)+math.Abs(db) < 1e-10 {
+			break
+		}
+	}
+	return c
+}
+
+// fitIsotonic fits a non-decreasing step function with pool-adjacent-violators
+func fitIsotonic(observations []Observation) *Calibrator {
+	sorted := append([]Observation(nil), observations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Prob < sorted[j].Prob })
+
+	type block struct {
+		lower, sum, count float64
+	}
+	var blocks []block
+	for _, obs := range sorted {
+		// Tied probabilities must map to one value
+		if n := len(blocks); n > 0 && blocks[n-1].lower == obs.Prob {
+			blocks[n-1].sum += obs.Label
+			blocks[n-1].count++
+		} else {
+			blocks = append(blocks, block{lower: obs.Prob, sum: obs.Label, count: 1})
+		}
+		// Merge while the newest block's mean is below its predecessor's
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sum/prev.count < last.sum/last.count {
+				break
+			}
+			blocks = blocks[:len(blocks)-2]
+			blocks = append(blocks, block{lower: prev.lower, sum: prev.sum + last.sum, count: prev.count + last.count})
+		}
+	}
+
+	c := &Calibrator{Method: MethodIsotonic, Examples: len(observations)}
+	for _, b := range blocks {
+		c.Thresholds = append(c.Thresholds, b.lower)
+		c.Values = append(c.Values, b.sum/b.count)
+	}
+	return c
+}
+
+// Calibration is a versioned set of calibrators keyed by strategy name
+type Calibration struct {
+	Version     string                 `json:"version"`
+	FittedAt    time.Time              `json:"fitted_at"`
+	Calibrators map[string]*Calibrator `json:"calibrators"` // "*" applies to strategies without their own
+}
+
+// FitCalibration fits one calibrator across all observations and one per
+// strategy that has at least minPerStrategy observations
+func FitCalibration(observations []Observation, method string, minPerStrategy int) (*Calibration, error) {
+	calibration := &Calibration{
+		FittedAt:    time.Now().UTC(),
+		Calibrators: make(map[string]*Calibrator),
+	}
+	calibration.Version = calibration.FittedAt.Format("20060102T150405Z")
+
+	all, err := FitCalibrator(observations, method)
+	if err != nil {
+		return nil, err
+	}
+	calibration.Calibrators[DefaultCalibrator] = all
+
+	byStrategy := make(map[string][]Observation)
+	for _, obs := range observations {
+		if obs.Strategy != "" {
+			byStrategy[obs.Strategy] = append(byStrategy[obs.Strategy], obs)
+		}
+	}
+	for strategy, group := range byStrategy {
+		if len(group) < minPerStrategy {
+			continue
+		}
+		calibrator, err := FitCalibrator(group, method)
+		if err != nil {
+			return nil, err
+		}
+		calibration.Calibrators[strategy] = calibrator
+	}
+	return calibration, nil
+}
+
+// Apply calibrates a strategy's win probability
+func (c *Calibration) Apply(strategy string, p float64) float64 {
+	calibrator, exists := c.Calibrators[strategy]
+	if !exists {
+		calibrator, exists = c.Calibrators[DefaultCalibrator]
+	}
+	if !exists {
+		return p
+	}
+	return calibrator.Apply(p)
+}
+
+// LoadCalibration reads a calibration file
+func LoadCalibration(path string) (*Calibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Calibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if c.Version == "" {
+		return nil, fmt.Errorf("%s: calibration version is required", path)
+	}
+	for name, calibrator := range c.Calibrators {
+		if calibrator.Method != MethodPlatt && calibrator.Method != MethodIsotonic {
+			return nil, fmt.Errorf("%s: calibrator %s: unknown method %q", path, name, calibrator.Method)
+		}
+		if len(calibrator.Thresholds) != len(calibrator.Values) || !sort.Float64sAreSorted(calibrator.Thresholds) {
+			return nil, fmt.Errorf("%s: calibrator %s: invalid isotonic steps", path, name)
+		}
+	}
+	return &c, nil
+}
+
+// Save writes the calibration as indented JSON
+func (c *Calibration) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// logit maps a probability to log-odds, clamped away from 0 and 1
+func logit(p float64) float64 {
+	p = math.Min(math.Max(p, 1e-6), 1-1e-6)
+	return math.Log(p / (1 - p))
+}
+
+// CalibrationReport is the reliability of raw win probabilities overall and
+// per strategy, and of calibrated ones when a calibration is in effect
+type CalibrationReport struct {
+	Raw                ReliabilityReport            `json:"raw"`
+	Calibrated         *ReliabilityReport           `json:"calibrated,omitempty"`
+	CalibrationVersion string                       `json:"calibration_version,omitempty"`
+	ByStrategy         map[string]ReliabilityReport `json:"by_strategy"`
+}
+
+// NewCalibrationReport builds the report; calibration may be nil
+func NewCalibrationReport(observations []Observation, bins int, calibration *Calibration) CalibrationReport {
+	report := CalibrationReport{
+		Raw:        Reliability(observations, bins, nil),
+		ByStrategy: make(map[string]ReliabilityReport),
+	}
+
+	if calibration != nil {
+		calibrated := Reliability(observations, bins, func(obs Observation) float64 {
+			return calibration.Apply(obs.Strategy, obs.Prob)
+		})
+		report.Calibrated = &calibrated
+		report.CalibrationVersion = calibration.Version
+	}
+
+	byStrategy := make(map[string][]Observation)
+	for _, obs := range observations {
+		name := obs.Strategy
+		if name == "" {
+			name = "unknown"
+		}
+		byStrategy[name] = append(byStrategy[name], obs)
+	}
+	for name, group := range byStrategy {
+		report.ByStrategy[name] = Reliability(group, bins, nil)
+	}
+	return report
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// overconfident generates observations whose true win rate is p^2
+func overconfident(n int, seed int64) []Observation {
+	rng := rand.New(rand.NewSource(seed))
+	observations := make([]Observation, n)
+	for i := range observations {
+		p := rng.Float64()
+		observations[i] = Observation{Strategy: "heuristic", Prob: p, Label: flag(rng.Float64() < p*p)}
+	}
+	return observations
+}
+
+func TestReliability(t *testing.T) {
+	observations := []Observation{
+		{Prob: 0.85, Label: 1},
+		{Prob: 0.85, Label: 0},
+		{Prob: 0.15, Label: 0},
+		{Prob: 1.0, Label: 1},
+	}
+	report := Reliability(observations, 10, nil)
+
+	if report.Examples != 4 || report.BaseRate != 0.5 {
+		t.Errorf("unexpected totals %+v", report)
+	}
+	wantBrier := (0.15*0.15 + 0.85*0.85 + 0.15*0.15 + 0) / 4
+	if math.Abs(report.Brier-wantBrier) > 1e-12 {
+		t.Errorf("Brier = %f, want %f", report.Brier, wantBrier)
+	}
+	if bin := report.Bins[8]; bin.Count != 2 || bin.ObservedRate != 0.5 || math.Abs(bin.MeanPredicted-0.85) > 1e-12 {
+		t.Errorf("unexpected 0.8-0.9 bin %+v", bin)
+	}
+	// A probability of exactly 1 falls in the top bin
+	if report.Bins[9].Count != 1 {
+		t.Errorf("expected p=1 in the last bin, got %+v", report.Bins[9])
+	}
+}
+
+func TestCalibratorsImproveOverconfidentPredictions(t *testing.T) {
+	fitSet, testSet := overconfident(4000, 1), overconfident(2000, 2)
+	raw := Reliability(testSet, 10, nil)
+
+	for _, method := range []string{MethodPlatt, MethodIsotonic} {
+		calibrator, err := FitCalibrator(fitSet, method)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		calibrated := Reliability(testSet, 10, func(obs Observation) float64 { return calibrator.Apply(obs.Prob) })
+		if calibrated.Brier >= raw.Brier || calibrated.ECE >= raw.ECE/2 {
+			t.Errorf("%s: Brier %.4f -> %.4f, ECE %.4f -> %.4f", method, raw.Brier, calibrated.Brier, raw.ECE, calibrated.ECE)
+		}
+		if got := calibrator.Apply(0.8); math.Abs(got-0.64) > 0.08 {
+			t.Errorf("%s: Apply(0.8) = %.3f, want about 0.64", method, got)
+		}
+	}
+}
+
+func TestIsotonicIsMonotonic(t *testing.T) {
+	calibrator := fitIsotonic([]Observation{
+		{Prob: 0.1, Label: 1}, {Prob: 0.2, Label: 0}, {Prob: 0.2, Label: 1},
+		{Prob: 0.5, Label: 0}, {Prob: 0.7, Label: 1}, {Prob: 0.9, Label: 1},
+	})
+	previous := -1.0
+	for p := 0.0; p <= 1; p += 0.05 {
+		got := calibrator.Apply(p)
+		if got < previous {
+			t.Fatalf("Apply(%.2f) = %f decreased from %f", p, got, previous)
+		}
+		previous = got
+	}
+	if !sortedStrictly(calibrator.Thresholds) {
+		t.Errorf("thresholds not strictly increasing: %v", calibrator.Thresholds)
+	}
+}
+
+func TestCalibrationFile(t *testing.T) {
+	observations := overconfident(500, 3)
+	for i := 0; i < 100; i++ {
+		observations = append(observations, Observation{Strategy: "momentum", Prob: 0.5, Label: flag(i%4 == 0)})
+	}
+
+	calibration, err := FitCalibration(observations, MethodIsotonic, 100)
+	if err != nil {
+		t.Fatalf("FitCalibration: %v", err)
+	}
+	if _, exists := calibration.Calibrators["momentum"]; !exists {
+		t.Fatal("expected a momentum calibrator")
+	}
+
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	if err := calibration.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCalibration(path)
+	if err != nil {
+		t.Fatalf("LoadCalibration: %v", err)
+	}
+	if got := loaded.Apply("momentum", 0.5); got != 0.25 {
+		t.Errorf("momentum Apply(0.5) = %f, want 0.25", got)
+	}
+	// Strategies without their own calibrator use the default one
+	if loaded.Apply("pumpfun_sniper", 0.5) != loaded.Calibrators[DefaultCalibrator].Apply(0.5) {
+		t.Error("expected fallback to the default calibrator")
+	}
+}
+
+func sortedStrictly(values []float64) bool {
+	for i := 1; i < len(values); i++ {
+		if values[i] <= values[i-1] {
+			return false
+		}
+	}
+	return true
+}
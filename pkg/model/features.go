@@ -0,0 +1,117 @@
+package model
+
+import (
+	"math"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// FeatureNames lists the model inputs in the order Features returns them.
+// Models store the names they were trained on, so features can be appended
+// here without invalidating existing model files.
+var FeatureNames = []string{
+	"can_trade",
+	"honeypot_score",
+	"liquidity_locked",
+	"owner_renounced",
+	"has_blacklist",
+	"has_transfer_hook",
+	"tax_fee",
+	"sell_slippage",
+	"log_volume_dex",
+	"log_volume_cex",
+	"log_liquidity_usd",
+	"log_reserve_native",
+	"log_social_mentions",
+	"social_member_growth",
+	"cex_listed",
+	"velocity",
+	"relative_slope",
+	"relative_accel",
+	"log_unique_buyers",
+	"buy_sell_ratio",
+	"sniper_supply_share",
+	"chain_solana",
+}
+
+// Features builds the feature vector for a token from its evaluation reports
+func Features(safety *models.SafetyReport, offchain *models.OffChainMetrics, token models.TokenFound) []float64 {
+	mentions := 0
+	for _, count := range offchain.SocialMentions {
+		mentions += count
+	}
+	growth := 0.0
+	for _, g := range offchain.SocialGrowth {
+		growth += g.MemberGrowthPerHour
+	}
+
+	liquidity := offchain.LiquidityUSD
+	velocity := 0.0
+	switch offchain.Velocity {
+	case "rising":
+		velocity = 1
+	case "falling":
+		velocity = -1
+	}
+
+	var slope, accel float64
+	for _, window := range offchain.VelocityWindows {
+		if window.Samples >= 3 {
+			slope, accel = window.RelativeSlope, window.RelativeAccel
+			break
+		}
+	}
+
+	var buyers, ratio, sniperShare float64
+	if flow := offchain.TradeFlow; flow != nil {
+		buyers, ratio, sniperShare = float64(flow.UniqueBuyers), flow.BuySellRatio, flow.SniperSupplyShare
+	} else {
+		buyers = float64(offchain.UniqueBuyers)
+	}
+
+	return []float64{
+		flag(safety.CanBuy && safety.CanSell),
+		safety.HoneypotScore,
+		flag(safety.LiquidityLocked),
+		flag(safety.OwnerControls.Renounced),
+		flag(safety.OwnerControls.HasBlacklist),
+		flag(safety.OwnerControls.HasTransferHook),
+		safety.OwnerControls.TaxFee,
+		safety.SimulatedSell.Slippage,
+		logScale(offchain.Volume24hDEX),
+		logScale(offchain.Volume24hCEX),
+		logScale(liquidity),
+		logScale(token.InitialLiquidity.ReserveNative),
+		logScale(float64(mentions)),
+		logScale(math.Max(growth, 0)),
+		flag(len(offchain.CEXListings) > 0),
+		velocity,
+		slope,
+		accel,
+		logScale(buyers),
+		ratio,
+		sniperShare,
+		flag(token.Chain == models.ChainSolana),
+	}
+}
+
+// CandidateFeatures builds the feature vector from a recorded candidate
+func CandidateFeatures(candidate *models.CandidateToken) []float64 {
+	return Features(&candidate.SafetyReport, &candidate.OffChainMetrics, candidate.Token)
+}
+
+// flag encodes a boolean as 1 or 0
+func flag(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// logScale compresses heavy-tailed counts and amounts
+func logScale(v float64) float64 {
+	if v <= 0 {
+		return 0
+	}
+	return math.Log1p(v)
+}
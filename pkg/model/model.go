@@ -0,0 +1,221 @@
+// Package model scores win probability with models trained on our own trade
+// history. Models are stored as portable JSON and evaluated in pure Go.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Model types
+const (
+	TypeLogistic = "logistic"
+	TypeEnsemble = "gbdt"
+)
+
+// Model is a trained win probability model
+type Model struct {
+	Version   string    `json:"version"`
+	Type      string    `json:"type"`     // "logistic" or "gbdt"
+	Features  []string  `json:"features"` // input names, in the order the parameters use
+	TrainedAt time.Time `json:"trained_at"`
+	Examples  int       `json:"examples"`
+
+	// Realized returns in the training data, used to derive expected ROI
+	MeanWinROI  float64 `json:"mean_win_roi"`
+	MeanLossROI float64 `json:"mean_loss_roi"`
+	ROIStd      float64 `json:"roi_std"`
+
+	Logistic *Logistic `json:"logistic,omitempty"`
+	Ensemble *Ensemble `json:"ensemble,omitempty"`
+
+	index []int // position of each model feature in FeatureNames
+}
+
+// Logistic holds logistic regression parameters
+type Logistic struct {
+	Bias    float64   `json:"bias"`
+	Weights []float64 `json:"weights"`
+}
+
+// Ensemble holds a gradient-boosted tree ensemble. Its output is the log-odds
+// BaseScore + LearningRate * sum of tree outputs.
+type Ensemble struct {
+	BaseScore    float64 `json:"base_score"`
+	LearningRate float64 `json:"learning_rate"`
+	Trees        []Tree  `json:"trees"`
+}
+
+// Tree is a binary regression tree stored as a flat node list; node 0 is the root
+type Tree struct {
+	Nodes []Node `json:"nodes"`
+}
+
+// Node is a split or a leaf. Every node carries the value a leaf at that
+// position would have, which is used to attribute the output to features.
+type Node struct {
+	Leaf      bool    `json:"leaf,omitempty"`
+	Feature   int     `json:"feature,omitempty"`   // index into Model.Features
+	Threshold float64 `json:"threshold,omitempty"` // go left when feature < threshold
+	Left      int     `json:"left,omitempty"`
+	Right     int     `json:"right,omitempty"`
+	Value     float64 `json:"value"`
+}
+
+// Contribution is one feature's share of a prediction in log-odds
+type Contribution struct {
+	Feature string
+	Value   float64
+	LogOdds float64
+}
+
+// Load reads and validates a model file
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := m.init(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Save writes the model as indented JSON
+func (m *Model) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// init validates the parameters and resolves feature names
+func (m *Model) init() error {
+	if m.Version == "" {
+		return fmt.Errorf("model version is required")
+	}
+
+	positions := make(map[string]int, len(FeatureNames))
+	for i, name := range FeatureNames {
+		positions[name] = i
+	}
+	m.index = make([]int, len(m.Features))
+	for i, name := range m.Features {
+		pos, exists := positions[name]
+		if !exists {
+			return fmt.Errorf("unknown feature %q", name)
+		}
+		m.index[i] = pos
+	}
+
+	switch m.Type {
+	case TypeLogistic:
+		if m.Logistic == nil || len(m.Logistic.Weights) != len(m.Features) {
+			return fmt.Errorf("logistic model needs one weight per feature")
+		}
+	case TypeEnsemble:
+		if m.Ensemble == nil {
+			return fmt.Errorf("gbdt model has no ensemble")
+		}
+		for t, tree := range m.Ensemble.Trees {
+			if len(tree.Nodes) == 0 {
+				return fmt.Errorf("tree %d is empty", t)
+			}
+			for n, node := range tree.Nodes {
+				if node.Leaf {
+					continue
+				}
+				if node.Feature < 0 || node.Feature >= len(m.Features) {
+					return fmt.Errorf("tree %d node %d splits on unknown feature %d", t, n, node.Feature)
+				}
+				if node.Left <= n || node.Right <= n || node.Left >= len(tree.Nodes) || node.Right >= len(tree.Nodes) {
+					return fmt.Errorf("tree %d node %d has invalid children", t, n)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unknown model type %q", m.Type)
+	}
+	return nil
+}
+
+// input selects the model's features from a full FeatureNames vector
+func (m *Model) input(x []float64) []float64 {
+	in := make([]float64, len(m.index))
+	for i, pos := range m.index {
+		if pos < len(x) {
+			in[i] = x[pos]
+		}
+	}
+	return in
+}
+
+// Predict returns the win probability for a FeatureNames-ordered vector
+func (m *Model) Predict(x []float64) float64 {
+	base, contributions := m.Explain(x)
+	logOdds := base
+	for _, c := range contributions {
+		logOdds += c.LogOdds
+	}
+	return sigmoid(logOdds)
+}
+
+// Explain splits a prediction into a base log-odds and per-feature
+// contributions that sum to the model output. Features that did not affect
+// the prediction are omitted.
+func (m *Model) Explain(x []float64) (float64, []Contribution) {
+	in := m.input(x)
+	logOdds := make([]float64, len(m.Features))
+	var base float64
+
+	switch m.Type {
+	case TypeLogistic:
+		base = m.Logistic.Bias
+		for i, w := range m.Logistic.Weights {
+			logOdds[i] = w * in[i]
+		}
+	case TypeEnsemble:
+		base = m.Ensemble.BaseScore
+		for _, tree := range m.Ensemble.Trees {
+			// Credit each split's feature with the change in node value along the path
+			node := 0
+			base += m.Ensemble.LearningRate * tree.Nodes[0].Value
+			for !tree.Nodes[node].Leaf {
+				split := tree.Nodes[node]
+				next := split.Right
+				if in[split.Feature] < split.Threshold {
+					next = split.Left
+				}
+				logOdds[split.Feature] += m.Ensemble.LearningRate * (tree.Nodes[next].Value - split.Value)
+				node = next
+			}
+		}
+	}
+
+	var contributions []Contribution
+	for i, delta := range logOdds {
+		if delta != 0 {
+			contributions = append(contributions, Contribution{Feature: m.Features[i], Value: in[i], LogOdds: delta})
+		}
+	}
+	return base, contributions
+}
+
+// ExpectedROI returns the mean and standard deviation of return implied by a win probability
+func (m *Model) ExpectedROI(winProb float64) (float64, float64) {
+	return winProb*m.MeanWinROI + (1-winProb)*m.MeanLossROI, m.ROIStd
+}
+
+// sigmoid maps log-odds to a probability
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// syntheticRecords generates trades that win when volume is high and the
+// honeypot score is low, with some label noise
+func syntheticRecords(n int, seed int64) []models.LabeledCandidate {
+	rng := rand.New(rand.NewSource(seed))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	records := make([]models.LabeledCandidate, n)
+	for i := range records {
+		volume := math.Exp(rng.Float64() * 12)
+		honeypot := rng.Float64() * 0.3
+		edge := math.Log1p(volume)/12 - honeypot*2
+		roi := -0.1
+		if edge+rng.NormFloat64()*0.1 > 0.2 {
+			roi = 0.3
+		}
+
+		records[i] = models.LabeledCandidate{
+			Candidate: models.CandidateToken{
+				Token:           models.TokenFound{Chain: models.ChainBase},
+				SafetyReport:    models.SafetyReport{CanBuy: true, CanSell: true, HoneypotScore: honeypot},
+				OffChainMetrics: models.OffChainMetrics{Volume24hDEX: volume},
+				ListedAt:        start.Add(time.Duration(i) * time.Minute),
+			},
+			Outcome: models.TradeOutcome{ROI: roi},
+		}
+	}
+	return records
+}
+
+func TestTrainModels(t *testing.T) {
+	var buf bytes.Buffer
+	for _, record := range syntheticRecords(600, 1) {
+		line, _ := json.Marshal(record)
+		buf.Write(append(line, '\n'))
+	}
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecords: %v", err)
+	}
+	examples := ExamplesFromRecords(records)
+	trainSet, testSet := examples[:500], examples[500:]
+
+	for _, modelType := range []string{TypeLogistic, TypeEnsemble} {
+		opts := DefaultTrainOptions()
+		opts.Type = modelType
+		opts.Version = "test-" + modelType
+
+		m, err := Train(trainSet, opts)
+		if err != nil {
+			t.Fatalf("%s: Train: %v", modelType, err)
+		}
+		metrics := Evaluate(m, testSet)
+		if metrics.Accuracy < 0.85 {
+			t.Errorf("%s: holdout accuracy %.2f, want >= 0.85", modelType, metrics.Accuracy)
+		}
+		if math.Abs(m.MeanWinROI-0.3) > 1e-9 || math.Abs(m.MeanLossROI+0.1) > 1e-9 {
+			t.Errorf("%s: unexpected ROI stats %v %v", modelType, m.MeanWinROI, m.MeanLossROI)
+		}
+
+		// Explanations add up to the prediction
+		x := testSet[0].Features
+		base, contributions := m.Explain(x)
+		for _, c := range contributions {
+			base += c.LogOdds
+		}
+		if p := m.Predict(x); math.Abs(1/(1+math.Exp(-base))-p) > 1e-12 {
+			t.Errorf("%s: contributions do not sum to prediction %f", modelType, p)
+		}
+
+		// The JSON file round-trips
+		path := filepath.Join(t.TempDir(), "model.json")
+		if err := m.Save(path); err != nil {
+			t.Fatalf("%s: Save: %v", modelType, err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: Load: %v", modelType, err)
+		}
+		if loaded.Predict(x) != m.Predict(x) {
+			t.Errorf("%s: loaded model predicts %f, trained %f", modelType, loaded.Predict(x), m.Predict(x))
+		}
+	}
+}
+
+func TestModelFeatureSubset(t *testing.T) {
+	// A model can use any subset of features, in any order
+	m := &Model{
+		Version:  "v1",
+		Type:     TypeLogistic,
+		Features: []string{"log_volume_dex", "honeypot_score"},
+		Logistic: &Logistic{Bias: -1, Weights: []float64{0.5, -4}},
+	}
+	if err := m.init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	x := Features(
+		&models.SafetyReport{HoneypotScore: 0.1},
+		&models.OffChainMetrics{Volume24hDEX: math.E - 1},
+		models.TokenFound{},
+	)
+	want := 1 / (1 + math.Exp(-(-1 + 0.5 - 0.4)))
+	if got := m.Predict(x); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Predict = %f, want %f", got, want)
+	}
+
+	m.Features = []string{"made_up"}
+	if err := m.init(); err == nil {
+		t.Error("expected unknown feature to be rejected")
+	}
+}
+
+func TestTrainRequiresBothOutcomes(t *testing.T) {
+	examples := ExamplesFromRecords(syntheticRecords(20, 2))
+	for i := range examples {
+		examples[i].Label = 1
+	}
+	if _, err := Train(examples, DefaultTrainOptions()); err == nil {
+		t.Error("expected training on only winning trades to fail")
+	}
+}
@@ -0,0 +1,400 @@
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// Example is one labeled feature vector
+type Example struct {
+	Features []float64
+	Label    float64 // 1 if the trade was profitable
+	ROI      float64
+}
+
+// TrainOptions control model fitting
+type TrainOptions struct {
+	Type    string
+	Version string
+
+	// Logistic regression
+	Epochs       int
+	LearningRate float64
+	L2           float64
+
+	// Gradient-boosted trees
+	Trees     int
+	MaxDepth  int
+	MinLeaf   int
+	Shrinkage float64
+}
+
+// DefaultTrainOptions returns options that work for a few hundred to a few thousand trades
+func DefaultTrainOptions() TrainOptions {
+	return TrainOptions{
+		Type:         TypeLogistic,
+		Epochs:       2000,
+		LearningRate: 0.1,
+		L2:           0.01,
+		Trees:        50,
+		MaxDepth:     3,
+		MinLeaf:      10,
+		Shrinkage:    0.1,
+	}
+}
+
+// Metrics summarize a model's fit on a set of examples
+type Metrics struct {
+	Examples int     `json:"examples"`
+	BaseRate float64 `json:"base_rate"` // share of profitable trades
+	LogLoss  float64 `json:"log_loss"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// ReadRecords reads labeled candidates, one JSON object per line
+func ReadRecords(r io.Reader) ([]models.LabeledCandidate, error) {
+	var records []models.LabeledCandidate
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record models.LabeledCandidate
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// ExamplesFromRecords turns labeled candidates into examples ordered by listing time
+func ExamplesFromRecords(records []models.LabeledCandidate) []Example {
+	sorted := make([]models.LabeledCandidate, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Candidate.ListedAt.Before(sorted[j].Candidate.ListedAt)
+	})
+
+	examples := make([]Example, len(sorted))
+	for i := range sorted {
+		examples[i] = Example{
+			Features: CandidateFeatures(&sorted[i].Candidate),
+			Label:    flag(sorted[i].Outcome.ROI > 0),
+			ROI:      sorted[i].Outcome.ROI,
+		}
+	}
+	return examples
+}
+
+// Train fits a model of the requested type
+func Train(examples []Example, opts TrainOptions) (*Model, error) {
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no training examples")
+	}
+	wins := 0
+	for _, ex := range examples {
+		if len(ex.Features) != len(FeatureNames) {
+			return nil, fmt.Errorf("example has %d features, want %d", len(ex.Features), len(FeatureNames))
+		}
+		wins += int(ex.Label)
+	}
+	if wins == 0 || wins == len(examples) {
+		return nil, fmt.Errorf("training data needs both profitable and losing trades")
+	}
+
+	m := &Model{
+		Version:   opts.Version,
+		Type:      opts.Type,
+		Features:  append([]string(nil), FeatureNames...),
+		TrainedAt: time.Now().UTC(),
+		Examples:  len(examples),
+	}
+	if m.Version == "" {
+		m.Version = m.TrainedAt.Format("20060102T150405Z")
+	}
+	m.MeanWinROI, m.MeanLossROI, m.ROIStd = roiStats(examples)
+
+	switch opts.Type {
+	case TypeLogistic:
+		m.Logistic = trainLogistic(examples, opts)
+	case TypeEnsemble:
+		m.Ensemble = trainEnsemble(examples, opts)
+	default:
+		return nil, fmt.Errorf("unknown model type %q", opts.Type)
+	}
+
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Evaluate measures the model on a set of examples
+func Evaluate(m *Model, examples []Example) Metrics {
+	metrics := Metrics{Examples: len(examples)}
+	if len(examples) == 0 {
+		return metrics
+	}
+
+	correct := 0
+	for _, ex := range examples {
+		p := math.Min(math.Max(m.Predict(ex.Features), 1e-15), 1-1e-15)
+		metrics.BaseRate += ex.Label
+		metrics.LogLoss -= ex.Label*math.Log(p) + (1-ex.Label)*math.Log(1-p)
+		if (p >= 0.5) == (ex.Label == 1) {
+			correct++
+		}
+	}
+
+	n := float64(len(examples))
+	metrics.BaseRate /= n
+	metrics.LogLoss /= n
+	metrics.Accuracy = float64(correct) / n
+	return metrics
+}
+
+// roiStats returns the mean return of winning and losing trades and the overall standard deviation
+func roiStats(examples []Example) (float64, float64, float64) {
+	var winSum, lossSum, sum float64
+	var wins, losses int
+	for _, ex := range examples {
+		sum += ex.ROI
+		if ex.Label == 1 {
+			winSum += ex.ROI
+			wins++
+		} else {
+			lossSum += ex.ROI
+			losses++
+		}
+	}
+
+	mean := sum / float64(len(examples))
+	variance := 0.0
+	for _, ex := range examples {
+		variance += (ex.ROI - mean) * (ex.ROI - mean)
+	}
+	return winSum / float64(wins), lossSum / float64(losses), math.Sqrt(variance / float64(len(examples)))
+}
+
+// trainLogistic fits L2-regularized logistic regression by batch gradient
+// descent on standardized features, then folds the scaling back into the weights
+func trainLogistic(examples []Example, opts TrainOptions) *Logistic {
+	dims := len(FeatureNames)
+	n := float64(len(examples))
+
+	mean := make([]float64, dims)
+	scale := make([]float64, dims)
+	for _, ex := range examples {
+		for j, v := range ex.Features {
+			mean[j] += v / n
+		}
+	}
+	for _, ex := range examples {
+		for j, v := range ex.Features {
+			scale[j] += (v - mean[j]) * (v - mean[j]) / n
+		}
+	}
+	for j := range scale {
+		scale[j] = math.Sqrt(scale[j])
+		if scale[j] < 1e-12 {
+			scale[j] = 1
+		}
+	}
+
+	std := make([][]float64, len(examples))
+	for i, ex := range examples {
+		std[i] = make([]float64, dims)
+		for j, v := range ex.Features {
+			std[i][j] = (v - mean[j]) / scale[j]
+		}
+	}
+
+	weights := make([]float64, dims)
+	bias := 0.0
+	grad := make([]float64, dims)
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		for j := range grad {
+			grad[j] = opts.L2 * weights[j]
+		}
+		gradBias := 0.0
+		for i, ex := range examples {
+			z := bias
+			for j, v := range std[i] {
+				z += weights[j] * v
+			}
+			residual := (sigmoid(z) - ex.Label) / n
+			gradBias += residual
+			for j, v := range std[i] {
+				grad[j] += residual * v
+			}
+		}
+		bias -= opts.LearningRate * gradBias
+		for j := range weights {
+			weights[j] -= opts.LearningRate * grad[j]
+		}
+	}
+
+	model := &Logistic{Bias: bias, Weights: make([]float64, dims)}
+	for j, w := range weights {
+		model.Weights[j] = w / scale[j]
+		model.Bias -= w * mean[j] / scale[j]
+	}
+	return model
+}
+
+// trainEnsemble fits gradient-boosted regression trees on the log loss
+func trainEnsemble(examples []Example, opts TrainOptions) *Ensemble {
+	rate := 0.0
+	for _, ex := range examples {
+		rate += ex.Label
+	}
+	rate = math.Min(math.Max(rate/float64(len(examples)), 1e-6), 1-1e-6)
+
+	ensemble := &Ensemble{
+		BaseScore:    math.Log(rate / (1 - rate)),
+		LearningRate: opts.Shrinkage,
+	}
+
+	scores := make([]float64, len(examples))
+	for i := range scores {
+		scores[i] = ensemble.BaseScore
+	}
+	grad := make([]float64, len(examples))
+	hess := make([]float64, len(examples))
+	rows := make([]int, len(examples))
+
+	for t := 0; t < opts.Trees; t++ {
+		for i, ex := range examples {
+			p := sigmoid(scores[i])
+			grad[i] = p - ex.Label
+			hess[i] = math.Max(p*(1-p), 1e-6)
+			rows[i] = i
+		}
+
+		builder := &treeBuilder{examples: examples, grad: grad, hess: hess, opts: opts}
+		builder.grow(rows, 0)
+		tree := Tree{Nodes: builder.nodes}
+		ensemble.Trees = append(ensemble.Trees, tree)
+
+		for i, ex := range examples {
+			scores[i] += ensemble.LearningRate * tree.leafValue(ex.Features)
+		}
+	}
+	return ensemble
+}
+
+// leafValue returns the tree output for a full feature vector
+func (t Tree) leafValue(x []float64) float64 {
+	node := 0
+	for !t.Nodes[node].Leaf {
+		split := t.Nodes[node]
+		if x[split.Feature] < split.Threshold {
+			node = split.Left
+		} else {
+			node = split.Right
+		}
+	}
+	return t.Nodes[node].Value
+}
+
+// treeBuilder grows one regression tree on the current gradients
+type treeBuilder struct {
+	examples []Example
+	grad     []float64
+	hess     []float64
+	opts     TrainOptions
+	nodes    []Node
+}
+
+// newtonValue is the regularized Newton step for a set of rows
+func (b *treeBuilder) newtonValue(g, h float64) float64 {
+	return -g / (h + b.opts.L2)
+}
+
+// grow adds the node for rows and its subtree, returning the node index
+func (b *treeBuilder) grow(rows []int, depth int) int {
+	var g, h float64
+	for _, i := range rows {
+		g += b.grad[i]
+		h += b.hess[i]
+	}
+
+	index := len(b.nodes)
+	b.nodes = append(b.nodes, Node{Leaf: true, Value: b.newtonValue(g, h)})
+	if depth >= b.opts.MaxDepth || len(rows) < 2*b.opts.MinLeaf {
+		return index
+	}
+
+	feature, threshold, ok := b.bestSplit(rows, g, h)
+	if !ok {
+		return index
+	}
+
+	var left, right []int
+	for _, i := range rows {
+		if b.examples[i].Features[feature] < threshold {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+
+	leftIndex := b.grow(left, depth+1)
+	rightIndex := b.grow(right, depth+1)
+	node := &b.nodes[index]
+	node.Leaf = false
+	node.Feature = feature
+	node.Threshold = threshold
+	node.Left = leftIndex
+	node.Right = rightIndex
+	return index
+}
+
+// bestSplit finds the split with the largest loss reduction
+func (b *treeBuilder) bestSplit(rows []int, g, h float64) (int, float64, bool) {
+	lambda := b.opts.L2
+	parentScore := g * g / (h + lambda)
+	bestGain, bestFeature, bestThreshold := 1e-9, -1, 0.0
+
+	sorted := make([]int, len(rows))
+	for feature := range FeatureNames {
+		copy(sorted, rows)
+		sort.Slice(sorted, func(a, c int) bool {
+			return b.examples[sorted[a]].Features[feature] < b.examples[sorted[c]].Features[feature]
+		})
+
+		var gl, hl float64
+		for k := 0; k < len(sorted)-1; k++ {
+			i := sorted[k]
+			gl += b.grad[i]
+			hl += b.hess[i]
+
+			value := b.examples[i].Features[feature]
+			next := b.examples[sorted[k+1]].Features[feature]
+			if value == next || k+1 < b.opts.MinLeaf || len(sorted)-k-1 < b.opts.MinLeaf {
+				continue
+			}
+
+			gr, hr := g-gl, h-hl
+			gain := gl*gl/(hl+lambda) + gr*gr/(hr+lambda) - parentScore
+			if gain > bestGain {
+				bestGain, bestFeature, bestThreshold = gain, feature, (value+next)/2
+			}
+		}
+	}
+	return bestFeature, bestThreshold, bestFeature >= 0
+}
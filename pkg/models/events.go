@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Chain represents supported blockchain networks
 type Chain string
@@ -19,8 +22,16 @@ type TokenFound struct {
 	InitialLiquidity InitialLiquidity   `json:"initial_liquidity"`
 	TxHash           string             `json:"tx_hash"`
 	Metadata         map[string]string  `json:"metadata,omitempty"`
+	CorrelationID    string             `json:"correlation_id,omitempty"` // ties the token's journal records together
 }
 
+// Well-known TokenFound.Metadata keys
+const (
+	MetadataSymbol    = "symbol"
+	MetadataLaunchpad = "launchpad" // e.g. "pumpfun"
+	MetadataDEX       = "dex"       // e.g. "uniswap_v2", "uniswap_v3", "raydium"
+)
+
 // InitialLiquidity details
 type InitialLiquidity struct {
 	Pair          string  `json:"pair"`
@@ -73,28 +84,206 @@ type OffChainMetrics struct {
 	Volume24hCEX    float64                `json:"24h_volume_cex"`
 	Volume24hDEX    float64                `json:"24h_volume_dex"`
 	SocialMentions  map[string]int         `json:"social_mentions"` // twitter, telegram, reddit
+	SocialGrowth    map[string]SocialGrowth `json:"social_growth,omitempty"` // platform -> audience growth
+	TradeCount24h   int                    `json:"trade_count_24h,omitempty"`
+	UniqueBuyers    int                    `json:"unique_buyers,omitempty"`
 	Velocity        string                 `json:"velocity"`        // "rising", "stable", "falling"
+	VelocityWindows []VelocityWindow       `json:"velocity_windows,omitempty"`
+	TradeFlow       *TradeFlow             `json:"trade_flow,omitempty"`
 	PriceOnCEX      float64                `json:"price_on_cex,omitempty"`
 	PriceOnDEX      float64                `json:"price_on_dex,omitempty"`
 	MarketCap       float64                `json:"market_cap,omitempty"`
+	LiquidityUSD    float64                `json:"liquidity_usd,omitempty"`   // pool liquidity on DEX
+	Pool            *PoolState             `json:"pool,omitempty"`            // deepest pool, for price impact
+	CEXListings     []string               `json:"cex_listings,omitempty"`    // e.g. "okx:PEPE-USDT"
+	Sources         []string               `json:"sources,omitempty"`         // providers that answered
+	ProviderErrors  map[string]string      `json:"provider_errors,omitempty"` // provider -> error
 	EvaluatedAt     time.Time              `json:"evaluated_at"`
 }
 
+// SocialGrowth tracks a project's audience on one platform
+type SocialGrowth struct {
+	Channels            []string `json:"channels,omitempty"` // e.g. "@pepe_portal"
+	Members             int      `json:"members"`
+	MemberGrowthPerHour float64  `json:"member_growth_per_hour"`
+	MessagesPerHour     float64  `json:"messages_per_hour"`
+}
+
+// VelocityWindow holds trend statistics over one observation window.
+// Slopes are per minute, accelerations per minute squared.
+type VelocityWindow struct {
+	Window        string  `json:"window"` // "5m", "15m", "1h"
+	Samples       int     `json:"samples"`
+	VolumeSlope   float64 `json:"volume_slope"`
+	VolumeAccel   float64 `json:"volume_accel"`
+	TradesSlope   float64 `json:"trades_slope"`
+	TradesAccel   float64 `json:"trades_accel"`
+	BuyersSlope   float64 `json:"buyers_slope"`
+	BuyersAccel   float64 `json:"buyers_accel"`
+	MentionsSlope float64 `json:"mentions_slope"`
+	MentionsAccel float64 `json:"mentions_accel"`
+	RelativeSlope float64 `json:"relative_slope"` // mean slope as a fraction of each metric's level
+	RelativeAccel float64 `json:"relative_accel"`
+}
+
+// Pool kinds
+const (
+	PoolConstantProduct = "constant_product"
+	PoolConcentrated    = "concentrated"
+)
+
+// PoolState is the liquidity a swap of the token would trade against.
+// Prices are in quote units per token.
+type PoolState struct {
+	DEX           string           `json:"dex"`
+	Address       string           `json:"address"`
+	Kind          string           `json:"kind"`              // "constant_product", "concentrated"
+	Version       string           `json:"version,omitempty"` // DEX version label, e.g. "v2", "v3", "v4", "clmm"
+	QuoteSymbol   string           `json:"quote_symbol"`
+	QuotePriceUSD float64          `json:"quote_price_usd"`
+	ReserveToken  float64          `json:"reserve_token"`
+	ReserveQuote  float64          `json:"reserve_quote"`
+	FeeBps        float64          `json:"fee_bps"`
+	Price         float64          `json:"price,omitempty"`  // concentrated pools: current price
+	Ranges        []LiquidityRange `json:"ranges,omitempty"` // concentrated pools: active liquidity by ascending, non-overlapping price range
+}
+
+// LiquidityRange is the liquidity active between two prices of a concentrated pool
+type LiquidityRange struct {
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	Liquidity float64 `json:"liquidity"` // L, in sqrt(token * quote) units
+}
+
+// TradeCosts are the expected costs of a position of a given size
+type TradeCosts struct {
+	SizeUSD     float64 `json:"size_usd"`
+	EntryImpact float64 `json:"entry_impact"` // buy cost vs spot, incl. LP fee
+	ExitImpact  float64 `json:"exit_impact"`  // sell cost vs the post-buy spot, incl. LP fee
+	Tax         float64 `json:"tax"`          // token transfer tax per leg
+	RoundTrip   float64 `json:"round_trip"`   // share of the position lost to an immediate buy and sell, incl. tax
+	GrossROI    float64 `json:"gross_roi"`    // expected ROI before costs
+}
+
+// SwapEvent is a single swap against a token's pool
+type SwapEvent struct {
+	Chain        Chain     `json:"chain"`
+	TokenAddress string    `json:"token_address"`
+	Pool         string    `json:"pool"`
+	Trader       string    `json:"trader"`
+	Side         string    `json:"side"`          // "buy", "sell"
+	AmountToken  float64   `json:"amount_token"`  // in token units
+	AmountNative float64   `json:"amount_native"` // in ETH/SOL
+	Block        uint64    `json:"block"`         // block number or slot
+	TxHash       string    `json:"tx_hash"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// TradeFlow summarizes the swap flow on a token's pool since launch.
+// Volumes and trade sizes are in native units (ETH/SOL).
+type TradeFlow struct {
+	Swaps                     int     `json:"swaps"`
+	UniqueBuyers              int     `json:"unique_buyers"`
+	UniqueSellers             int     `json:"unique_sellers"`
+	BuyVolume                 float64 `json:"buy_volume"`
+	SellVolume                float64 `json:"sell_volume"`
+	BuySellRatio              float64 `json:"buy_sell_ratio"` // capped when there are no sells
+	MedianTradeSize           float64 `json:"median_trade_size"`
+	SniperBlocks              int     `json:"sniper_blocks"`
+	SniperSupplyShare         float64 `json:"sniper_supply_share"` // share of supply bought in the first SniperBlocks blocks
+	MedianBuyerWalletAgeHours float64 `json:"median_buyer_wallet_age_hours,omitempty"`
+	BuyersAged                int     `json:"buyers_aged,omitempty"` // buyers whose wallet age is known
+}
+
 // StrategyDecision from StrategyEvaluatorAgent
 type StrategyDecision struct {
 	TokenAddress        string    `json:"token_address"`
 	Chain               Chain     `json:"chain"`
 	WinProbability      float64   `json:"win_probability"`      // 0..1
+	RawWinProbability   float64   `json:"raw_win_probability,omitempty"` // before calibration, when calibrated
 	ExpectedROI         float64   `json:"expected_roi"`         // mean ROI
 	ExpectedROIStd      float64   `json:"expected_roi_std"`     // std deviation
 	Confidence          string    `json:"confidence"`           // "high", "medium", "low"
 	Action              string    `json:"action"`               // "list", "buy", "monitor", "skip"
 	SuggestedAmountUSD  float64   `json:"suggested_amount_usd"`
+	SizedBy             string    `json:"sized_by,omitempty"` // binding size limit: "kelly", "single_position", "liquidity", "exposure"
+	Costs               *TradeCosts `json:"costs,omitempty"` // price impact and taxes at SuggestedAmountUSD
 	StopLossPct         float64   `json:"stop_loss_pct"`
 	TakeProfitPct       float64   `json:"take_profit_pct"`
 	TimeHorizonMinutes  int       `json:"time_horizon_minutes"`
 	EvaluatedAt         time.Time `json:"evaluated_at"`
-	Rationale           []string  `json:"rationale,omitempty"`
+	Rationale           []RationaleEntry `json:"rationale,omitempty"`
+	Strategy            string    `json:"strategy,omitempty"` // strategy that produced the decision
+	ParamsVersion       string    `json:"params_version,omitempty"` // weights or model version that produced the decision
+	CalibrationVersion  string    `json:"calibration_version,omitempty"`
+}
+
+// RationaleEntry explains one factor's contribution to a decision's win probability
+type RationaleEntry struct {
+	Factor string  `json:"factor"` // e.g. "low_honeypot_score"
+	Value  float64 `json:"value"`  // input the factor was evaluated on; 1/-1 for flags
+	Delta  float64 `json:"delta"`  // change in win probability
+}
+
+// String renders the entry as "factor=value (+delta%)"
+func (r RationaleEntry) String() string {
+	return fmt.Sprintf("%s=%g (%+.1f%%)", r.Factor, r.Value, r.Delta*100)
+}
+
+// CandidateStatus is a candidate's place in its lifecycle
+type CandidateStatus string
+
+// Candidate lifecycle: discovered -> pending -> approved/rejected ->
+// submitted -> executed/failed; executed -> closed once its position closes
+const (
+	CandidateDiscovered CandidateStatus = "discovered"
+	CandidatePending    CandidateStatus = "pending"   // listed, awaiting approval
+	CandidateApproved   CandidateStatus = "approved"
+	CandidateRejected   CandidateStatus = "rejected"
+	CandidateSubmitted  CandidateStatus = "submitted" // trade sent for execution
+	CandidateExecuted   CandidateStatus = "executed"
+	CandidateFailed     CandidateStatus = "failed"
+	CandidateClosed     CandidateStatus = "closed"    // position sold
+)
+
+// candidateTransitions lists the statuses each status may move to
+var candidateTransitions = map[CandidateStatus][]CandidateStatus{
+	CandidateDiscovered: {CandidatePending, CandidateRejected},
+	CandidatePending:    {CandidateApproved, CandidateRejected},
+	CandidateApproved:   {CandidateSubmitted, CandidateRejected}, // rejected if risk or simulation blocks the trade
+	CandidateSubmitted:  {CandidateExecuted, CandidateFailed},
+	CandidateExecuted:   {CandidateClosed},
+	CandidateFailed:     {CandidateClosed}, // closed if the failed trade still left a position
+}
+
+// CanTransitionTo reports whether a candidate may move from s to next
+func (s CandidateStatus) CanTransitionTo(next CandidateStatus) bool {
+	for _, allowed := range candidateTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Final reports whether a candidate in status s is done and will not trade again
+func (s CandidateStatus) Final() bool {
+	return s == CandidateRejected || s == CandidateFailed || s == CandidateClosed
+}
+
+// Who moved a candidate between statuses
+const (
+	ActorAuto     = "auto"
+	ActorOperator = "operator"
+)
+
+// StatusTransition is one recorded change of a candidate's status
+type StatusTransition struct {
+	From   CandidateStatus `json:"from,omitempty"` // empty for the initial status
+	To     CandidateStatus `json:"to"`
+	At     time.Time       `json:"at"`
+	Actor  string          `json:"actor"` // ActorAuto or ActorOperator
+	Reason string          `json:"reason,omitempty"`
 }
 
 // CandidateToken for listing queue
@@ -104,7 +293,78 @@ type CandidateToken struct {
 	OffChainMetrics OffChainMetrics   `json:"offchain_metrics"`
 	StrategyDecision StrategyDecision `json:"strategy_decision"`
 	ListedAt        time.Time         `json:"listed_at"`
-	Status          string            `json:"status"` // "pending", "approved", "rejected", "executed"
+	Status          CandidateStatus   `json:"status"`
+	History         []StatusTransition `json:"history"` // oldest first
+}
+
+// CandidateKey identifies a candidate; the same address can exist on several chains
+type CandidateKey struct {
+	Chain        Chain  `json:"chain"`
+	TokenAddress string `json:"token_address"`
+}
+
+// String renders the key as "chain:address"
+func (k CandidateKey) String() string {
+	return string(k.Chain) + ":" + k.TokenAddress
+}
+
+// Key returns the key the candidate is stored under
+func (c *CandidateToken) Key() CandidateKey {
+	return c.Token.Key()
+}
+
+// Key returns the key a candidate for the token is stored under
+func (t TokenFound) Key() CandidateKey {
+	return CandidateKey{Chain: t.Chain, TokenAddress: t.TokenAddress}
+}
+
+// StatusSince returns when the candidate entered its current status
+func (c *CandidateToken) StatusSince() time.Time {
+	if len(c.History) == 0 {
+		return c.ListedAt
+	}
+	return c.History[len(c.History)-1].At
+}
+
+// WatchedToken is a token whose strategy decision was "monitor". It is
+// re-evaluated with fresh off-chain data until it is promoted or expires.
+type WatchedToken struct {
+	Token              PreFilteredToken `json:"token"`
+	SafetyReport       SafetyReport     `json:"safety_report"`
+	OffChainMetrics    OffChainMetrics  `json:"offchain_metrics"`
+	StrategyDecision   StrategyDecision `json:"strategy_decision"` // latest decision
+	AddedAt            time.Time        `json:"added_at"`
+	ExpiresAt          time.Time        `json:"expires_at"`
+	LastEvaluatedAt    time.Time        `json:"last_evaluated_at"`
+	Evaluations        int              `json:"evaluations"`       // re-evaluations since the token was added
+	PeakWinProbability float64          `json:"peak_win_probability"`
+}
+
+// TradeOutcome is the realized result of trading a candidate
+type TradeOutcome struct {
+	ROI      float64   `json:"roi"` // realized return, e.g. 0.25 = +25%
+	ClosedAt time.Time `json:"closed_at"`
+	Reason   string    `json:"reason,omitempty"` // "take_profit", "stop_loss", "time_horizon", "manual"
+}
+
+// LabeledCandidate pairs a recorded candidate with how its trade turned out
+type LabeledCandidate struct {
+	Candidate CandidateToken `json:"candidate"`
+	Outcome   TradeOutcome   `json:"outcome"`
+}
+
+// ShadowTrade is the position a decision would have opened, marked against
+// later prices. Shadow decisions are only ever recorded this way, never executed.
+type ShadowTrade struct {
+	Decision   StrategyDecision `json:"decision"`
+	Shadow     bool             `json:"shadow"`
+	EntryPrice float64          `json:"entry_price,omitempty"` // 0 when the decision did not trade
+	OpenedAt   time.Time        `json:"opened_at,omitempty"`
+	LastPrice  float64          `json:"last_price,omitempty"`
+	ClosedAt   time.Time        `json:"closed_at,omitempty"`
+	ExitReason string           `json:"exit_reason,omitempty"` // "take_profit", "stop_loss", "time_horizon"
+	ROI        float64          `json:"roi"`                   // net of trade costs; realized once closed
+	PnLUSD     float64          `json:"pnl_usd"`
 }
 
 // ExecutionResult from ExecutionAgent
@@ -120,6 +380,24 @@ type ExecutionResult struct {
 	Error          string    `json:"error,omitempty"`
 }
 
+// RiskVerdict is the risk manager's answer on whether a candidate may trade
+type RiskVerdict struct {
+	Allowed   bool    `json:"allowed"`
+	Reason    string  `json:"reason,omitempty"` // why it was blocked
+	AmountUSD float64 `json:"amount_usd"`
+}
+
+// Position is a holding opened by a confirmed buy
+type Position struct {
+	TokenAddress string     `json:"token_address"`
+	Chain        Chain      `json:"chain"`
+	EntryTxHash  string     `json:"entry_tx_hash"`
+	SizeUSD      float64    `json:"size_usd"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"` // nil while open
+	PnLUSD       float64    `json:"pnl_usd"`             // realized once closed
+}
+
 // RiskControl parameters and state
 type RiskControl struct {
 	SinglePositionPct  float64 `json:"single_position_pct"`  // max % of balance per trade
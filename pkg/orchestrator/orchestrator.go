@@ -2,6 +2,8 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -14,8 +16,11 @@ import (
 	"github.com/mumugogoing/meme_bot/pkg/agents/scanner"
 	"github.com/mumugogoing/meme_bot/pkg/agents/strategy"
 	"github.com/mumugogoing/meme_bot/pkg/agents/telemetry"
+	"github.com/mumugogoing/meme_bot/pkg/agents/watchlist"
 	"github.com/mumugogoing/meme_bot/pkg/config"
+	"github.com/mumugogoing/meme_bot/pkg/journal"
 	"github.com/mumugogoing/meme_bot/pkg/models"
+	"github.com/mumugogoing/meme_bot/pkg/storage"
 )
 
 // Orchestrator coordinates all agents
@@ -29,9 +34,12 @@ type Orchestrator struct {
 	offchain  *offchain.OffChainDataAgent
 	strategy  *strategy.StrategyEvaluatorAgent
 	listing   *listing.CandidateListingAgent
+	watchlist *watchlist.WatchlistAgent
 	execution *execution.ExecutionAgent
 	risk      *risk.RiskManagerAgent
 	telemetry *telemetry.TelemetryAgent
+	store     storage.Store    // nil when nothing is persisted
+	journal   *journal.Journal // nil when JOURNAL_DIR is empty
 	
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -41,20 +49,58 @@ type Orchestrator struct {
 func NewOrchestrator(cfg *config.Config) *Orchestrator {
 	ctx, cancel := context.WithCancel(context.Background())
 	
-	return &Orchestrator{
+	o := &Orchestrator{
 		config:    cfg,
 		scanner:   scanner.NewChainScannerAgent(cfg),
 		prefilter: prefilter.NewPreFilterAgent(cfg),
 		safety:    safety.NewOnChainSafetyAgent(cfg),
 		offchain:  offchain.NewOffChainDataAgent(cfg),
 		strategy:  strategy.NewStrategyEvaluatorAgent(cfg),
-		listing:   listing.NewCandidateListingAgent(),
+		listing:   listing.NewCandidateListingAgent(cfg),
+		watchlist: watchlist.NewWatchlistAgent(cfg),
 		execution: execution.NewExecutionAgent(cfg),
 		risk:      risk.NewRiskManagerAgent(cfg),
 		telemetry: telemetry.NewTelemetryAgent(),
 		ctx:       ctx,
 		cancel:    cancel,
 	}
+	
+	// Size positions within the exposure the risk manager has left
+	o.strategy.SetExposureProvider(o.risk)
+	
+	// Shadow strategies mark their hypothetical trades against off-chain prices
+	o.strategy.SetPriceSource(o.offchain)
+	
+	// Base swaps are sized in ETH at the off-chain WETH price
+	o.execution.SetPriceSource(o.offchain)
+	
+	// Report execution queue depth and drops
+	o.listing.GetQueue().SetObserver(o.telemetry)
+	
+	// Persist tokens, decisions, candidates, executions and risk state
+	store, err := storage.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Orchestrator: Persistence disabled: %v\n", err)
+	} else if store != nil {
+		o.store = store
+		o.listing.SetStore(store, store)
+		o.execution.SetStore(store)
+		o.risk.SetStore(store, store)
+		o.resumePending(store)
+		log.Printf("Orchestrator: Persisting to %s\n", cfg.DatabaseURL)
+	}
+	
+	// Journal every pipeline event for audits
+	if cfg.JournalDir != "" {
+		j, err := journal.Open(cfg.JournalDir, int64(cfg.JournalMaxSizeMB)<<20, cfg.JournalCompress)
+		if err != nil {
+			log.Printf("Orchestrator: Journal disabled: %v\n", err)
+		} else {
+			o.journal = j
+		}
+	}
+	
+	return o
 }
 
 // Start starts the orchestration
@@ -66,6 +112,32 @@ func (o *Orchestrator) Start() {
 	telemetryStop := o.telemetry.StartPeriodicLogging(30 * time.Second)
 	defer close(telemetryStop)
 	
+	// Pick up strategy weight changes without a restart
+	weightsStop := o.strategy.StartWeightsReload(o.config.StrategyWeightsReload)
+	defer close(weightsStop)
+	
+	// Track hypothetical PnL of shadow strategies
+	shadowStop := o.strategy.StartShadowMarking(o.config.ShadowMarkInterval)
+	defer close(shadowStop)
+	
+	// Keep sampling gathered tokens so velocity comes from a time series
+	samplerStop := o.offchain.StartSampler()
+	defer close(samplerStop)
+	
+	// Re-evaluate monitored tokens until they are promoted or expire
+	watchlistStop := o.watchlist.Start(o.reevaluateToken)
+	defer close(watchlistStop)
+	
+	// Settle trades still pending after their confirmation timeout
+	reconcileStop := o.execution.StartReconciler(o.settlePending)
+	defer close(reconcileStop)
+	
+	// Reject candidates nobody approved in time
+	expiryStop := o.listing.StartExpiry()
+	retentionStop := o.listing.StartRetention()
+	defer close(expiryStop)
+	defer close(retentionStop)
+	
 	// Start chain scanner
 	o.scanner.Start()
 	defer o.scanner.Stop()
@@ -84,6 +156,39 @@ func (o *Orchestrator) Start() {
 // Stop stops the orchestration
 func (o *Orchestrator) Stop() {
 	o.cancel()
+	if err := o.offchain.Close(); err != nil {
+		log.Printf("Orchestrator: Failed to close off-chain providers: %v\n", err)
+	}
+	if o.store != nil {
+		if err := o.store.Close(); err != nil {
+			log.Printf("Orchestrator: Failed to close store: %v\n", err)
+		}
+	}
+	if o.journal != nil {
+		if err := o.journal.Close(); err != nil {
+			log.Printf("Orchestrator: Failed to close journal: %v\n", err)
+		}
+	}
+}
+
+// journalEvent appends a pipeline event to the journal under the token's correlation ID
+func (o *Orchestrator) journalEvent(token models.TokenFound, stage string, event interface{}) {
+	if o.journal == nil {
+		return
+	}
+	if err := o.journal.Append(token, stage, event); err != nil {
+		log.Printf("Orchestrator: Failed to journal %s for %s: %v\n", stage, token.TokenAddress, err)
+	}
+}
+
+// record logs a failed write of a pipeline result to the store
+func (o *Orchestrator) record(what string, token models.TokenFound, save func(storage.Store) error) {
+	if o.store == nil {
+		return
+	}
+	if err := save(o.store); err != nil {
+		log.Printf("Orchestrator: Failed to save %s for %s: %v\n", what, token.TokenAddress, err)
+	}
 }
 
 // processTokens processes discovered tokens through the pipeline
@@ -106,9 +211,15 @@ func (o *Orchestrator) processToken(token models.TokenFound) {
 	
 	log.Printf("Orchestrator: Processing token %s on %s\n", token.TokenAddress, token.Chain)
 	o.telemetry.RecordTokenFound()
+	if token.CorrelationID == "" {
+		token.CorrelationID = journal.NewCorrelationID()
+	}
+	o.record("token", token, func(s storage.Store) error { return s.SaveToken(o.ctx, &token) })
+	o.journalEvent(token, journal.StageTokenFound, token)
 	
 	// Step 1: Pre-filtering
 	prefiltered := o.prefilter.Filter(token)
+	o.journalEvent(token, journal.StagePreFiltered, prefiltered)
 	o.telemetry.RecordTokenFiltered(prefiltered.Dropped)
 	
 	if prefiltered.Dropped {
@@ -122,6 +233,8 @@ func (o *Orchestrator) processToken(token models.TokenFound) {
 		log.Printf("Orchestrator: Safety evaluation failed for %s: %v\n", token.TokenAddress, err)
 		return
 	}
+	o.record("safety report", token, func(s storage.Store) error { return s.SaveSafetyReport(o.ctx, safetyReport) })
+	o.journalEvent(token, journal.StageSafetyReport, safetyReport)
 	
 	isHoneypot := safetyReport.HoneypotScore >= o.config.MaxHoneypotScore
 	isSafe := o.safety.CanTrade(safetyReport)
@@ -139,6 +252,8 @@ func (o *Orchestrator) processToken(token models.TokenFound) {
 		log.Printf("Orchestrator: Off-chain data gathering failed for %s: %v\n", token.TokenAddress, err)
 		return
 	}
+	o.record("metrics", token, func(s storage.Store) error { return s.SaveMetrics(o.ctx, token.Chain, offchainMetrics) })
+	o.journalEvent(token, journal.StageOffChainMetrics, offchainMetrics)
 	
 	// Step 4: Strategy evaluation
 	decision, err := o.strategy.Evaluate(safetyReport, offchainMetrics, prefiltered)
@@ -146,6 +261,8 @@ func (o *Orchestrator) processToken(token models.TokenFound) {
 		log.Printf("Orchestrator: Strategy evaluation failed for %s: %v\n", token.TokenAddress, err)
 		return
 	}
+	o.record("decision", token, func(s storage.Store) error { return s.SaveDecision(o.ctx, decision) })
+	o.journalEvent(token, journal.StageStrategyDecision, decision)
 	
 	o.telemetry.RecordEvaluation()
 	o.telemetry.RecordDecisionLatency(time.Since(startTime))
@@ -154,53 +271,169 @@ func (o *Orchestrator) processToken(token models.TokenFound) {
 		token.TokenAddress, decision.WinProbability, decision.Action, decision.Confidence)
 	
 	// Step 5: Check if should list/execute
-	if decision.Action == "list" || decision.Action == "buy" {
-		candidate := o.listing.AddCandidate(token, *safetyReport, *offchainMetrics, *decision)
-		o.telemetry.RecordCandidateListed()
-		
-		log.Printf("Orchestrator: Token %s added to candidate list\n", token.TokenAddress)
-		
-		// If auto-execute is enabled and action is buy, it will be processed by execution processor
-		if decision.Action == "buy" && o.config.AutoExecute {
-			log.Printf("Orchestrator: Token %s queued for execution\n", candidate.Token.TokenAddress)
-		}
-	} else {
+	switch decision.Action {
+	case "list", "buy":
+		o.listCandidate(token, safetyReport, offchainMetrics, decision)
+	case "monitor":
+		o.watchlist.Add(prefiltered, *safetyReport, *offchainMetrics, *decision)
+		log.Printf("Orchestrator: Token %s added to watchlist\n", token.TokenAddress)
+	default:
 		log.Printf("Orchestrator: Token %s action: %s - not listing\n", token.TokenAddress, decision.Action)
 	}
 }
 
-// processExecutions processes execution queue
+// listCandidate adds a token to the candidate list
+func (o *Orchestrator) listCandidate(
+	token models.TokenFound,
+	safetyReport *models.SafetyReport,
+	offchainMetrics *models.OffChainMetrics,
+	decision *models.StrategyDecision,
+) {
+	candidate := o.listing.AddCandidate(token, *safetyReport, *offchainMetrics, *decision)
+	o.telemetry.RecordCandidateListed()
+	o.watchlist.Remove(token.Key())
+	
+	log.Printf("Orchestrator: Token %s added to candidate list\n", token.TokenAddress)
+	
+	// If auto-execute is enabled and action is buy, it will be processed by execution processor
+	if decision.Action == "buy" && o.config.AutoExecute {
+		log.Printf("Orchestrator: Token %s queued for execution\n", candidate.Token.TokenAddress)
+	}
+}
+
+// reevaluateToken refreshes a watched token's off-chain data and strategy
+// decision, and lists it once it crosses the threshold. The safety report is
+// reused from when the token was first evaluated.
+func (o *Orchestrator) reevaluateToken(entry models.WatchedToken) {
+	token := entry.Token.Token
+	
+	offchainMetrics, err := o.offchain.Gather(o.ctx, entry.Token)
+	if err != nil {
+		log.Printf("Orchestrator: Off-chain data gathering failed for watched %s: %v\n", token.TokenAddress, err)
+		return
+	}
+	o.record("metrics", token, func(s storage.Store) error { return s.SaveMetrics(o.ctx, token.Chain, offchainMetrics) })
+	o.journalEvent(token, journal.StageOffChainMetrics, offchainMetrics)
+	
+	decision, err := o.strategy.Evaluate(&entry.SafetyReport, offchainMetrics, entry.Token)
+	if err != nil {
+		log.Printf("Orchestrator: Strategy re-evaluation failed for %s: %v\n", token.TokenAddress, err)
+		return
+	}
+	o.record("decision", token, func(s storage.Store) error { return s.SaveDecision(o.ctx, decision) })
+	o.journalEvent(token, journal.StageStrategyDecision, decision)
+	o.telemetry.RecordEvaluation()
+	
+	if decision.Action == "list" || decision.Action == "buy" {
+		o.telemetry.RecordWatchlistPromotion()
+		log.Printf("Orchestrator: Watched token %s promoted - WinProb: %.2f (was %.2f)\n",
+			token.TokenAddress, decision.WinProbability, entry.StrategyDecision.WinProbability)
+		o.listCandidate(token, &entry.SafetyReport, offchainMetrics, decision)
+		return
+	}
+	
+	// Keep watching until the window runs out, even if the token dipped below monitor
+	if updated, ok := o.watchlist.Update(token.Key(), *offchainMetrics, *decision); ok {
+		log.Printf("Orchestrator: Watched token %s - WinProb: %.2f, Action: %s, expires %s\n",
+			token.TokenAddress, decision.WinProbability, decision.Action, updated.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+// processExecutions executes queued buys one at a time, strongest first
 func (o *Orchestrator) processExecutions() {
 	log.Println("Orchestrator: Execution processor started")
 	
 	for {
-		select {
-		case <-o.ctx.Done():
+		candidate, err := o.listing.GetQueue().Pop(o.ctx)
+		if err != nil {
 			return
-		case candidate := <-o.listing.GetQueue():
-			if o.config.AutoExecute && candidate.StrategyDecision.Action == "buy" {
-				go o.executeCandidate(candidate)
-			}
 		}
+		o.executeCandidate(candidate)
 	}
 }
 
-// executeCandidate executes a trade for a candidate
+// executeCandidate approves a buy candidate and executes it
 func (o *Orchestrator) executeCandidate(candidate *models.CandidateToken) {
+	approved, err := o.listing.Approve(candidate.Key(), 0, models.ActorAuto, "auto-execute buy")
+	if err != nil {
+		log.Printf("Orchestrator: %v\n", err)
+		return
+	}
+	o.execute(approved)
+}
+
+// ApproveCandidate approves a pending candidate on an operator's behalf and
+// executes it through the usual risk checks. A positive sizeUSD overrides the
+// suggested position size.
+func (o *Orchestrator) ApproveCandidate(key models.CandidateKey, sizeUSD float64, reason string) (*models.CandidateToken, error) {
+	if reason == "" {
+		reason = "approved by operator"
+	}
+	approved, err := o.listing.Approve(key, sizeUSD, models.ActorOperator, reason)
+	if err != nil {
+		return approved, err
+	}
+	go o.execute(approved)
+	return approved, nil
+}
+
+// RejectCandidate rejects a candidate on an operator's behalf
+func (o *Orchestrator) RejectCandidate(key models.CandidateKey, reason string) (*models.CandidateToken, error) {
+	if reason == "" {
+		reason = "rejected by operator"
+	}
+	return o.listing.Transition(key, models.CandidateRejected, models.ActorOperator, reason)
+}
+
+// ClosePosition closes the open position in a token on an operator's behalf
+// with the realized profit/loss, releases its exposure and closes the
+// executed or failed candidate. The candidate is nil once it has been evicted.
+func (o *Orchestrator) ClosePosition(key models.CandidateKey, profitLoss float64, reason string) (*models.Position, *models.CandidateToken, error) {
+	position, err := o.risk.ClosePosition(key, profitLoss)
+	if err != nil {
+		return nil, nil, err
+	}
+	o.telemetry.RecordProfit(profitLoss)
+	
+	if reason == "" {
+		reason = fmt.Sprintf("position closed, PnL $%.2f", profitLoss)
+	}
+	candidate, err := o.listing.Transition(key, models.CandidateClosed, models.ActorOperator, reason)
+	if errors.Is(err, listing.ErrCandidateNotFound) {
+		return position, nil, nil
+	}
+	return position, candidate, err
+}
+
+// execute trades an approved candidate
+func (o *Orchestrator) execute(candidate *models.CandidateToken) {
 	startTime := time.Now()
+	key := candidate.Key()
 	
-	log.Printf("Orchestrator: Executing candidate %s\n", candidate.Token.TokenAddress)
+	log.Printf("Orchestrator: Executing candidate %s\n", key)
 	
 	// Check daily reset
 	o.risk.CheckDailyReset()
 	
-	// Check risk management
-	canExecute, reason := o.risk.CanExecute(&candidate.StrategyDecision)
+	// Check risk management and hold the trade's exposure while it is in flight
+	canExecute, reason := o.risk.Reserve(&candidate.StrategyDecision)
+	o.journalEvent(candidate.Token, journal.StageRiskVerdict, models.RiskVerdict{
+		Allowed:   canExecute,
+		Reason:    reason,
+		AmountUSD: candidate.StrategyDecision.SuggestedAmountUSD,
+	})
 	if !canExecute {
 		log.Printf("Orchestrator: Execution blocked by risk manager: %s\n", reason)
-		o.listing.UpdateStatus(candidate.Token.TokenAddress, "rejected")
+		o.transition(key, models.CandidateRejected, "risk: "+reason)
 		return
 	}
+	// A trade still pending keeps its exposure until the reconciler settles it
+	pending := false
+	defer func() {
+		if !pending {
+			o.risk.Unreserve(candidate.StrategyDecision.SuggestedAmountUSD)
+		}
+	}()
 	
 	// Simulate first if not in dry run
 	if !o.config.DryRun {
@@ -208,34 +441,108 @@ func (o *Orchestrator) executeCandidate(candidate *models.CandidateToken) {
 		if err != nil || !success {
 			log.Printf("Orchestrator: Simulation failed for %s\n", candidate.Token.TokenAddress)
 			o.telemetry.RecordSimulationFailure()
-			o.listing.UpdateStatus(candidate.Token.TokenAddress, "rejected")
+			reason := "simulation failed"
+			if err != nil {
+				reason += ": " + err.Error()
+			}
+			o.transition(key, models.CandidateRejected, reason)
 			return
 		}
 	}
 	
 	// Execute trade
+	if !o.transition(key, models.CandidateSubmitted, fmt.Sprintf("buy $%.2f", candidate.StrategyDecision.SuggestedAmountUSD)) {
+		return
+	}
 	result, err := o.execution.Execute(o.ctx, candidate)
+	if result != nil {
+		o.journalEvent(candidate.Token, journal.StageExecutionResult, result)
+	} else if err != nil {
+		o.journalEvent(candidate.Token, journal.StageExecutionResult, models.ExecutionResult{
+			TokenAddress: candidate.Token.TokenAddress,
+			Chain:        candidate.Token.Chain,
+			Status:       "failed",
+			AmountUSD:    candidate.StrategyDecision.SuggestedAmountUSD,
+			Timestamp:    time.Now(),
+			Error:        err.Error(),
+		})
+	}
 	o.telemetry.RecordExecutionTime(time.Since(startTime))
 	
 	if err != nil {
 		log.Printf("Orchestrator: Execution failed for %s: %v\n", candidate.Token.TokenAddress, err)
 		o.telemetry.RecordExecution(false, 0)
-		o.listing.UpdateStatus(candidate.Token.TokenAddress, "failed")
+		o.transition(key, models.CandidateFailed, err.Error())
 		return
 	}
 	
+	if result.Status == "pending" {
+		log.Printf("Orchestrator: Execution pending for %s - TX: %s\n",
+			candidate.Token.TokenAddress, result.TxHash)
+		pending = true
+		return
+	}
+	o.recordResult(result)
+}
+
+// recordResult moves a submitted candidate on once its trade has settled
+func (o *Orchestrator) recordResult(result *models.ExecutionResult) {
+	key := models.CandidateKey{Chain: result.Chain, TokenAddress: result.TokenAddress}
 	if result.Status == "confirmed" {
 		log.Printf("Orchestrator: Execution successful for %s - TX: %s\n",
-			candidate.Token.TokenAddress, result.TxHash)
+			result.TokenAddress, result.TxHash)
 		o.telemetry.RecordExecution(true, result.AmountUSD)
 		o.risk.RecordExecution(result)
-		o.listing.UpdateStatus(candidate.Token.TokenAddress, "executed")
+		o.transition(key, models.CandidateExecuted, "tx "+result.TxHash)
 	} else {
 		log.Printf("Orchestrator: Execution status %s for %s\n",
-			result.Status, candidate.Token.TokenAddress)
+			result.Status, result.TokenAddress)
 		o.telemetry.RecordExecution(false, 0)
-		o.listing.UpdateStatus(candidate.Token.TokenAddress, "failed")
+		reason := "execution status " + result.Status
+		if result.Error != "" {
+			reason += ": " + result.Error
+		}
+		o.transition(key, models.CandidateFailed, reason)
+	}
+}
+
+// settlePending records a trade that was still pending after its
+// confirmation timeout once it settles, and releases the exposure held for it
+func (o *Orchestrator) settlePending(result *models.ExecutionResult) {
+	token := models.TokenFound{Chain: result.Chain, TokenAddress: result.TokenAddress}
+	if candidate, ok := o.listing.GetCandidate(token.Key()); ok {
+		token = candidate.Token
 	}
+	o.journalEvent(token, journal.StageExecutionResult, result)
+	o.recordResult(result)
+	o.risk.Unreserve(result.AmountUSD)
+}
+
+// resumePending goes on checking the trades that were still pending when the
+// bot stopped, holding their exposure until they settle
+func (o *Orchestrator) resumePending(executions storage.ExecutionRepository) {
+	submitted, _ := o.listing.GetAllCandidates(listing.CandidateFilter{
+		Statuses: []models.CandidateStatus{models.CandidateSubmitted},
+	})
+	for _, candidate := range submitted {
+		result := listing.LatestExecution(o.ctx, executions, candidate)
+		if result == nil || result.Status != "pending" {
+			continue
+		}
+		log.Printf("Orchestrator: Resuming pending trade for %s - TX: %s\n", candidate.Key(), result.TxHash)
+		o.risk.Hold(result.AmountUSD)
+		o.execution.Track(result)
+	}
+}
+
+// transition moves a candidate through its lifecycle on the bot's behalf,
+// reporting whether the move was allowed
+func (o *Orchestrator) transition(key models.CandidateKey, status models.CandidateStatus, reason string) bool {
+	if _, err := o.listing.Transition(key, status, models.ActorAuto, reason); err != nil {
+		log.Printf("Orchestrator: %v\n", err)
+		return false
+	}
+	return true
 }
 
 // GetTelemetry returns the telemetry agent
@@ -248,6 +555,16 @@ func (o *Orchestrator) GetListing() *listing.CandidateListingAgent {
 	return o.listing
 }
 
+// GetWatchlist returns the watchlist agent
+func (o *Orchestrator) GetWatchlist() *watchlist.WatchlistAgent {
+	return o.watchlist
+}
+
+// GetStrategy returns the strategy evaluator
+func (o *Orchestrator) GetStrategy() *strategy.StrategyEvaluatorAgent {
+	return o.strategy
+}
+
 // GetRisk returns the risk manager
 func (o *Orchestrator) GetRisk() *risk.RiskManagerAgent {
 	return o.risk
@@ -0,0 +1,110 @@
+// Package rpc is a minimal JSON-RPC 2.0 client used for EVM and Solana nodes
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Client sends JSON-RPC 2.0 requests over HTTP
+type Client struct {
+	url    string
+	http   *http.Client
+	nextID uint64
+}
+
+// NewClient creates a JSON-RPC client for the given endpoint
+func NewClient(url string) *Client {
+	return &Client{
+		url:  url,
+		http: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Error is a JSON-RPC error object returned by the node
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// Call invokes method with params and decodes the result into result.
+// A null result leaves result untouched.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	body, err := json.Marshal(request{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&c.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d: %s", method, resp.StatusCode, truncate(raw, 256))
+	}
+
+	var rpcResp response
+	if err := json.Unmarshal(raw, &rpcResp); err != nil {
+		return fmt.Errorf("%s: decode response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s: %w", method, rpcResp.Error)
+	}
+	if result == nil || len(rpcResp.Result) == 0 || string(rpcResp.Result) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		return string(b[:n]) + "..."
+	}
+	return string(b)
+}
@@ -0,0 +1,186 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/mumugogoing/meme_bot/pkg/rpc"
+)
+
+// Client wraps the JSON-RPC methods needed to send transactions
+type Client struct {
+	rpc        *rpc.Client
+	commitment string
+}
+
+// NewClient creates a client for the given RPC endpoint that reads state at
+// "confirmed" commitment
+func NewClient(rpcURL string) *Client {
+	return &Client{rpc: rpc.NewClient(rpcURL), commitment: "confirmed"}
+}
+
+// LatestBlockhash returns a recent blockhash and the last block height at
+// which transactions using it are still accepted
+func (c *Client) LatestBlockhash(ctx context.Context) (PublicKey, uint64, error) {
+	var result struct {
+		Value struct {
+			Blockhash            string `json:"blockhash"`
+			LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+		} `json:"value"`
+	}
+	if err := c.rpc.Call(ctx, "getLatestBlockhash", []interface{}{c.config()}, &result); err != nil {
+		return PublicKey{}, 0, err
+	}
+	hash, err := ParsePublicKey(result.Value.Blockhash)
+	return hash, result.Value.LastValidBlockHeight, err
+}
+
+// BlockHeight returns the current block height
+func (c *Client) BlockHeight(ctx context.Context) (uint64, error) {
+	var height uint64
+	err := c.rpc.Call(ctx, "getBlockHeight", []interface{}{c.config()}, &height)
+	return height, err
+}
+
+// AccountData returns the data of an account, or nil if it does not exist
+func (c *Client) AccountData(ctx context.Context, account PublicKey) ([]byte, error) {
+	opts := c.config()
+	opts["encoding"] = "base64"
+	var result struct {
+		Value *struct {
+			Data []string `json:"data"` // [data, encoding]
+		} `json:"value"`
+	}
+	if err := c.rpc.Call(ctx, "getAccountInfo", []interface{}{account.String(), opts}, &result); err != nil {
+		return nil, err
+	}
+	if result.Value == nil {
+		return nil, nil
+	}
+	if len(result.Value.Data) == 0 {
+		return []byte{}, nil
+	}
+	return base64.StdEncoding.DecodeString(result.Value.Data[0])
+}
+
+// TokenAccountBalance returns the raw token amount held by a token account
+func (c *Client) TokenAccountBalance(ctx context.Context, account PublicKey) (uint64, error) {
+	var result struct {
+		Value struct {
+			Amount string `json:"amount"`
+		} `json:"value"`
+	}
+	if err := c.rpc.Call(ctx, "getTokenAccountBalance", []interface{}{account.String(), c.config()}, &result); err != nil {
+		return 0, err
+	}
+	var amount uint64
+	if _, err := fmt.Sscan(result.Value.Amount, &amount); err != nil {
+		return 0, fmt.Errorf("invalid token amount %q", result.Value.Amount)
+	}
+	return amount, nil
+}
+
+// SendTransaction submits a signed transaction after preflight simulation
+// and returns its signature
+func (c *Client) SendTransaction(ctx context.Context, tx *Transaction) (string, error) {
+	opts := map[string]interface{}{
+		"encoding":            "base64",
+		"preflightCommitment": c.commitment,
+		"maxRetries":          0, // the caller re-sends with a fresh blockhash instead
+	}
+	var signature string
+	raw := base64.StdEncoding.EncodeToString(tx.Serialize())
+	if err := c.rpc.Call(ctx, "sendTransaction", []interface{}{raw, opts}, &signature); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// SignatureStatus is the cluster's view of a sent transaction
+type SignatureStatus struct {
+	Slot               uint64      `json:"slot"`
+	Confirmations      *uint64     `json:"confirmations"` // nil once finalized
+	Err                interface{} `json:"err"`
+	ConfirmationStatus string      `json:"confirmationStatus"` // "processed", "confirmed", "finalized"
+}
+
+// SignatureStatus returns the status of a transaction, or nil if the
+// cluster has not seen it
+func (c *Client) SignatureStatus(ctx context.Context, signature string) (*SignatureStatus, error) {
+	var result struct {
+		Value []*SignatureStatus `json:"value"`
+	}
+	opts := map[string]bool{"searchTransactionHistory": false}
+	if err := c.rpc.Call(ctx, "getSignatureStatuses", []interface{}{[]string{signature}, opts}, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Value) == 0 {
+		return nil, nil
+	}
+	return result.Value[0], nil
+}
+
+// TokenBalance is a token account balance before or after a transaction
+type TokenBalance struct {
+	Mint          string `json:"mint"`
+	Owner         string `json:"owner"`
+	UITokenAmount struct {
+		Amount string `json:"amount"`
+	} `json:"uiTokenAmount"`
+}
+
+// TransactionMeta is the outcome of a confirmed transaction
+type TransactionMeta struct {
+	Err               interface{}    `json:"err"` // nil when the transaction succeeded
+	Fee               uint64         `json:"fee"` // lamports
+	PreTokenBalances  []TokenBalance `json:"preTokenBalances"`
+	PostTokenBalances []TokenBalance `json:"postTokenBalances"`
+}
+
+// TransactionMeta returns the fee and token balance changes of a confirmed
+// transaction, or nil if it is not yet available
+func (c *Client) TransactionMeta(ctx context.Context, signature string) (*TransactionMeta, error) {
+	opts := c.config()
+	opts["encoding"] = "json"
+	opts["maxSupportedTransactionVersion"] = 0
+	var result *struct {
+		Meta *TransactionMeta `json:"meta"`
+	}
+	if err := c.rpc.Call(ctx, "getTransaction", []interface{}{signature, opts}, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return result.Meta, nil
+}
+
+// TokenDelta returns how much of mint owner's accounts gained in a transaction
+func (m *TransactionMeta) TokenDelta(owner, mint PublicKey) int64 {
+	sum := func(balances []TokenBalance) int64 {
+		var total int64
+		for _, b := range balances {
+			if b.Owner != owner.String() || b.Mint != mint.String() {
+				continue
+			}
+			var amount int64
+			fmt.Sscan(b.UITokenAmount.Amount, &amount)
+			total += amount
+		}
+		return total
+	}
+	return sum(m.PostTokenBalances) - sum(m.PreTokenBalances)
+}
+
+// IsBlockhashExpired reports whether err is the node rejecting a transaction
+// whose blockhash is too old
+func IsBlockhashExpired(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Blockhash not found")
+}
+
+// config returns the commitment options shared by read methods
+func (c *Client) config() map[string]interface{} {
+	return map[string]interface{}{"commitment": c.commitment}
+}
@@ -0,0 +1,165 @@
+// Package solana builds, signs and sends Solana transactions over JSON-RPC
+package solana
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"filippo.io/edwards25519"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Base58Encode encodes b with the Bitcoin alphabet Solana uses
+func Base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// Base58Decode decodes a base58 string
+func Base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(i)))
+	}
+	var zeros int
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// PublicKey is an account address
+type PublicKey [32]byte
+
+// ParsePublicKey parses a base58 address
+func ParsePublicKey(s string) (PublicKey, error) {
+	var k PublicKey
+	b, err := Base58Decode(s)
+	if err != nil || len(b) != len(k) {
+		return k, fmt.Errorf("invalid public key %q", s)
+	}
+	copy(k[:], b)
+	return k, nil
+}
+
+// MustPublicKey parses a well-known address and panics if it is malformed
+func MustPublicKey(s string) PublicKey {
+	k, err := ParsePublicKey(s)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// String returns the base58 address
+func (k PublicKey) String() string {
+	return Base58Encode(k[:])
+}
+
+// ErrOnCurve is returned for program address seeds that land on the curve
+var ErrOnCurve = errors.New("address is on the ed25519 curve")
+
+// CreateProgramAddress derives the address of program owned by seeds,
+// which must not be a valid ed25519 public key
+func CreateProgramAddress(seeds [][]byte, program PublicKey) (PublicKey, error) {
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > 32 {
+			return PublicKey{}, fmt.Errorf("seed longer than 32 bytes")
+		}
+		h.Write(seed)
+	}
+	h.Write(program[:])
+	h.Write([]byte("ProgramDerivedAddress"))
+
+	var k PublicKey
+	copy(k[:], h.Sum(nil))
+	if _, err := new(edwards25519.Point).SetBytes(k[:]); err == nil {
+		return PublicKey{}, ErrOnCurve
+	}
+	return k, nil
+}
+
+// FindProgramAddress derives the program address of seeds with the highest
+// bump seed that lands off the curve
+func FindProgramAddress(seeds [][]byte, program PublicKey) (PublicKey, uint8, error) {
+	withBump := append(append([][]byte(nil), seeds...), nil)
+	for bump := 255; bump >= 0; bump-- {
+		withBump[len(seeds)] = []byte{byte(bump)}
+		k, err := CreateProgramAddress(withBump, program)
+		if err == nil {
+			return k, uint8(bump), nil
+		}
+	}
+	return PublicKey{}, 0, errors.New("no viable bump seed")
+}
+
+// PrivateKey signs transactions for one account
+type PrivateKey struct {
+	key ed25519.PrivateKey
+}
+
+// ParsePrivateKey parses a keypair as exported by wallets (base58 of the 64
+// secret+public bytes) or as a solana-keygen JSON byte array
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+	s = strings.TrimSpace(s)
+	var b []byte
+	if strings.HasPrefix(s, "[") {
+		if err := json.Unmarshal([]byte(s), &b); err != nil {
+			return nil, fmt.Errorf("invalid keypair: %w", err)
+		}
+	} else {
+		var err error
+		if b, err = Base58Decode(s); err != nil {
+			return nil, err
+		}
+	}
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("keypair must be %d bytes, got %d", ed25519.PrivateKeySize, len(b))
+	}
+
+	key := ed25519.NewKeyFromSeed(b[:32])
+	if string(key[32:]) != string(b[32:]) {
+		return nil, errors.New("keypair public key does not match its secret")
+	}
+	return &PrivateKey{key: key}, nil
+}
+
+// PublicKey returns the account the key signs for
+func (k *PrivateKey) PublicKey() PublicKey {
+	var p PublicKey
+	copy(p[:], k.key[32:])
+	return p
+}
+
+// Sign signs message
+func (k *PrivateKey) Sign(message []byte) []byte {
+	return ed25519.Sign(k.key, message)
+}
@@ -0,0 +1,81 @@
+package solana
+
+import "encoding/binary"
+
+// Well-known programs and mints
+var (
+	SystemProgram          = MustPublicKey("11111111111111111111111111111111")
+	TokenProgram           = MustPublicKey("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	AssociatedTokenProgram = MustPublicKey("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
+	ComputeBudgetProgram   = MustPublicKey("ComputeBudget111111111111111111111111111111")
+	WrappedSOLMint         = MustPublicKey("So11111111111111111111111111111111111111112")
+)
+
+// AssociatedTokenAddress returns owner's token account for mint
+func AssociatedTokenAddress(owner, mint PublicKey) (PublicKey, error) {
+	k, _, err := FindProgramAddress([][]byte{owner[:], TokenProgram[:], mint[:]}, AssociatedTokenProgram)
+	return k, err
+}
+
+// CreateAssociatedTokenAccount creates owner's token account for mint, paid by payer
+func CreateAssociatedTokenAccount(payer, account, owner, mint PublicKey) Instruction {
+	return Instruction{
+		ProgramID: AssociatedTokenProgram,
+		Accounts: []AccountMeta{
+			{PublicKey: payer, IsSigner: true, IsWritable: true},
+			{PublicKey: account, IsWritable: true},
+			{PublicKey: owner},
+			{PublicKey: mint},
+			{PublicKey: SystemProgram},
+			{PublicKey: TokenProgram},
+		},
+		Data: []byte{0},
+	}
+}
+
+// SetComputeUnitLimit caps the compute units the transaction may use
+func SetComputeUnitLimit(units uint32) Instruction {
+	data := binary.LittleEndian.AppendUint32([]byte{2}, units)
+	return Instruction{ProgramID: ComputeBudgetProgram, Data: data}
+}
+
+// SetComputeUnitPrice sets the priority fee in micro-lamports per compute unit
+func SetComputeUnitPrice(microLamports uint64) Instruction {
+	data := binary.LittleEndian.AppendUint64([]byte{3}, microLamports)
+	return Instruction{ProgramID: ComputeBudgetProgram, Data: data}
+}
+
+// Transfer moves lamports between system accounts
+func Transfer(from, to PublicKey, lamports uint64) Instruction {
+	data := binary.LittleEndian.AppendUint64([]byte{2, 0, 0, 0}, lamports)
+	return Instruction{
+		ProgramID: SystemProgram,
+		Accounts: []AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: to, IsWritable: true},
+		},
+		Data: data,
+	}
+}
+
+// SyncNative updates a wrapped SOL account's token balance to its lamports
+func SyncNative(account PublicKey) Instruction {
+	return Instruction{
+		ProgramID: TokenProgram,
+		Accounts:  []AccountMeta{{PublicKey: account, IsWritable: true}},
+		Data:      []byte{17},
+	}
+}
+
+// CloseAccount closes a token account, returning its lamports to destination
+func CloseAccount(account, destination, owner PublicKey) Instruction {
+	return Instruction{
+		ProgramID: TokenProgram,
+		Accounts: []AccountMeta{
+			{PublicKey: account, IsWritable: true},
+			{PublicKey: destination, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: []byte{9},
+	}
+}
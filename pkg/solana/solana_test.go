@@ -0,0 +1,123 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+)
+
+func testKeypair(seed byte) *PrivateKey {
+	s := make([]byte, ed25519.SeedSize)
+	s[0] = seed
+	key, err := ParsePrivateKey(Base58Encode(ed25519.NewKeyFromSeed(s)))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func TestBase58RoundTrip(t *testing.T) {
+	if SystemProgram != (PublicKey{}) {
+		t.Errorf("system program should be all zeros, got %x", SystemProgram[:])
+	}
+	for _, b := range [][]byte{{0, 0, 1, 2, 255}, {}, {0}, []byte("hello world")} {
+		decoded, err := Base58Decode(Base58Encode(b))
+		if err != nil || string(decoded) != string(b) {
+			t.Errorf("round trip of %x gave %x (%v)", b, decoded, err)
+		}
+	}
+	if got := Base58Encode([]byte("hello world")); got != "StV1DL6CwTryKyV" {
+		t.Errorf("base58 %s, want StV1DL6CwTryKyV", got)
+	}
+}
+
+func TestFindProgramAddress(t *testing.T) {
+	raydium := MustPublicKey("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8")
+	authority, _, err := FindProgramAddress([][]byte{[]byte("amm authority")}, raydium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := authority.String(); got != "5Q544fKrFoe6tsEbD7S8EmxGTJYAKtTVhAW5Q5pge4j1" {
+		t.Errorf("amm authority %s, want 5Q544fKrFoe6tsEbD7S8EmxGTJYAKtTVhAW5Q5pge4j1", got)
+	}
+}
+
+func TestParsePrivateKeyFormats(t *testing.T) {
+	key := testKeypair(1)
+	array, _ := json.Marshal(func() []int {
+		var out []int
+		for _, b := range key.key {
+			out = append(out, int(b))
+		}
+		return out
+	}())
+	fromJSON, err := ParsePrivateKey(string(array))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromJSON.PublicKey() != key.PublicKey() {
+		t.Error("JSON keypair parsed to a different key")
+	}
+
+	bad := append([]byte(nil), key.key...)
+	bad[40] ^= 1
+	if _, err := ParsePrivateKey(Base58Encode(bad)); err == nil {
+		t.Error("expected a mismatched public key to be rejected")
+	}
+}
+
+func TestTransactionCompilesAndVerifies(t *testing.T) {
+	payer := testKeypair(1)
+	other := testKeypair(2)
+	mint := testKeypair(3).PublicKey()
+	ata, err := AssociatedTokenAddress(payer.PublicKey(), mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockhash := testKeypair(4).PublicKey()
+
+	instructions := []Instruction{
+		SetComputeUnitLimit(200_000),
+		SetComputeUnitPrice(10_000),
+		CreateAssociatedTokenAccount(payer.PublicKey(), ata, payer.PublicKey(), mint),
+		Transfer(other.PublicKey(), ata, 1_000),
+	}
+	tx, err := NewTransaction(instructions, blockhash, payer, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTransaction(instructions, blockhash, payer); err == nil {
+		t.Error("expected a missing signer to be reported")
+	}
+
+	parsed, err := ParseTransaction(tx.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Signers != 2 || parsed.AccountKeys[0] != payer.PublicKey() || parsed.AccountKeys[1] != other.PublicKey() {
+		t.Errorf("signers must come first, payer leading: %v", parsed.AccountKeys[:2])
+	}
+	if parsed.AccountKeys[2] != ata {
+		t.Errorf("the writable token account should follow the signers, got %s", parsed.AccountKeys[2])
+	}
+	if parsed.Blockhash != blockhash || len(parsed.Instructions) != 4 {
+		t.Fatalf("unexpected blockhash or instruction count %d", len(parsed.Instructions))
+	}
+	limit := parsed.Instructions[0]
+	if limit.ProgramID != ComputeBudgetProgram || string(limit.Data) != string([]byte{2, 0x40, 0x0d, 0x03, 0}) {
+		t.Errorf("unexpected compute unit limit instruction %x", limit.Data)
+	}
+	create := parsed.Instructions[2]
+	if create.ProgramID != AssociatedTokenProgram || create.Accounts[1] != ata || create.Accounts[3] != mint {
+		t.Errorf("unexpected create account instruction %+v", create)
+	}
+	if tx.Signature() != Base58Encode(parsed.Signatures[0]) {
+		t.Error("transaction ID should be the payer's signature")
+	}
+
+	raw := tx.Serialize()
+	raw[len(raw)-1] ^= 1
+	if _, err := ParseTransaction(raw); err == nil {
+		t.Error("expected a tampered message to fail verification")
+	}
+}
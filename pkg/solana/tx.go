@@ -0,0 +1,255 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+)
+
+// AccountMeta is an account an instruction reads or writes
+type AccountMeta struct {
+	PublicKey  PublicKey
+	IsSigner   bool
+	IsWritable bool
+}
+
+// Instruction is a call into a program
+type Instruction struct {
+	ProgramID PublicKey
+	Accounts  []AccountMeta
+	Data      []byte
+}
+
+// Transaction is a signed legacy transaction
+type Transaction struct {
+	Signatures [][]byte
+	Message    []byte
+}
+
+// NewTransaction compiles instructions into a legacy message paid for by
+// payer and signs it with the given keys, which must cover every signer
+func NewTransaction(instructions []Instruction, blockhash PublicKey, payer *PrivateKey, signers ...*PrivateKey) (*Transaction, error) {
+	keys, header := compileAccounts(payer.PublicKey(), instructions)
+	index := make(map[PublicKey]int, len(keys))
+	for i, k := range keys {
+		index[k] = i
+	}
+
+	msg := []byte{header[0], header[1], header[2]}
+	msg = appendLength(msg, len(keys))
+	for _, k := range keys {
+		msg = append(msg, k[:]...)
+	}
+	msg = append(msg, blockhash[:]...)
+	msg = appendLength(msg, len(instructions))
+	for _, ix := range instructions {
+		msg = append(msg, byte(index[ix.ProgramID]))
+		msg = appendLength(msg, len(ix.Accounts))
+		for _, a := range ix.Accounts {
+			msg = append(msg, byte(index[a.PublicKey]))
+		}
+		msg = appendLength(msg, len(ix.Data))
+		msg = append(msg, ix.Data...)
+	}
+
+	byKey := map[PublicKey]*PrivateKey{payer.PublicKey(): payer}
+	for _, s := range signers {
+		byKey[s.PublicKey()] = s
+	}
+	tx := &Transaction{Message: msg}
+	for _, k := range keys[:header[0]] {
+		signer, ok := byKey[k]
+		if !ok {
+			return nil, fmt.Errorf("missing signer %s", k)
+		}
+		tx.Signatures = append(tx.Signatures, signer.Sign(msg))
+	}
+	return tx, nil
+}
+
+// Signature returns the transaction ID, the payer's base58 signature
+func (tx *Transaction) Signature() string {
+	return Base58Encode(tx.Signatures[0])
+}
+
+// Serialize returns the wire encoding of the transaction
+func (tx *Transaction) Serialize() []byte {
+	out := appendLength(nil, len(tx.Signatures))
+	for _, sig := range tx.Signatures {
+		out = append(out, sig...)
+	}
+	return append(out, tx.Message...)
+}
+
+// compileAccounts orders the accounts of instructions the way the runtime
+// expects: writable signers (payer first), read-only signers, writable
+// non-signers, then read-only non-signers. header holds the number of
+// signatures, read-only signers and read-only non-signers.
+func compileAccounts(payer PublicKey, instructions []Instruction) ([]PublicKey, [3]byte) {
+	metas := map[PublicKey]*AccountMeta{payer: {PublicKey: payer, IsSigner: true, IsWritable: true}}
+	order := []PublicKey{payer}
+	add := func(m AccountMeta) {
+		if existing, ok := metas[m.PublicKey]; ok {
+			existing.IsSigner = existing.IsSigner || m.IsSigner
+			existing.IsWritable = existing.IsWritable || m.IsWritable
+			return
+		}
+		metas[m.PublicKey] = &m
+		order = append(order, m.PublicKey)
+	}
+	for _, ix := range instructions {
+		for _, a := range ix.Accounts {
+			add(a)
+		}
+		add(AccountMeta{PublicKey: ix.ProgramID})
+	}
+
+	var keys []PublicKey
+	var header [3]byte
+	for _, group := range []struct{ signer, writable bool }{{true, true}, {true, false}, {false, true}, {false, false}} {
+		for _, k := range order {
+			m := metas[k]
+			if m.IsSigner != group.signer || m.IsWritable != group.writable {
+				continue
+			}
+			keys = append(keys, k)
+			switch {
+			case m.IsSigner:
+				header[0]++
+				if !m.IsWritable {
+					header[1]++
+				}
+			case !m.IsWritable:
+				header[2]++
+			}
+		}
+	}
+	return keys, header
+}
+
+// appendLength appends n in the compact-u16 encoding
+func appendLength(b []byte, n int) []byte {
+	for {
+		c := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// readLength reads a compact-u16 length
+func readLength(b []byte) (int, []byte, error) {
+	n := 0
+	for i := 0; i < 3; i++ {
+		if len(b) <= i {
+			return 0, nil, errors.New("unexpected end of input")
+		}
+		n |= int(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return n, b[i+1:], nil
+		}
+	}
+	return 0, nil, errors.New("length too long")
+}
+
+// CompiledInstruction is an instruction of a parsed transaction
+type CompiledInstruction struct {
+	ProgramID PublicKey
+	Accounts  []PublicKey
+	Data      []byte
+}
+
+// ParsedTransaction is a decoded wire transaction
+type ParsedTransaction struct {
+	Signatures   [][]byte
+	AccountKeys  []PublicKey
+	Signers      int
+	Blockhash    PublicKey
+	Instructions []CompiledInstruction
+}
+
+// ParseTransaction decodes a legacy wire transaction and verifies its signatures
+func ParseTransaction(raw []byte) (*ParsedTransaction, error) {
+	n, rest, err := readLength(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < n*ed25519.SignatureSize+3 {
+		return nil, errors.New("unexpected end of input")
+	}
+	tx := &ParsedTransaction{}
+	for i := 0; i < n; i++ {
+		tx.Signatures = append(tx.Signatures, rest[:ed25519.SignatureSize])
+		rest = rest[ed25519.SignatureSize:]
+	}
+	msg := rest
+	tx.Signers = int(rest[0])
+	if tx.Signers != n {
+		return nil, fmt.Errorf("%d signatures for %d signers", n, tx.Signers)
+	}
+
+	if n, rest, err = readLength(rest[3:]); err != nil {
+		return nil, err
+	}
+	if len(rest) < (n+1)*32 {
+		return nil, errors.New("unexpected end of input")
+	}
+	for i := 0; i < n; i++ {
+		var k PublicKey
+		copy(k[:], rest[:32])
+		tx.AccountKeys = append(tx.AccountKeys, k)
+		rest = rest[32:]
+	}
+	copy(tx.Blockhash[:], rest[:32])
+	rest = rest[32:]
+
+	account := func(i byte) (PublicKey, error) {
+		if int(i) >= len(tx.AccountKeys) {
+			return PublicKey{}, fmt.Errorf("account index %d out of range", i)
+		}
+		return tx.AccountKeys[i], nil
+	}
+	if n, rest, err = readLength(rest); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		if len(rest) == 0 {
+			return nil, errors.New("unexpected end of input")
+		}
+		var ix CompiledInstruction
+		if ix.ProgramID, err = account(rest[0]); err != nil {
+			return nil, err
+		}
+		var count int
+		if count, rest, err = readLength(rest[1:]); err != nil {
+			return nil, err
+		}
+		if len(rest) < count {
+			return nil, errors.New("unexpected end of input")
+		}
+		for _, idx := range rest[:count] {
+			k, err := account(idx)
+			if err != nil {
+				return nil, err
+			}
+			ix.Accounts = append(ix.Accounts, k)
+		}
+		if count, rest, err = readLength(rest[count:]); err != nil {
+			return nil, err
+		}
+		if len(rest) < count {
+			return nil, errors.New("unexpected end of input")
+		}
+		ix.Data, rest = rest[:count], rest[count:]
+		tx.Instructions = append(tx.Instructions, ix)
+	}
+
+	for i, sig := range tx.Signatures {
+		if !ed25519.Verify(tx.AccountKeys[i][:], msg, sig) {
+			return nil, fmt.Errorf("invalid signature for %s", tx.AccountKeys[i])
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// LabeledCandidates joins every position closed at or after since with the
+// candidate listing that opened it, giving the records train and calibrate
+// read. A position's outcome is its realized PnL over its size. Positions
+// whose candidate is no longer stored are counted in unmatched and left out.
+func LabeledCandidates(
+	ctx context.Context,
+	candidates CandidateRepository,
+	positions PositionRepository,
+	since time.Time,
+) (records []models.LabeledCandidate, unmatched int, err error) {
+	closed, err := positions.ClosedPositions(ctx, since)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, position := range closed {
+		listings, err := candidates.CandidateHistory(ctx, models.CandidateKey{Chain: position.Chain, TokenAddress: position.TokenAddress})
+		if err != nil {
+			return nil, 0, err
+		}
+		candidate := openedBy(listings, position)
+		if candidate == nil || position.SizeUSD <= 0 {
+			unmatched++
+			continue
+		}
+		records = append(records, models.LabeledCandidate{
+			Candidate: *candidate,
+			Outcome: models.TradeOutcome{
+				ROI:      position.PnLUSD / position.SizeUSD,
+				ClosedAt: *position.ClosedAt,
+				Reason:   "manual",
+			},
+		})
+	}
+	return records, unmatched, nil
+}
+
+// openedBy returns the traded listing a position was opened from: the latest
+// one listed before the position opened. listings are newest first.
+func openedBy(listings []*models.CandidateToken, position *models.Position) *models.CandidateToken {
+	for _, listing := range listings {
+		if listing.ListedAt.After(position.OpenedAt) {
+			continue
+		}
+		if listing.Status == models.CandidateExecuted || listing.Status == models.CandidateClosed {
+			return listing
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration is one schema change, applied once in order of version
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+// migrations must only ever be appended to; a released migration never changes
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "initial schema",
+		statements: []string{
+			`CREATE TABLE tokens (
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				first_seen_ts INTEGER NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (chain, token_address)
+			)`,
+			`CREATE TABLE safety_reports (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				honeypot_score REAL NOT NULL,
+				evaluated_at INTEGER NOT NULL,
+				data TEXT NOT NULL
+			)`,
+			`CREATE INDEX safety_reports_token ON safety_reports (chain, token_address, evaluated_at)`,
+			`CREATE TABLE metrics_snapshots (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				evaluated_at INTEGER NOT NULL,
+				data TEXT NOT NULL
+			)`,
+			`CREATE INDEX metrics_snapshots_token ON metrics_snapshots (chain, token_address, evaluated_at)`,
+			`CREATE TABLE decisions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				strategy TEXT NOT NULL,
+				action TEXT NOT NULL,
+				win_probability REAL NOT NULL,
+				evaluated_at INTEGER NOT NULL,
+				data TEXT NOT NULL
+			)`,
+			`CREATE INDEX decisions_token ON decisions (chain, token_address, evaluated_at)`,
+			`CREATE TABLE candidates (
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				listed_at INTEGER NOT NULL,
+				status TEXT NOT NULL,
+				win_probability REAL NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (chain, token_address, listed_at)
+			)`,
+			`CREATE INDEX candidates_status ON candidates (status)`,
+			`CREATE TABLE executions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				tx_hash TEXT NOT NULL,
+				status TEXT NOT NULL,
+				amount_usd REAL NOT NULL,
+				executed_at INTEGER NOT NULL,
+				data TEXT NOT NULL
+			)`,
+			`CREATE INDEX executions_token ON executions (chain, token_address, executed_at)`,
+			`CREATE TABLE positions (
+				chain TEXT NOT NULL,
+				token_address TEXT NOT NULL,
+				opened_at INTEGER NOT NULL,
+				closed_at INTEGER,
+				size_usd REAL NOT NULL,
+				pnl_usd REAL NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (chain, token_address, opened_at)
+			)`,
+			`CREATE TABLE risk_state (
+				id INTEGER PRIMARY KEY CHECK (id = 1),
+				updated_at INTEGER NOT NULL,
+				data TEXT NOT NULL
+			)`,
+		},
+	},
+}
+
+// migrate applies the migrations the database has not seen yet, each in its
+// own transaction, and returns the resulting schema version
+func migrate(ctx context.Context, db *sql.DB) (int, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return 0, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := apply(ctx, db, m); err != nil {
+			return current, fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Storage: Applied migration %d (%s)\n", m.version, m.name)
+		current = m.version
+	}
+	return current, nil
+}
+
+// apply runs one migration and records it
+func apply(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
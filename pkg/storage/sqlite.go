@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+
+	_ "modernc.org/sqlite" // pure-Go driver registered as "sqlite"
+)
+
+// SQLiteStore keeps every repository in one SQLite file. Each row holds the
+// record as JSON next to the columns it is looked up and ordered by.
+type SQLiteStore struct {
+	db      *sql.DB
+	version int
+}
+
+// OpenSQLite opens or creates the database at path and migrates it to the
+// latest schema; ":memory:" opens a private in-memory database
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: sqlite needs a file path", ErrUnsupportedURL)
+	}
+	if path != ":memory:" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("create database directory: %w", err)
+			}
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// One connection serializes writers, which SQLite needs anyway, and keeps
+	// an in-memory database alive for the store's lifetime
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA busy_timeout=5000"} {
+		if _, err := db.ExecContext(ctx, pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+
+	version, err := migrate(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db, version: version}, nil
+}
+
+// Version returns the schema version the database is at
+func (s *SQLiteStore) Version() int {
+	return s.version
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// nanos stores a time as Unix nanoseconds so rows order correctly; the zero time is 0
+func nanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// query decodes the JSON data column of every row returned
+func query[T any](ctx context.Context, db *sql.DB, statement string, args ...interface{}) ([]*T, error) {
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*T
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		record := new(T)
+		if err := json.Unmarshal([]byte(data), record); err != nil {
+			return nil, fmt.Errorf("decode row: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// queryOne decodes the single row a lookup returns, or ErrNotFound
+func queryOne[T any](ctx context.Context, db *sql.DB, statement string, args ...interface{}) (*T, error) {
+	var data string
+	if err := db.QueryRowContext(ctx, statement, args...).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	record := new(T)
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		return nil, fmt.Errorf("decode row: %w", err)
+	}
+	return record, nil
+}
+
+// exec encodes record as the statement's last argument and runs it
+func (s *SQLiteStore) exec(ctx context.Context, statement string, record interface{}, args ...interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, statement, append(args, string(data))...)
+	return err
+}
+
+// SaveToken stores a token, replacing an earlier sighting of the same one
+func (s *SQLiteStore) SaveToken(ctx context.Context, token *models.TokenFound) error {
+	return s.exec(ctx, `INSERT INTO tokens (chain, token_address, first_seen_ts, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chain, token_address) DO UPDATE SET data = excluded.data`,
+		token, token.Chain, token.TokenAddress, token.FirstSeenTS)
+}
+
+// GetToken returns a stored token
+func (s *SQLiteStore) GetToken(ctx context.Context, key models.CandidateKey) (*models.TokenFound, error) {
+	return queryOne[models.TokenFound](ctx, s.db, `SELECT data FROM tokens WHERE chain = ? AND token_address = ?`,
+		key.Chain, key.TokenAddress)
+}
+
+// SaveSafetyReport stores a safety report
+func (s *SQLiteStore) SaveSafetyReport(ctx context.Context, report *models.SafetyReport) error {
+	return s.exec(ctx, `INSERT INTO safety_reports (chain, token_address, honeypot_score, evaluated_at, data) VALUES (?, ?, ?, ?, ?)`,
+		report, report.Chain, report.TokenAddress, report.HoneypotScore, nanos(report.EvaluatedAt))
+}
+
+// LatestSafetyReport returns the most recent safety report for a token
+func (s *SQLiteStore) LatestSafetyReport(ctx context.Context, key models.CandidateKey) (*models.SafetyReport, error) {
+	return queryOne[models.SafetyReport](ctx, s.db, `SELECT data FROM safety_reports WHERE chain = ? AND token_address = ?
+		ORDER BY evaluated_at DESC, id DESC LIMIT 1`, key.Chain, key.TokenAddress)
+}
+
+// SaveMetrics stores an off-chain metrics snapshot
+func (s *SQLiteStore) SaveMetrics(ctx context.Context, chain models.Chain, metrics *models.OffChainMetrics) error {
+	return s.exec(ctx, `INSERT INTO metrics_snapshots (chain, token_address, evaluated_at, data) VALUES (?, ?, ?, ?)`,
+		metrics, chain, metrics.TokenAddress, nanos(metrics.EvaluatedAt))
+}
+
+// MetricsHistory returns a token's snapshots taken at or after since, oldest first
+func (s *SQLiteStore) MetricsHistory(ctx context.Context, key models.CandidateKey, since time.Time) ([]*models.OffChainMetrics, error) {
+	return query[models.OffChainMetrics](ctx, s.db, `SELECT data FROM metrics_snapshots
+		WHERE chain = ? AND token_address = ? AND evaluated_at >= ? ORDER BY evaluated_at, id`,
+		key.Chain, key.TokenAddress, nanos(since))
+}
+
+// SaveDecision stores a strategy decision
+func (s *SQLiteStore) SaveDecision(ctx context.Context, decision *models.StrategyDecision) error {
+	return s.exec(ctx, `INSERT INTO decisions (chain, token_address, strategy, action, win_probability, evaluated_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		decision, decision.Chain, decision.TokenAddress, decision.Strategy, decision.Action, decision.WinProbability, nanos(decision.EvaluatedAt))
+}
+
+// Decisions returns every decision made on a token, oldest first
+func (s *SQLiteStore) Decisions(ctx context.Context, key models.CandidateKey) ([]*models.StrategyDecision, error) {
+	return query[models.StrategyDecision](ctx, s.db, `SELECT data FROM decisions
+		WHERE chain = ? AND token_address = ? ORDER BY evaluated_at, id`, key.Chain, key.TokenAddress)
+}
+
+// SaveCandidate stores a candidate, replacing the row of the same listing
+func (s *SQLiteStore) SaveCandidate(ctx context.Context, candidate *models.CandidateToken) error {
+	return s.exec(ctx, `INSERT INTO candidates (chain, token_address, listed_at, status, win_probability, data) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chain, token_address, listed_at) DO UPDATE SET status = excluded.status, data = excluded.data`,
+		candidate, candidate.Token.Chain, candidate.Token.TokenAddress, nanos(candidate.ListedAt),
+		candidate.Status, candidate.StrategyDecision.WinProbability)
+}
+
+// ActiveCandidates returns the candidates that have not traded or finished,
+// oldest listing first. Executed candidates are left out: their positions
+// are restored by the risk manager, and archiving re-saves them unchanged.
+func (s *SQLiteStore) ActiveCandidates(ctx context.Context) ([]*models.CandidateToken, error) {
+	return query[models.CandidateToken](ctx, s.db, `SELECT data FROM candidates
+		WHERE status IN (?, ?, ?, ?) ORDER BY listed_at`,
+		models.CandidateDiscovered, models.CandidatePending, models.CandidateApproved, models.CandidateSubmitted)
+}
+
+// CandidateHistory returns every listing of a token, newest first
+func (s *SQLiteStore) CandidateHistory(ctx context.Context, key models.CandidateKey) ([]*models.CandidateToken, error) {
+	return query[models.CandidateToken](ctx, s.db, `SELECT data FROM candidates
+		WHERE chain = ? AND token_address = ? ORDER BY listed_at DESC`, key.Chain, key.TokenAddress)
+}
+
+// SaveExecution stores a trade attempt
+func (s *SQLiteStore) SaveExecution(ctx context.Context, result *models.ExecutionResult) error {
+	return s.exec(ctx, `INSERT INTO executions (chain, token_address, tx_hash, status, amount_usd, executed_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		result, result.Chain, result.TokenAddress, result.TxHash, result.Status, result.AmountUSD, nanos(result.Timestamp))
+}
+
+// Executions returns every trade attempt on a token, oldest first
+func (s *SQLiteStore) Executions(ctx context.Context, key models.CandidateKey) ([]*models.ExecutionResult, error) {
+	return query[models.ExecutionResult](ctx, s.db, `SELECT data FROM executions
+		WHERE chain = ? AND token_address = ? ORDER BY executed_at, id`, key.Chain, key.TokenAddress)
+}
+
+// SavePosition stores a position, replacing it when it is closed
+func (s *SQLiteStore) SavePosition(ctx context.Context, position *models.Position) error {
+	var closedAt interface{}
+	if position.ClosedAt != nil {
+		closedAt = nanos(*position.ClosedAt)
+	}
+	return s.exec(ctx, `INSERT INTO positions (chain, token_address, opened_at, closed_at, size_usd, pnl_usd, data) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chain, token_address, opened_at) DO UPDATE SET
+			closed_at = excluded.closed_at, size_usd = excluded.size_usd, pnl_usd = excluded.pnl_usd, data = excluded.data`,
+		position, position.Chain, position.TokenAddress, nanos(position.OpenedAt), closedAt, position.SizeUSD, position.PnLUSD)
+}
+
+// OpenPositions returns the positions not yet closed, oldest first
+func (s *SQLiteStore) OpenPositions(ctx context.Context) ([]*models.Position, error) {
+	return query[models.Position](ctx, s.db, `SELECT data FROM positions WHERE closed_at IS NULL ORDER BY opened_at`)
+}
+
+// ClosedPositions returns the positions closed at or after since, oldest close first
+func (s *SQLiteStore) ClosedPositions(ctx context.Context, since time.Time) ([]*models.Position, error) {
+	return query[models.Position](ctx, s.db, `SELECT data FROM positions
+		WHERE closed_at IS NOT NULL AND closed_at >= ? ORDER BY closed_at`, nanos(since))
+}
+
+// SaveRiskState stores the risk manager's state, replacing the previous one
+func (s *SQLiteStore) SaveRiskState(ctx context.Context, state *models.RiskControl) error {
+	return s.exec(ctx, `INSERT INTO risk_state (id, updated_at, data) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET updated_at = excluded.updated_at, data = excluded.data`,
+		state, time.Now().UnixNano())
+}
+
+// LoadRiskState returns the last stored risk state, or ErrNotFound
+func (s *SQLiteStore) LoadRiskState(ctx context.Context) (*models.RiskControl, error) {
+	return queryOne[models.RiskControl](ctx, s.db, `SELECT data FROM risk_state WHERE id = 1`)
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+func TestOpenURL(t *testing.T) {
+	if store, err := Open(""); store != nil || err != nil {
+		t.Errorf("expected an empty url to disable persistence, got %v, %v", store, err)
+	}
+	for _, url := range []string{"postgres://db/meme", "meme_bot.db", "sqlite://"} {
+		if store, err := Open(url); store != nil || !errors.Is(err, ErrUnsupportedURL) {
+			t.Errorf("%s: expected ErrUnsupportedURL, got %v, %v", url, store, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "data", "bot.db")
+	store, err := Open("sqlite://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+}
+
+func TestMigrationsApplyOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+	for i := 0; i < 2; i++ {
+		store, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatalf("open %d: %v", i, err)
+		}
+		if store.Version() != len(migrations) {
+			t.Errorf("open %d: expected version %d, got %d", i, len(migrations), store.Version())
+		}
+		var applied int
+		if err := store.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+			t.Fatal(err)
+		}
+		if applied != len(migrations) {
+			t.Errorf("open %d: expected %d recorded migrations, got %d", i, len(migrations), applied)
+		}
+		store.Close()
+	}
+}
+
+func TestRepositories(t *testing.T) {
+	store, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := models.CandidateKey{Chain: models.ChainBase, TokenAddress: "0xabc"}
+
+	token := &models.TokenFound{Chain: key.Chain, TokenAddress: key.TokenAddress, TxHash: "0xfirst", FirstSeenTS: now.Unix()}
+	if err := store.SaveToken(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	token.TxHash = "0xsecond"
+	if err := store.SaveToken(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.GetToken(ctx, key); err != nil || got.TxHash != "0xsecond" {
+		t.Errorf("expected the latest sighting, got %+v, %v", got, err)
+	}
+	if _, err := store.GetToken(ctx, models.CandidateKey{Chain: models.ChainSolana, TokenAddress: "0xabc"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the same address on another chain to be not found, got %v", err)
+	}
+
+	for i, score := range []float64{0.4, 0.1} {
+		report := &models.SafetyReport{Chain: key.Chain, TokenAddress: key.TokenAddress, HoneypotScore: score, EvaluatedAt: now.Add(time.Duration(i) * time.Minute)}
+		if err := store.SaveSafetyReport(ctx, report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if report, err := store.LatestSafetyReport(ctx, key); err != nil || report.HoneypotScore != 0.1 {
+		t.Errorf("expected the latest safety report, got %+v, %v", report, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		metrics := &models.OffChainMetrics{TokenAddress: key.TokenAddress, Volume24hDEX: float64(i), EvaluatedAt: now.Add(time.Duration(i) * time.Minute)}
+		if err := store.SaveMetrics(ctx, key.Chain, metrics); err != nil {
+			t.Fatal(err)
+		}
+		decision := &models.StrategyDecision{Chain: key.Chain, TokenAddress: key.TokenAddress, Action: "monitor", WinProbability: 0.5 + 0.1*float64(i), EvaluatedAt: metrics.EvaluatedAt}
+		if err := store.SaveDecision(ctx, decision); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if history, err := store.MetricsHistory(ctx, key, now.Add(time.Minute)); err != nil || len(history) != 2 || history[0].Volume24hDEX != 1 {
+		t.Errorf("expected the last two snapshots oldest first, got %d, %v", len(history), err)
+	}
+	if decisions, err := store.Decisions(ctx, key); err != nil || len(decisions) != 3 || decisions[2].WinProbability != 0.7 {
+		t.Errorf("expected three decisions oldest first, got %d, %v", len(decisions), err)
+	}
+
+	candidate := &models.CandidateToken{Token: *token, ListedAt: now, Status: models.CandidatePending}
+	if err := store.SaveCandidate(ctx, candidate); err != nil {
+		t.Fatal(err)
+	}
+	if active, err := store.ActiveCandidates(ctx); err != nil || len(active) != 1 {
+		t.Fatalf("expected one active candidate, got %d, %v", len(active), err)
+	}
+	candidate.Status = models.CandidateRejected
+	if err := store.SaveCandidate(ctx, candidate); err != nil {
+		t.Fatal(err)
+	}
+	relisted := &models.CandidateToken{Token: *token, ListedAt: now.Add(time.Hour), Status: models.CandidatePending}
+	if err := store.SaveCandidate(ctx, relisted); err != nil {
+		t.Fatal(err)
+	}
+	if active, err := store.ActiveCandidates(ctx); err != nil || len(active) != 1 || !active[0].ListedAt.Equal(relisted.ListedAt) {
+		t.Errorf("expected only the relisted candidate to be active, got %+v, %v", active, err)
+	}
+	if listings, err := store.CandidateHistory(ctx, key); err != nil || len(listings) != 2 || listings[1].Status != models.CandidateRejected {
+		t.Errorf("expected both listings newest first, got %+v, %v", listings, err)
+	}
+
+	result := &models.ExecutionResult{Chain: key.Chain, TokenAddress: key.TokenAddress, TxHash: "0x1", Status: "confirmed", AmountUSD: 50, Timestamp: now}
+	if err := store.SaveExecution(ctx, result); err != nil {
+		t.Fatal(err)
+	}
+	if executions, err := store.Executions(ctx, key); err != nil || len(executions) != 1 || executions[0].TxHash != "0x1" {
+		t.Errorf("unexpected executions %+v, %v", executions, err)
+	}
+
+	position := &models.Position{Chain: key.Chain, TokenAddress: key.TokenAddress, EntryTxHash: "0x1", SizeUSD: 50, OpenedAt: now}
+	if err := store.SavePosition(ctx, position); err != nil {
+		t.Fatal(err)
+	}
+	if open, err := store.OpenPositions(ctx); err != nil || len(open) != 1 {
+		t.Fatalf("expected one open position, got %d, %v", len(open), err)
+	}
+	closedAt := now.Add(time.Hour)
+	position.ClosedAt = &closedAt
+	position.PnLUSD = -20
+	if err := store.SavePosition(ctx, position); err != nil {
+		t.Fatal(err)
+	}
+	if open, err := store.OpenPositions(ctx); err != nil || len(open) != 0 {
+		t.Errorf("expected the closed position to be gone, got %d, %v", len(open), err)
+	}
+
+	if _, err := store.LoadRiskState(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected no risk state yet, got %v", err)
+	}
+	for _, loss := range []float64{20, 45} {
+		if err := store.SaveRiskState(ctx, &models.RiskControl{DailyLoss: loss, TradingHalted: loss > 40}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state, err := store.LoadRiskState(ctx); err != nil || state.DailyLoss != 45 || !state.TradingHalted {
+		t.Errorf("expected the last risk state, got %+v, %v", state, err)
+	}
+}
+
+func TestLabeledCandidatesJoinClosedPositions(t *testing.T) {
+	store, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	token := models.TokenFound{Chain: models.ChainBase, TokenAddress: "tokenA"}
+
+	// The first listing was rejected; the token traded when listed again
+	for _, candidate := range []*models.CandidateToken{
+		{Token: token, ListedAt: now, Status: models.CandidateRejected},
+		{Token: token, ListedAt: now.Add(time.Hour), Status: models.CandidateClosed,
+			StrategyDecision: models.StrategyDecision{WinProbability: 0.85}},
+	} {
+		if err := store.SaveCandidate(ctx, candidate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	closedAt := now.Add(3 * time.Hour)
+	for _, position := range []*models.Position{
+		{Chain: models.ChainBase, TokenAddress: "tokenA", SizeUSD: 50, OpenedAt: now.Add(2 * time.Hour), ClosedAt: &closedAt, PnLUSD: 10},
+		{Chain: models.ChainBase, TokenAddress: "tokenA", SizeUSD: 50, OpenedAt: now.Add(4 * time.Hour)},
+		{Chain: models.ChainSolana, TokenAddress: "gone", SizeUSD: 20, OpenedAt: now, ClosedAt: &closedAt, PnLUSD: -5},
+	} {
+		if err := store.SavePosition(ctx, position); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, unmatched, err := LabeledCandidates(ctx, store, store, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || unmatched != 1 {
+		t.Fatalf("expected one labeled trade and one position without a candidate, got %d, %d", len(records), unmatched)
+	}
+	record := records[0]
+	if record.Candidate.StrategyDecision.WinProbability != 0.85 || record.Outcome.ROI != 0.2 || !record.Outcome.ClosedAt.Equal(closedAt) {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if records, _, _ := LabeledCandidates(ctx, store, store, closedAt.Add(time.Second)); len(records) != 0 {
+		t.Errorf("expected positions closed before since to be left out, got %d", len(records))
+	}
+}
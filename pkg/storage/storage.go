@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mumugogoing/meme_bot/pkg/models"
+)
+
+// ErrNotFound is returned when nothing has been stored under a key
+var ErrNotFound = errors.New("not found")
+
+// ErrUnsupportedURL is returned for a DATABASE_URL scheme without a driver
+var ErrUnsupportedURL = errors.New("unsupported database url")
+
+// TokenRepository stores discovered tokens, one row per chain and address
+type TokenRepository interface {
+	SaveToken(ctx context.Context, token *models.TokenFound) error
+	GetToken(ctx context.Context, key models.CandidateKey) (*models.TokenFound, error)
+}
+
+// SafetyReportRepository stores every safety report produced for a token
+type SafetyReportRepository interface {
+	SaveSafetyReport(ctx context.Context, report *models.SafetyReport) error
+	LatestSafetyReport(ctx context.Context, key models.CandidateKey) (*models.SafetyReport, error)
+}
+
+// MetricsRepository stores off-chain metrics snapshots. Metrics do not carry
+// their chain, so it is passed alongside.
+type MetricsRepository interface {
+	SaveMetrics(ctx context.Context, chain models.Chain, metrics *models.OffChainMetrics) error
+	MetricsHistory(ctx context.Context, key models.CandidateKey, since time.Time) ([]*models.OffChainMetrics, error)
+}
+
+// DecisionRepository stores every strategy decision
+type DecisionRepository interface {
+	SaveDecision(ctx context.Context, decision *models.StrategyDecision) error
+	Decisions(ctx context.Context, key models.CandidateKey) ([]*models.StrategyDecision, error)
+}
+
+// CandidateRepository stores candidates, one row per listing, updated on
+// every status transition
+type CandidateRepository interface {
+	SaveCandidate(ctx context.Context, candidate *models.CandidateToken) error
+	ActiveCandidates(ctx context.Context) ([]*models.CandidateToken, error)
+	CandidateHistory(ctx context.Context, key models.CandidateKey) ([]*models.CandidateToken, error)
+}
+
+// ExecutionRepository stores the result of every trade attempt
+type ExecutionRepository interface {
+	SaveExecution(ctx context.Context, result *models.ExecutionResult) error
+	Executions(ctx context.Context, key models.CandidateKey) ([]*models.ExecutionResult, error)
+}
+
+// PositionRepository stores positions, keyed by token and when they opened
+type PositionRepository interface {
+	SavePosition(ctx context.Context, position *models.Position) error
+	OpenPositions(ctx context.Context) ([]*models.Position, error)
+	ClosedPositions(ctx context.Context, since time.Time) ([]*models.Position, error)
+}
+
+// RiskStateRepository stores the risk manager's exposure, daily loss and
+// circuit breaker
+type RiskStateRepository interface {
+	SaveRiskState(ctx context.Context, state *models.RiskControl) error
+	LoadRiskState(ctx context.Context) (*models.RiskControl, error)
+}
+
+// Store is a database holding every repository
+type Store interface {
+	TokenRepository
+	SafetyReportRepository
+	MetricsRepository
+	DecisionRepository
+	CandidateRepository
+	ExecutionRepository
+	PositionRepository
+	RiskStateRepository
+	Close() error
+}
+
+// Open opens the store named by a DATABASE_URL such as sqlite://./meme_bot.db.
+// An empty URL means nothing is persisted, and returns a nil store.
+func Open(databaseURL string) (Store, error) {
+	if databaseURL == "" {
+		return nil, nil
+	}
+
+	scheme, path, found := strings.Cut(databaseURL, "://")
+	if !found {
+		return nil, fmt.Errorf("%w: %q has no scheme", ErrUnsupportedURL, databaseURL)
+	}
+	switch scheme {
+	case "sqlite":
+		store, err := OpenSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("%w: scheme %q", ErrUnsupportedURL, scheme)
+	}
+}